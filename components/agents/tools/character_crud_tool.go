@@ -36,11 +36,11 @@ func NewCharacterCRUDTool(novelDir string, llmManager *providers.Manager) *Chara
 func (t *CharacterCRUDTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 	return &schema.ToolInfo{
 		Name: "character_crud",
-		Desc: "角色管理工具，用于读取、更新、分析角色信息。支持AI智能分析章节对角色的影响并自动更新角色状态",
+		Desc: "角色管理工具，用于读取、更新、分析角色信息。支持AI智能分析章节对角色的影响并自动更新角色状态，每次变更都会按章节记录历史，可查询角色在某一章的状态或回滚错误的变更",
 		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
 			"action": {
 				Type:     schema.String,
-				Desc:     "操作类型: read/update/analyze_changes",
+				Desc:     "操作类型: read/update/analyze_changes/history/state_at/rollback",
 				Required: true,
 			},
 			"character_name": {
@@ -58,6 +58,16 @@ func (t *CharacterCRUDTool) Info(ctx context.Context) (*schema.ToolInfo, error)
 				Desc:     "最新章节内容，用于AI分析",
 				Required: false,
 			},
+			"chapter_number": {
+				Type:     schema.Integer,
+				Desc:     "章节号：update/analyze_changes 时为引起变更的章节（默认最新章节），state_at 时为要查询的章节",
+				Required: false,
+			},
+			"change_id": {
+				Type:     schema.Integer,
+				Desc:     "要回滚的变更编号（rollback 时必填，可通过 history 查询）",
+				Required: false,
+			},
 		}),
 	}, nil
 }
@@ -83,6 +93,7 @@ func (t *CharacterCRUDTool) invoke(ctx context.Context, input map[string]any) (s
 
 	// 创建角色管理器
 	characterManager := managers.NewCharacterManager(t.novelDir)
+	characterManager.SetVersionInfo(managers.VersionInfoFromContext(ctx).WithReason("character_crud " + action))
 
 	switch action {
 	case "read":
@@ -91,15 +102,21 @@ func (t *CharacterCRUDTool) invoke(ctx context.Context, input map[string]any) (s
 		return t.handleUpdate(characterManager, input)
 	case "analyze_changes":
 		return t.handleAnalyzeChanges(ctx, characterManager, input)
+	case "history":
+		return t.handleHistory(characterManager, input)
+	case "state_at":
+		return t.handleStateAt(characterManager, input)
+	case "rollback":
+		return t.handleRollback(characterManager, input)
 	default:
-		return "", compose.NewInterruptAndRerunErr("不支持的操作类型: " + action + "，支持的操作: read/update/analyze_changes，当前参数: " + fmt.Sprintf("%v", input))
+		return "", compose.NewInterruptAndRerunErr("不支持的操作类型: " + action + "，支持的操作: read/update/analyze_changes/history/state_at/rollback，当前参数: " + fmt.Sprintf("%v", input))
 	}
 }
 
 // handleRead 处理读取角色信息
 func (t *CharacterCRUDTool) handleRead(characterManager *managers.CharacterManager) (string, error) {
 	// 检查文件是否存在
-	if !characterManager.Exists() {
+	if !characterManager.Exists() && !characterManager.IsStructured() {
 		return "", fmt.Errorf("角色信息文件不存在")
 	}
 
@@ -125,13 +142,15 @@ func (t *CharacterCRUDTool) handleUpdate(characterManager *managers.CharacterMan
 		return "", compose.NewInterruptAndRerunErr("更新角色信息需要提供有效的 update_content 参数（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
 	}
 
-	// 更新角色信息
-	if err := characterManager.Update(updateContent); err != nil {
+	// 更新角色信息并记录变更历史
+	changes, err := characterManager.UpdateWithHistory(updateContent, t.chapterNumber(input), managers.ChangeSourceManual, "直接更新")
+	if err != nil {
 		return "", fmt.Errorf("更新角色信息失败: %w", err)
 	}
 
-	return t.successResponse("角色信息更新成功", map[string]string{
+	return t.successResponse("角色信息更新成功", map[string]any{
 		"updated_content": updateContent,
+		"changes":         summarizeCharacterChanges(changes),
 	}), nil
 }
 
@@ -148,7 +167,7 @@ func (t *CharacterCRUDTool) handleAnalyzeChanges(ctx context.Context, characterM
 	}
 
 	// 检查文件是否存在
-	if !characterManager.Exists() {
+	if !characterManager.Exists() && !characterManager.IsStructured() {
 		return "", fmt.Errorf("角色信息文件不存在")
 	}
 
@@ -168,9 +187,10 @@ func (t *CharacterCRUDTool) handleAnalyzeChanges(ctx context.Context, characterM
 		return "", fmt.Errorf("分析角色变化失败: %w", err)
 	}
 
-	// 如果需要更新，执行更新
+	// 如果需要更新，执行更新并记录变更历史，便于之后回滚
 	if analysisResult.NeedsUpdate {
-		if err := characterManager.Update(analysisResult.UpdatedCharacter); err != nil {
+		characterManager.SetVersionInfo(managers.VersionInfoFromContext(ctx).WithReason("AI分析章节后更新角色: " + analysisResult.Reason))
+		if _, err := characterManager.UpdateWithHistory(analysisResult.UpdatedCharacter, t.chapterNumber(input), managers.ChangeSourceAI, analysisResult.Reason); err != nil {
 			return "", fmt.Errorf("保存角色信息失败: %w", err)
 		}
 	}
@@ -178,6 +198,95 @@ func (t *CharacterCRUDTool) handleAnalyzeChanges(ctx context.Context, characterM
 	return t.successResponse("角色变化分析完成", analysisResult), nil
 }
 
+// handleHistory 查询角色的变更历史
+func (t *CharacterCRUDTool) handleHistory(characterManager *managers.CharacterManager, input map[string]any) (string, error) {
+	characterName, ok := input["character_name"].(string)
+	if !ok || characterName == "" {
+		return "", compose.NewInterruptAndRerunErr("查询变更历史需要提供有效的 character_name 参数（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
+	}
+
+	changes, err := characterManager.GetHistory(characterName)
+	if err != nil {
+		return "", fmt.Errorf("读取角色变更历史失败: %w", err)
+	}
+
+	return t.successResponse(fmt.Sprintf("角色 %s 共有 %d 条变更记录", characterName, len(changes)), map[string]any{
+		"character_name": characterName,
+		"changes":        summarizeCharacterChanges(changes),
+	}), nil
+}
+
+// handleStateAt 查询角色在指定章节结束时的状态
+func (t *CharacterCRUDTool) handleStateAt(characterManager *managers.CharacterManager, input map[string]any) (string, error) {
+	characterName, ok := input["character_name"].(string)
+	if !ok || characterName == "" {
+		return "", compose.NewInterruptAndRerunErr("查询角色状态需要提供有效的 character_name 参数（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
+	}
+	chapter, ok := intInput(input, "chapter_number")
+	if !ok {
+		return "", compose.NewInterruptAndRerunErr("查询角色状态需要提供有效的 chapter_number 参数（整数类型），当前参数: " + fmt.Sprintf("%v", input))
+	}
+
+	state, exists, err := characterManager.StateAt(characterName, chapter)
+	if err != nil {
+		return "", fmt.Errorf("查询角色状态失败: %w", err)
+	}
+	if !exists {
+		return t.successResponse(fmt.Sprintf("第%d章时角色 %s 尚未出现或已被移除", chapter, characterName), nil), nil
+	}
+
+	return t.successResponse(fmt.Sprintf("角色 %s 在第%d章的状态", characterName, chapter), map[string]string{
+		"character_info": state.RenderMarkdown(),
+	}), nil
+}
+
+// handleRollback 将角色回滚到指定变更之前的状态
+func (t *CharacterCRUDTool) handleRollback(characterManager *managers.CharacterManager, input map[string]any) (string, error) {
+	characterName, ok := input["character_name"].(string)
+	if !ok || characterName == "" {
+		return "", compose.NewInterruptAndRerunErr("回滚角色需要提供有效的 character_name 参数（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
+	}
+	changeID, ok := intInput(input, "change_id")
+	if !ok {
+		return "", compose.NewInterruptAndRerunErr("回滚角色需要提供有效的 change_id 参数（整数类型），当前参数: " + fmt.Sprintf("%v", input))
+	}
+
+	rollback, err := characterManager.Rollback(characterName, changeID)
+	if err != nil {
+		return "", fmt.Errorf("回滚角色失败: %w", err)
+	}
+
+	return t.successResponse(fmt.Sprintf("角色 %s 已回滚到变更 #%d 之前的状态", characterName, changeID), map[string]any{
+		"rollback": summarizeCharacterChanges([]*managers.CharacterChange{rollback}),
+	}), nil
+}
+
+// chapterNumber 变更对应的章节号：优先使用 chapter_number 参数，否则使用最新章节
+func (t *CharacterCRUDTool) chapterNumber(input map[string]any) int {
+	if chapter, ok := intInput(input, "chapter_number"); ok {
+		return chapter
+	}
+	return managers.NewChapterManager(t.novelDir).GetLatestChapterID()
+}
+
+// summarizeCharacterChanges 将变更记录整理为简要信息，供模型阅读
+func summarizeCharacterChanges(changes []*managers.CharacterChange) []map[string]any {
+	summaries := make([]map[string]any, 0, len(changes))
+	for _, change := range changes {
+		summaries = append(summaries, map[string]any{
+			"id":          change.ID,
+			"character":   change.Character,
+			"chapter":     change.Chapter,
+			"source":      change.Source,
+			"reason":      change.Reason,
+			"summary":     change.Summary(),
+			"rolled_back": change.RolledBack,
+			"created_at":  change.CreatedAt,
+		})
+	}
+	return summaries
+}
+
 // AnalysisResult AI分析结果
 type AnalysisResult struct {
 	NeedsUpdate      bool   `json:"needs_update"`      // 是否需要更新
@@ -0,0 +1,242 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content"
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+)
+
+// passageMinRunes 前文片段的最小长度，较短的相邻段落会合并为一个片段
+const passageMinRunes = 300
+
+// ContextRetriever 为上下文构建器检索相关前文片段
+// 默认只使用本地关键词索引；注入向量服务后，会把向量检索命中的章节与关键词结果融合
+type ContextRetriever struct {
+	novelDir      string
+	searchConfig  *VectorSearchConfig
+	service       *VectorSearchService
+	keywordWeight float64
+	threshold     float64
+}
+
+// NewContextRetriever 创建仅使用本地关键词索引的前文检索器
+func NewContextRetriever(novelDir string) *ContextRetriever {
+	return &ContextRetriever{
+		novelDir:      novelDir,
+		searchConfig:  &VectorSearchConfig{NovelDir: novelDir},
+		keywordWeight: 1,
+		threshold:     0.7,
+	}
+}
+
+// NewHybridContextRetriever 创建结合向量服务与本地关键词索引的前文检索器
+func NewHybridContextRetriever(novelDir string, service *VectorSearchService, sessionID, tenant, database string) *ContextRetriever {
+	return &ContextRetriever{
+		novelDir: novelDir,
+		searchConfig: &VectorSearchConfig{
+			SessionID: sessionID,
+			Tenant:    tenant,
+			Database:  database,
+			NovelDir:  novelDir,
+		},
+		service:       service,
+		keywordWeight: 0.5,
+		threshold:     0.7,
+	}
+}
+
+// ContextCacheKey 实现 content.ContextCacheKeyer 接口，不同会话、租户或数据库的检索结果不共用缓存
+func (r *ContextRetriever) ContextCacheKey() string {
+	return fmt.Sprintf("novel=%s,session=%s,tenant=%s,database=%s,vector=%t",
+		r.novelDir, r.searchConfig.SessionID, r.searchConfig.Tenant, r.searchConfig.Database, r.service != nil)
+}
+
+// ContextCacheFingerprint 实现 content.ContextCacheFingerprinter 接口
+// 关键词索引由章节文件生成，以章节文件状态为指纹；向量库在远端，其内容变化无法在本地观测，不参与缓存
+func (r *ContextRetriever) ContextCacheFingerprint() (string, bool) {
+	if r.service != nil {
+		return "", false
+	}
+	return keywordSourceSignature(r.novelDir, "passage"), true
+}
+
+// RetrievePassages 实现 content.PassageRetriever 接口
+func (r *ContextRetriever) RetrievePassages(ctx context.Context, query string, topK int) ([]content.RetrievedPassage, error) {
+	keywordHits, err := KeywordSearchDocuments(r.searchConfig, query, "passage", topK*2, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var vectorHits []map[string]interface{}
+	if r.service != nil {
+		vectorHits, err = r.vectorPassageHits(ctx, query, topK*2)
+		if err != nil {
+			// 向量服务不可用时退化为纯关键词检索
+			vectorHits = nil
+		}
+	}
+
+	fused := fuseSearchResults(vectorHits, keywordHits, r.keywordWeight, topK)
+
+	passages := make([]content.RetrievedPassage, 0, len(fused))
+	for _, item := range fused {
+		passage := content.RetrievedPassage{
+			ID:      fmt.Sprint(item["id"]),
+			Content: fmt.Sprint(item["content"]),
+		}
+		if score, ok := item["score"].(float64); ok {
+			passage.Score = score
+		}
+		if metadata, ok := item["metadata"].(map[string]interface{}); ok {
+			passage.Chapter = chapterNumberFromMetadata(metadata)
+			if title, ok := metadata["title"].(string); ok {
+				passage.Title = title
+			}
+		}
+		passages = append(passages, passage)
+	}
+
+	return passages, nil
+}
+
+// vectorPassageHits 执行章节级向量检索，并为每个命中章节选出与查询最相关的片段
+func (r *ContextRetriever) vectorPassageHits(ctx context.Context, query string, topK int) ([]map[string]interface{}, error) {
+	chapterHits, err := r.service.SearchDocuments(ctx, r.searchConfig, query, "chapter", topK, r.threshold, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := GetKeywordIndex(r.novelDir, "passage")
+	if err != nil {
+		return nil, err
+	}
+	queryTerms := uniqueTerms(tokenizeKeywords(query))
+
+	var hits []map[string]interface{}
+	for _, hit := range chapterHits {
+		metadata, _ := hit["metadata"].(map[string]interface{})
+		chapter := chapterNumberFromMetadata(metadata)
+		if chapter <= 0 {
+			continue
+		}
+		best := bestChapterPassage(index, chapter, queryTerms)
+		if best == nil {
+			continue
+		}
+		hits = append(hits, map[string]interface{}{
+			"id":       best.ID,
+			"content":  best.Content,
+			"score":    hit["score"],
+			"metadata": best.Metadata,
+		})
+	}
+
+	return hits, nil
+}
+
+// bestChapterPassage 在指定章节的片段中选出包含查询词最多的片段
+func bestChapterPassage(index *KeywordIndex, chapter int, queryTerms []string) *ReindexDocument {
+	var best *ReindexDocument
+	bestOverlap := -1
+	for i := range index.docs {
+		doc := &index.docs[i]
+		if num, _ := doc.Metadata["chapter"].(int); num != chapter {
+			continue
+		}
+		terms := make(map[string]bool)
+		for _, term := range tokenizeKeywords(doc.Content) {
+			terms[term] = true
+		}
+		overlap := 0
+		for _, term := range queryTerms {
+			if terms[term] {
+				overlap++
+			}
+		}
+		if overlap > bestOverlap {
+			best = doc
+			bestOverlap = overlap
+		}
+	}
+	return best
+}
+
+// chapterNumberFromMetadata 从文档元数据中读取章节编号：优先使用 chapter，旧索引中只有 chapter_id
+// 向量库返回的数字可能是 float64 或字符串，无法解析时返回0
+func chapterNumberFromMetadata(metadata map[string]interface{}) int {
+	for _, key := range []string{"chapter", "chapter_id"} {
+		switch value := metadata[key].(type) {
+		case int:
+			return value
+		case int64:
+			return int(value)
+		case float64:
+			return int(value)
+		case string:
+			if num, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+				return num
+			}
+		}
+	}
+	return 0
+}
+
+// collectPassageDocuments 将章节按段落切分为片段文档，相邻短段落合并
+func collectPassageDocuments(novelDir string) ([]ReindexDocument, error) {
+	cm := managers.NewChapterManager(novelDir)
+
+	// 按章节清单的阅读顺序收集
+	var docs []ReindexDocument
+	for _, entry := range cm.GetChapterEntries() {
+		fileName := entry.File
+		data, err := os.ReadFile(filepath.Join(novelDir, fileName))
+		if err != nil {
+			return nil, fmt.Errorf("读取章节文件 %s 失败: %w", fileName, err)
+		}
+
+		var chapter managers.ChapterData
+		if err := json.Unmarshal(data, &chapter); err != nil {
+			return nil, fmt.Errorf("解析章节文件 %s 失败: %w", fileName, err)
+		}
+
+		number := entry.ID
+		var buffer []string
+		bufferRunes := 0
+		part := 0
+		flush := func() {
+			if len(buffer) == 0 {
+				return
+			}
+			part++
+			docs = append(docs, newReindexDocument("passage", fmt.Sprintf("chapter_%d#%d", number, part), strings.Join(buffer, "\n\n"), map[string]interface{}{
+				"chapter": number,
+				"title":   chapter.Title,
+			}))
+			buffer = nil
+			bufferRunes = 0
+		}
+
+		for _, paragraph := range chapter.Content {
+			text := strings.TrimSpace(paragraph.Text)
+			if text == "" {
+				continue
+			}
+			buffer = append(buffer, text)
+			bufferRunes += utf8.RuneCountInString(text)
+			if bufferRunes >= passageMinRunes {
+				flush()
+			}
+		}
+		flush()
+	}
+
+	return docs, nil
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+)
+
+func TestChapterNumberFromMetadata(t *testing.T) {
+	assert.Equal(t, 3, chapterNumberFromMetadata(map[string]interface{}{"chapter": 3}))
+	assert.Equal(t, 4, chapterNumberFromMetadata(map[string]interface{}{"chapter": float64(4)}))
+	// 旧索引只有 chapter_id
+	assert.Equal(t, 12, chapterNumberFromMetadata(map[string]interface{}{"chapter_id": "012"}))
+	assert.Equal(t, 0, chapterNumberFromMetadata(map[string]interface{}{"title": "第一章"}))
+	assert.Equal(t, 0, chapterNumberFromMetadata(nil))
+}
+
+func TestContextRetrieverKeywordOnly(t *testing.T) {
+	dir := t.TempDir()
+	cm := managers.NewChapterManager(dir)
+	_, err := cm.WriteChapter("醒来", "林凡在青云山下醒来，身边只有一把断剑。")
+	require.NoError(t, err)
+	_, err = cm.WriteChapter("入城", "远方的城池灯火通明，商贩在街头叫卖。")
+	require.NoError(t, err)
+
+	retriever := NewContextRetriever(dir)
+	passages, err := retriever.RetrievePassages(context.Background(), "断剑", 3)
+	require.NoError(t, err)
+	require.NotEmpty(t, passages)
+	assert.Equal(t, 1, passages[0].Chapter)
+	assert.Contains(t, passages[0].Content, "断剑")
+
+	// 不同会话的检索器不共用上下文缓存
+	assert.NotEqual(t, retriever.ContextCacheKey(), NewHybridContextRetriever(dir, nil, "novel_a", "t", "d").ContextCacheKey())
+	assert.NotEqual(t, NewHybridContextRetriever(dir, nil, "novel_a", "t", "d").ContextCacheKey(), NewHybridContextRetriever(dir, nil, "novel_b", "t", "d").ContextCacheKey())
+
+	// 关键词检索器以章节文件状态为指纹，新增章节后指纹变化
+	before, ok := retriever.ContextCacheFingerprint()
+	assert.True(t, ok)
+	_, err = cm.WriteChapter("出山", "林凡背着断剑离开了青云山。")
+	require.NoError(t, err)
+	after, ok := retriever.ContextCacheFingerprint()
+	assert.True(t, ok)
+	assert.NotEqual(t, before, after)
+
+	// 向量库状态无法在本地观测，混合检索器不参与上下文缓存
+	_, ok = NewHybridContextRetriever(dir, &VectorSearchService{}, "novel_a", "t", "d").ContextCacheFingerprint()
+	assert.False(t, ok)
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/bytedance/sonic"
@@ -17,30 +17,37 @@ import (
 
 // CurrentChapterCRUDTool 当前章节增删改查工具
 type CurrentChapterCRUDTool struct {
-	novelDir string
+	novelDir       string
+	chapterOptions []managers.ChapterManagerOption
 }
 
-// NewCurrentChapterCRUDTool 创建当前章节CRUD工具
-func NewCurrentChapterCRUDTool(novelDir string) *CurrentChapterCRUDTool {
+// NewCurrentChapterCRUDTool 创建当前章节CRUD工具，opts 用于创建章节管理器（如新章节的文件名格式）
+func NewCurrentChapterCRUDTool(novelDir string, opts ...managers.ChapterManagerOption) *CurrentChapterCRUDTool {
 	return &CurrentChapterCRUDTool{
-		novelDir: novelDir,
+		novelDir:       novelDir,
+		chapterOptions: opts,
 	}
 }
 
+// newChapterManager 创建章节管理器
+func (t *CurrentChapterCRUDTool) newChapterManager() *managers.ChapterManager {
+	return managers.NewChapterManager(t.novelDir, t.chapterOptions...)
+}
+
 // Info 工具信息描述
 func (t *CurrentChapterCRUDTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 	return &schema.ToolInfo{
 		Name: "current_chapter_crud",
-		Desc: "当前章节管理工具：增删改查当前正在编写的章节。支持创建、读取、更新章节内容，以及获取章节状态。",
+		Desc: "当前章节管理工具：增删改查当前正在编写的章节。支持创建、读取、更新章节内容，以及获取章节状态。修改局部内容时先用 paragraphs 查看段落ID，再用 edit_paragraph 按段落插入、替换、删除或移动，无需重写整章。",
 		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
 			"action": {
 				Type:     schema.String,
-				Desc:     "操作类型：create, read, update, get_latest, list, count",
+				Desc:     "操作类型：create, read, update, get_latest, list, count, paragraphs（读取带段落ID的正文）, edit_paragraph（段落级编辑）",
 				Required: true,
 			},
 			"chapter_id": {
 				Type:     schema.String,
-				Desc:     "章节ID（如：001、002）- 仅用于读取操作，创建时由系统自动递增生成",
+				Desc:     "章节ID（如：1、2，与章节文件名一致，兼容 001 形式）- 用于读取和更新操作，创建时由系统分配",
 				Required: false,
 			},
 			"title": {
@@ -50,7 +57,22 @@ func (t *CurrentChapterCRUDTool) Info(ctx context.Context) (*schema.ToolInfo, er
 			},
 			"content": {
 				Type:     schema.String,
-				Desc:     "章节内容",
+				Desc:     "章节内容；edit_paragraph 的 insert_after/replace 中为新段落内容，空行分隔多段",
+				Required: false,
+			},
+			"op": {
+				Type:     schema.String,
+				Desc:     "edit_paragraph 的操作: insert_after（插入到 paragraph_id 之后，paragraph_id 为0时插到开头）, replace（替换 paragraph_id）, delete（删除 paragraph_id）, move（把 paragraph_id 移到 after_id 之后，after_id 为0时移到开头）",
+				Required: false,
+			},
+			"paragraph_id": {
+				Type:     schema.Integer,
+				Desc:     "edit_paragraph 操作的段落ID，编辑后其他段落的ID保持不变",
+				Required: false,
+			},
+			"after_id": {
+				Type:     schema.Integer,
+				Desc:     "move 的目标位置：移到该段落之后，0 表示移到开头",
 				Required: false,
 			},
 			"limit": {
@@ -58,6 +80,26 @@ func (t *CurrentChapterCRUDTool) Info(ctx context.Context) (*schema.ToolInfo, er
 				Desc:     "限制返回数量（用于列表操作）",
 				Required: false,
 			},
+			"pov": {
+				Type:     schema.String,
+				Desc:     "视角人物：create/update 时写入章节元数据，list 时只列出该视角的章节",
+				Required: false,
+			},
+			"story_time": {
+				Type:     schema.String,
+				Desc:     "故事内的日期/时间（如 第三天 黄昏），create/update 时写入章节元数据",
+				Required: false,
+			},
+			"locations": {
+				Type:     schema.String,
+				Desc:     "本章出现的地点，多个地点用逗号或顿号分隔；list 时列出出现其中任一地点的章节",
+				Required: false,
+			},
+			"status": {
+				Type:     schema.String,
+				Desc:     "章节状态: draft/revised/final（默认 draft）；list 时只列出该状态的章节",
+				Required: false,
+			},
 		}),
 	}, nil
 }
@@ -80,6 +122,10 @@ type ChapterInfo struct {
 	Content   string `json:"content"`
 	WordCount int    `json:"word_count"`
 	Path      string `json:"path"`
+
+	Meta *managers.ChapterMeta `json:"meta,omitempty"` // 章节元数据（视角、地点、状态、模型等）
+
+	Paragraphs []managers.ChapterParagraph `json:"paragraphs,omitempty"` // 带ID的段落，paragraphs 返回全部段落，edit_paragraph 返回受影响的段落
 }
 
 // 响应结构体定义
@@ -92,7 +138,7 @@ type ChapterResponse struct {
 }
 
 // invoke 工具执行逻辑
-func (t *CurrentChapterCRUDTool) invoke(_ context.Context, input map[string]any) (string, error) {
+func (t *CurrentChapterCRUDTool) invoke(ctx context.Context, input map[string]any) (string, error) {
 	// 解析操作类型
 	action, ok := input["action"].(string)
 	if !ok {
@@ -101,24 +147,28 @@ func (t *CurrentChapterCRUDTool) invoke(_ context.Context, input map[string]any)
 
 	switch action {
 	case "create":
-		return t.handleCreate(input)
+		return t.handleCreate(ctx, input)
 	case "read":
 		return t.handleRead(input)
 	case "update":
-		return t.handleUpdate(input)
+		return t.handleUpdate(ctx, input)
 	case "get_latest":
 		return t.handleGetLatest()
 	case "list":
 		return t.handleList(input)
 	case "count":
 		return t.handleCount()
+	case "paragraphs":
+		return t.handleParagraphs(input)
+	case "edit_paragraph":
+		return t.handleEditParagraph(ctx, input)
 	default:
-		return "", compose.NewInterruptAndRerunErr("未知操作类型: " + action + "，支持的操作: create/read/update/get_latest/list/count，当前参数: " + fmt.Sprintf("%v", input))
+		return "", compose.NewInterruptAndRerunErr("未知操作类型: " + action + "，支持的操作: create/read/update/get_latest/list/count/paragraphs/edit_paragraph，当前参数: " + fmt.Sprintf("%v", input))
 	}
 }
 
 // handleCreate 处理创建章节
-func (t *CurrentChapterCRUDTool) handleCreate(input map[string]any) (string, error) {
+func (t *CurrentChapterCRUDTool) handleCreate(ctx context.Context, input map[string]any) (string, error) {
 	title, titleOk := input["title"].(string)
 	if !titleOk || title == "" {
 		return "", compose.NewInterruptAndRerunErr("创建章节需要提供有效的 title 参数（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
@@ -129,28 +179,32 @@ func (t *CurrentChapterCRUDTool) handleCreate(input map[string]any) (string, err
 		return "", compose.NewInterruptAndRerunErr("创建章节需要提供有效的 content 参数（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
 	}
 
-	// 创建章节管理器
-	chapterManager := managers.NewChapterManager(t.novelDir)
+	meta, err := t.metaInput(ctx, input)
+	if err != nil {
+		return "", err
+	}
 
-	// 获取下一个章节编号（写入前）
-	nextChapterNum := chapterManager.GetChapterCount() + 1
+	// 创建章节管理器
+	chapterManager := t.newChapterManager()
+	chapterManager.SetVersionInfo(managers.VersionInfoFromContext(ctx).WithReason("创建章节: " + title))
+	chapterManager.SetChapterMeta(meta)
 
-	// 实际写入章节文件
-	chapterPath, err := chapterManager.WriteChapter(title, contentStr)
+	// 写入章节文件，章节ID由章节清单分配
+	chapterNum, chapterPath, err := chapterManager.CreateChapter(title, contentStr)
 	if err != nil {
 		return "", err
 	}
-
-	// 使用预先确定的章节ID
-	chapterID := fmt.Sprintf("%03d", nextChapterNum)
+	chapterID := strconv.Itoa(chapterNum)
+	savedMeta, _ := chapterManager.GetChapterMeta(chapterNum)
 
 	// 构建响应数据
 	info := &ChapterInfo{
 		ID:        chapterID,
 		Title:     title,
 		Content:   contentStr,
-		WordCount: len(contentStr),
+		WordCount: managers.CountChapterChars(contentStr),
 		Path:      chapterPath,
+		Meta:      savedMeta,
 	}
 
 	return t.successResponse("章节创建成功", info, nil, 0), nil
@@ -163,19 +217,13 @@ func (t *CurrentChapterCRUDTool) handleRead(input map[string]any) (string, error
 		return "", compose.NewInterruptAndRerunErr("读取章节需要提供有效的 chapter_id 参数（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
 	}
 
-	// 验证章节ID是否在有效范围内
-	chapterManager := managers.NewChapterManager(t.novelDir)
-	existingCount := chapterManager.GetChapterCount()
-
-	// 解析章节ID为数字进行验证
-	var chapterNum int
-	if _, err := fmt.Sscanf(chapterID, "%d", &chapterNum); err != nil {
+	// 验证章节是否存在
+	chapterManager := t.newChapterManager()
+	chapterNum, err := t.existingChapter(chapterManager, chapterID, input)
+	if err != nil {
 		return "", err
 	}
-
-	if chapterNum <= 0 || chapterNum > existingCount {
-		return "", compose.NewInterruptAndRerunErr(fmt.Sprintf("读取章节ID超出范围，当前有效范围: 001-%03d，提供的chapter_id: %s，当前参数: %v", existingCount, chapterID, input))
-	}
+	chapterID = strconv.Itoa(chapterNum)
 
 	// 使用已创建的章节管理器获取真实内容
 
@@ -205,37 +253,39 @@ func (t *CurrentChapterCRUDTool) handleRead(input map[string]any) (string, error
 	}
 
 	// 构建真实的响应数据
+	meta, _ := chapterManager.GetChapterMeta(chapterNum)
 	info := &ChapterInfo{
 		ID:        chapterID,
 		Title:     title,
 		Content:   content,
-		WordCount: len(content),
+		WordCount: managers.CountChapterChars(content),
 		Path:      chapterPath,
+		Meta:      meta,
 	}
 
 	return t.successResponse("章节读取成功", info, nil, 0), nil
 }
 
 // handleUpdate 处理更新章节
-func (t *CurrentChapterCRUDTool) handleUpdate(input map[string]any) (string, error) {
+func (t *CurrentChapterCRUDTool) handleUpdate(ctx context.Context, input map[string]any) (string, error) {
 	chapterID, chapterIDOk := input["chapter_id"].(string)
 	if !chapterIDOk || chapterID == "" {
 		return "", compose.NewInterruptAndRerunErr("更新章节需要提供有效的 chapter_id 参数（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
 	}
 
-	// 验证章节ID是否在有效范围内（防止越界修改）
-	chapterManager := managers.NewChapterManager(t.novelDir)
-	existingCount := chapterManager.GetChapterCount()
-
-	// 解析章节ID为数字进行验证
-	var chapterNum int
-	if _, err := fmt.Sscanf(chapterID, "%d", &chapterNum); err != nil {
-		return "", compose.NewInterruptAndRerunErr("无效的章节ID格式，需要数字格式（如: 001, 002），提供的chapter_id: " + chapterID + "，当前参数: " + fmt.Sprintf("%v", input))
+	// 验证章节是否存在（防止写入不存在的章节）
+	chapterManager := t.newChapterManager()
+	chapterNum, err := t.existingChapter(chapterManager, chapterID, input)
+	if err != nil {
+		return "", err
 	}
-
-	if chapterNum <= 0 || chapterNum > existingCount {
-		return "", compose.NewInterruptAndRerunErr(fmt.Sprintf("更新章节ID超出范围，当前有效范围: 001-%03d，提供的chapter_id: %s，当前参数: %v", existingCount, chapterID, input))
+	chapterID = strconv.Itoa(chapterNum)
+	meta, err := t.metaInput(ctx, input)
+	if err != nil {
+		return "", err
 	}
+	chapterManager.SetVersionInfo(managers.VersionInfoFromContext(ctx).WithReason("更新第" + chapterID + "章"))
+	chapterManager.SetChapterMeta(meta)
 
 	// 使用ChapterManager获取正确的章节路径
 	chapterPath := chapterManager.GetChapterPath(chapterNum)
@@ -279,21 +329,22 @@ func (t *CurrentChapterCRUDTool) handleUpdate(input map[string]any) (string, err
 	}
 
 	// 执行真正的更新操作
-	err := chapterManager.UpdateChapter(chapterNum, title, content)
-	if err != nil {
+	if err := chapterManager.UpdateChapter(chapterNum, title, content); err != nil {
 		return "", err
 	}
 
 	// 获取更新后的文件路径
 	updatedPath := chapterManager.GetChapterPath(chapterNum)
+	savedMeta, _ := chapterManager.GetChapterMeta(chapterNum)
 
 	// 构建真实的响应数据
 	info := &ChapterInfo{
 		ID:        chapterID,
 		Title:     title,
 		Content:   content,
-		WordCount: len(content),
+		WordCount: managers.CountChapterChars(content),
 		Path:      updatedPath,
+		Meta:      savedMeta,
 	}
 
 	return t.successResponse("章节更新成功", info, nil, 0), nil
@@ -302,7 +353,7 @@ func (t *CurrentChapterCRUDTool) handleUpdate(input map[string]any) (string, err
 // handleGetLatest 处理获取最新章节
 func (t *CurrentChapterCRUDTool) handleGetLatest() (string, error) {
 	// 使用章节兼容管理器
-	chapterManager := managers.NewChapterManager(t.novelDir)
+	chapterManager := t.newChapterManager()
 
 	// 获取最新章节路径
 	latestPath := chapterManager.GetLatestChapterPath()
@@ -316,9 +367,8 @@ func (t *CurrentChapterCRUDTool) handleGetLatest() (string, error) {
 		return "", err
 	}
 
-	// 从路径提取章节ID
-	baseName := filepath.Base(latestPath)
-	chapterID := strings.TrimSuffix(baseName, filepath.Ext(baseName))
+	// 章节ID取自章节清单
+	chapterID := strconv.Itoa(chapterManager.GetLatestChapterID())
 
 	// 读取真实的章节标题
 	var title string
@@ -336,98 +386,233 @@ func (t *CurrentChapterCRUDTool) handleGetLatest() (string, error) {
 	}
 
 	// 构建响应数据
+	meta, _ := chapterManager.GetChapterMeta(chapterManager.GetLatestChapterID())
 	info := &ChapterInfo{
 		ID:        chapterID,
 		Title:     title,
 		Content:   content,
-		WordCount: len(content),
+		WordCount: managers.CountChapterChars(content),
 		Path:      latestPath,
+		Meta:      meta,
 	}
 
 	return t.successResponse("获取最新章节成功", info, nil, 0), nil
 }
 
-// handleList 处理列出章节
+// handleList 处理列出章节，可按状态、视角人物、地点筛选
 func (t *CurrentChapterCRUDTool) handleList(input map[string]any) (string, error) {
 	limit := 10 // 默认限制
 	if l, ok := input["limit"].(float64); ok && l > 0 && l <= 1000 { // 添加上限检查
 		limit = int(l)
 	}
 
+	filter := managers.ChapterFilter{}
+	filter.Status, _ = input["status"].(string)
+	filter.POV, _ = input["pov"].(string)
+	if locations, _ := input["locations"].(string); locations != "" {
+		filter.Locations = managers.SplitLocations(locations)
+	}
+	if filter.Status != "" && !managers.ValidChapterStatus(filter.Status) {
+		return "", compose.NewInterruptAndRerunErr("无效的章节状态: " + filter.Status + "，可选 draft/revised/final，当前参数: " + fmt.Sprintf("%v", input))
+	}
+
 	// 使用章节兼容管理器
-	chapterManager := managers.NewChapterManager(t.novelDir)
+	chapterManager := t.newChapterManager()
 
-	// 获取章节数量（简化实现）
-	count := chapterManager.GetChapterCount()
-	if count == 0 {
+	// 按阅读顺序获取章节
+	entries := chapterManager.GetChapterEntries()
+	if len(entries) == 0 {
 		return t.successResponse("当前没有章节", nil, []ChapterInfo{}, 0), nil
 	}
 
-	// 限制返回数量
-	actualLimit := limit
-	if actualLimit > count {
-		actualLimit = count
-	}
-
-	// 构建真实的章节信息
-	var chapterInfos []ChapterInfo
-	for i := 1; i <= actualLimit; i++ {
-		chapterID := fmt.Sprintf("%03d", i)
-
-		// 获取真实的章节内容
-		content, err := chapterManager.GetChapterContent(i)
+	// 构建真实的章节信息，count 为满足筛选条件的章节总数
+	chapterInfos := []ChapterInfo{}
+	count := 0
+	for _, entry := range entries {
+		chapter, err := chapterManager.GetChapterData(entry.ID)
 		if err != nil {
 			// 如果读取失败，跳过这个章节
 			continue
 		}
+		if !filter.Match(chapter.Meta) {
+			continue
+		}
+		count++
+		if len(chapterInfos) >= limit {
+			continue
+		}
 
-		// 获取真实的章节路径
-		chapterPath := chapterManager.GetChapterPath(i)
-
-		// 读取章节标题
-		var title string
-		if data, err := os.ReadFile(chapterPath); err == nil {
-			var chapterData struct {
-				Title string `json:"title"`
-			}
-			if sonic.Unmarshal(data, &chapterData) == nil && chapterData.Title != "" {
-				title = chapterData.Title
-			} else {
-				title = fmt.Sprintf("第%s章", chapterID)
-			}
-		} else {
+		chapterID := strconv.Itoa(entry.ID)
+		title := chapter.Title
+		if title == "" {
 			title = fmt.Sprintf("第%s章", chapterID)
 		}
 
-		// 创建内容摘要（前100个字符）
+		// 创建内容摘要（前100个字）
+		content := chapter.GetText()
 		contentPreview := content
-		if len(contentPreview) > 100 {
-			contentPreview = contentPreview[:100] + "..."
+		if runes := []rune(contentPreview); len(runes) > 100 {
+			contentPreview = string(runes[:100]) + "..."
 		}
 
-		info := ChapterInfo{
+		chapterInfos = append(chapterInfos, ChapterInfo{
 			ID:        chapterID,
 			Title:     title,
 			Content:   contentPreview,
-			WordCount: len(content),
-			Path:      chapterPath,
-		}
-		chapterInfos = append(chapterInfos, info)
+			WordCount: managers.CountChapterChars(content),
+			Path:      chapterManager.GetChapterPath(entry.ID),
+			Meta:      chapter.Meta,
+		})
 	}
 
+	if !filter.Empty() {
+		return t.successResponse(fmt.Sprintf("找到%d个符合条件的章节", count), nil, chapterInfos, count), nil
+	}
 	return t.successResponse(fmt.Sprintf("找到%d个章节", len(chapterInfos)), nil, chapterInfos, count), nil
 }
 
 // handleCount 处理获取章节数量
 func (t *CurrentChapterCRUDTool) handleCount() (string, error) {
 	// 使用章节兼容管理器
-	chapterManager := managers.NewChapterManager(t.novelDir)
+	chapterManager := t.newChapterManager()
 
 	count := chapterManager.GetChapterCount()
 
 	return t.successResponse(fmt.Sprintf("共有%d个章节", count), nil, nil, count), nil
 }
 
+// handleParagraphs 读取带段落ID的章节正文，供段落级编辑定位
+func (t *CurrentChapterCRUDTool) handleParagraphs(input map[string]any) (string, error) {
+	chapterID, _ := input["chapter_id"].(string)
+	if chapterID == "" {
+		return "", compose.NewInterruptAndRerunErr("读取段落需要提供有效的 chapter_id 参数（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
+	}
+
+	chapterManager := t.newChapterManager()
+	chapterNum, err := t.existingChapter(chapterManager, chapterID, input)
+	if err != nil {
+		return "", err
+	}
+	data, err := chapterManager.GetChapterData(chapterNum)
+	if err != nil {
+		return "", err
+	}
+
+	info := &ChapterInfo{
+		ID:         strconv.Itoa(chapterNum),
+		Title:      data.Title,
+		WordCount:  managers.CountChapterChars(data.GetText()),
+		Path:       chapterManager.GetChapterPath(chapterNum),
+		Meta:       data.Meta,
+		Paragraphs: data.Content,
+	}
+	return t.successResponse(fmt.Sprintf("第%d章共%d段", chapterNum, len(data.Content)), info, nil, 0), nil
+}
+
+// handleEditParagraph 按段落ID插入、替换、删除或移动段落，其他段落的ID保持不变
+func (t *CurrentChapterCRUDTool) handleEditParagraph(ctx context.Context, input map[string]any) (string, error) {
+	chapterID, _ := input["chapter_id"].(string)
+	if chapterID == "" {
+		return "", compose.NewInterruptAndRerunErr("编辑段落需要提供有效的 chapter_id 参数（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
+	}
+	op, _ := input["op"].(string)
+	if !managers.ValidParagraphOp(op) {
+		return "", compose.NewInterruptAndRerunErr("编辑段落需要提供有效的 op 参数: insert_after/replace/delete/move，当前参数: " + fmt.Sprintf("%v", input))
+	}
+	paragraphID, ok := intInput(input, "paragraph_id")
+	if !ok {
+		return "", compose.NewInterruptAndRerunErr(op + " 需要提供 paragraph_id 参数（整数，insert_after 时为0表示插到开头），可先用 paragraphs 查看段落ID，当前参数: " + fmt.Sprintf("%v", input))
+	}
+	afterID, ok := intInput(input, "after_id")
+	if !ok && op == managers.ParagraphMove {
+		return "", compose.NewInterruptAndRerunErr("move 需要提供 after_id 参数（整数，0 表示移到开头），当前参数: " + fmt.Sprintf("%v", input))
+	}
+	text, _ := input["content"].(string)
+	if strings.TrimSpace(text) == "" && (op == managers.ParagraphInsertAfter || op == managers.ParagraphReplace) {
+		return "", compose.NewInterruptAndRerunErr(op + " 需要在 content 参数中提供新段落内容，当前参数: " + fmt.Sprintf("%v", input))
+	}
+
+	chapterManager := t.newChapterManager()
+	chapterNum, err := t.existingChapter(chapterManager, chapterID, input)
+	if err != nil {
+		return "", err
+	}
+	meta, err := t.metaInput(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	chapterManager.SetVersionInfo(managers.VersionInfoFromContext(ctx).WithReason(fmt.Sprintf("编辑第%d章段落: %s %d", chapterNum, op, paragraphID)))
+	chapterManager.SetChapterMeta(meta)
+
+	ids, err := chapterManager.EditParagraphs(chapterNum, managers.ParagraphEdit{
+		Op:          op,
+		ParagraphID: paragraphID,
+		AfterID:     afterID,
+		Text:        text,
+	})
+	if err != nil {
+		return "", compose.NewInterruptAndRerunErr("编辑段落失败: " + err.Error() + "，可先用 paragraphs 查看段落ID，当前参数: " + fmt.Sprintf("%v", input))
+	}
+
+	data, err := chapterManager.GetChapterData(chapterNum)
+	if err != nil {
+		return "", err
+	}
+	info := &ChapterInfo{
+		ID:        strconv.Itoa(chapterNum),
+		Title:     data.Title,
+		WordCount: managers.CountChapterChars(data.GetText()),
+		Path:      chapterManager.GetChapterPath(chapterNum),
+		Meta:      data.Meta,
+	}
+	if op != managers.ParagraphDelete {
+		for _, id := range ids {
+			if index, ok := data.FindParagraph(id); ok {
+				info.Paragraphs = append(info.Paragraphs, data.Content[index])
+			}
+		}
+	}
+	return t.successResponse(fmt.Sprintf("段落编辑成功（%s），受影响的段落ID: %v", op, ids), info, nil, 0), nil
+}
+
+// metaInput 读取要写入章节元数据的字段：工作流通过 context 传入模型和提示词哈希，其余取自参数
+func (t *CurrentChapterCRUDTool) metaInput(ctx context.Context, input map[string]any) (*managers.ChapterMeta, error) {
+	meta := managers.ChapterMetaFromContext(ctx)
+	if pov, _ := input["pov"].(string); strings.TrimSpace(pov) != "" {
+		meta.POV = strings.TrimSpace(pov)
+	}
+	if storyTime, _ := input["story_time"].(string); strings.TrimSpace(storyTime) != "" {
+		meta.StoryTime = strings.TrimSpace(storyTime)
+	}
+	if locations, _ := input["locations"].(string); locations != "" {
+		meta.Locations = managers.SplitLocations(locations)
+	}
+	if status, _ := input["status"].(string); status != "" {
+		if !managers.ValidChapterStatus(status) {
+			return nil, compose.NewInterruptAndRerunErr("无效的章节状态: " + status + "，可选 draft/revised/final，当前参数: " + fmt.Sprintf("%v", input))
+		}
+		meta.Status = status
+	}
+	return meta, nil
+}
+
+// existingChapter 解析章节ID（兼容 001 形式）并确认章节存在
+func (t *CurrentChapterCRUDTool) existingChapter(chapterManager *managers.ChapterManager, chapterID string, input map[string]any) (int, error) {
+	chapterNum, err := strconv.Atoi(strings.TrimSpace(chapterID))
+	if err != nil || chapterNum <= 0 {
+		return 0, compose.NewInterruptAndRerunErr("无效的章节ID格式，需要数字格式（如: 1, 2），提供的chapter_id: " + chapterID + "，当前参数: " + fmt.Sprintf("%v", input))
+	}
+
+	if !chapterManager.HasChapter(chapterNum) {
+		validIDs := managers.FormatChapterIDs(chapterManager.GetChapterIDs())
+		if validIDs == "" {
+			validIDs = "无"
+		}
+		return 0, compose.NewInterruptAndRerunErr(fmt.Sprintf("第%d章不存在，当前有效章节ID: %s，提供的chapter_id: %s，当前参数: %v", chapterNum, validIDs, chapterID, input))
+	}
+	return chapterNum, nil
+}
+
 // successResponse 创建成功响应
 func (t *CurrentChapterCRUDTool) successResponse(message string, data *ChapterInfo, chapters []ChapterInfo, count int) string {
 	response := ChapterResponse{
@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// 搜索模式
+const (
+	SearchModeVector  = "vector"
+	SearchModeKeyword = "keyword"
+	SearchModeHybrid  = "hybrid"
+)
+
+// rrfK 倒数排名融合常数，越大则排名靠后的结果权重衰减越慢
+const rrfK = 60.0
+
+// HybridSearchResult 混合检索结果
+type HybridSearchResult struct {
+	Documents   []map[string]interface{}
+	VectorError error // 向量检索失败时记录原因，结果退化为纯关键词检索
+}
+
+// KeywordSearchDocuments 仅使用本地关键词索引检索，返回与向量检索相同结构的文档
+func KeywordSearchDocuments(config *VectorSearchConfig, query, contentType string, topK int, filters map[string]interface{}) ([]map[string]interface{}, error) {
+	if config.NovelDir == "" {
+		return nil, fmt.Errorf("novel_dir must be injected for keyword search")
+	}
+
+	index, err := GetKeywordIndex(config.NovelDir, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build keyword index: %w", err)
+	}
+
+	hits := index.Search(query, topK, filters)
+	results := make([]map[string]interface{}, 0, len(hits))
+	for _, hit := range hits {
+		item := map[string]interface{}{
+			"id":            hit.ID,
+			"content":       hit.Content,
+			"score":         hit.Score,
+			"keyword_score": hit.Score,
+			"matched_terms": hit.MatchedTerms,
+			"match_sources": []string{SearchModeKeyword},
+		}
+		if hit.Metadata != nil {
+			item["metadata"] = hit.Metadata
+		}
+		results = append(results, item)
+	}
+
+	return results, nil
+}
+
+// HybridSearchDocuments 同时执行向量检索和关键词检索，并用加权倒数排名融合(RRF)合并结果
+// keywordWeight 取值 0~1，表示关键词检索在融合中的权重
+func (v *VectorSearchService) HybridSearchDocuments(ctx context.Context, config *VectorSearchConfig, query, contentType string, topK int, threshold, keywordWeight float64, filters map[string]interface{}) (*HybridSearchResult, error) {
+	if config.NovelDir == "" {
+		return nil, fmt.Errorf("novel_dir must be injected for hybrid search")
+	}
+
+	// 各路召回更多候选，融合后再截断
+	candidates := topK * 2
+
+	keywordHits, err := KeywordSearchDocuments(config, query, contentType, candidates, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &HybridSearchResult{}
+	vectorHits, err := v.SearchDocuments(ctx, config, query, contentType, candidates, threshold, filters)
+	if err != nil {
+		result.VectorError = err
+		vectorHits = nil
+	}
+
+	result.Documents = fuseSearchResults(vectorHits, keywordHits, keywordWeight, topK)
+	return result, nil
+}
+
+// fuseSearchResults 按文档ID合并两路结果，记录每个文档的命中来源
+func fuseSearchResults(vectorHits, keywordHits []map[string]interface{}, keywordWeight float64, topK int) []map[string]interface{} {
+	vectorWeight := 1 - keywordWeight
+
+	type fused struct {
+		item  map[string]interface{}
+		score float64
+		order int
+	}
+	merged := make(map[string]*fused)
+	var order int
+
+	get := func(hit map[string]interface{}) *fused {
+		id := fmt.Sprint(hit["id"])
+		entry, ok := merged[id]
+		if !ok {
+			entry = &fused{
+				item: map[string]interface{}{
+					"id":      hit["id"],
+					"content": hit["content"],
+				},
+				order: order,
+			}
+			if metadata, ok := hit["metadata"]; ok {
+				entry.item["metadata"] = metadata
+			}
+			entry.item["match_sources"] = []string{}
+			merged[id] = entry
+			order++
+		}
+		return entry
+	}
+
+	for rank, hit := range vectorHits {
+		entry := get(hit)
+		entry.score += vectorWeight / (rrfK + float64(rank+1))
+		entry.item["vector_score"] = hit["score"]
+		entry.item["vector_rank"] = rank + 1
+		entry.item["match_sources"] = append(entry.item["match_sources"].([]string), SearchModeVector)
+	}
+
+	for rank, hit := range keywordHits {
+		entry := get(hit)
+		entry.score += keywordWeight / (rrfK + float64(rank+1))
+		entry.item["keyword_score"] = hit["keyword_score"]
+		entry.item["keyword_rank"] = rank + 1
+		entry.item["matched_terms"] = hit["matched_terms"]
+		entry.item["match_sources"] = append(entry.item["match_sources"].([]string), SearchModeKeyword)
+		// 本地文档内容最新，优先使用
+		entry.item["content"] = hit["content"]
+		if _, ok := entry.item["metadata"]; !ok && hit["metadata"] != nil {
+			entry.item["metadata"] = hit["metadata"]
+		}
+	}
+
+	list := make([]*fused, 0, len(merged))
+	for _, entry := range merged {
+		entry.item["score"] = entry.score
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].score == list[j].score {
+			return list[i].order < list[j].order
+		}
+		return list[i].score > list[j].score
+	})
+
+	if topK > 0 && len(list) > topK {
+		list = list[:topK]
+	}
+
+	results := make([]map[string]interface{}, 0, len(list))
+	for _, entry := range list {
+		results = append(results, entry.item)
+	}
+	return results
+}
@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeywordIndexBM25(t *testing.T) {
+	index := NewKeywordIndex([]ReindexDocument{
+		{ID: "a", Content: "林凡在青云山下醒来，身边只有一把断剑。", Metadata: map[string]interface{}{"chapter": 1}},
+		{ID: "b", Content: "青云宗的弟子们在山门前练剑。", Metadata: map[string]interface{}{"chapter": 2}},
+		{ID: "c", Content: "远方的城池灯火通明。", Metadata: map[string]interface{}{"chapter": 3}},
+	})
+	assert.Equal(t, 3, index.Size())
+
+	hits := index.Search("林凡 断剑", 5, nil)
+	require.NotEmpty(t, hits)
+	assert.Equal(t, "a", hits[0].ID)
+	assert.Contains(t, hits[0].MatchedTerms, "林凡")
+
+	// 两篇都包含“青云”，过滤后只剩第2章
+	hits = index.Search("青云", 5, map[string]interface{}{"chapter": 2})
+	require.Len(t, hits, 1)
+	assert.Equal(t, "b", hits[0].ID)
+
+	assert.Empty(t, index.Search("不相干", 5, nil))
+}
+
+func TestFuseSearchResults(t *testing.T) {
+	vectorHits := []map[string]interface{}{
+		{"id": "x", "content": "向量旧内容", "score": 0.9},
+		{"id": "y", "content": "y", "score": 0.8},
+	}
+	keywordHits := []map[string]interface{}{
+		{"id": "y", "content": "y", "keyword_score": 3.0, "metadata": map[string]interface{}{"chapter": 2}},
+		{"id": "z", "content": "z", "keyword_score": 2.0},
+		{"id": "x", "content": "本地新内容", "keyword_score": 1.0},
+	}
+
+	fused := fuseSearchResults(vectorHits, keywordHits, 0.5, 0)
+	require.Len(t, fused, 3)
+	ids := []interface{}{fused[0]["id"], fused[1]["id"], fused[2]["id"]}
+	// y 在两路中的排名之和最小；x 同样两路命中，排在只有关键词命中的 z 之前
+	assert.Equal(t, []interface{}{"y", "x", "z"}, ids)
+	assert.Equal(t, []string{SearchModeVector, SearchModeKeyword}, fused[0]["match_sources"])
+	assert.Equal(t, map[string]interface{}{"chapter": 2}, fused[0]["metadata"])
+	assert.Equal(t, "本地新内容", fused[1]["content"])
+	assert.Equal(t, []string{SearchModeKeyword}, fused[2]["match_sources"])
+
+	// 只使用关键词权重时向量结果不影响排序，topK 截断结果
+	fused = fuseSearchResults(vectorHits, keywordHits, 1, 2)
+	require.Len(t, fused, 2)
+	assert.Equal(t, "y", fused[0]["id"])
+	assert.Equal(t, "z", fused[1]["id"])
+}
@@ -0,0 +1,275 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+)
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// KeywordHit 关键词检索命中结果
+type KeywordHit struct {
+	ID           string
+	Content      string
+	Metadata     map[string]interface{}
+	Score        float64
+	MatchedTerms []string
+}
+
+// keywordPosting 倒排表条目
+type keywordPosting struct {
+	doc  int
+	freq int
+}
+
+// KeywordIndex 基于字符 n-gram 的 BM25 倒排索引
+// 中文按相邻汉字二元组切分，英文和数字按小写单词切分，适合人名、地名、技能名等专有词检索
+type KeywordIndex struct {
+	docs      []ReindexDocument
+	docLens   []int
+	avgDocLen float64
+	postings  map[string][]keywordPosting
+}
+
+// NewKeywordIndex 根据文档构建关键词索引
+func NewKeywordIndex(docs []ReindexDocument) *KeywordIndex {
+	index := &KeywordIndex{
+		docs:     docs,
+		docLens:  make([]int, len(docs)),
+		postings: make(map[string][]keywordPosting),
+	}
+
+	totalLen := 0
+	for i, doc := range docs {
+		terms := tokenizeKeywords(doc.Content)
+		index.docLens[i] = len(terms)
+		totalLen += len(terms)
+
+		freqs := make(map[string]int)
+		for _, term := range terms {
+			freqs[term]++
+		}
+		for term, freq := range freqs {
+			index.postings[term] = append(index.postings[term], keywordPosting{doc: i, freq: freq})
+		}
+	}
+
+	if len(docs) > 0 {
+		index.avgDocLen = float64(totalLen) / float64(len(docs))
+	}
+
+	return index
+}
+
+// Size 返回索引中的文档数量
+func (ki *KeywordIndex) Size() int {
+	return len(ki.docs)
+}
+
+// Search 按 BM25 得分检索文档，filters 对文档元数据做等值过滤
+func (ki *KeywordIndex) Search(query string, topK int, filters map[string]interface{}) []KeywordHit {
+	queryTerms := uniqueTerms(tokenizeKeywords(query))
+	if len(queryTerms) == 0 || len(ki.docs) == 0 {
+		return nil
+	}
+
+	scores := make(map[int]float64)
+	matched := make(map[int][]string)
+	n := float64(len(ki.docs))
+
+	for _, term := range queryTerms {
+		postings := ki.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		for _, p := range postings {
+			if !matchKeywordFilters(ki.docs[p.doc].Metadata, filters) {
+				continue
+			}
+			tf := float64(p.freq)
+			norm := 1 - bm25B + bm25B*float64(ki.docLens[p.doc])/math.Max(ki.avgDocLen, 1)
+			scores[p.doc] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+			matched[p.doc] = append(matched[p.doc], term)
+		}
+	}
+
+	hits := make([]KeywordHit, 0, len(scores))
+	for docIdx, score := range scores {
+		doc := ki.docs[docIdx]
+		hits = append(hits, KeywordHit{
+			ID:           doc.ID,
+			Content:      doc.Content,
+			Metadata:     doc.Metadata,
+			Score:        score,
+			MatchedTerms: matched[docIdx],
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score == hits[j].Score {
+			return hits[i].ID < hits[j].ID
+		}
+		return hits[i].Score > hits[j].Score
+	})
+
+	if topK > 0 && len(hits) > topK {
+		hits = hits[:topK]
+	}
+
+	return hits
+}
+
+// tokenizeKeywords 将文本切分为检索词
+// 连续汉字切分为二元组（单个汉字保留为一元组），字母数字按单词切分并转小写
+func tokenizeKeywords(text string) []string {
+	var terms []string
+	var han []rune
+	var word []rune
+
+	flushHan := func() {
+		switch {
+		case len(han) == 1:
+			terms = append(terms, string(han))
+		case len(han) > 1:
+			for i := 0; i+1 < len(han); i++ {
+				terms = append(terms, string(han[i:i+2]))
+			}
+		}
+		han = han[:0]
+	}
+	flushWord := func() {
+		if len(word) > 0 {
+			terms = append(terms, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushHan()
+			flushWord()
+		}
+	}
+	flushHan()
+	flushWord()
+
+	return terms
+}
+
+// uniqueTerms 去重并保持顺序
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	result := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			result = append(result, term)
+		}
+	}
+	return result
+}
+
+// matchKeywordFilters 检查文档元数据是否满足全部过滤条件
+func matchKeywordFilters(metadata, filters map[string]interface{}) bool {
+	for key, expected := range filters {
+		actual, ok := metadata[key]
+		if !ok || fmt.Sprint(actual) != fmt.Sprint(expected) {
+			return false
+		}
+	}
+	return true
+}
+
+// keywordIndexEntry 缓存的关键词索引及其源文件签名
+type keywordIndexEntry struct {
+	signature string
+	index     *KeywordIndex
+}
+
+// keywordIndexCache 按小说目录和内容类型缓存关键词索引，源文件变化后自动重建
+var keywordIndexCache = struct {
+	sync.Mutex
+	entries map[string]*keywordIndexEntry
+}{entries: make(map[string]*keywordIndexEntry)}
+
+// GetKeywordIndex 获取小说目录中指定内容类型的关键词索引
+// contentType 除 chapter/summary/plan 外还支持 passage（按段落切分的章节片段）
+func GetKeywordIndex(novelDir, contentType string) (*KeywordIndex, error) {
+	if contentType == "" {
+		contentType = "chapter"
+	}
+
+	signature := keywordSourceSignature(novelDir, contentType)
+	key := novelDir + "|" + contentType
+
+	keywordIndexCache.Lock()
+	defer keywordIndexCache.Unlock()
+
+	if entry, ok := keywordIndexCache.entries[key]; ok && entry.signature == signature {
+		return entry.index, nil
+	}
+
+	var (
+		docs []ReindexDocument
+		err  error
+	)
+	if contentType == "passage" {
+		docs, err = collectPassageDocuments(novelDir)
+	} else {
+		docs, err = CollectNovelDocuments(novelDir, []string{contentType})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := NewKeywordIndex(docs)
+	keywordIndexCache.entries[key] = &keywordIndexEntry{signature: signature, index: index}
+	return index, nil
+}
+
+// keywordSourceSignature 根据源文件名、大小和修改时间生成签名
+func keywordSourceSignature(novelDir, contentType string) string {
+	var files []string
+	switch contentType {
+	case "chapter", "passage":
+		files = managers.NewChapterManager(novelDir).GetChapterFiles()
+	case "summary":
+		files = []string{"index.json"}
+	case "plan":
+		files = []string{"planner.json"}
+	}
+	sort.Strings(files)
+
+	var builder strings.Builder
+	for _, name := range files {
+		builder.WriteString(name)
+		if stat, err := os.Stat(filepath.Join(novelDir, name)); err == nil {
+			builder.WriteString(fmt.Sprintf(":%d:%d;", stat.Size(), stat.ModTime().UnixNano()))
+		} else {
+			builder.WriteString(":missing;")
+		}
+	}
+	return builder.String()
+}
@@ -55,6 +55,11 @@ func (t *PlanCRUDTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 				Desc:     "是否已完成",
 				Required: false,
 			},
+			"arc_id": {
+				Type:     schema.Integer,
+				Desc:     "所属篇章ID（见 story_structure 工具）：create/update 时关联规划与篇章，list 时只列出该篇章的规划",
+				Required: false,
+			},
 		}),
 	}, nil
 }
@@ -72,7 +77,7 @@ func (t *PlanCRUDTool) InvokableRun(ctx context.Context, argumentsInJSON string,
 }
 
 // invoke 内部调用方法，保留原有逻辑
-func (t *PlanCRUDTool) invoke(_ context.Context, input map[string]any) (string, error) {
+func (t *PlanCRUDTool) invoke(ctx context.Context, input map[string]any) (string, error) {
 	action, ok := input["action"].(string)
 	if !ok {
 		return "", compose.NewInterruptAndRerunErr("缺少必要参数 action（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
@@ -80,6 +85,7 @@ func (t *PlanCRUDTool) invoke(_ context.Context, input map[string]any) (string,
 
 	// 创建规划内容管理器
 	pcm := managers.NewPlannerContentManager(t.novelDir)
+	pcm.SetVersionInfo(managers.VersionInfoFromContext(ctx).WithReason("plan_crud " + action))
 
 	switch action {
 	case "create":
@@ -93,7 +99,7 @@ func (t *PlanCRUDTool) invoke(_ context.Context, input map[string]any) (string,
 	case "set_finished":
 		return t.handleSetFinished(pcm, input)
 	case "list":
-		return t.handleList(pcm)
+		return t.handleList(pcm, input)
 	case "get_unfinished":
 		return t.handleGetUnfinished(pcm)
 	default:
@@ -125,6 +131,13 @@ func (t *PlanCRUDTool) handleCreate(pcm *managers.PlannerContentManager, input m
 		return "", fmt.Errorf("%s", fmt.Sprintf("创建计划失败: %v", err))
 	}
 
+	arcID, hasArc := intInput(input, "arc_id")
+	if hasArc {
+		if err := pcm.SetPlanArc(chapter, arcID); err != nil {
+			return "", compose.NewInterruptAndRerunErr("计划已创建，但关联篇章失败: " + err.Error() + "，请使用 story_structure 的 read 操作确认篇章ID后用 update 重新关联")
+		}
+	}
+
 	finished := false
 	// 构建成功响应
 	planEntry := &managers.PlanEntry{
@@ -132,6 +145,7 @@ func (t *PlanCRUDTool) handleCreate(pcm *managers.PlannerContentManager, input m
 		Plan:     plan,
 		Content:  content,
 		Finished: finished,
+		Arc:      arcID,
 	}
 
 	return t.successResponse("计划创建成功", planEntry, nil), nil
@@ -194,12 +208,21 @@ func (t *PlanCRUDTool) handleUpdate(pcm *managers.PlannerContentManager, input m
 		return "", fmt.Errorf("%s", fmt.Sprintf("更新计划失败: %v", err))
 	}
 
+	arcID := existingPlan.Arc
+	if value, hasArc := intInput(input, "arc_id"); hasArc && value != arcID {
+		if err := pcm.SetPlanArc(chapter, value); err != nil {
+			return "", compose.NewInterruptAndRerunErr("关联篇章失败: " + err.Error() + "，当前参数: " + fmt.Sprintf("%v", input))
+		}
+		arcID = value
+	}
+
 	// 构建响应
 	planEntry := &managers.PlanEntry{
 		Chapter:  chapter,
 		Plan:     plan,
 		Content:  content,
 		Finished: finished,
+		Arc:      arcID,
 	}
 
 	return t.successResponse("计划更新成功", planEntry, nil), nil
@@ -243,9 +266,15 @@ func (t *PlanCRUDTool) handleSetFinished(pcm *managers.PlannerContentManager, in
 	return t.successResponse("完成状态设置成功", nil, nil), nil
 }
 
-// handleList 处理列表所有计划
-func (t *PlanCRUDTool) handleList(pcm *managers.PlannerContentManager) (string, error) {
+// handleList 处理列表所有计划，指定 arc_id 时只列出该篇章的计划
+func (t *PlanCRUDTool) handleList(pcm *managers.PlannerContentManager, input map[string]any) (string, error) {
 	plans := pcm.GetAllPlans()
+	if arcID, ok := intInput(input, "arc_id"); ok {
+		plans = pcm.GetPlansForArc(arcID)
+		if plans == nil {
+			plans = []managers.PlanEntry{}
+		}
+	}
 	return t.successResponse("计划列表获取成功", nil, plans), nil
 }
 
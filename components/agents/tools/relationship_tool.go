@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+)
+
+// RelationshipTool 人物关系图工具，读取和维护角色之间的关系（类型、强度、变化章节）
+type RelationshipTool struct {
+	novelDir string
+}
+
+// NewRelationshipTool 创建人物关系图工具
+func NewRelationshipTool(novelDir string) *RelationshipTool {
+	return &RelationshipTool{
+		novelDir: novelDir,
+	}
+}
+
+// Info 实现BaseTool接口
+func (t *RelationshipTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "relationship_graph",
+		Desc: "人物关系图工具，用于读取、新增、修改、删除角色之间的关系。每条关系包含类型（如 师姐、仇敌、盟友）、强度（1-10）和发生变化的章节；可导出为 Graphviz DOT 或 Mermaid",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"action": {
+				Type:     schema.String,
+				Desc:     "操作类型: read/upsert/remove/export",
+				Required: true,
+			},
+			"character_name": {
+				Type:     schema.String,
+				Desc:     "角色名称：read/export 时只返回与该角色相关的关系，为空返回全部",
+				Required: false,
+			},
+			"from": {
+				Type:     schema.String,
+				Desc:     "关系的一方（upsert/remove 必填）",
+				Required: false,
+			},
+			"to": {
+				Type:     schema.String,
+				Desc:     "关系的另一方（upsert/remove 必填）",
+				Required: false,
+			},
+			"type": {
+				Type:     schema.String,
+				Desc:     "关系类型，表示 to 对于 from 的身份或二者的关系，如 师姐、仇敌、盟友",
+				Required: false,
+			},
+			"strength": {
+				Type:     schema.Integer,
+				Desc:     "关系强度 1-10，数值越大关系越紧密或越激烈（默认5）",
+				Required: false,
+			},
+			"directed": {
+				Type:     schema.Boolean,
+				Desc:     "是否为单向关系（如 暗恋、效忠），默认双向",
+				Required: false,
+			},
+			"description": {
+				Type:     schema.String,
+				Desc:     "关系的补充说明",
+				Required: false,
+			},
+			"chapter_number": {
+				Type:     schema.Integer,
+				Desc:     "关系建立或变化的章节（默认最新章节）",
+				Required: false,
+			},
+			"format": {
+				Type:     schema.String,
+				Desc:     "导出格式: dot/mermaid（export 时使用，默认 mermaid）",
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+// InvokableRun 实现InvokableTool接口
+func (t *RelationshipTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var input map[string]interface{}
+	if err := sonic.Unmarshal([]byte(argumentsInJSON), &input); err != nil {
+		return "", compose.NewInterruptAndRerunErr("JSON参数解析失败，请检查格式并重新调用。原始参数: " + argumentsInJSON + "，错误: " + err.Error())
+	}
+
+	return t.invoke(ctx, input)
+}
+
+// invoke 内部调用方法
+func (t *RelationshipTool) invoke(ctx context.Context, input map[string]any) (string, error) {
+	action, ok := input["action"].(string)
+	if !ok {
+		return "", compose.NewInterruptAndRerunErr("缺少必要参数 action（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
+	}
+
+	store := managers.NewRelationshipStore(t.novelDir)
+	store.SetVersionInfo(managers.VersionInfoFromContext(ctx).WithReason("relationship_graph " + action))
+
+	switch action {
+	case "read", "export":
+		graph, err := store.LoadGraph()
+		if err != nil {
+			return "", fmt.Errorf("读取人物关系图失败: %w", err)
+		}
+		if action == "read" {
+			return t.handleRead(graph, input)
+		}
+		return t.handleExport(graph, input)
+	case "upsert":
+		return t.handleUpsert(store, input)
+	case "remove":
+		return t.handleRemove(store, input)
+	default:
+		return "", compose.NewInterruptAndRerunErr("不支持的操作类型: " + action + "，支持的操作: read/upsert/remove/export，当前参数: " + fmt.Sprintf("%v", input))
+	}
+}
+
+// handleRead 读取关系，可按角色筛选
+func (t *RelationshipTool) handleRead(graph *managers.RelationshipGraph, input map[string]any) (string, error) {
+	edges := graph.Edges
+	if name, _ := input["character_name"].(string); name != "" {
+		edges = graph.ForCharacter(name)
+	}
+	if edges == nil {
+		edges = []*managers.RelationshipEdge{}
+	}
+
+	return t.successResponse(fmt.Sprintf("共 %d 条关系", len(edges)), map[string]any{
+		"relationships": edges,
+	}), nil
+}
+
+// handleUpsert 新增或更新关系
+func (t *RelationshipTool) handleUpsert(store *managers.RelationshipStore, input map[string]any) (string, error) {
+	from, to, err := t.endpoints(input)
+	if err != nil {
+		return "", err
+	}
+
+	edge := &managers.RelationshipEdge{From: from, To: to}
+	edge.Type, _ = input["type"].(string)
+	edge.Description, _ = input["description"].(string)
+	edge.Strength, _ = intInput(input, "strength")
+	directed, hasDirected := input["directed"].(bool)
+	chapter := t.chapterNumber(input)
+
+	var saved *managers.RelationshipEdge
+	changed := false
+	err = store.ModifyGraph(func(graph *managers.RelationshipGraph) error {
+		existing, exists := graph.Find(from, to)
+		if !exists && strings.TrimSpace(edge.Type) == "" {
+			return compose.NewInterruptAndRerunErr("新增关系需要提供有效的 type 参数（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
+		}
+		// 未指定的字段保留原值
+		if exists {
+			if edge.Strength == 0 {
+				edge.Strength = existing.Strength
+			}
+			edge.Directed = existing.Directed
+		}
+		if hasDirected {
+			edge.Directed = directed
+		}
+
+		changed = graph.Upsert(edge, chapter)
+		saved, _ = graph.Find(from, to)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !changed {
+		return t.successResponse("关系未发生变化", map[string]any{"relationship": saved}), nil
+	}
+	return t.successResponse("关系已更新", map[string]any{"relationship": saved}), nil
+}
+
+// handleRemove 删除关系
+func (t *RelationshipTool) handleRemove(store *managers.RelationshipStore, input map[string]any) (string, error) {
+	from, to, err := t.endpoints(input)
+	if err != nil {
+		return "", err
+	}
+
+	err = store.ModifyGraph(func(graph *managers.RelationshipGraph) error {
+		if !graph.Remove(from, to) {
+			return fmt.Errorf("未找到 %s 与 %s 之间的关系", from, to)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return t.successResponse(fmt.Sprintf("已删除 %s 与 %s 之间的关系", from, to), nil), nil
+}
+
+// handleExport 导出为 DOT 或 Mermaid
+func (t *RelationshipTool) handleExport(graph *managers.RelationshipGraph, input map[string]any) (string, error) {
+	name, _ := input["character_name"].(string)
+	format, _ := input["format"].(string)
+
+	var diagram string
+	switch strings.ToLower(format) {
+	case "dot":
+		diagram = graph.ToDOT(name)
+	case "", "mermaid":
+		format = "mermaid"
+		diagram = graph.ToMermaid(name)
+	default:
+		return "", compose.NewInterruptAndRerunErr("不支持的导出格式: " + format + "，支持的格式: dot/mermaid")
+	}
+
+	return t.successResponse("人物关系图导出成功", map[string]any{
+		"format":  strings.ToLower(format),
+		"diagram": diagram,
+	}), nil
+}
+
+// endpoints 读取关系的两端角色
+func (t *RelationshipTool) endpoints(input map[string]any) (string, string, error) {
+	from, _ := input["from"].(string)
+	to, _ := input["to"].(string)
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if from == "" || to == "" {
+		return "", "", compose.NewInterruptAndRerunErr("需要提供有效的 from 和 to 参数（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
+	}
+	if from == to {
+		return "", "", compose.NewInterruptAndRerunErr("from 和 to 不能是同一个角色，当前参数: " + fmt.Sprintf("%v", input))
+	}
+	return from, to, nil
+}
+
+// chapterNumber 读取章节号，未指定时使用最新章节
+func (t *RelationshipTool) chapterNumber(input map[string]any) int {
+	if chapter, ok := intInput(input, "chapter_number"); ok {
+		return chapter
+	}
+	return managers.NewChapterManager(t.novelDir).GetLatestChapterID()
+}
+
+// successResponse 构建成功响应
+func (t *RelationshipTool) successResponse(message string, data interface{}) string {
+	response := map[string]interface{}{
+		"success": true,
+		"message": message,
+		"data":    data,
+	}
+
+	jsonBytes, _ := sonic.MarshalIndent(response, "", "  ")
+	return string(jsonBytes)
+}
@@ -3,6 +3,7 @@ package tools
 import (
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/bytedance/sonic"
@@ -139,6 +140,21 @@ func ValidateIntParam(param int, paramName string, min, max int) error {
 	return nil
 }
 
+// intInput 读取整数参数，兼容JSON数字（float64）和数字字符串
+func intInput(input map[string]any, key string) (int, bool) {
+	switch value := input[key].(type) {
+	case float64:
+		return int(value), true
+	case int:
+		return value, true
+	case string:
+		number, err := strconv.Atoi(strings.TrimSpace(value))
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
+
 // marshalResponse 私有函数，统一序列化响应
 func marshalResponse(response *ToolResponse) (string, error) {
 	responseJSON, err := sonic.Marshal(response)
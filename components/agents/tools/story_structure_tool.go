@@ -0,0 +1,243 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+)
+
+// StoryStructureTool 卷与篇章工具，维护章节之上的卷和篇章（目标、状态、章节范围、摘要）
+type StoryStructureTool struct {
+	novelDir string
+}
+
+// NewStoryStructureTool 创建卷与篇章工具
+func NewStoryStructureTool(novelDir string) *StoryStructureTool {
+	return &StoryStructureTool{
+		novelDir: novelDir,
+	}
+}
+
+// Info 实现BaseTool接口
+func (t *StoryStructureTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: "story_structure",
+		Desc: "卷与篇章工具，用于读取和维护章节之上的卷、篇章（故事阶段，如 生存→适应→主宰）。每个篇章有目标、状态、章节范围和篇章摘要；规划可通过 plan_crud 的 arc_id 关联到篇章",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"action": {
+				Type:     schema.String,
+				Desc:     "操作类型: read/current/upsert_volume/upsert_arc/remove_volume/remove_arc",
+				Required: true,
+			},
+			"volume_id": {
+				Type:     schema.Integer,
+				Desc:     "卷ID：upsert_volume 时为空表示新建；upsert_arc 时表示篇章所属的卷",
+				Required: false,
+			},
+			"arc_id": {
+				Type:     schema.Integer,
+				Desc:     "篇章ID：upsert_arc 时为空表示新建",
+				Required: false,
+			},
+			"title": {
+				Type:     schema.String,
+				Desc:     "卷或篇章的标题（新建时必填）",
+				Required: false,
+			},
+			"goal": {
+				Type:     schema.String,
+				Desc:     "卷或篇章要达成的目标",
+				Required: false,
+			},
+			"status": {
+				Type:     schema.String,
+				Desc:     "状态: planned/active/completed",
+				Required: false,
+			},
+			"start_chapter": {
+				Type:     schema.Integer,
+				Desc:     "篇章的起始章节",
+				Required: false,
+			},
+			"end_chapter": {
+				Type:     schema.Integer,
+				Desc:     "篇章的结束章节，未确定时留空",
+				Required: false,
+			},
+			"summary": {
+				Type:     schema.String,
+				Desc:     "卷或篇章的摘要，篇章完成时总结本篇章的主要进展",
+				Required: false,
+			},
+		}),
+	}, nil
+}
+
+// InvokableRun 实现InvokableTool接口
+func (t *StoryStructureTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var input map[string]interface{}
+	if err := sonic.Unmarshal([]byte(argumentsInJSON), &input); err != nil {
+		return "", compose.NewInterruptAndRerunErr("JSON参数解析失败，请检查格式并重新调用。原始参数: " + argumentsInJSON + "，错误: " + err.Error())
+	}
+
+	return t.invoke(ctx, input)
+}
+
+// invoke 内部调用方法
+func (t *StoryStructureTool) invoke(ctx context.Context, input map[string]any) (string, error) {
+	action, ok := input["action"].(string)
+	if !ok {
+		return "", compose.NewInterruptAndRerunErr("缺少必要参数 action（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
+	}
+
+	store := managers.NewStoryStructureStore(t.novelDir)
+	store.SetVersionInfo(managers.VersionInfoFromContext(ctx).WithReason("story_structure " + action))
+
+	switch action {
+	case "read":
+		return t.handleRead(store)
+	case "current":
+		return t.handleCurrent(store)
+	case "upsert_volume":
+		return t.handleUpsertVolume(store, input)
+	case "upsert_arc":
+		return t.handleUpsertArc(store, input)
+	case "remove_volume", "remove_arc":
+		return t.handleRemove(store, action, input)
+	default:
+		return "", compose.NewInterruptAndRerunErr("不支持的操作类型: " + action + "，支持的操作: read/current/upsert_volume/upsert_arc/remove_volume/remove_arc，当前参数: " + fmt.Sprintf("%v", input))
+	}
+}
+
+// handleRead 读取完整的卷与篇章结构
+func (t *StoryStructureTool) handleRead(store *managers.StoryStructureStore) (string, error) {
+	structure, err := store.LoadStructure()
+	if err != nil {
+		return "", fmt.Errorf("读取卷与篇章结构失败: %w", err)
+	}
+
+	return t.successResponse(fmt.Sprintf("共 %d 卷、%d 个篇章", len(structure.Volumes), len(structure.Arcs)), map[string]any{
+		"outline":   structure.RenderOutline(),
+		"structure": structure,
+	}), nil
+}
+
+// handleCurrent 读取接下来要写的章节所属的篇章及其规划
+func (t *StoryStructureTool) handleCurrent(store *managers.StoryStructureStore) (string, error) {
+	arc, ok := store.GetCurrentArc()
+	if !ok {
+		return t.successResponse("当前章节不属于任何篇章，可使用 upsert_arc 创建篇章", nil), nil
+	}
+
+	plans := managers.NewPlannerContentManager(t.novelDir).GetPlansForArc(arc.ID)
+	if plans == nil {
+		plans = []managers.PlanEntry{}
+	}
+	return t.successResponse(fmt.Sprintf("当前篇章: 《%s》%s", arc.Title, arc.RangeText()), map[string]any{
+		"arc":     arc,
+		"context": store.GetArcContext(),
+		"plans":   plans,
+	}), nil
+}
+
+// handleUpsertVolume 新增或更新卷
+func (t *StoryStructureTool) handleUpsertVolume(store *managers.StoryStructureStore, input map[string]any) (string, error) {
+	volume := &managers.Volume{}
+	volume.ID, _ = intInput(input, "volume_id")
+	volume.Title = t.stringInput(input, "title")
+	volume.Goal = t.stringInput(input, "goal")
+	volume.Status = t.stringInput(input, "status")
+	volume.Summary = t.stringInput(input, "summary")
+
+	var saved *managers.Volume
+	err := store.ModifyStructure(func(structure *managers.StoryStructure) error {
+		var err error
+		saved, err = structure.UpsertVolume(volume)
+		return err
+	})
+	if err != nil {
+		return "", compose.NewInterruptAndRerunErr("保存卷失败: " + err.Error() + "，当前参数: " + fmt.Sprintf("%v", input))
+	}
+
+	return t.successResponse(fmt.Sprintf("卷 #%d《%s》已保存", saved.ID, saved.Title), map[string]any{"volume": saved}), nil
+}
+
+// handleUpsertArc 新增或更新篇章
+func (t *StoryStructureTool) handleUpsertArc(store *managers.StoryStructureStore, input map[string]any) (string, error) {
+	arc := &managers.Arc{}
+	arc.ID, _ = intInput(input, "arc_id")
+	arc.Volume, _ = intInput(input, "volume_id")
+	arc.StartChapter, _ = intInput(input, "start_chapter")
+	arc.EndChapter, _ = intInput(input, "end_chapter")
+	arc.Title = t.stringInput(input, "title")
+	arc.Goal = t.stringInput(input, "goal")
+	arc.Status = t.stringInput(input, "status")
+	arc.Summary = t.stringInput(input, "summary")
+
+	var saved *managers.Arc
+	err := store.ModifyStructure(func(structure *managers.StoryStructure) error {
+		var err error
+		saved, err = structure.UpsertArc(arc)
+		return err
+	})
+	if err != nil {
+		return "", compose.NewInterruptAndRerunErr("保存篇章失败: " + err.Error() + "，当前参数: " + fmt.Sprintf("%v", input))
+	}
+
+	return t.successResponse(fmt.Sprintf("篇章 #%d《%s》已保存（%s）", saved.ID, saved.Title, saved.RangeText()), map[string]any{"arc": saved}), nil
+}
+
+// handleRemove 删除卷或篇章
+func (t *StoryStructureTool) handleRemove(store *managers.StoryStructureStore, action string, input map[string]any) (string, error) {
+	key, kind := "arc_id", "篇章"
+	if action == "remove_volume" {
+		key, kind = "volume_id", "卷"
+	}
+	id, ok := intInput(input, key)
+	if !ok || id <= 0 {
+		return "", compose.NewInterruptAndRerunErr(action + " 需要提供有效的 " + key + " 参数（整数类型），当前参数: " + fmt.Sprintf("%v", input))
+	}
+
+	err := store.ModifyStructure(func(structure *managers.StoryStructure) error {
+		removed := false
+		if action == "remove_volume" {
+			removed = structure.RemoveVolume(id)
+		} else {
+			removed = structure.RemoveArc(id)
+		}
+		if !removed {
+			return fmt.Errorf("%s不存在: %d", kind, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return t.successResponse(fmt.Sprintf("已删除%s #%d", kind, id), nil), nil
+}
+
+// stringInput 读取去除首尾空白的字符串参数
+func (t *StoryStructureTool) stringInput(input map[string]any, key string) string {
+	value, _ := input[key].(string)
+	return strings.TrimSpace(value)
+}
+
+// successResponse 构建成功响应
+func (t *StoryStructureTool) successResponse(message string, data interface{}) string {
+	response := map[string]interface{}{
+		"success": true,
+		"message": message,
+		"data":    data,
+	}
+
+	jsonBytes, _ := sonic.MarshalIndent(response, "", "  ")
+	return string(jsonBytes)
+}
@@ -89,7 +89,7 @@ func (t *SummaryCRUDTool) invoke(ctx context.Context, input map[string]any) (str
 	case "read":
 		return t.handleRead(input)
 	case "update":
-		return t.handleUpdate(input)
+		return t.handleUpdate(ctx, input)
 	case "extract_info":
 		return t.handleExtractInfo(input)
 	default:
@@ -124,6 +124,7 @@ func (t *SummaryCRUDTool) handleCreate(ctx context.Context, input map[string]any
 
 	// 更新索引
 	indexManager := managers.NewIndexManager(t.novelDir)
+	indexManager.SetVersionInfo(managers.VersionInfoFromContext(ctx).WithReason("生成第" + summary.ChapterID + "章摘要"))
 	if err := indexManager.UpdateSummary(*summary); err != nil {
 		return "", fmt.Errorf("更新索引失败: %w", err)
 	}
@@ -153,7 +154,7 @@ func (t *SummaryCRUDTool) handleRead(input map[string]any) (string, error) {
 }
 
 // handleUpdate 处理更新摘要
-func (t *SummaryCRUDTool) handleUpdate(input map[string]any) (string, error) {
+func (t *SummaryCRUDTool) handleUpdate(ctx context.Context, input map[string]any) (string, error) {
 	chapterID, idOk := input["chapter_id"].(string)
 	if !idOk || chapterID == "" {
 		return "", compose.NewInterruptAndRerunErr("更新摘要需要提供有效的 chapter_id 参数（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
@@ -175,6 +176,7 @@ func (t *SummaryCRUDTool) handleUpdate(input map[string]any) (string, error) {
 
 	// 更新索引
 	indexManager := managers.NewIndexManager(t.novelDir)
+	indexManager.SetVersionInfo(managers.VersionInfoFromContext(ctx).WithReason("更新第" + chapterID + "章摘要"))
 	if err := indexManager.UpdateSummary(*summary); err != nil {
 		return "", fmt.Errorf("更新索引失败: %w", err)
 	}
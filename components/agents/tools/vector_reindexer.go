@@ -0,0 +1,396 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+)
+
+// ReindexStateFilePattern 重建索引进度文件名模板（按会话保存在小说目录中）
+const ReindexStateFilePattern = ".reindex_%s.json"
+
+// 重建索引的文档变更类型
+const (
+	ReindexActionAdd       = "add"
+	ReindexActionUpdate    = "update"
+	ReindexActionUnchanged = "unchanged"
+	ReindexActionRemove    = "remove"
+)
+
+// ReindexDocument 待写入向量库的文档
+type ReindexDocument struct {
+	ContentType string                 `json:"content_type"`
+	ID          string                 `json:"id"`
+	Content     string                 `json:"content"`
+	Metadata    map[string]interface{} `json:"metadata"`
+	Hash        string                 `json:"hash"`
+}
+
+// ReindexState 重建索引进度，记录每个集合中已成功写入文档的内容哈希
+type ReindexState struct {
+	SessionID   string                       `json:"session_id"`
+	Collections map[string]map[string]string `json:"collections"`
+	UpdatedAt   time.Time                    `json:"updated_at"`
+}
+
+// ReindexItem 单个文档的变更计划
+type ReindexItem struct {
+	Collection string `json:"collection"`
+	ID         string `json:"id"`
+	Action     string `json:"action"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ReindexReport 重建索引结果
+type ReindexReport struct {
+	DryRun    bool          `json:"dry_run"`
+	Added     int           `json:"added"`
+	Updated   int           `json:"updated"`
+	Unchanged int           `json:"unchanged"`
+	Removed   int           `json:"removed"`
+	Failed    int           `json:"failed"`
+	Items     []ReindexItem `json:"items"`
+}
+
+// ReindexOptions 重建索引选项
+type ReindexOptions struct {
+	DryRun       bool                                       // 仅计算变更，不写入向量库
+	Force        bool                                       // 忽略进度文件，全部重新写入
+	ContentTypes []string                                   // 限定内容类型，空表示 chapter/summary/plan 全部
+	Progress     func(current, total int, item ReindexItem) // 进度回调
+}
+
+// VectorReindexer 根据小说目录中的章节、摘要和规划重建向量集合
+type VectorReindexer struct {
+	novelDir  string
+	config    *VectorStoreConfig
+	service   *VectorStoreService
+	statePath string
+}
+
+// NewVectorReindexer 创建重建索引器，service 为 nil 时只能执行 dry-run
+func NewVectorReindexer(novelDir string, config *VectorStoreConfig, service *VectorStoreService) *VectorReindexer {
+	return &VectorReindexer{
+		novelDir:  novelDir,
+		config:    config,
+		service:   service,
+		statePath: filepath.Join(novelDir, fmt.Sprintf(ReindexStateFilePattern, config.SessionID)),
+	}
+}
+
+// GetStatePath 获取进度文件路径
+func (r *VectorReindexer) GetStatePath() string {
+	return r.statePath
+}
+
+// CollectDocuments 收集小说目录中需要索引的全部文档
+func (r *VectorReindexer) CollectDocuments(contentTypes []string) ([]ReindexDocument, error) {
+	return CollectNovelDocuments(r.novelDir, contentTypes)
+}
+
+// CollectNovelDocuments 从小说目录收集章节、摘要和规划文档，文档ID与重建索引时写入向量库的ID一致
+func CollectNovelDocuments(novelDir string, contentTypes []string) ([]ReindexDocument, error) {
+	if len(contentTypes) == 0 {
+		contentTypes = []string{"chapter", "summary", "plan"}
+	}
+
+	var docs []ReindexDocument
+	for _, contentType := range contentTypes {
+		var (
+			collected []ReindexDocument
+			err       error
+		)
+		switch contentType {
+		case "chapter":
+			collected, err = collectChapterDocuments(novelDir)
+		case "summary":
+			collected = collectSummaryDocuments(novelDir)
+		case "plan":
+			collected = collectPlanDocuments(novelDir)
+		default:
+			return nil, fmt.Errorf("不支持的内容类型: %s", contentType)
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, collected...)
+	}
+
+	return docs, nil
+}
+
+// collectChapterDocuments 收集章节文档
+func collectChapterDocuments(novelDir string) ([]ReindexDocument, error) {
+	cm := managers.NewChapterManager(novelDir)
+
+	// 按章节清单的阅读顺序收集
+	var docs []ReindexDocument
+	for _, entry := range cm.GetChapterEntries() {
+		fileName := entry.File
+		data, err := os.ReadFile(filepath.Join(novelDir, fileName))
+		if err != nil {
+			return nil, fmt.Errorf("读取章节文件 %s 失败: %w", fileName, err)
+		}
+
+		var chapter managers.ChapterData
+		if err := json.Unmarshal(data, &chapter); err != nil {
+			return nil, fmt.Errorf("解析章节文件 %s 失败: %w", fileName, err)
+		}
+
+		paragraphs := make([]string, 0, len(chapter.Content))
+		for _, paragraph := range chapter.Content {
+			paragraphs = append(paragraphs, paragraph.Text)
+		}
+		text := strings.Join(paragraphs, "\n\n")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		number := entry.ID
+		docs = append(docs, newReindexDocument("chapter", fmt.Sprintf("chapter_%d", number), text, map[string]interface{}{
+			"chapter":    number,
+			"chapter_id": chapter.ChapterID,
+			"title":      chapter.Title,
+			"file":       fileName,
+		}))
+	}
+
+	return docs, nil
+}
+
+// collectSummaryDocuments 收集 index.json 中的章节摘要文档
+func collectSummaryDocuments(novelDir string) []ReindexDocument {
+	ir := managers.NewIndexReader(novelDir)
+
+	var docs []ReindexDocument
+	for _, summary := range ir.GetChapterSummaries() {
+		if strings.TrimSpace(summary.Summary) == "" {
+			continue
+		}
+		docs = append(docs, newReindexDocument("summary", "summary_"+summary.ChapterID, summary.Summary, map[string]interface{}{
+			"chapter_id": summary.ChapterID,
+			"title":      summary.Title,
+			"word_count": summary.WordCount,
+		}))
+	}
+
+	return docs
+}
+
+// collectPlanDocuments 收集 planner.json 中的规划文档
+func collectPlanDocuments(novelDir string) []ReindexDocument {
+	pcm := managers.NewPlannerContentManager(novelDir)
+
+	var docs []ReindexDocument
+	for _, plan := range pcm.GetAllPlans() {
+		text := strings.TrimSpace(plan.Plan + "\n" + plan.Content)
+		if text == "" {
+			continue
+		}
+		docs = append(docs, newReindexDocument("plan", "plan_"+plan.Chapter, text, map[string]interface{}{
+			"chapter":  plan.Chapter,
+			"plan":     plan.Plan,
+			"finished": plan.Finished,
+		}))
+	}
+
+	return docs
+}
+
+// newReindexDocument 创建文档并计算内容哈希
+func newReindexDocument(contentType, id, content string, metadata map[string]interface{}) ReindexDocument {
+	metadata["content_type"] = contentType
+	sum := sha256.Sum256([]byte(content))
+	return ReindexDocument{
+		ContentType: contentType,
+		ID:          id,
+		Content:     content,
+		Metadata:    metadata,
+		Hash:        hex.EncodeToString(sum[:]),
+	}
+}
+
+// LoadState 加载进度文件，不存在时返回空进度
+func (r *VectorReindexer) LoadState() (*ReindexState, error) {
+	state := &ReindexState{
+		SessionID:   r.config.SessionID,
+		Collections: make(map[string]map[string]string),
+	}
+
+	data, err := os.ReadFile(r.statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("读取进度文件失败: %w", err)
+	}
+
+	var saved ReindexState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("解析进度文件失败: %w", err)
+	}
+
+	// 不同会话的进度互不影响
+	if saved.SessionID != r.config.SessionID || saved.Collections == nil {
+		return state, nil
+	}
+
+	return &saved, nil
+}
+
+// saveState 保存进度文件
+func (r *VectorReindexer) saveState(state *ReindexState) error {
+	state.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化进度文件失败: %w", err)
+	}
+	if err := os.WriteFile(r.statePath, data, 0644); err != nil {
+		return fmt.Errorf("写入进度文件失败: %w", err)
+	}
+	return nil
+}
+
+// Run 执行重建索引；每写入一个文档即保存进度，中断后再次运行会跳过已写入且未变化的文档
+func (r *VectorReindexer) Run(ctx context.Context, opts ReindexOptions) (*ReindexReport, error) {
+	if !isValidStoreSessionID(r.config.SessionID) {
+		return nil, fmt.Errorf("无效的会话ID: %q", r.config.SessionID)
+	}
+	if !opts.DryRun && r.service == nil {
+		return nil, fmt.Errorf("向量存储服务未初始化")
+	}
+
+	docs, err := r.CollectDocuments(opts.ContentTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := r.LoadState()
+	if err != nil {
+		return nil, err
+	}
+	// 已记录但本次未收集到的文档需要从集合中删除
+	type staleDoc struct {
+		contentType string
+		collection  string
+		id          string
+	}
+	present := make(map[string]map[string]bool)
+	for _, doc := range docs {
+		collection := r.config.ResolveStoreCollection(doc.ContentType)
+		if present[collection] == nil {
+			present[collection] = make(map[string]bool)
+		}
+		present[collection][doc.ID] = true
+	}
+
+	contentTypes := opts.ContentTypes
+	if len(contentTypes) == 0 {
+		contentTypes = []string{"chapter", "summary", "plan"}
+	}
+	var stale []staleDoc
+	for _, contentType := range contentTypes {
+		collection := r.config.ResolveStoreCollection(contentType)
+		ids := make([]string, 0, len(state.Collections[collection]))
+		for id := range state.Collections[collection] {
+			if !present[collection][id] {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			stale = append(stale, staleDoc{contentType: contentType, collection: collection, id: id})
+		}
+	}
+
+	// 强制模式下所有文档都按新增处理，但仍删除进度中记录的过期文档
+	if opts.Force {
+		state.Collections = make(map[string]map[string]string)
+	}
+
+	report := &ReindexReport{DryRun: opts.DryRun}
+	total := len(docs) + len(stale)
+	current := 0
+
+	record := func(item ReindexItem) {
+		current++
+		switch {
+		case item.Error != "":
+			report.Failed++
+		case item.Action == ReindexActionAdd:
+			report.Added++
+		case item.Action == ReindexActionUpdate:
+			report.Updated++
+		case item.Action == ReindexActionUnchanged:
+			report.Unchanged++
+		case item.Action == ReindexActionRemove:
+			report.Removed++
+		}
+		report.Items = append(report.Items, item)
+		if opts.Progress != nil {
+			opts.Progress(current, total, item)
+		}
+	}
+
+	for _, doc := range docs {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		collection := r.config.ResolveStoreCollection(doc.ContentType)
+		item := ReindexItem{Collection: collection, ID: doc.ID, Action: ReindexActionAdd}
+		if previous, ok := state.Collections[collection][doc.ID]; ok {
+			item.Action = ReindexActionUpdate
+			if previous == doc.Hash {
+				item.Action = ReindexActionUnchanged
+			}
+		}
+
+		if item.Action != ReindexActionUnchanged && !opts.DryRun {
+			if err := r.service.StoreDocument(ctx, r.config, doc.ContentType, doc.ID, doc.Content, doc.Metadata); err != nil {
+				item.Error = err.Error()
+			} else {
+				if state.Collections[collection] == nil {
+					state.Collections[collection] = make(map[string]string)
+				}
+				state.Collections[collection][doc.ID] = doc.Hash
+				if err := r.saveState(state); err != nil {
+					return report, err
+				}
+			}
+		}
+
+		record(item)
+	}
+
+	for _, doc := range stale {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		item := ReindexItem{Collection: doc.collection, ID: doc.id, Action: ReindexActionRemove}
+		if !opts.DryRun {
+			if err := r.service.DeleteDocument(ctx, r.config, doc.contentType, doc.id); err != nil {
+				item.Error = err.Error()
+			} else {
+				delete(state.Collections[doc.collection], doc.id)
+				if err := r.saveState(state); err != nil {
+					return report, err
+				}
+			}
+		}
+
+		record(item)
+	}
+
+	return report, nil
+}
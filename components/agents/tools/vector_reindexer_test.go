@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+)
+
+func reindexActions(report *ReindexReport) map[string]string {
+	actions := make(map[string]string)
+	for _, item := range report.Items {
+		actions[item.ID] = item.Action
+	}
+	return actions
+}
+
+func TestVectorReindexerDryRun(t *testing.T) {
+	dir := t.TempDir()
+	cm := managers.NewChapterManager(dir)
+	_, err := cm.WriteChapter("醒来", "林凡在青云山下醒来。")
+	require.NoError(t, err)
+	_, err = cm.WriteChapter("入城", "远方的城池灯火通明。")
+	require.NoError(t, err)
+
+	reindexer := NewVectorReindexer(dir, &VectorStoreConfig{SessionID: "novel_test"}, nil)
+	docs, err := reindexer.CollectDocuments([]string{"chapter"})
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, 1, docs[0].Metadata["chapter"])
+
+	// 进度中第1章未变化、第2章内容已变、第9章已不存在
+	collection := reindexer.config.ResolveStoreCollection("chapter")
+	state := &ReindexState{SessionID: "novel_test", Collections: map[string]map[string]string{
+		collection: {docs[0].ID: docs[0].Hash, docs[1].ID: "stale-hash", "chapter_9": "gone"},
+	}}
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(reindexer.GetStatePath(), data, 0644))
+
+	report, err := reindexer.Run(context.Background(), ReindexOptions{DryRun: true, ContentTypes: []string{"chapter"}})
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, map[string]string{
+		docs[0].ID:  ReindexActionUnchanged,
+		docs[1].ID:  ReindexActionUpdate,
+		"chapter_9": ReindexActionRemove,
+	}, reindexActions(report))
+
+	// 强制模式下全部重新写入，过期文档仍被删除
+	report, err = reindexer.Run(context.Background(), ReindexOptions{DryRun: true, Force: true, ContentTypes: []string{"chapter"}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Added)
+	assert.Equal(t, 1, report.Removed)
+
+	// dry-run 不修改进度文件
+	saved, err := reindexer.LoadState()
+	require.NoError(t, err)
+	assert.Equal(t, "stale-hash", saved.Collections[collection][docs[1].ID])
+
+	// 没有向量服务时只能 dry-run
+	_, err = reindexer.Run(context.Background(), ReindexOptions{})
+	assert.Error(t, err)
+}
@@ -18,6 +18,7 @@ type VectorSearchConfig struct {
 	SessionID string // 会话ID，必须注入
 	Tenant    string // Chroma租户，注入
 	Database  string // Chroma数据库，注入
+	NovelDir  string // 小说目录，关键词/混合检索时注入
 }
 
 // WithSearchConfig 创建向量搜索工具配置选项
@@ -29,6 +30,13 @@ func WithSearchConfig(sessionID, tenant, database string) tool.Option {
 	})
 }
 
+// WithSearchNovelDir 注入小说目录，用于关键词/混合检索构建本地倒排索引
+func WithSearchNovelDir(novelDir string) tool.Option {
+	return tool.WrapImplSpecificOptFn(func(config *VectorSearchConfig) {
+		config.NovelDir = novelDir
+	})
+}
+
 // ResolveSearchCollection 基于注入的sessionID和contentType解析集合名称
 func (config *VectorSearchConfig) ResolveSearchCollection(contentType string) string {
 	// 验证sessionID格式，防止注入攻击
@@ -196,6 +204,16 @@ func (s *VectorSearchTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 				Desc:     "Document filter conditions",
 				Required: false,
 			},
+			"mode": {
+				Type:     "string",
+				Desc:     "Search mode: vector, keyword, hybrid (default: vector). Use keyword/hybrid for character names, places and invented terms",
+				Required: false,
+			},
+			"keyword_weight": {
+				Type:     "number",
+				Desc:     "Weight of keyword matches in hybrid mode, 0-1 (default: 0.5)",
+				Required: false,
+			},
 		}),
 	}, nil
 }
@@ -216,11 +234,13 @@ func (s *VectorSearchTool) InvokableRun(ctx context.Context, argumentsInJSON str
 	}
 
 	var input struct {
-		Query       string                 `json:"query"`
-		ContentType string                 `json:"content_type"`
-		TopK        int                    `json:"top_k"`
-		Threshold   float64                `json:"threshold"`
-		Filters     map[string]interface{} `json:"filters,omitempty"`
+		Query         string                 `json:"query"`
+		ContentType   string                 `json:"content_type"`
+		TopK          int                    `json:"top_k"`
+		Threshold     float64                `json:"threshold"`
+		Filters       map[string]interface{} `json:"filters,omitempty"`
+		Mode          string                 `json:"mode"`
+		KeywordWeight *float64               `json:"keyword_weight,omitempty"`
 	}
 
 	if err := SafeParseJSON(argumentsInJSON, &input); err != nil {
@@ -247,9 +267,41 @@ func (s *VectorSearchTool) InvokableRun(ctx context.Context, argumentsInJSON str
 		return BuildErrorResponse(err)
 	}
 
-	results, err := s.service.SearchDocuments(ctx, config, input.Query, input.ContentType, input.TopK, input.Threshold, input.Filters)
-	if err != nil {
-		return BuildErrorResponse(err, "Vector search failed")
+	if input.Mode == "" {
+		input.Mode = SearchModeVector
+	}
+	keywordWeight := 0.5
+	if input.KeywordWeight != nil {
+		keywordWeight = *input.KeywordWeight
+	}
+	if keywordWeight < 0 || keywordWeight > 1 {
+		return BuildErrorResponse(fmt.Errorf("keyword_weight must be between 0 and 1, got %v", keywordWeight))
+	}
+
+	var results []map[string]interface{}
+	var vectorErr error
+	switch input.Mode {
+	case SearchModeVector:
+		var err error
+		results, err = s.service.SearchDocuments(ctx, config, input.Query, input.ContentType, input.TopK, input.Threshold, input.Filters)
+		if err != nil {
+			return BuildErrorResponse(err, "Vector search failed")
+		}
+	case SearchModeKeyword:
+		var err error
+		results, err = KeywordSearchDocuments(config, input.Query, input.ContentType, input.TopK, input.Filters)
+		if err != nil {
+			return BuildErrorResponse(err, "Keyword search failed")
+		}
+	case SearchModeHybrid:
+		hybrid, err := s.service.HybridSearchDocuments(ctx, config, input.Query, input.ContentType, input.TopK, input.Threshold, keywordWeight, input.Filters)
+		if err != nil {
+			return BuildErrorResponse(err, "Hybrid search failed")
+		}
+		results = hybrid.Documents
+		vectorErr = hybrid.VectorError
+	default:
+		return BuildErrorResponse(fmt.Errorf("unsupported search mode: %s", input.Mode))
 	}
 
 	_ = time.Since(start) // Duration recorded but metrics removed
@@ -258,9 +310,18 @@ func (s *VectorSearchTool) InvokableRun(ctx context.Context, argumentsInJSON str
 		"documents":   results,
 		"total_found": len(results),
 		"query":       input.Query,
-		"collection":  config.ResolveSearchCollection(input.ContentType),
 		"session_id":  config.SessionID,
 	}
+	// 纯关键词检索只使用本地索引，不涉及向量集合
+	if input.Mode != SearchModeKeyword {
+		data["collection"] = config.ResolveSearchCollection(input.ContentType)
+	}
+	if input.Mode != SearchModeVector {
+		data["mode"] = input.Mode
+	}
+	if vectorErr != nil {
+		data["vector_error"] = vectorErr.Error()
+	}
 
 	return BuildSuccessResponse(data)
 }
\ No newline at end of file
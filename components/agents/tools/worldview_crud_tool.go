@@ -15,6 +15,7 @@ import (
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
 	"github.com/Kizunad/modular-workflow-v2/providers"
 )
 
@@ -78,15 +79,18 @@ func (t *WorldviewCRUDTool) invoke(ctx context.Context, input map[string]any) (s
 		return "", compose.NewInterruptAndRerunErr("缺少必要参数 action（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
 	}
 
+	// 写入世界观时记录的版本来源
+	info := managers.VersionInfoFromContext(ctx).WithReason("worldview_crud " + action)
+
 	switch action {
 	case "read":
 		return t.handleRead()
 	case "update":
-		return t.handleUpdate(input)
+		return t.handleUpdate(info, input)
 	case "analyze_changes":
-		return t.handleAnalyzeChanges(ctx, input)
+		return t.handleAnalyzeChanges(ctx, info, input)
 	case "merge_update":
-		return t.handleMergeUpdate(input)
+		return t.handleMergeUpdate(info, input)
 	default:
 		return "", compose.NewInterruptAndRerunErr("不支持的操作类型: " + action + "，支持的操作: read/update/analyze_changes/merge_update，当前参数: " + fmt.Sprintf("%v", input))
 	}
@@ -116,7 +120,7 @@ func (t *WorldviewCRUDTool) handleRead() (string, error) {
 }
 
 // handleUpdate 处理直接更新世界观信息
-func (t *WorldviewCRUDTool) handleUpdate(input map[string]any) (string, error) {
+func (t *WorldviewCRUDTool) handleUpdate(info managers.VersionInfo, input map[string]any) (string, error) {
 	updateContent, contentOk := input["update_content"].(string)
 	if !contentOk || updateContent == "" {
 		return "", compose.NewInterruptAndRerunErr("更新世界观信息需要提供有效的 update_content 参数（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
@@ -126,7 +130,7 @@ func (t *WorldviewCRUDTool) handleUpdate(input map[string]any) (string, error) {
 	worldviewPath := filepath.Join(t.novelDir, "worldview.md")
 
 	// 保存世界观文件
-	if err := t.saveWorldview(worldviewPath, updateContent); err != nil {
+	if err := t.saveWorldview(info, worldviewPath, updateContent); err != nil {
 		return "", fmt.Errorf("保存世界观文件失败: %w", err)
 	}
 
@@ -137,7 +141,7 @@ func (t *WorldviewCRUDTool) handleUpdate(input map[string]any) (string, error) {
 }
 
 // handleMergeUpdate 处理合并更新世界观信息
-func (t *WorldviewCRUDTool) handleMergeUpdate(input map[string]any) (string, error) {
+func (t *WorldviewCRUDTool) handleMergeUpdate(info managers.VersionInfo, input map[string]any) (string, error) {
 	updateContent, contentOk := input["update_content"].(string)
 	if !contentOk || updateContent == "" {
 		return "", compose.NewInterruptAndRerunErr("合并更新世界观信息需要提供有效的 update_content 参数（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
@@ -156,7 +160,7 @@ func (t *WorldviewCRUDTool) handleMergeUpdate(input map[string]any) (string, err
 	updatedWorldview := t.mergeWorldviewUpdate(currentWorldview, updateContent)
 
 	// 保存世界观文件
-	if err := t.saveWorldview(worldviewPath, updatedWorldview); err != nil {
+	if err := t.saveWorldview(info, worldviewPath, updatedWorldview); err != nil {
 		return "", fmt.Errorf("保存世界观文件失败: %w", err)
 	}
 
@@ -168,7 +172,7 @@ func (t *WorldviewCRUDTool) handleMergeUpdate(input map[string]any) (string, err
 }
 
 // handleAnalyzeChanges 处理AI分析世界观变化
-func (t *WorldviewCRUDTool) handleAnalyzeChanges(ctx context.Context, input map[string]any) (string, error) {
+func (t *WorldviewCRUDTool) handleAnalyzeChanges(ctx context.Context, info managers.VersionInfo, input map[string]any) (string, error) {
 	latestChapter, chapterOk := input["latest_chapter"].(string)
 	if !chapterOk || latestChapter == "" {
 		return "", compose.NewInterruptAndRerunErr("分析世界观变化需要提供有效的 latest_chapter 参数（字符串类型），当前参数: " + fmt.Sprintf("%v", input))
@@ -191,7 +195,7 @@ func (t *WorldviewCRUDTool) handleAnalyzeChanges(ctx context.Context, input map[
 
 	// 如果需要更新，执行更新
 	if analysisResult.NeedsUpdate {
-		if err := t.saveWorldview(worldviewPath, analysisResult.UpdatedWorldview); err != nil {
+		if err := t.saveWorldview(info.WithReason("AI分析章节后更新世界观: "+analysisResult.Reason), worldviewPath, analysisResult.UpdatedWorldview); err != nil {
 			return "", fmt.Errorf("保存世界观信息失败: %w", err)
 		}
 	}
@@ -454,35 +458,24 @@ func (t *WorldviewCRUDTool) addUpdateToLatestWorld(currentWorldview, updateConte
 }
 
 // saveWorldview 追加保存世界观文件（只能追加，不能覆盖）
-func (t *WorldviewCRUDTool) saveWorldview(worldviewPath, content string) error {
-	// 确保目录存在
-	if err := os.MkdirAll(filepath.Dir(worldviewPath), 0755); err != nil {
-		return fmt.Errorf("创建目录失败: %w", err)
-	}
-	
-	// 检查原文件是否存在
-	if _, err := os.Stat(worldviewPath); err == nil {
-		// 文件已存在，将内容追加到"世界 1"章节末尾
-		return t.appendToWorld1Section(worldviewPath, content)
-	} else {
-		// 文件不存在，创建新文件
-		if err := os.WriteFile(worldviewPath, []byte(content), 0644); err != nil {
-			return fmt.Errorf("创建世界观文件失败: %w", err)
+func (t *WorldviewCRUDTool) saveWorldview(info managers.VersionInfo, worldviewPath, content string) error {
+	manager := managers.NewBaseFileManager(worldviewPath)
+	manager.SetVersionInfo(info)
+
+	// 在文件锁内读取最新内容并原子写入，避免与其他任务的世界观更新相互覆盖
+	return manager.Modify(func(existing string) (string, error) {
+		if existing == "" {
+			// 文件不存在，创建新文件
+			return content, nil
 		}
-	}
-
-	return nil
+		// 文件已存在，将内容追加到"世界 1"章节末尾
+		return t.appendToWorld1Section(existing, content), nil
+	})
 }
 
-// appendToWorld1Section 将内容追加到"世界 1"章节的末尾
-func (t *WorldviewCRUDTool) appendToWorld1Section(worldviewPath, content string) error {
-	// 读取现有文件内容
-	existingContent, err := os.ReadFile(worldviewPath)
-	if err != nil {
-		return fmt.Errorf("读取现有文件失败: %w", err)
-	}
-	
-	lines := strings.Split(string(existingContent), "\n")
+// appendToWorld1Section 将内容追加到"世界 1"章节的末尾，返回更新后的内容
+func (t *WorldviewCRUDTool) appendToWorld1Section(existingContent, content string) string {
+	lines := strings.Split(existingContent, "\n")
 	
 	// 查找"世界 1"章节的开始和结束位置
 	world1StartIndex := -1
@@ -503,17 +496,7 @@ func (t *WorldviewCRUDTool) appendToWorld1Section(worldviewPath, content string)
 	
 	if world1StartIndex == -1 {
 		// 没有找到"世界 1"章节，直接追加到文件末尾
-		file, err := os.OpenFile(worldviewPath, os.O_APPEND|os.O_WRONLY, 0644)
-		if err != nil {
-			return fmt.Errorf("打开文件进行追加失败: %w", err)
-		}
-		defer file.Close()
-		
-		_, err = file.WriteString("\n\n" + content)
-		if err != nil {
-			return fmt.Errorf("追加世界观内容失败: %w", err)
-		}
-		return nil
+		return existingContent + "\n\n" + content
 	}
 	
 	// 构建新的文件内容：在"世界 1"章节末尾插入新内容
@@ -530,13 +513,7 @@ func (t *WorldviewCRUDTool) appendToWorld1Section(worldviewPath, content string)
 		newLines = append(newLines, lines[world1EndIndex:]...)
 	}
 	
-	// 写入更新后的内容
-	newContent := strings.Join(newLines, "\n")
-	if err := os.WriteFile(worldviewPath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("写入更新后的文件失败: %w", err)
-	}
-	
-	return nil
+	return strings.Join(newLines, "\n")
 }
 
 
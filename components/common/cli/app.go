@@ -6,8 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Kizunad/modular-workflow-v2/components/common"
+	"github.com/Kizunad/modular-workflow-v2/components/content"
 	"github.com/Kizunad/modular-workflow-v2/config"
 	"github.com/Kizunad/modular-workflow-v2/logger"
 	"github.com/Kizunad/modular-workflow-v2/providers"
@@ -63,6 +65,9 @@ func (a *App) Initialize(ctx context.Context) error {
 		return err
 	}
 	a.cfg = cfg
+	if err := content.ValidateContentConfig(&cfg.Novel.Content); err != nil {
+		return fmt.Errorf("配置验证失败: %w", err)
+	}
 	
 	// 初始化全局配置
 	if err := config.InitGlobal(a.config.ConfigPath); err != nil {
@@ -138,9 +143,9 @@ func (a *App) ParseArgs(args []string) (string, error) {
 	return a.cli.ParseArgs(args, 1)
 }
 
-// ParseArgsWithFlags 解析命令行参数，支持配置文件标志
-func (a *App) ParseArgsWithFlags(args []string) (string, map[string]string, error) {
-	return a.cli.ParseArgsWithFlags(args, 1)
+// ParseArgsWithFlags 解析命令行参数，支持配置文件标志；boolFlags 为不带值的布尔标志
+func (a *App) ParseArgsWithFlags(args []string, boolFlags ...string) (string, map[string]string, error) {
+	return a.cli.ParseArgsWithFlags(args, 1, boolFlags...)
 }
 
 // ShowUsage 显示使用说明
@@ -278,6 +283,51 @@ func (a *App) RunWithFlags(args []string, handler func(ctx context.Context, app
 	return nil
 }
 
+// GetBudgetProfileFlag 读取 --profile 标志并校验预算配置是否存在，未指定时返回空字符串
+func (a *App) GetBudgetProfileFlag(flags map[string]string) (string, error) {
+	profile := flags["--profile"]
+	if profile == "" {
+		return "", nil
+	}
+
+	if a.cfg != nil {
+		if _, err := content.ResolveBudgetProfile(&a.cfg.Novel.Content, profile); err != nil {
+			return "", err
+		}
+	}
+
+	a.cli.ShowInfo("📐", fmt.Sprintf("使用预算配置: %s", profile))
+	return profile, nil
+}
+
+// ContextDumper 可以转储上下文的工作流
+type ContextDumper interface {
+	DumpContext(path string) error
+}
+
+// DumpWorkflowContext 将工作流的上下文和系统提示词写入文件，path 为空时使用默认文件名
+func (a *App) DumpWorkflowContext(dumper ContextDumper, workflow, path string) error {
+	if path == "" {
+		path = defaultContextDumpPath(workflow)
+	}
+
+	if err := dumper.DumpContext(path); err != nil {
+		// 上下文构建失败时工作流仍会写出不含上下文的转储，提示用户查看
+		if _, statErr := os.Stat(path); statErr == nil {
+			return fmt.Errorf("转储上下文失败（不含上下文的转储已写入 %s）: %w", path, err)
+		}
+		return fmt.Errorf("转储上下文失败: %w", err)
+	}
+
+	a.cli.ShowSuccess(fmt.Sprintf("上下文已写入: %s（未调用模型）", path))
+	return nil
+}
+
+// defaultContextDumpPath 默认的上下文转储文件名
+func defaultContextDumpPath(workflow string) string {
+	return fmt.Sprintf("context_dump_%s_%s.md", workflow, time.Now().Format("20060102_150405"))
+}
+
 // IsVerboseMode 检查是否为详细模式
 func (a *App) IsVerboseMode() bool {
 	return a.cli.IsVerbose()
@@ -0,0 +1,265 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+)
+
+// chapterBoolFlags 章节管理命令中不带值的标志，--html 的输出路径需用 --html=<path> 指定
+var chapterBoolFlags = []string{"-h", "--help", "--versions", "--all", "--html", "--no-color"}
+
+// ChapterApp 章节清单管理应用
+type ChapterApp struct {
+	*App
+}
+
+// NewChapterApp 创建章节清单管理应用
+func NewChapterApp() *ChapterApp {
+	config := DefaultAppConfig()
+	config.Name = "小说章节管理工具"
+	config.Description = "查看章节清单，校验章节ID与文件，重建章节清单，比较章节的历史版本"
+
+	return &ChapterApp{
+		App: NewApp(config),
+	}
+}
+
+// Run 运行章节管理应用，第一个参数为子命令
+func (ca *ChapterApp) Run(args []string) error {
+	if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+		ca.showUsage()
+		return nil
+	}
+
+	command := args[1]
+	target, flags, _ := ca.ParseArgsWithFlags(append([]string{args[0]}, args[2:]...), chapterBoolFlags...)
+	if _, hasHelp := flags["-h"]; hasHelp || command == "help" {
+		ca.showUsage()
+		return nil
+	}
+	if _, hasHelp := flags["--help"]; hasHelp {
+		ca.showUsage()
+		return nil
+	}
+
+	novelDir, err := ca.resolveNovelDir(flags)
+	if err != nil {
+		ca.GetCLI().ShowGracefulError("初始化失败", err.Error(), "请检查配置文件或使用 --novel-dir 指定小说目录")
+		return err
+	}
+	var opts []managers.ChapterManagerOption
+	if ca.App.cfg != nil {
+		opts = append(opts, managers.WithChapterFilePattern(ca.App.cfg.Novel.ChapterFilePattern))
+	}
+	chapterManager := managers.NewChapterManager(novelDir, opts...)
+
+	switch command {
+	case "list":
+		return ca.handleList(chapterManager)
+	case "validate":
+		return ca.handleValidate(chapterManager)
+	case "rebuild":
+		return ca.handleRebuild(chapterManager)
+	case "diff":
+		return ca.handleDiff(chapterManager, target, flags)
+	default:
+		ca.showUsage()
+		return fmt.Errorf("未知的子命令: %s", command)
+	}
+}
+
+// resolveNovelDir 确定小说目录：--novel-dir 优先，否则读取配置文件
+func (ca *ChapterApp) resolveNovelDir(flags map[string]string) (string, error) {
+	if novelDir, ok := flags["--novel-dir"]; ok && novelDir != "" {
+		ca.GetCLI().ShowInfo("📂", fmt.Sprintf("使用指定小说目录: %s", novelDir))
+		return novelDir, nil
+	}
+
+	if configPath, ok := flags["--config"]; ok {
+		ca.App.config.ConfigPath = configPath
+	} else if configPath, ok := flags["-c"]; ok {
+		ca.App.config.ConfigPath = configPath
+	}
+
+	cfg, err := ca.App.loadConfig()
+	if err != nil {
+		return "", err
+	}
+	ca.App.cfg = cfg
+	return cfg.Novel.GetAbsolutePath()
+}
+
+// handleList 按阅读顺序列出章节
+func (ca *ChapterApp) handleList(chapterManager *managers.ChapterManager) error {
+	cli := ca.GetCLI()
+	manifest, err := chapterManager.LoadManifest()
+	if err != nil {
+		return fmt.Errorf("读取章节清单失败: %w", err)
+	}
+	if len(manifest.Chapters) == 0 {
+		cli.ShowInfo("📭", "暂无章节")
+		return nil
+	}
+
+	for _, entry := range manifest.Chapters {
+		line := fmt.Sprintf("第%d章 %s", entry.ID, entry.File)
+		if entry.Title != "" {
+			line += " - " + entry.Title
+		}
+		if meta, err := chapterManager.GetChapterMeta(entry.ID); err == nil && meta != nil {
+			line += fmt.Sprintf(" [%s，%d字]", meta.Status, meta.CharCount)
+		}
+		cli.ShowInfo("📖", line)
+	}
+	cli.ShowInfo("📊", fmt.Sprintf("共 %d 章，章节ID: %s", len(manifest.Chapters), managers.FormatChapterIDs(manifest.IDs())))
+	return nil
+}
+
+// handleValidate 校验章节，发现问题时返回错误
+func (ca *ChapterApp) handleValidate(chapterManager *managers.ChapterManager) error {
+	cli := ca.GetCLI()
+	validation, err := chapterManager.Validate()
+	if err != nil {
+		return fmt.Errorf("校验章节失败: %w", err)
+	}
+
+	if !validation.ManifestExists {
+		cli.ShowInfo("💡", "尚未生成章节清单，以下结果基于目录中的章节文件（可运行 rebuild 生成清单）")
+	}
+	if validation.OK() {
+		ca.ShowSuccess(fmt.Sprintf("共 %d 章，未发现问题", validation.Chapters))
+		return nil
+	}
+
+	for _, issue := range validation.Issues {
+		cli.ShowInfo("⚠️", fmt.Sprintf("[%s] %s", issue.Type, issue.Message))
+	}
+	return fmt.Errorf("发现 %d 个章节问题", len(validation.Issues))
+}
+
+// handleRebuild 按目录中的实际文件重建章节清单
+func (ca *ChapterApp) handleRebuild(chapterManager *managers.ChapterManager) error {
+	manifest, err := chapterManager.RebuildManifest()
+	if err != nil {
+		return fmt.Errorf("重建章节清单失败: %w", err)
+	}
+
+	ca.GetCLI().ShowInfo("💾", fmt.Sprintf("章节清单: %s", chapterManager.GetManifestPath()))
+	ca.ShowSuccess(fmt.Sprintf("章节清单已重建，共 %d 章", len(manifest.Chapters)))
+	return nil
+}
+
+// handleDiff 比较章节的两个历史版本，默认比较上一次改写前的内容与当前内容
+func (ca *ChapterApp) handleDiff(chapterManager *managers.ChapterManager, target string, flags map[string]string) error {
+	chapterNum, err := strconv.Atoi(strings.TrimSpace(target))
+	if err != nil || chapterNum <= 0 {
+		ca.showUsage()
+		return fmt.Errorf("请提供有效的章节ID")
+	}
+	if !chapterManager.HasChapter(chapterNum) {
+		return fmt.Errorf("第%d章不存在，当前有效章节ID: %s", chapterNum, managers.FormatChapterIDs(chapterManager.GetChapterIDs()))
+	}
+
+	options := map[string]int{"--from": 0, "--to": 0, "--context": 1}
+	for flag := range options {
+		if value, ok := flags[flag]; ok {
+			number, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("请使用 %s 指定有效的数字", flag)
+			}
+			options[flag] = number
+		}
+	}
+	if _, ok := flags["--all"]; ok {
+		options["--context"] = -1
+	}
+
+	if _, ok := flags["--versions"]; ok {
+		return ca.showChapterVersions(chapterManager, chapterNum)
+	}
+
+	diff, err := chapterManager.DiffChapterVersions(chapterNum, options["--from"], options["--to"])
+	if err != nil {
+		return fmt.Errorf("比较章节失败: %w", err)
+	}
+	if !diff.Changed() {
+		ca.GetCLI().ShowInfo("✅", "内容相同，没有差异")
+		return nil
+	}
+
+	if path, ok := flags["--html"]; ok {
+		if path == "" {
+			path = fmt.Sprintf("chapter-%d-diff.html", chapterNum)
+			ca.GetCLI().ShowInfo("💡", fmt.Sprintf("未指定输出路径，使用默认路径 %s（可用 --html=<path> 指定）", path))
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(diff.HTML(options["--context"])), 0644); err != nil {
+			return fmt.Errorf("写入差异页面失败: %w", err)
+		}
+		ca.GetCLI().ShowInfo("💾", fmt.Sprintf("差异页面: %s", path))
+		ca.GetCLI().ShowInfo("📊", diff.Summary())
+		return nil
+	}
+
+	_, noColor := flags["--no-color"]
+	color := !noColor && os.Getenv("NO_COLOR") == ""
+	fmt.Print(diff.Text(color, options["--context"]))
+	return nil
+}
+
+// showChapterVersions 列出章节的历史版本，供 diff 的 --from/--to 使用
+func (ca *ChapterApp) showChapterVersions(chapterManager *managers.ChapterManager, chapterNum int) error {
+	cli := ca.GetCLI()
+	versions, err := chapterManager.ChapterVersions(chapterNum)
+	if err != nil {
+		return fmt.Errorf("读取版本历史失败: %w", err)
+	}
+	if len(versions) == 0 {
+		cli.ShowInfo("📭", fmt.Sprintf("第%d章暂无版本记录", chapterNum))
+		return nil
+	}
+	for _, version := range versions {
+		line := fmt.Sprintf("#%d %s", version.ID, version.CreatedAt)
+		if version.Reason != "" {
+			line += " " + version.Reason
+		}
+		cli.ShowInfo("📝", line)
+	}
+	return nil
+}
+
+// showUsage 显示chapter应用的使用说明
+func (ca *ChapterApp) showUsage() {
+	cli := ca.GetCLI()
+	fmt.Printf("用法: %s <子命令> [选项]\n", cli.AppName)
+	fmt.Println("\n子命令:")
+	fmt.Println("  list                   按阅读顺序列出章节（ID、文件、标题、状态、字数）")
+	fmt.Println("  validate               校验章节：ID不连续、重复文件、清单与文件不一致")
+	fmt.Println("  rebuild                按目录中的章节文件重建章节清单 chapters.json")
+	fmt.Println("  diff <章节ID>          比较章节的历史版本（默认为上一次改写前与当前内容），段落对齐后逐字标出修改")
+
+	fmt.Println("\n选项:")
+	fmt.Println("  --versions             diff 列出章节的历史版本编号")
+	fmt.Println("  --from <版本>          diff 的旧版本（默认为内容不同的最近一个版本）")
+	fmt.Println("  --to <版本>            diff 的新版本（默认为当前内容）")
+	fmt.Println("  --context <n>          diff 在每处修改前后显示的未修改段落数（默认 1）")
+	fmt.Println("  --all                  diff 显示全部段落")
+	fmt.Println("  --html[=path]          diff 输出为 HTML 页面（默认 chapter-<ID>-diff.html）")
+	fmt.Println("  --no-color             diff 不使用颜色，以 [-删除-]{+新增+} 标记修改")
+	fmt.Println("  --novel-dir <path>     指定小说目录")
+	fmt.Println("  -c, --config <path>    指定配置文件路径")
+	fmt.Println("  -h, --help             显示帮助信息")
+
+	fmt.Printf("\n示例:\n")
+	fmt.Printf("  %s list\n", cli.AppName)
+	fmt.Printf("  %s validate --novel-dir ../novels/my_novel\n", cli.AppName)
+	fmt.Printf("  %s diff 12                   # 第12章最近一次改写的差异\n", cli.AppName)
+	fmt.Printf("  %s diff 12 --from 30 --html=diff.html\n", cli.AppName)
+}
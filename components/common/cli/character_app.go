@@ -0,0 +1,301 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+)
+
+// CharacterApp 结构化角色库管理应用
+type CharacterApp struct {
+	*App
+}
+
+// NewCharacterApp 创建角色库管理应用
+func NewCharacterApp() *CharacterApp {
+	config := DefaultAppConfig()
+	config.Name = "小说角色库管理工具"
+	config.Description = "迁移、查看结构化角色库，查询和回滚角色变更历史，导出人物关系图"
+
+	return &CharacterApp{
+		App: NewApp(config),
+	}
+}
+
+// Run 运行角色库管理应用，第一个参数为子命令
+func (ca *CharacterApp) Run(args []string) error {
+	if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+		ca.showUsage()
+		return nil
+	}
+
+	command := args[1]
+	target, flags, _ := ca.ParseArgsWithFlags(append([]string{args[0]}, args[2:]...), "-h", "--help", "--force")
+	if _, hasHelp := flags["-h"]; hasHelp || command == "help" {
+		ca.showUsage()
+		return nil
+	}
+	if _, hasHelp := flags["--help"]; hasHelp {
+		ca.showUsage()
+		return nil
+	}
+
+	novelDir, err := ca.resolveNovelDir(flags)
+	if err != nil {
+		ca.GetCLI().ShowGracefulError("初始化失败", err.Error(), "请检查配置文件或使用 --novel-dir 指定小说目录")
+		return err
+	}
+	characterManager := managers.NewCharacterManager(novelDir)
+
+	switch command {
+	case "migrate":
+		_, force := flags["--force"]
+		return ca.handleMigrate(characterManager, flags["--format"], force)
+	case "list":
+		return ca.handleList(characterManager)
+	case "graph":
+		return ca.handleGraph(characterManager, target, flags["--format"], flags["--output"])
+	case "show", "history", "state", "rollback":
+	default:
+		ca.showUsage()
+		return fmt.Errorf("未知的子命令: %s", command)
+	}
+
+	// 以下子命令都需要角色名称
+	if target == "" {
+		ca.showUsage()
+		return fmt.Errorf("未提供角色名称")
+	}
+
+	switch command {
+	case "history":
+		return ca.handleHistory(characterManager, target)
+	case "state":
+		chapter, err := strconv.Atoi(flags["--chapter"])
+		if err != nil {
+			return fmt.Errorf("请使用 --chapter 指定有效的章节号")
+		}
+		return ca.handleState(characterManager, target, chapter)
+	case "rollback":
+		changeID, err := strconv.Atoi(flags["--change"])
+		if err != nil {
+			return fmt.Errorf("请使用 --change 指定要回滚的变更编号")
+		}
+		return ca.handleRollback(characterManager, target, changeID)
+	default:
+		return ca.handleShow(characterManager, target)
+	}
+}
+
+// resolveNovelDir 确定小说目录：--novel-dir 优先，否则读取配置文件
+func (ca *CharacterApp) resolveNovelDir(flags map[string]string) (string, error) {
+	if novelDir, ok := flags["--novel-dir"]; ok && novelDir != "" {
+		ca.GetCLI().ShowInfo("📂", fmt.Sprintf("使用指定小说目录: %s", novelDir))
+		return novelDir, nil
+	}
+
+	if configPath, ok := flags["--config"]; ok {
+		ca.App.config.ConfigPath = configPath
+	} else if configPath, ok := flags["-c"]; ok {
+		ca.App.config.ConfigPath = configPath
+	}
+
+	cfg, err := ca.App.loadConfig()
+	if err != nil {
+		return "", err
+	}
+	ca.App.cfg = cfg
+	return cfg.Novel.GetAbsolutePath()
+}
+
+// handleMigrate 将 character.md 迁移为结构化角色库
+func (ca *CharacterApp) handleMigrate(characterManager *managers.CharacterManager, format string, force bool) error {
+	cli := ca.GetCLI()
+	if format == "" {
+		format = managers.CharacterFormatJSON
+	}
+	if format != managers.CharacterFormatJSON && format != managers.CharacterFormatYAML {
+		return fmt.Errorf("不支持的角色库格式: %s（可选 json、yaml）", format)
+	}
+
+	count, err := characterManager.MigrateToStructured(format, force)
+	if err != nil {
+		return fmt.Errorf("迁移角色信息失败: %w", err)
+	}
+
+	cli.ShowInfo("📄", fmt.Sprintf("来源: %s（已备份，原文件保留）", characterManager.GetPath()))
+	cli.ShowInfo("💾", fmt.Sprintf("角色库: %s", characterManager.GetStore().GetPath()))
+	cli.ShowSuccess(fmt.Sprintf("已迁移 %d 个角色", count))
+	return nil
+}
+
+// handleList 列出所有角色
+func (ca *CharacterApp) handleList(characterManager *managers.CharacterManager) error {
+	cli := ca.GetCLI()
+	db, err := characterManager.LoadDB()
+	if err != nil {
+		return fmt.Errorf("读取角色库失败: %w", err)
+	}
+
+	if !characterManager.IsStructured() {
+		cli.ShowInfo("💡", "尚未迁移为结构化角色库，以下内容解析自 character.md")
+	}
+	if len(db.Characters) == 0 {
+		cli.ShowInfo("📭", "暂无角色")
+		return nil
+	}
+
+	for _, character := range db.Characters {
+		line := character.Name
+		if len(character.Aliases) > 0 {
+			line += fmt.Sprintf("（%s）", strings.Join(character.Aliases, "、"))
+		}
+		if character.Status != "" {
+			line += " - " + character.Status
+		}
+		if character.LastAppearance > 0 {
+			line += fmt.Sprintf(" [最近出场: 第%d章]", character.LastAppearance)
+		}
+		cli.ShowInfo("👤", line)
+	}
+	cli.ShowInfo("📊", fmt.Sprintf("共 %d 个角色", len(db.Characters)))
+	return nil
+}
+
+// handleShow 显示单个角色的完整信息
+func (ca *CharacterApp) handleShow(characterManager *managers.CharacterManager, name string) error {
+	db, err := characterManager.LoadDB()
+	if err != nil {
+		return fmt.Errorf("读取角色库失败: %w", err)
+	}
+
+	character, ok := db.Find(name)
+	if !ok {
+		return fmt.Errorf("未找到角色: %s", name)
+	}
+
+	fmt.Println(character.RenderMarkdown())
+	return nil
+}
+
+// handleHistory 显示角色的变更历史
+func (ca *CharacterApp) handleHistory(characterManager *managers.CharacterManager, name string) error {
+	cli := ca.GetCLI()
+	changes, err := characterManager.GetHistory(name)
+	if err != nil {
+		return fmt.Errorf("读取角色变更历史失败: %w", err)
+	}
+	if len(changes) == 0 {
+		cli.ShowInfo("📭", fmt.Sprintf("角色 %s 暂无变更记录", name))
+		return nil
+	}
+
+	for _, change := range changes {
+		line := fmt.Sprintf("#%d 第%d章 [%s] %s", change.ID, change.Chapter, change.Source, change.Summary())
+		if change.Reason != "" {
+			line += "（" + change.Reason + "）"
+		}
+		if change.RolledBack {
+			line += " [已回滚]"
+		}
+		cli.ShowInfo("📝", line)
+	}
+	return nil
+}
+
+// handleState 显示角色在指定章节结束时的状态
+func (ca *CharacterApp) handleState(characterManager *managers.CharacterManager, name string, chapter int) error {
+	state, exists, err := characterManager.StateAt(name, chapter)
+	if err != nil {
+		return fmt.Errorf("查询角色状态失败: %w", err)
+	}
+	if !exists {
+		ca.GetCLI().ShowInfo("📭", fmt.Sprintf("第%d章时角色 %s 尚未出现或已被移除", chapter, name))
+		return nil
+	}
+
+	ca.GetCLI().ShowInfo("🕰️", fmt.Sprintf("角色 %s 在第%d章的状态:", name, chapter))
+	fmt.Println(state.RenderMarkdown())
+	return nil
+}
+
+// handleRollback 将角色回滚到指定变更之前的状态
+func (ca *CharacterApp) handleRollback(characterManager *managers.CharacterManager, name string, changeID int) error {
+	rollback, err := characterManager.Rollback(name, changeID)
+	if err != nil {
+		return err
+	}
+
+	ca.GetCLI().ShowInfo("↩️", rollback.Summary())
+	ca.ShowSuccess(fmt.Sprintf("角色 %s 已回滚到变更 #%d 之前的状态（记录为变更 #%d）", name, changeID, rollback.ID))
+	return nil
+}
+
+// handleGraph 导出人物关系图（Graphviz DOT 或 Mermaid），name 不为空时只导出与该角色相关的关系
+func (ca *CharacterApp) handleGraph(characterManager *managers.CharacterManager, name, format, output string) error {
+	graph, err := characterManager.GetRelationshipStore().LoadGraph()
+	if err != nil {
+		return fmt.Errorf("读取人物关系图失败: %w", err)
+	}
+	if len(graph.Edges) == 0 {
+		ca.GetCLI().ShowInfo("📭", "暂无人物关系")
+		return nil
+	}
+
+	var diagram string
+	switch format {
+	case "", "mermaid":
+		diagram = graph.ToMermaid(name)
+	case "dot":
+		diagram = graph.ToDOT(name)
+	default:
+		return fmt.Errorf("不支持的导出格式: %s（可选 mermaid、dot）", format)
+	}
+
+	if output == "" {
+		fmt.Print(diagram)
+		return nil
+	}
+	if err := os.WriteFile(output, []byte(diagram), 0644); err != nil {
+		return fmt.Errorf("写入关系图文件失败: %w", err)
+	}
+	ca.ShowSuccess(fmt.Sprintf("人物关系图已导出到 %s", output))
+	return nil
+}
+
+// showUsage 显示character应用的使用说明
+func (ca *CharacterApp) showUsage() {
+	cli := ca.GetCLI()
+	fmt.Printf("用法: %s <子命令> [选项]\n", cli.AppName)
+	fmt.Println("\n子命令:")
+	fmt.Println("  migrate                将 character.md 迁移为结构化角色库（原文件保留并备份）")
+	fmt.Println("  list                   列出所有角色")
+	fmt.Println("  show <名称>            显示角色完整信息（支持别名）")
+	fmt.Println("  history <名称>         显示角色的变更历史")
+	fmt.Println("  state <名称>           显示角色在 --chapter 指定章节时的状态")
+	fmt.Println("  rollback <名称>        将角色回滚到 --change 指定变更之前的状态")
+	fmt.Println("  graph [名称]           导出人物关系图，指定名称时只导出与该角色相关的关系")
+
+	fmt.Println("\n选项:")
+	fmt.Println("  --format <json|yaml>   migrate 生成的角色库格式（默认 json）")
+	fmt.Println("  --force                角色库已存在时重新迁移并覆盖")
+	fmt.Println("  --chapter <n>          state 查询的章节号")
+	fmt.Println("  --change <id>          rollback 的变更编号（见 history）")
+	fmt.Println("  --format <mermaid|dot> graph 的导出格式（默认 mermaid）")
+	fmt.Println("  --output <path>        graph 导出到文件（默认输出到终端）")
+	fmt.Println("  --novel-dir <path>     指定小说目录")
+	fmt.Println("  -c, --config <path>    指定配置文件路径")
+	fmt.Println("  -h, --help             显示帮助信息")
+
+	fmt.Printf("\n示例:\n")
+	fmt.Printf("  %s migrate                 # 迁移为 characters.json\n", cli.AppName)
+	fmt.Printf("  %s migrate --format yaml   # 迁移为 characters.yaml\n", cli.AppName)
+	fmt.Printf("  %s list                    # 列出角色\n", cli.AppName)
+	fmt.Printf("  %s show 林凡               # 查看角色\n", cli.AppName)
+	fmt.Printf("  %s state 林凡 --chapter 12 # 查看第12章时的林凡\n", cli.AppName)
+	fmt.Printf("  %s rollback 林凡 --change 7\n", cli.AppName)
+	fmt.Printf("  %s graph --format dot --output relationships.dot\n", cli.AppName)
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/export"
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+)
+
+// ExportApp 书稿导出应用
+type ExportApp struct {
+	*App
+}
+
+// NewExportApp 创建书稿导出应用
+func NewExportApp() *ExportApp {
+	config := DefaultAppConfig()
+	config.Name = "小说书稿导出工具"
+	config.Description = "按阅读顺序整理章节，导出为 EPUB、Markdown、TXT 或 HTML"
+
+	return &ExportApp{
+		App: NewApp(config),
+	}
+}
+
+// Run 运行导出应用，第一个非选项参数为导出格式（epub/md/txt/html/all，默认 epub）
+func (ea *ExportApp) Run(args []string) error {
+	formatArg, flags, _ := ea.ParseArgsWithFlags(args, "-h", "--help")
+	if _, hasHelp := flags["-h"]; hasHelp {
+		ea.showUsage()
+		return nil
+	}
+	if _, hasHelp := flags["--help"]; hasHelp {
+		ea.showUsage()
+		return nil
+	}
+
+	output := flags["--output"]
+	if value, ok := flags["-o"]; ok {
+		output = value
+	}
+	formats, err := ea.resolveFormats(formatArg, flags["--format"], output)
+	if err != nil {
+		ea.showUsage()
+		return err
+	}
+	if len(formats) > 1 && output != "" && filepath.Ext(output) != "" {
+		return fmt.Errorf("导出多种格式时 --output 需要指定目录")
+	}
+
+	opts := export.Options{
+		Title:  flags["--title"],
+		Author: flags["--author"],
+		Status: flags["--status"],
+	}
+	if opts.Status != "" && !managers.ValidChapterStatus(opts.Status) {
+		return fmt.Errorf("无效的章节状态: %s（可选 draft/revised/final）", opts.Status)
+	}
+	for flag, target := range map[string]*int{"--from": &opts.From, "--to": &opts.To} {
+		if value, ok := flags[flag]; ok {
+			if *target, err = strconv.Atoi(value); err != nil || *target <= 0 {
+				return fmt.Errorf("请使用 %s 指定有效的章节ID", flag)
+			}
+		}
+	}
+
+	novelDir, err := ea.resolveNovelDir(flags)
+	if err != nil {
+		ea.GetCLI().ShowGracefulError("初始化失败", err.Error(), "请检查配置文件或使用 --novel-dir 指定小说目录")
+		return err
+	}
+
+	manuscript, err := export.LoadManuscript(novelDir, opts)
+	if err != nil {
+		return err
+	}
+	ea.GetCLI().ShowInfo("📚", fmt.Sprintf("《%s》共 %d 章，%d 字", manuscript.Title, len(manuscript.Chapters()), manuscript.CharCount()))
+
+	for _, format := range formats {
+		path := ea.outputPath(novelDir, manuscript.Title, output, format, len(formats) > 1)
+		if err := export.WriteFile(manuscript, format, path); err != nil {
+			return fmt.Errorf("导出 %s 失败: %w", format, err)
+		}
+		ea.GetCLI().ShowInfo("💾", fmt.Sprintf("%s: %s", strings.ToUpper(string(format)), path))
+	}
+
+	ea.ShowSuccess("书稿导出完成")
+	return nil
+}
+
+// resolveFormats 确定导出格式：位置参数或 --format 优先，其次按输出文件扩展名推断，默认 epub
+func (ea *ExportApp) resolveFormats(formatArg, formatFlag, output string) ([]export.Format, error) {
+	value := formatArg
+	if formatFlag != "" {
+		value = formatFlag
+	}
+	if value == "all" {
+		return export.Formats, nil
+	}
+	if value != "" {
+		format, err := export.ParseFormat(value)
+		if err != nil {
+			return nil, err
+		}
+		return []export.Format{format}, nil
+	}
+	if format, ok := export.FormatFromPath(output); ok {
+		return []export.Format{format}, nil
+	}
+	return []export.Format{export.FormatEPUB}, nil
+}
+
+// outputPath 确定导出文件路径：未指定时写入小说目录下的 export 目录，文件名为书名
+func (ea *ExportApp) outputPath(novelDir, title, output string, format export.Format, multiple bool) string {
+	if output != "" && !multiple && filepath.Ext(output) != "" {
+		return output
+	}
+	dir := output
+	if dir == "" {
+		dir = filepath.Join(novelDir, "export")
+	}
+	name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(title)
+	return filepath.Join(dir, name+format.Extension())
+}
+
+// resolveNovelDir 确定小说目录：--novel-dir 优先，否则读取配置文件
+func (ea *ExportApp) resolveNovelDir(flags map[string]string) (string, error) {
+	if novelDir, ok := flags["--novel-dir"]; ok && novelDir != "" {
+		ea.GetCLI().ShowInfo("📂", fmt.Sprintf("使用指定小说目录: %s", novelDir))
+		return novelDir, nil
+	}
+
+	if configPath, ok := flags["--config"]; ok {
+		ea.App.config.ConfigPath = configPath
+	} else if configPath, ok := flags["-c"]; ok {
+		ea.App.config.ConfigPath = configPath
+	}
+
+	cfg, err := ea.App.loadConfig()
+	if err != nil {
+		return "", err
+	}
+	ea.App.cfg = cfg
+	return cfg.Novel.GetAbsolutePath()
+}
+
+// showUsage 显示export应用的使用说明
+func (ea *ExportApp) showUsage() {
+	cli := ea.GetCLI()
+	fmt.Printf("用法: %s [格式] [选项]\n", cli.AppName)
+	fmt.Println("\n格式:")
+	fmt.Println("  epub                   EPUB 3 电子书（默认）")
+	fmt.Println("  md                     单个 Markdown 文件")
+	fmt.Println("  txt                    纯文本，段首两个全角空格，适合上传网文平台")
+	fmt.Println("  html                   单个静态 HTML 页面")
+	fmt.Println("  all                    导出以上全部格式")
+
+	fmt.Println("\n选项:")
+	fmt.Println("  -o, --output <path>    输出文件或目录（默认为小说目录下的 export/书名.格式）")
+	fmt.Println("  --title <书名>         书名（默认读取小说目录中的 title 文件）")
+	fmt.Println("  --author <作者>        作者")
+	fmt.Println("  --from <章节ID>        从该章开始导出")
+	fmt.Println("  --to <章节ID>          导出到该章为止")
+	fmt.Println("  --status <状态>        只导出该状态的章节: draft/revised/final")
+	fmt.Println("  --novel-dir <path>     指定小说目录")
+	fmt.Println("  -c, --config <path>    指定配置文件路径")
+	fmt.Println("  -h, --help             显示帮助信息")
+
+	fmt.Printf("\n示例:\n")
+	fmt.Printf("  %s                            # 导出 EPUB\n", cli.AppName)
+	fmt.Printf("  %s txt --from 1 --to 50\n", cli.AppName)
+	fmt.Printf("  %s -o book.md                 # 按扩展名推断格式\n", cli.AppName)
+	fmt.Printf("  %s all --status final -o dist\n", cli.AppName)
+}
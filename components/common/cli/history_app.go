@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+)
+
+// HistoryApp 小说文件版本历史应用
+type HistoryApp struct {
+	*App
+}
+
+// NewHistoryApp 创建版本历史应用
+func NewHistoryApp() *HistoryApp {
+	config := DefaultAppConfig()
+	config.Name = "小说文件版本历史工具"
+	config.Description = "查看小说文件的历史版本，比较任意两个版本，恢复任意文件"
+
+	return &HistoryApp{
+		App: NewApp(config),
+	}
+}
+
+// Run 运行版本历史应用，第一个参数为子命令
+func (ha *HistoryApp) Run(args []string) error {
+	if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+		ha.showUsage()
+		return nil
+	}
+
+	command := args[1]
+	target, flags, _ := ha.ParseArgsWithFlags(append([]string{args[0]}, args[2:]...), "-h", "--help")
+	if _, hasHelp := flags["-h"]; hasHelp || command == "help" {
+		ha.showUsage()
+		return nil
+	}
+	if _, hasHelp := flags["--help"]; hasHelp {
+		ha.showUsage()
+		return nil
+	}
+
+	novelDir, err := ha.resolveNovelDir(flags)
+	if err != nil {
+		ha.GetCLI().ShowGracefulError("初始化失败", err.Error(), "请检查配置文件或使用 --novel-dir 指定小说目录")
+		return err
+	}
+	store := managers.NewVersionStore(novelDir)
+
+	if command == "list" {
+		limit := 0
+		if value, ok := flags["--limit"]; ok {
+			if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+				return fmt.Errorf("请使用 --limit 指定有效的数量")
+			}
+		}
+		return ha.handleList(store, target, limit)
+	}
+
+	// 以下子命令都需要版本编号
+	if command != "show" && command != "diff" && command != "restore" {
+		ha.showUsage()
+		return fmt.Errorf("未知的子命令: %s", command)
+	}
+	id, err := strconv.Atoi(target)
+	if err != nil {
+		ha.showUsage()
+		return fmt.Errorf("请提供有效的版本编号（见 list）")
+	}
+
+	switch command {
+	case "show":
+		return ha.handleShow(store, id)
+	case "diff":
+		toID := 0
+		if value, ok := flags["--to"]; ok {
+			if toID, err = strconv.Atoi(value); err != nil {
+				return fmt.Errorf("请使用 --to 指定有效的版本编号")
+			}
+		}
+		return ha.handleDiff(store, id, toID)
+	default:
+		return ha.handleRestore(store, id)
+	}
+}
+
+// resolveNovelDir 确定小说目录：--novel-dir 优先，否则读取配置文件
+func (ha *HistoryApp) resolveNovelDir(flags map[string]string) (string, error) {
+	if novelDir, ok := flags["--novel-dir"]; ok && novelDir != "" {
+		ha.GetCLI().ShowInfo("📂", fmt.Sprintf("使用指定小说目录: %s", novelDir))
+		return novelDir, nil
+	}
+
+	if configPath, ok := flags["--config"]; ok {
+		ha.App.config.ConfigPath = configPath
+	} else if configPath, ok := flags["-c"]; ok {
+		ha.App.config.ConfigPath = configPath
+	}
+
+	cfg, err := ha.App.loadConfig()
+	if err != nil {
+		return "", err
+	}
+	ha.App.cfg = cfg
+	return cfg.Novel.GetAbsolutePath()
+}
+
+// handleList 列出版本，file 为空时列出所有文件；limit 大于0时只显示最近的 limit 个版本
+func (ha *HistoryApp) handleList(store *managers.VersionStore, file string, limit int) error {
+	cli := ha.GetCLI()
+	versions, err := store.List(file)
+	if err != nil {
+		return fmt.Errorf("读取版本历史失败: %w", err)
+	}
+	if len(versions) == 0 {
+		cli.ShowInfo("📭", "暂无版本记录")
+		return nil
+	}
+
+	if limit > 0 && len(versions) > limit {
+		versions = versions[len(versions)-limit:]
+	}
+	for _, version := range versions {
+		line := fmt.Sprintf("#%d %s %s %s（%d 字节）", version.ID, version.CreatedAt, version.File, version.Hash[:8], version.Size)
+		if version.Author != "" {
+			line += " [" + version.Author + "]"
+		}
+		if version.Reason != "" {
+			line += " " + version.Reason
+		}
+		cli.ShowInfo("📝", line)
+	}
+	return nil
+}
+
+// handleShow 输出版本的完整内容
+func (ha *HistoryApp) handleShow(store *managers.VersionStore, id int) error {
+	version, err := store.Get(id)
+	if err != nil {
+		return err
+	}
+	data, err := store.Content(version)
+	if err != nil {
+		return fmt.Errorf("读取版本内容失败: %w", err)
+	}
+
+	fmt.Print(data)
+	return nil
+}
+
+// handleDiff 比较两个版本，toID 为0时与文件当前内容比较
+func (ha *HistoryApp) handleDiff(store *managers.VersionStore, fromID, toID int) error {
+	diff, err := store.Diff(fromID, toID)
+	if err != nil {
+		return fmt.Errorf("比较版本失败: %w", err)
+	}
+	if diff == "" {
+		ha.GetCLI().ShowInfo("✅", "内容相同，没有差异")
+		return nil
+	}
+
+	fmt.Print(diff)
+	return nil
+}
+
+// handleRestore 将文件恢复为指定版本
+func (ha *HistoryApp) handleRestore(store *managers.VersionStore, id int) error {
+	restored, err := store.Restore(id, managers.VersionInfo{Author: managers.DefaultVersionAuthor})
+	if err != nil {
+		return fmt.Errorf("恢复版本失败: %w", err)
+	}
+
+	ha.ShowSuccess(fmt.Sprintf("%s 已恢复到版本 #%d（记录为版本 #%d，可再次恢复撤销）", restored.File, id, restored.ID))
+	return nil
+}
+
+// showUsage 显示history应用的使用说明
+func (ha *HistoryApp) showUsage() {
+	cli := ha.GetCLI()
+	fmt.Printf("用法: %s <子命令> [选项]\n", cli.AppName)
+	fmt.Println("\n子命令:")
+	fmt.Println("  list [文件名]          列出版本历史，指定文件名时只列出该文件（如 character.md）")
+	fmt.Println("  show <版本>            输出版本的完整内容")
+	fmt.Println("  diff <版本>            比较版本与文件当前内容，或与 --to 指定的版本比较")
+	fmt.Println("  restore <版本>         将文件恢复为该版本的内容（恢复本身也会记录为新版本）")
+
+	fmt.Println("\n选项:")
+	fmt.Println("  --limit <n>            list 只显示最近 n 个版本")
+	fmt.Println("  --to <版本>            diff 比较的目标版本（默认为文件当前内容）")
+	fmt.Println("  --novel-dir <path>     指定小说目录")
+	fmt.Println("  -c, --config <path>    指定配置文件路径")
+	fmt.Println("  -h, --help             显示帮助信息")
+
+	fmt.Printf("\n示例:\n")
+	fmt.Printf("  %s list                      # 列出全部版本\n", cli.AppName)
+	fmt.Printf("  %s list worldview.md --limit 5\n", cli.AppName)
+	fmt.Printf("  %s diff 12                   # 版本 #12 与当前内容的差异\n", cli.AppName)
+	fmt.Printf("  %s diff 12 --to 15           # 版本 #12 与 #15 的差异\n", cli.AppName)
+	fmt.Printf("  %s restore 12                # 恢复到版本 #12\n", cli.AppName)
+}
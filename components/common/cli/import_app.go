@@ -0,0 +1,290 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/importer"
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+	"github.com/Kizunad/modular-workflow-v2/providers"
+	"github.com/Kizunad/modular-workflow-v2/queue"
+)
+
+// importAnalyses --analyze 支持的分析任务
+var importAnalyses = []string{"summary", "character", "worldview"}
+
+// ImportApp 书稿导入应用
+type ImportApp struct {
+	*App
+}
+
+// NewImportApp 创建书稿导入应用
+func NewImportApp() *ImportApp {
+	config := DefaultAppConfig()
+	config.Name = "小说书稿导入工具"
+	config.Description = "按章节标题拆分 TXT/Markdown 书稿并导入小说目录"
+
+	return &ImportApp{
+		App: NewApp(config),
+	}
+}
+
+// Run 运行导入应用，第一个非选项参数为书稿文件
+func (ia *ImportApp) Run(args []string) error {
+	ctx := context.Background()
+
+	path, flags, _ := ia.ParseArgsWithFlags(args, "-h", "--help", "--dry-run")
+	if _, hasHelp := flags["-h"]; hasHelp {
+		ia.showUsage()
+		return nil
+	}
+	if _, hasHelp := flags["--help"]; hasHelp {
+		ia.showUsage()
+		return nil
+	}
+
+	_, dryRun := flags["--dry-run"]
+	analyses, err := ia.parseAnalyses(flags, &path)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		ia.showUsage()
+		return nil
+	}
+
+	opts := importer.Options{}
+	if pattern, ok := flags["--pattern"]; ok {
+		opts.ChapterPatterns = []string{pattern}
+	}
+	if pattern, ok := flags["--volume-pattern"]; ok {
+		opts.VolumePatterns = []string{pattern}
+	}
+	manuscript, err := importer.ParseFile(path, opts)
+	if err != nil {
+		return err
+	}
+	ia.showManuscript(manuscript)
+	if dryRun {
+		ia.GetCLI().ShowInfo("💡", "预览模式，未写入任何文件")
+		return nil
+	}
+
+	novelDir, err := ia.resolveNovelDir(ctx, flags, len(analyses) > 0)
+	if err != nil {
+		ia.GetCLI().ShowGracefulError("初始化失败", err.Error(), "请检查配置文件或使用 --novel-dir 指定小说目录")
+		return err
+	}
+	if err := os.MkdirAll(novelDir, 0755); err != nil {
+		return fmt.Errorf("创建小说目录失败: %w", err)
+	}
+
+	importOpts := importer.ImportOptions{
+		Title:  flags["--title"],
+		Status: flags["--status"],
+	}
+	if ia.App.cfg != nil {
+		importOpts.FilePattern = ia.App.cfg.Novel.ChapterFilePattern
+	}
+	result, err := importer.Import(novelDir, manuscript, importOpts)
+	if err != nil {
+		return err
+	}
+	if result.Title != "" {
+		ia.GetCLI().ShowInfo("📖", fmt.Sprintf("书名: %s", result.Title))
+	}
+	ids := result.ChapterIDs
+	ia.GetCLI().ShowSuccess(fmt.Sprintf("已导入 %d 章（章节ID %d-%d）", len(ids), ids[0], ids[len(ids)-1]))
+	if result.Volumes > 0 {
+		ia.GetCLI().ShowInfo("📚", fmt.Sprintf("已创建 %d 卷，可使用 story_structure 工具调整", result.Volumes))
+	}
+
+	if len(analyses) > 0 {
+		if err := ia.runAnalyses(ctx, novelDir, ids, analyses); err != nil {
+			return err
+		}
+	}
+
+	ia.ShowSuccess("书稿导入完成")
+	return nil
+}
+
+// parseAnalyses 解析 --analyze：不带值或为 all 时执行全部分析，也可用逗号指定部分分析
+func (ia *ImportApp) parseAnalyses(flags map[string]string, path *string) ([]string, error) {
+	value, ok := flags["--analyze"]
+	if !ok {
+		return nil, nil
+	}
+	value = strings.TrimSpace(value)
+	if value == "" || value == "all" {
+		return importAnalyses, nil
+	}
+
+	var analyses []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		valid := false
+		for _, analysis := range importAnalyses {
+			valid = valid || name == analysis
+		}
+		if !valid {
+			if *path == "" {
+				*path = value
+				return importAnalyses, nil
+			}
+			return nil, fmt.Errorf("无效的分析类型: %s（可选 summary/character/worldview/all）", name)
+		}
+		analyses = append(analyses, name)
+	}
+	return analyses, nil
+}
+
+// showManuscript 显示拆分结果
+func (ia *ImportApp) showManuscript(manuscript *importer.Manuscript) {
+	cli := ia.GetCLI()
+	if manuscript.Title != "" {
+		cli.ShowInfo("📖", fmt.Sprintf("识别到书名: %s", manuscript.Title))
+	}
+	cli.ShowInfo("📑", fmt.Sprintf("识别到 %d 章", len(manuscript.Chapters)))
+	if volumes := manuscript.Volumes(); len(volumes) > 0 {
+		cli.ShowInfo("📚", fmt.Sprintf("识别到 %d 卷: %s", len(volumes), strings.Join(volumes, "、")))
+	}
+	if manuscript.Skipped > 0 {
+		cli.ShowInfo("⏭️", fmt.Sprintf("跳过 %d 个没有正文的标题（通常是目录）", manuscript.Skipped))
+	}
+	if len(manuscript.Preface) > 0 {
+		cli.ShowInfo("📝", fmt.Sprintf("第一章之前的 %d 段内容未导入", len(manuscript.Preface)))
+	}
+
+	fmt.Println()
+	for i, chapter := range manuscript.Chapters {
+		volume := ""
+		if chapter.Volume != "" {
+			volume = fmt.Sprintf("  [%s]", chapter.Volume)
+		}
+		fmt.Printf("  %4d  %-30s %7d字%s\n", i+1, chapter.Title, managers.CountChapterChars(chapter.Content()), volume)
+	}
+	fmt.Println()
+}
+
+// runAnalyses 为每个导入的章节提交分析任务并等待完成，用于补全 index.json、角色档案和世界观
+func (ia *ImportApp) runAnalyses(ctx context.Context, novelDir string, ids []int, analyses []string) error {
+	cli := ia.GetCLI()
+	cfg := ia.GetConfig()
+	logger := ia.GetLogger()
+
+	llmManager := providers.NewManager(cfg, *logger)
+	mq, err := queue.InitQueue(&cfg.MessageQueue, novelDir, llmManager, logger)
+	if err != nil {
+		return fmt.Errorf("初始化消息队列失败: %w", err)
+	}
+	if mq == nil {
+		return fmt.Errorf("消息队列被禁用，请在配置文件中启用")
+	}
+	if err := mq.Start(ctx); err != nil {
+		return fmt.Errorf("启动消息队列失败: %w", err)
+	}
+	defer mq.Shutdown(30 * time.Second)
+
+	cli.ShowInfo("🤖", fmt.Sprintf("提交分析任务: %s，共 %d 章", strings.Join(analyses, "/"), len(ids)))
+	// 以提交前的完成数为基准，等到本次提交的任务全部成功或失败
+	initial := mq.GetStatus()
+	baseTime := time.Now().Unix()
+	enqueued := int64(0)
+	for _, id := range ids {
+		chapterID := strconv.Itoa(id)
+		for _, analysis := range analyses {
+			taskID := fmt.Sprintf("import-%s-%s-%d", analysis, chapterID, baseTime)
+			var task queue.Task
+			switch analysis {
+			case "summary":
+				task = queue.CreateSummarizeByIDTask(taskID, chapterID)
+			case "character":
+				task = queue.CreateChapterCharacterTask(taskID, chapterID)
+			case "worldview":
+				task = queue.CreateChapterWorldviewTask(taskID, chapterID)
+			}
+			if err := mq.EnqueueWait(ctx, task); err != nil {
+				return fmt.Errorf("提交第%d章%s任务失败: %w", id, analysis, err)
+			}
+			enqueued++
+		}
+	}
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("等待分析任务完成时中断: %w", ctx.Err())
+		case <-ticker.C:
+		}
+
+		status := mq.GetStatus()
+		completed := status.CompletedTasks - initial.CompletedTasks
+		failed := status.FailedTasks - initial.FailedTasks
+		if completed+failed >= enqueued {
+			cli.ShowInfo("✅", fmt.Sprintf("分析任务处理完成，成功 %d，失败 %d", completed, failed))
+			return nil
+		}
+		cli.ShowInfo("📊", fmt.Sprintf("分析进度 - 已完成: %d/%d, 失败: %d, 处理中: %d",
+			completed, enqueued, failed, status.ProcessingTasks))
+	}
+}
+
+// resolveNovelDir 确定小说目录：--novel-dir 优先，否则读取配置文件；需要分析时总是初始化应用配置
+func (ia *ImportApp) resolveNovelDir(ctx context.Context, flags map[string]string, needConfig bool) (string, error) {
+	if configPath, ok := flags["--config"]; ok {
+		ia.App.config.ConfigPath = configPath
+	} else if configPath, ok := flags["-c"]; ok {
+		ia.App.config.ConfigPath = configPath
+	}
+
+	if needConfig {
+		if err := ia.App.Initialize(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	if novelDir, ok := flags["--novel-dir"]; ok && novelDir != "" {
+		ia.GetCLI().ShowInfo("📂", fmt.Sprintf("使用指定小说目录: %s", novelDir))
+		return novelDir, nil
+	}
+
+	if ia.App.cfg == nil {
+		cfg, err := ia.App.loadConfig()
+		if err != nil {
+			return "", err
+		}
+		ia.App.cfg = cfg
+	}
+	return ia.App.cfg.Novel.GetAbsolutePath()
+}
+
+// showUsage 显示import应用的使用说明
+func (ia *ImportApp) showUsage() {
+	cli := ia.GetCLI()
+	fmt.Printf("用法: %s <书稿文件> [选项]\n", cli.AppName)
+	fmt.Println("\n支持 .txt 和 .md 书稿（UTF-8 编码）。TXT 每个非空行为一段，Markdown 以空行分段。")
+	fmt.Println("章节追加在已有章节之后；小说目录还没有书名时写入识别到的书名。")
+
+	fmt.Println("\n选项:")
+	fmt.Println("  --pattern <正则>          章节标题模式（默认识别 第X章/第X回、Chapter N、序章/楔子/番外 等）")
+	fmt.Println("  --volume-pattern <正则>   卷标题模式（默认识别 第X卷/第X部、Volume N），识别到的卷写入卷与篇章结构")
+	fmt.Println("  --title <书名>            书名，覆盖小说目录中已有的书名")
+	fmt.Println("  --status <状态>           导入章节的状态: draft/revised/final")
+	fmt.Println("  --analyze [类型]          导入后为每章提交分析任务: summary,character,worldview（默认全部）")
+	fmt.Println("  --dry-run                 只显示拆分结果，不写入")
+	fmt.Println("  --novel-dir <path>        指定小说目录")
+	fmt.Println("  -c, --config <path>       指定配置文件路径")
+	fmt.Println("  -h, --help                显示帮助信息")
+
+	fmt.Printf("\n示例:\n")
+	fmt.Printf("  %s book.txt --dry-run\n", cli.AppName)
+	fmt.Printf("  %s book.md --status final --analyze\n", cli.AppName)
+	fmt.Printf("  %s book.txt --pattern '^【[0-9]+】' --analyze summary\n", cli.AppName)
+}
@@ -47,7 +47,7 @@ func (pa *PlanApp) Run(args []string) error {
 	ctx := context.Background()
 
 	// 解析参数和标志
-	userInput, flags, err := pa.ParseArgsWithFlags(args)
+	userInput, flags, err := pa.ParseArgsWithFlags(args, "-h", "--help", "-v", "--verbose", "--dump-context")
 
 	// 检查帮助标志
 	if _, hasHelp := flags["-h"]; hasHelp {
@@ -59,6 +59,11 @@ func (pa *PlanApp) Run(args []string) error {
 		return nil
 	}
 
+	// 转储上下文时不需要规划需求
+	if _, dump := flags["--dump-context"]; dump {
+		err = nil
+	}
+
 	// 如果有-p参数，忽略"参数不足"错误
 	if err != nil {
 		if _, hasP := flags["-p"]; !hasP {
@@ -116,12 +121,12 @@ func (pa *PlanApp) handlePlanningWithFlags(ctx context.Context, app *App, userPr
 	}
 
 	// 如果既没有命令行提示词也没有文件提示词，显示使用说明
-	if finalPrompt == "" {
+	if _, dump := flags["--dump-context"]; finalPrompt == "" && !dump {
 		pa.showUsage()
 		return fmt.Errorf("未提供有效的提示词")
 	}
 
-	return pa.handlePlanning(ctx, app, finalPrompt)
+	return pa.handlePlanning(ctx, app, finalPrompt, flags)
 }
 
 // showUsage 显示plan应用的使用说明
@@ -131,6 +136,8 @@ func (pa *PlanApp) showUsage() {
 	fmt.Println("\n选项:")
 	fmt.Println("  -c, --config <path>    指定配置文件路径")
 	fmt.Println("  -p, --prompt <file>    指定包含规划需求的.md或.txt文件")
+	fmt.Println("  --profile <name>       使用指定的Token预算配置（默认 plan）")
+	fmt.Println("  --dump-context[=file]  只构建上下文并写入文件，不调用模型")
 	fmt.Println("  -v, --verbose          启用详细输出")
 	fmt.Println("  -h, --help             显示帮助信息")
 
@@ -139,6 +146,7 @@ func (pa *PlanApp) showUsage() {
 	fmt.Printf("  %s --prompt /path/to/planning-requirements.md\n", cli.AppName)
 	fmt.Printf("  %s -p requirements.md \"基于现有章节制定后续规划\"\n", cli.AppName)
 	fmt.Printf("  %s --config /path/to/config.yaml -p prompt.txt\n", cli.AppName)
+	fmt.Printf("  %s --dump-context=context.md\n", cli.AppName)
 }
 
 // loadPromptFile 加载prompt文件内容（使用App的LoadPromptFile方法）
@@ -147,7 +155,7 @@ func (pa *PlanApp) loadPromptFile(filePath string) (string, error) {
 }
 
 // handlePlanning 处理计划制定逻辑
-func (pa *PlanApp) handlePlanning(ctx context.Context, app *App, userPrompt string) error {
+func (pa *PlanApp) handlePlanning(ctx context.Context, app *App, userPrompt string, flags map[string]string) error {
 	cli := app.GetCLI()
 	logger := app.GetLogger()
 	config := app.GetConfig()
@@ -163,6 +171,13 @@ func (pa *PlanApp) handlePlanning(ctx context.Context, app *App, userPrompt stri
 		return fmt.Errorf("获取小说路径失败: %w", err)
 	}
 
+	// 可选的预算配置
+	profile, err := pa.GetBudgetProfileFlag(flags)
+	if err != nil {
+		cli.ShowGracefulError("预算配置无效", err.Error(), "请检查 --profile 参数或配置文件中的 budget_profiles")
+		return err
+	}
+
 	// 创建组件
 	llmManager := providers.NewManager(config, *logger)
 
@@ -177,8 +192,15 @@ func (pa *PlanApp) handlePlanning(ctx context.Context, app *App, userPrompt stri
 		LLMManager:   llmManager,
 		ShowProgress: pa.planConfig.ShowSteps,
 		PlannerModel: "deepseek-chat",
+
+		BudgetProfile: profile,
 	})
 
+	// 仅转储上下文，不调用模型
+	if dumpPath, dump := flags["--dump-context"]; dump {
+		return pa.DumpWorkflowContext(planWorkflow, "plan", dumpPath)
+	}
+
 	result, _ := planWorkflow.ExecuteWithMonitoring(userPrompt)
 
 	cli.ShowFooterText("规划工作流程完成！")
@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Kizunad/modular-workflow-v2/components/agents/tools"
+)
+
+// ReindexAppConfig 重建索引应用配置
+type ReindexAppConfig struct {
+	*AppConfig
+	Tenant   string
+	Database string
+}
+
+// DefaultReindexAppConfig 默认重建索引应用配置
+func DefaultReindexAppConfig() *ReindexAppConfig {
+	return &ReindexAppConfig{
+		AppConfig: DefaultAppConfig(),
+		Tenant:    "novel_system",
+		Database:  "novel_db",
+	}
+}
+
+// ReindexApp 重建向量索引应用
+type ReindexApp struct {
+	*App
+	reindexConfig *ReindexAppConfig
+}
+
+// NewReindexApp 创建重建索引应用
+func NewReindexApp() *ReindexApp {
+	config := DefaultReindexAppConfig()
+	config.Name = "小说向量索引重建工具"
+	config.Description = "为已有章节、摘要和规划批量重建向量集合"
+
+	return &ReindexApp{
+		App:           NewApp(config.AppConfig),
+		reindexConfig: config,
+	}
+}
+
+// Run 运行重建索引应用
+func (ra *ReindexApp) Run(args []string) error {
+	ctx := context.Background()
+
+	// 会话ID可以作为位置参数或 --session 提供
+	sessionID, flags, _ := ra.ParseArgsWithFlags(args, "-h", "--help", "--dry-run", "--force")
+
+	if _, hasHelp := flags["-h"]; hasHelp {
+		ra.showUsage()
+		return nil
+	}
+	if _, hasHelp := flags["--help"]; hasHelp {
+		ra.showUsage()
+		return nil
+	}
+
+	if value, ok := flags["--session"]; ok && value != "" {
+		sessionID = value
+	} else if value, ok := flags["-s"]; ok && value != "" {
+		sessionID = value
+	}
+	if sessionID == "" {
+		ra.showUsage()
+		return fmt.Errorf("未提供会话ID")
+	}
+
+	if configPath, ok := flags["--config"]; ok {
+		ra.App.config.ConfigPath = configPath
+		ra.GetCLI().ShowInfo("🔧", fmt.Sprintf("使用指定配置文件: %s", configPath))
+	} else if configPath, ok := flags["-c"]; ok {
+		ra.App.config.ConfigPath = configPath
+		ra.GetCLI().ShowInfo("🔧", fmt.Sprintf("使用指定配置文件: %s", configPath))
+	}
+
+	// 重建索引不需要消息队列，只加载配置
+	cfg, err := ra.App.loadConfig()
+	if err != nil {
+		ra.GetCLI().ShowGracefulError("初始化失败", err.Error(), "请检查配置文件是否正确")
+		return err
+	}
+	ra.App.cfg = cfg
+
+	if novelDir, ok := flags["--novel-dir"]; ok {
+		cfg.Novel.Path = novelDir
+		ra.GetCLI().ShowInfo("📂", fmt.Sprintf("使用指定小说目录: %s", novelDir))
+	}
+
+	opts := tools.ReindexOptions{}
+	if _, ok := flags["--dry-run"]; ok {
+		opts.DryRun = true
+	}
+	if _, ok := flags["--force"]; ok {
+		opts.Force = true
+	}
+	if types, ok := flags["--types"]; ok && types != "" {
+		for _, contentType := range strings.Split(types, ",") {
+			if contentType = strings.TrimSpace(contentType); contentType != "" {
+				opts.ContentTypes = append(opts.ContentTypes, contentType)
+			}
+		}
+	}
+
+	storeConfig := &tools.VectorStoreConfig{
+		SessionID: sessionID,
+		Tenant:    ra.reindexConfig.Tenant,
+		Database:  ra.reindexConfig.Database,
+	}
+	if tenant, ok := flags["--tenant"]; ok && tenant != "" {
+		storeConfig.Tenant = tenant
+	}
+	if database, ok := flags["--database"]; ok && database != "" {
+		storeConfig.Database = database
+	}
+
+	return ra.handleReindex(ctx, storeConfig, opts)
+}
+
+// handleReindex 执行重建索引并输出结果
+func (ra *ReindexApp) handleReindex(ctx context.Context, storeConfig *tools.VectorStoreConfig, opts tools.ReindexOptions) error {
+	cli := ra.GetCLI()
+
+	novelPath, err := ra.GetConfig().Novel.GetAbsolutePath()
+	if err != nil {
+		return fmt.Errorf("获取小说路径失败: %w", err)
+	}
+
+	cli.ShowBannerText("向量索引重建")
+	cli.ShowInfo("📂", fmt.Sprintf("小说目录: %s", novelPath))
+	cli.ShowInfo("🔑", fmt.Sprintf("会话ID: %s", storeConfig.SessionID))
+	if opts.DryRun {
+		cli.ShowInfo("🔍", "dry-run 模式：只显示将要发生的变更，不写入向量库")
+	}
+
+	var service *tools.VectorStoreService
+	if !opts.DryRun {
+		service, err = tools.NewVectorStoreService()
+		if err != nil {
+			return fmt.Errorf("创建向量存储服务失败: %w", err)
+		}
+		if err := service.Health(ctx); err != nil {
+			return fmt.Errorf("向量服务不可用: %w", err)
+		}
+	}
+
+	reindexer := tools.NewVectorReindexer(novelPath, storeConfig, service)
+	opts.Progress = func(current, total int, item tools.ReindexItem) {
+		desc := fmt.Sprintf("%s %s/%s", reindexActionLabel(item.Action), item.Collection, item.ID)
+		if item.Error != "" {
+			desc += " 失败: " + item.Error
+		}
+		cli.ShowProgress(current, total, desc)
+	}
+
+	cli.ShowSeparator()
+	report, err := reindexer.Run(ctx, opts)
+	cli.ShowSeparator()
+	if report != nil {
+		cli.ShowInfo("➕", fmt.Sprintf("新增: %d", report.Added))
+		cli.ShowInfo("🔄", fmt.Sprintf("更新: %d", report.Updated))
+		cli.ShowInfo("⏭️", fmt.Sprintf("未变化: %d", report.Unchanged))
+		cli.ShowInfo("🗑️", fmt.Sprintf("删除: %d", report.Removed))
+		if report.Failed > 0 {
+			cli.ShowInfo("❌", fmt.Sprintf("失败: %d（重新运行将从进度文件继续）", report.Failed))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("重建索引失败: %w", err)
+	}
+
+	if opts.DryRun {
+		cli.ShowFooterText("dry-run 完成，未写入任何数据")
+	} else {
+		cli.ShowInfo("💾", fmt.Sprintf("进度文件: %s", reindexer.GetStatePath()))
+		cli.ShowFooterText("向量索引重建完成！")
+	}
+	return nil
+}
+
+// reindexActionLabel 变更类型的显示名称
+func reindexActionLabel(action string) string {
+	switch action {
+	case tools.ReindexActionAdd:
+		return "新增"
+	case tools.ReindexActionUpdate:
+		return "更新"
+	case tools.ReindexActionUnchanged:
+		return "跳过"
+	case tools.ReindexActionRemove:
+		return "删除"
+	default:
+		return action
+	}
+}
+
+// showUsage 显示reindex应用的使用说明
+func (ra *ReindexApp) showUsage() {
+	cli := ra.GetCLI()
+	fmt.Printf("用法: %s [选项] <会话ID>\n", cli.AppName)
+	fmt.Println("\n说明:")
+	fmt.Println("  遍历章节文件、index.json 摘要和 planner.json 规划，")
+	fmt.Println("  重建 novel_<会话ID>、summary_<会话ID>、plan_<会话ID> 三个向量集合。")
+	fmt.Println("  每写入一个文档都会记录进度，中断后重新运行会跳过未变化的文档。")
+
+	fmt.Println("\n选项:")
+	fmt.Println("  -s, --session <id>     指定会话ID")
+	fmt.Println("  --dry-run              只显示将要新增/更新/删除的文档")
+	fmt.Println("  --force                忽略进度文件，全部重新写入")
+	fmt.Println("  --types <list>         限定内容类型，逗号分隔: chapter,summary,plan")
+	fmt.Println("  --tenant <name>        Chroma租户（默认 novel_system）")
+	fmt.Println("  --database <name>      Chroma数据库（默认 novel_db）")
+	fmt.Println("  --novel-dir <path>     指定小说目录")
+	fmt.Println("  -c, --config <path>    指定配置文件路径")
+	fmt.Println("  -h, --help             显示帮助信息")
+
+	fmt.Printf("\n示例:\n")
+	fmt.Printf("  %s my_novel --dry-run                  # 预览变更\n", cli.AppName)
+	fmt.Printf("  %s my_novel                            # 重建（可断点续跑）\n", cli.AppName)
+	fmt.Printf("  %s --session my_novel --types summary  # 只重建摘要集合\n", cli.AppName)
+	fmt.Printf("  %s my_novel --force                    # 全部重新写入\n", cli.AppName)
+}
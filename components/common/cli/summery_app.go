@@ -3,9 +3,13 @@ package cli
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/Kizunad/modular-workflow-v2/components/common"
+	"github.com/Kizunad/modular-workflow-v2/components/content/token"
+	"github.com/Kizunad/modular-workflow-v2/components/workflows"
 	"github.com/Kizunad/modular-workflow-v2/providers"
 	"github.com/Kizunad/modular-workflow-v2/queue"
 )
@@ -30,6 +34,7 @@ func DefaultSummeryAppConfig() *SummeryAppConfig {
 type SummeryApp struct {
 	*App
 	summeryConfig *SummeryAppConfig
+	profile       string // --profile 指定的预算配置，传给队列中的工作流
 }
 
 // NewSummeryApp 创建摘要应用
@@ -49,7 +54,7 @@ func (sa *SummeryApp) Run(args []string) error {
 	ctx := context.Background()
 
 	// 解析参数和标志
-	userInput, flags, err := sa.ParseArgsWithFlags(args)
+	userInput, flags, err := sa.ParseArgsWithFlags(args, "-h", "--help", "-v", "--verbose", "-l", "--latest", "--all", "--dump-context")
 
 	// 检查帮助标志
 	if _, hasHelp := flags["-h"]; hasHelp {
@@ -90,7 +95,8 @@ func (sa *SummeryApp) Run(args []string) error {
 		if _, hasP := flags["-p"]; !hasP {
 			if _, hasPrompt := flags["--prompt"]; !hasPrompt {
 				// 这些摘要类型可以不需要用户输入参数
-				if summaryType != "latest" && summaryType != "worldview" && summaryType != "character" && summaryType != "all" {
+				_, dump := flags["--dump-context"]
+				if !dump && summaryType != "latest" && summaryType != "worldview" && summaryType != "character" && summaryType != "all" {
 					sa.showUsage()
 					return nil
 				}
@@ -126,6 +132,19 @@ func (sa *SummeryApp) handleSummeryWithFlags(ctx context.Context, app *App, user
 		}
 	}
 
+	// 可选的预算配置：队列中的摘要、角色、世界观工作流都使用该配置
+	profile, err := sa.GetBudgetProfileFlag(flags)
+	if err != nil {
+		sa.GetCLI().ShowGracefulError("预算配置无效", err.Error(), "请检查 --profile 参数或配置文件中的 budget_profiles")
+		return err
+	}
+	sa.profile = profile
+
+	// 仅转储上下文，不调用模型
+	if dumpPath, dump := flags["--dump-context"]; dump {
+		return sa.handleDumpContext(app, summaryType, dumpPath, profile)
+	}
+
 	// 处理不同的摘要类型
 	switch summaryType {
 	case "content":
@@ -399,7 +418,7 @@ func (sa *SummeryApp) handleSummary(ctx context.Context, app *App, summaryTitle
 	llmManager := providers.NewManager(config, *logger)
 
 	// 初始化消息队列
-	mq, err := queue.InitQueue(&config.MessageQueue, novelPath, llmManager, logger)
+	mq, err := queue.InitQueue(&config.MessageQueue, novelPath, llmManager, logger, queue.WithBudgetProfile(sa.profile))
 	if err != nil {
 		return fmt.Errorf("初始化消息队列失败: %w", err)
 	}
@@ -462,6 +481,52 @@ func (sa *SummeryApp) waitForCompletion(cli *common.CLIHelper, mq *queue.Message
 	}
 }
 
+// handleDumpContext 转储摘要类任务使用的上下文：worldview/character 对应各自的工作流，all 转储全部三个，其余为章节摘要
+func (sa *SummeryApp) handleDumpContext(app *App, summaryType, dumpPath, profile string) error {
+	logger := app.GetLogger()
+	config := app.GetConfig()
+
+	novelPath, err := config.Novel.GetAbsolutePath()
+	if err != nil {
+		return fmt.Errorf("获取小说路径失败: %w", err)
+	}
+	llmManager := providers.NewManager(config, *logger)
+
+	dumpers := map[string]ContextDumper{
+		token.ProfileSummarize: workflows.NewSummarizerWorkflow(&workflows.SummarizerWorkflowConfig{
+			Logger: logger, NovelDir: novelPath, LLMManager: llmManager, Model: queue.AnalysisModel, BudgetProfile: profile,
+		}),
+		token.ProfileWorldview: workflows.NewWorldviewSummarizerWorkflow(&workflows.WorldviewSummarizerWorkflowConfig{
+			Logger: logger, NovelDir: novelPath, LLMManager: llmManager, Model: queue.AnalysisModel, BudgetProfile: profile,
+		}),
+		token.ProfileCharacter: workflows.NewCharacterUpdateWorkflow(&workflows.CharacterUpdateWorkflowConfig{
+			Logger: logger, NovelDir: novelPath, LLMManager: llmManager, Model: queue.AnalysisModel, BudgetProfile: profile,
+		}),
+	}
+
+	switch summaryType {
+	case "worldview":
+		return sa.DumpWorkflowContext(dumpers[token.ProfileWorldview], token.ProfileWorldview, dumpPath)
+	case "character":
+		return sa.DumpWorkflowContext(dumpers[token.ProfileCharacter], token.ProfileCharacter, dumpPath)
+	case "all":
+		// 多个工作流分别写入文件，指定路径时在文件名后追加工作流名称
+		for _, workflow := range []string{token.ProfileSummarize, token.ProfileCharacter, token.ProfileWorldview} {
+			path := ""
+			if dumpPath != "" {
+				ext := filepath.Ext(dumpPath)
+				path = strings.TrimSuffix(dumpPath, ext) + "_" + workflow + ext
+			}
+			if err := sa.DumpWorkflowContext(dumpers[workflow], workflow, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return sa.DumpWorkflowContext(dumpers[token.ProfileSummarize], token.ProfileSummarize, dumpPath)
+	}
+}
+
 // showUsage 显示summery应用的使用说明
 func (sa *SummeryApp) showUsage() {
 	cli := sa.GetCLI()
@@ -478,6 +543,8 @@ func (sa *SummeryApp) showUsage() {
 	fmt.Println("\n选项:")
 	fmt.Println("  -c, --config <path>    指定配置文件路径")
 	fmt.Println("  -p, --prompt <file>    指定包含内容的.md或.txt文件")
+	fmt.Println("  --profile <name>       使用指定的Token预算配置（默认各工作流同名配置）")
+	fmt.Println("  --dump-context[=file]  只构建对应任务的上下文并写入文件，不调用模型")
 	fmt.Println("  -v, --verbose          启用详细输出")
 	fmt.Println("  -h, --help             显示帮助信息")
 
@@ -493,6 +560,7 @@ func (sa *SummeryApp) showUsage() {
 	fmt.Printf("  %s --chapter --prompt chapter.md             # 深度分析章节结构\n", cli.AppName)
 	fmt.Printf("  %s --all                                     # 执行全部更新（摘要+角色+世界观）\n", cli.AppName)
 	fmt.Printf("  %s --config config.yaml --latest             # 使用指定配置为最新章节生成摘要\n", cli.AppName)
+	fmt.Printf("  %s --worldview --dump-context=ctx.md         # 查看世界观任务的完整上下文\n", cli.AppName)
 }
 
 // loadPromptFile 加载prompt文件内容（使用App的LoadPromptFile方法）
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/Kizunad/modular-workflow-v2/components/agents/tools"
+	"github.com/Kizunad/modular-workflow-v2/components/content"
 	"github.com/Kizunad/modular-workflow-v2/components/workflows"
 	"github.com/Kizunad/modular-workflow-v2/providers"
 )
@@ -47,7 +49,7 @@ func (wa *WriteApp) Run(args []string) error {
 	ctx := context.Background()
 
 	// 解析参数和标志
-	userInput, flags, err := wa.ParseArgsWithFlags(args)
+	userInput, flags, err := wa.ParseArgsWithFlags(args, "-h", "--help", "-v", "--verbose", "--retrieve", "--dump-context")
 
 	// 检查帮助标志
 	if _, hasHelp := flags["-h"]; hasHelp {
@@ -59,6 +61,11 @@ func (wa *WriteApp) Run(args []string) error {
 		return nil
 	}
 
+	// 转储上下文时不需要创作需求
+	if _, dump := flags["--dump-context"]; dump {
+		err = nil
+	}
+
 	// 如果有-p参数，忽略"参数不足"错误
 	if err != nil {
 		if _, hasP := flags["-p"]; !hasP {
@@ -116,12 +123,12 @@ func (wa *WriteApp) handleWritingWithFlags(ctx context.Context, app *App, userPr
 	}
 
 	// 如果既没有命令行提示词也没有文件提示词，显示使用说明
-	if finalPrompt == "" {
+	if _, dump := flags["--dump-context"]; finalPrompt == "" && !dump {
 		wa.showUsage()
 		return fmt.Errorf("未提供有效的提示词")
 	}
 
-	return wa.handleWriting(ctx, app, finalPrompt)
+	return wa.handleWriting(ctx, app, finalPrompt, flags)
 }
 
 // showUsage 显示write应用的使用说明
@@ -131,6 +138,10 @@ func (wa *WriteApp) showUsage() {
 	fmt.Println("\n选项:")
 	fmt.Println("  -c, --config <path>    指定配置文件路径")
 	fmt.Println("  -p, --prompt <file>    指定包含创作需求的.md或.txt文件")
+	fmt.Println("  --retrieve             根据当前规划检索相关前文片段加入上下文")
+	fmt.Println("  --session <id>         检索时同时使用该会话的向量集合（需配合 --retrieve）")
+	fmt.Println("  --profile <name>       使用指定的Token预算配置（默认 write）")
+	fmt.Println("  --dump-context[=file]  只构建上下文并写入文件，不调用模型")
 	fmt.Println("  -v, --verbose          启用详细输出")
 	fmt.Println("  -h, --help             显示帮助信息")
 
@@ -139,6 +150,9 @@ func (wa *WriteApp) showUsage() {
 	fmt.Printf("  %s --prompt /path/to/writing-requirements.md\n", cli.AppName)
 	fmt.Printf("  %s -p requirements.md \"基于规划创作精彩内容\"\n", cli.AppName)
 	fmt.Printf("  %s --config /path/to/config.yaml -p prompt.txt\n", cli.AppName)
+	fmt.Printf("  %s --retrieve --session my_novel -p prompt.txt\n", cli.AppName)
+	fmt.Printf("  %s --profile write_long -p prompt.txt\n", cli.AppName)
+	fmt.Printf("  %s --dump-context=context.md\n", cli.AppName)
 }
 
 // loadPromptFile 加载prompt文件内容（使用App的LoadPromptFile方法）
@@ -147,7 +161,7 @@ func (wa *WriteApp) loadPromptFile(filePath string) (string, error) {
 }
 
 // handleWriting 处理写作逻辑
-func (wa *WriteApp) handleWriting(ctx context.Context, app *App, userPrompt string) error {
+func (wa *WriteApp) handleWriting(ctx context.Context, app *App, userPrompt string, flags map[string]string) error {
 	cli := app.GetCLI()
 	logger := app.GetLogger()
 	config := app.GetConfig()
@@ -166,6 +180,19 @@ func (wa *WriteApp) handleWriting(ctx context.Context, app *App, userPrompt stri
 	// 创建组件
 	llmManager := providers.NewManager(config, *logger)
 
+	// 可选的预算配置
+	profile, err := wa.GetBudgetProfileFlag(flags)
+	if err != nil {
+		cli.ShowGracefulError("预算配置无效", err.Error(), "请检查 --profile 参数或配置文件中的 budget_profiles")
+		return err
+	}
+
+	// 可选的前文检索
+	var retriever content.PassageRetriever
+	if _, ok := flags["--retrieve"]; ok {
+		retriever = wa.createRetriever(novelPath, flags)
+	}
+
 	cli.ShowSuccess("系统初始化完成")
 	cli.ShowInfo("✍️", "开始执行创作工作流程...")
 	cli.ShowSeparator()
@@ -177,8 +204,16 @@ func (wa *WriteApp) handleWriting(ctx context.Context, app *App, userPrompt stri
 		LLMManager:   llmManager,
 		ShowProgress: wa.writeConfig.ShowSteps,
 		WriterModel:  "deepseek-chat",
+		Retriever:    retriever,
+
+		BudgetProfile: profile,
 	})
 
+	// 仅转储上下文，不调用模型
+	if dumpPath, dump := flags["--dump-context"]; dump {
+		return wa.DumpWorkflowContext(writeWorkflow, "write", dumpPath)
+	}
+
 	// 创建并编译工作流
 	result, err := writeWorkflow.ExecuteWithMonitoring(userPrompt)
 
@@ -199,6 +234,26 @@ func (wa *WriteApp) handleWriting(ctx context.Context, app *App, userPrompt stri
 	return nil
 }
 
+// createRetriever 创建前文检索器，指定会话且向量服务可用时使用混合检索
+func (wa *WriteApp) createRetriever(novelPath string, flags map[string]string) content.PassageRetriever {
+	cli := wa.GetCLI()
+
+	sessionID := flags["--session"]
+	if sessionID == "" {
+		cli.ShowInfo("🔎", "已启用前文检索（本地关键词索引）")
+		return tools.NewContextRetriever(novelPath)
+	}
+
+	service, err := tools.NewVectorSearchService()
+	if err != nil {
+		cli.ShowInfo("⚠️", fmt.Sprintf("向量服务不可用，前文检索仅使用本地关键词索引: %v", err))
+		return tools.NewContextRetriever(novelPath)
+	}
+
+	cli.ShowInfo("🔎", fmt.Sprintf("已启用前文检索（向量+关键词，会话: %s）", sessionID))
+	return tools.NewHybridContextRetriever(novelPath, service, sessionID, "novel_system", "novel_db")
+}
+
 // SetShowSteps 设置是否显示步骤
 func (wa *WriteApp) SetShowSteps(show bool) {
 	wa.writeConfig.ShowSteps = show
@@ -206,8 +206,13 @@ func (c *CLIHelper) GetFlagValue(flag string) (string, bool) {
 }
 
 // ParseArgsWithFlags 解析命令行参数，支持标志提取
-func (c *CLIHelper) ParseArgsWithFlags(args []string, minArgs int) (userInput string, flags map[string]string, err error) {
+// boolFlags 为不带值的布尔标志，不会把后面的参数当作自己的值；需要时仍可用 --flag=value 指定值
+func (c *CLIHelper) ParseArgsWithFlags(args []string, minArgs int, boolFlags ...string) (userInput string, flags map[string]string, err error) {
 	flags = make(map[string]string)
+	isBool := make(map[string]bool, len(boolFlags))
+	for _, flag := range boolFlags {
+		isBool[flag] = true
+	}
 	var nonFlagArgs []string
 	
 	i := 1 // 跳过程序名
@@ -225,7 +230,7 @@ func (c *CLIHelper) ParseArgsWithFlags(args []string, minArgs int) (userInput st
 		}
 		
 		// 处理 --flag value 格式
-		if strings.HasPrefix(arg, "-") && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+		if strings.HasPrefix(arg, "-") && !isBool[arg] && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
 			flags[arg] = args[i+1]
 			i += 2
 			continue
@@ -0,0 +1,88 @@
+package content
+
+import (
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+	"github.com/Kizunad/modular-workflow-v2/components/content/token"
+	"github.com/Kizunad/modular-workflow-v2/config"
+)
+
+// contextSources 一次上下文构建中共用的内容来源
+// 第一轮测量和随后的填充使用同一组管理器，源文件只读取一次
+type contextSources struct {
+	index     *managers.IndexReader
+	worldview *managers.WorldviewManager
+	character *managers.CharacterManager
+	planner   *managers.PlannerContentManager
+	chapters  *managers.ChapterManager
+
+	chapterData map[int]*managers.ChapterData // 已读取的章节数据，按章节编号缓存
+}
+
+// newContextSources 创建绑定到本次Token预算的内容来源
+func (cb *ContextBuilder) newContextSources(tokenBudget *token.TokenBudgetManager) *contextSources {
+	novelDir := cb.config.NovelDir
+	return &contextSources{
+		index:       managers.NewIndexReaderWithTokenBudget(novelDir, tokenBudget),
+		worldview:   managers.NewWorldviewManagerWithTokenBudget(novelDir, tokenBudget),
+		character:   managers.NewCharacterManagerWithTokenBudget(novelDir, tokenBudget),
+		planner:     managers.NewPlannerContentManagerWithTokenBudget(novelDir, tokenBudget),
+		chapters:    managers.NewChapterManager(novelDir),
+		chapterData: make(map[int]*managers.ChapterData),
+	}
+}
+
+// chapter 读取章节数据，同一次构建中每章只读取一次
+func (s *contextSources) chapter(num int) (*managers.ChapterData, error) {
+	if chapter, ok := s.chapterData[num]; ok {
+		return chapter, nil
+	}
+	chapter, err := s.chapters.GetChapterData(num)
+	if err != nil {
+		return nil, err
+	}
+	s.chapterData[num] = chapter
+	return chapter, nil
+}
+
+// measureComponentNeeds 测量各组件完整内容所需的Token数，作为两轮分配的第一轮输入
+func (cb *ContextBuilder) measureComponentNeeds(tokenBudget *token.TokenBudgetManager, sources *contextSources) map[string]int {
+	needs := make(map[string]int)
+
+	if cb.config.NovelDir != "" {
+		needs["index"] = tokenBudget.CountTokens(sources.index.GetSummary())
+	}
+	needs["worldview"] = tokenBudget.CountTokens(sources.worldview.GetCurrent())
+	needs["character"] = tokenBudget.CountTokens(sources.character.GetContextText())
+	needs["plan"] = tokenBudget.CountTokens(sources.planner.FormatPlansForContext())
+
+	// 章节需求按最近章节窗口内全部全文计算
+	ids := sources.chapters.GetChapterIDs()
+	chapterNeed := 0
+	for i := len(ids) - 1; i >= 0 && i >= len(ids)-cb.recentChapterWindow(); i-- {
+		if chapter, err := sources.chapter(ids[i]); err == nil {
+			chapterNeed += tokenBudget.CountTokens(chapter.GetText())
+		}
+	}
+	needs["chapters"] = chapterNeed
+
+	// 检索片段无法预先测量：启用检索且存在规划时保留完整上限，否则不需要
+	if cb.config.Retriever == nil || retrievalQuery(sources.planner) == "" {
+		needs["retrieved"] = 0
+	}
+
+	return needs
+}
+
+// redistributeBudget 执行两轮预算分配并返回分配报告
+func (cb *ContextBuilder) redistributeBudget(tokenBudget *token.TokenBudgetManager, sources *contextSources) *token.AllocationReport {
+	priorities := cb.config.ContentPriorities
+	if priorities == nil {
+		priorities = config.DefaultContentPriorities()
+	}
+	weights := cb.config.ContentWeights
+	if weights == nil {
+		weights = config.DefaultContentWeights()
+	}
+
+	return tokenBudget.Redistribute(cb.measureComponentNeeds(tokenBudget, sources), priorities, weights)
+}
@@ -2,7 +2,6 @@ package content
 
 import (
 	"fmt"
-	"path/filepath"
 
 	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
 	"github.com/Kizunad/modular-workflow-v2/components/content/token"
@@ -16,13 +15,32 @@ type ContextData struct {
 	Worldview  string `json:"worldview"`
 	Characters string `json:"characters"`
 	Chapter    string `json:"chapter"`
-	Plan       string `json:"plan"` // 规划信息
+	Plan       string `json:"plan"`      // 规划信息
+	Retrieved  string `json:"retrieved"` // 检索到的相关前文片段
+
+	Allocation *token.AllocationReport    `json:"allocation,omitempty"` // 最终Token分配情况
+	Usage      map[string]*ComponentUsage `json:"usage,omitempty"`      // 各组件实际使用的Token
 }
 
 // ContextConfig 上下文构建配置
 type ContextConfig struct {
 	NovelDir string            // 小说目录（所有内容的根目录）
 	Logger   *logger.ZapLogger // 日志记录器
+
+	Retriever     PassageRetriever // 前文检索器，为nil时不执行检索
+	RetrievalTopK int              // 检索片段数量，默认8
+
+	RecentChapters int // 最近章节窗口大小，默认3
+
+	ContentPriorities map[string]int     // 剩余Token的分配优先级，数字越小越优先，为nil时使用默认值
+	ContentWeights    map[string]float64 // 同一优先级内的分配权重，为nil时使用默认值
+
+	Compressor token.TextCompressor // 世界观、角色、规划超出预算时的压缩器，为nil时直接截断
+	Counter    token.TokenCounter   // 与目标模型匹配的Token计数器，为nil时使用估算计数器
+
+	TruncateModes map[string]token.TruncateMode // 各组件的截断方式，为nil时章节保留结尾、其余保留开头
+
+	Cache *ContextCache // 上下文缓存，源文件未变化时直接复用构建结果，为nil时不缓存
 }
 
 // ContextBuilder Token感知的上下文构建器
@@ -41,6 +59,22 @@ func NewContextBuilder(config *ContextConfig) *ContextBuilder {
 // tokenPercentages: Token分配百分比配置
 // maxTokens: 最大Token数量
 func (cb *ContextBuilder) BuildTokenAwareContext(tokenPercentages *token.TokenPercentages, maxTokens int) (*ContextData, error) {
+	var cacheKey, fingerprint string
+	cacheable := cb.config.Cache != nil && tokenPercentages != nil
+	if cacheable {
+		// 检索器状态无法观测时，检索结果可能随时变化，不使用缓存
+		fingerprint, cacheable = cb.sourceFingerprint()
+	}
+	if cacheable {
+		cacheKey = cb.cacheKey(tokenPercentages, maxTokens)
+		if cached, ok := cb.config.Cache.Get(cacheKey, fingerprint); ok {
+			if cb.config.Logger != nil {
+				cb.config.Logger.Info("源文件未变化，使用缓存的上下文")
+			}
+			return cached, nil
+		}
+	}
+
 	ctx := &ContextData{}
 
 	// 创建Token预算管理器
@@ -51,19 +85,29 @@ func (cb *ContextBuilder) BuildTokenAwareContext(tokenPercentages *token.TokenPe
 		}
 		return nil, fmt.Errorf("创建Token预算管理器失败: %w", err)
 	}
+	tokenBudget.SetCounter(cb.config.Counter)
+	tokenBudget.SetCompressor(cb.config.Compressor)
+	for component, mode := range cb.config.TruncateModes {
+		tokenBudget.SetTruncateMode(component, mode)
+	}
 
+	// 两轮分配：先按实际内容大小满足各组件，再把未用完的Token按优先级和权重重新分配
+	// 测量和填充共用同一组内容来源，源文件只读取一次
+	sources := cb.newContextSources(tokenBudget)
+	ctx.Allocation = cb.redistributeBudget(tokenBudget, sources)
 	allocation := tokenBudget.GetAllocatedTokens()
+	if cb.config.Logger != nil {
+		cb.config.Logger.Info(fmt.Sprintf("Token重新分配: %s", ctx.Allocation.String()))
+	}
 
 	// 获取标题和摘要（使用index token分配）
 	if cb.config.NovelDir != "" {
-		indexReader := managers.NewIndexReaderWithTokenBudget(cb.config.NovelDir, tokenBudget)
-
 		if indexTokens, exists := allocation["index"]; exists {
-			ctx.Title = indexReader.GetTitle()
-			ctx.Summary, _ = indexReader.GetSummaryWithTokenLimit(indexTokens)
+			ctx.Title = sources.index.GetTitle()
+			ctx.Summary, _ = sources.index.GetSummaryWithTokenLimit(indexTokens)
 		} else {
-			ctx.Title = indexReader.GetTitle()
-			ctx.Summary = indexReader.GetSummary()
+			ctx.Title = sources.index.GetTitle()
+			ctx.Summary = sources.index.GetSummary()
 		}
 	}
 
@@ -76,18 +120,16 @@ func (cb *ContextBuilder) BuildTokenAwareContext(tokenPercentages *token.TokenPe
 	}
 
 	// 获取Token感知的世界观（使用标准路径）
-	worldviewManager := managers.NewWorldviewManagerWithTokenBudget(cb.config.NovelDir, tokenBudget)
-
 	if worldviewTokens, exists := allocation["worldview"]; exists {
 		if cb.config.Logger != nil {
 			cb.config.Logger.Info(fmt.Sprintf("[DEBUG] 使用Token限制读取世界观: 最大Token=%d", worldviewTokens))
 		}
-		ctx.Worldview, _ = worldviewManager.GetCurrentWithTokenLimit(worldviewTokens)
+		ctx.Worldview, _ = sources.worldview.GetCurrentWithTokenLimit(worldviewTokens)
 	} else {
 		if cb.config.Logger != nil {
 			cb.config.Logger.Info("[DEBUG] 使用完整读取世界观")
 		}
-		ctx.Worldview = worldviewManager.GetCurrent()
+		ctx.Worldview = sources.worldview.GetCurrent()
 	}
 
 	if cb.config.Logger != nil {
@@ -108,28 +150,20 @@ func (cb *ContextBuilder) BuildTokenAwareContext(tokenPercentages *token.TokenPe
 	}
 
 	// 获取Token感知的角色信息（使用标准路径）
-	characterPath := filepath.Join(cb.config.NovelDir, "character.md")
-	characterManager := managers.NewCharacterManagerWithTokenBudget(characterPath, tokenBudget)
-
 	if characterTokens, exists := allocation["character"]; exists {
-		ctx.Characters, _ = characterManager.GetCurrentWithTokenLimit(characterTokens)
+		ctx.Characters, _ = sources.character.GetCurrentWithTokenLimit(characterTokens)
 	} else {
-		ctx.Characters = characterManager.GetCurrent()
+		ctx.Characters = sources.character.GetContextText()
 	}
 
 	// 获取Token感知的章节内容（使用标准的章节管理器）
-	chapterManager := managers.NewChapterManager(cb.config.NovelDir)
-
-	if _, exists := allocation["chapters"]; exists {
-		// 获取最新章节内容并限制Token数量
-		if content, err := chapterManager.GetLatestChapterContent(); err == nil {
-			ctx.Chapter, _ = tokenBudget.TruncateToTokenLimit(content, "chapters")
-		} else {
-			ctx.Chapter = ""
-		}
+	includedChapters := make(map[int]bool)
+	if chapterTokens, exists := allocation["chapters"]; exists {
+		// 从最新章节开始填充最近章节窗口，较早章节在预算不足时以摘要代替
+		ctx.Chapter, includedChapters = cb.buildRecentChaptersContext(tokenBudget, sources, chapterTokens)
 	} else {
 		// 不限制Token数量时，获取最新章节
-		if content, err := chapterManager.GetLatestChapterContent(); err == nil {
+		if content, err := sources.chapters.GetLatestChapterContent(); err == nil {
 			ctx.Chapter = content
 		} else {
 			ctx.Chapter = ""
@@ -137,20 +171,28 @@ func (cb *ContextBuilder) BuildTokenAwareContext(tokenPercentages *token.TokenPe
 	}
 
 	// 获取Token感知的规划信息（使用标准的规划管理器）
-	plannerManager := managers.NewPlannerContentManagerWithTokenBudget(cb.config.NovelDir, tokenBudget)
-
 	if planTokens, exists := allocation["plan"]; exists {
 		// 获取规划信息并限制Token数量
-		ctx.Plan, _ = plannerManager.GetPlansWithTokenLimit(planTokens)
+		ctx.Plan, _ = sources.planner.GetPlansWithTokenLimit(planTokens)
 	} else {
 		// 不限制Token数量时，获取所有规划
-		ctx.Plan = plannerManager.FormatPlansForContext()
+		ctx.Plan = sources.planner.FormatPlansForContext()
+	}
+
+	// 检索与当前规划相关的前文片段（仅在配置了检索器且分配了retrieved预算时执行）
+	if retrievedTokens := allocation["retrieved"]; retrievedTokens > 0 {
+		ctx.Retrieved = cb.buildRetrievedContext(tokenBudget, sources, retrievedTokens, includedChapters)
 	}
 
 	// 记录Token使用情况
+	cb.recordUsage(ctx, tokenBudget)
 	if cb.config.Logger != nil {
-		cb.config.Logger.Info(fmt.Sprintf("Token分配: index=%d, worldview=%d, character=%d, chapters=%d, plan=%d",
-			allocation["index"], allocation["worldview"], allocation["character"], allocation["chapters"], allocation["plan"]))
+		cb.config.Logger.Info(fmt.Sprintf("Token分配: index=%d, worldview=%d, character=%d, chapters=%d, plan=%d, retrieved=%d",
+			allocation["index"], allocation["worldview"], allocation["character"], allocation["chapters"], allocation["plan"], allocation["retrieved"]))
+	}
+
+	if cacheKey != "" {
+		cb.config.Cache.Put(cacheKey, cb.config.NovelDir, fingerprint, ctx)
 	}
 
 	return ctx, nil
@@ -158,8 +200,12 @@ func (cb *ContextBuilder) BuildTokenAwareContext(tokenPercentages *token.TokenPe
 
 // FormatContext 将上下文数据格式化为文本
 func (cb *ContextBuilder) FormatContext(ctx *ContextData) string {
-	return fmt.Sprintf("章节标题: %s\n\n章节摘要:\n%s\n\n世界观:\n%s\n\n角色信息:\n%s\n\n当前章节:\n%s\n\n规划信息:\n%s",
+	formatted := fmt.Sprintf("章节标题: %s\n\n章节摘要:\n%s\n\n世界观:\n%s\n\n角色信息:\n%s\n\n当前章节:\n%s\n\n规划信息:\n%s",
 		ctx.Title, ctx.Summary, ctx.Worldview, ctx.Characters, ctx.Chapter, ctx.Plan)
+	if ctx.Retrieved != "" {
+		formatted += fmt.Sprintf("\n\n相关前文:\n%s", ctx.Retrieved)
+	}
+	return formatted
 }
 
 // GetContextAsMap 将上下文数据转换为map格式
@@ -171,6 +217,7 @@ func (cb *ContextBuilder) GetContextAsMap(ctx *ContextData) map[string]any {
 		"characters": ctx.Characters,
 		"chapter":    ctx.Chapter,
 		"plan":       ctx.Plan,
+		"retrieved":  ctx.Retrieved,
 		"context":    cb.FormatContext(ctx),
 	}
 }
@@ -206,9 +253,8 @@ func (cb *ContextBuilder) BuildFullContext() (*ContextData, error) {
 	}
 
 	// 获取角色信息（使用标准路径）
-	characterPath := filepath.Join(cb.config.NovelDir, "character.md")
-	characterManager := managers.NewCharacterManager(characterPath)
-	ctx.Characters = characterManager.GetCurrent()
+	characterManager := managers.NewCharacterManager(cb.config.NovelDir)
+	ctx.Characters = characterManager.GetContextText()
 
 	// 获取章节内容（使用标准的章节管理器）
 	chapterManager := managers.NewChapterManager(cb.config.NovelDir)
@@ -0,0 +1,27 @@
+package content
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/token"
+)
+
+// 角色设定从小说目录下的 character.md 读取，而不是 character.md/character.md
+func TestContextBuilderReadsCharacters(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "character.md"), []byte("## 林凡\n- 状态：健康"), 0644))
+
+	builder := NewContextBuilder(&ContextConfig{NovelDir: dir})
+	full, err := builder.BuildFullContext()
+	assert.NoError(t, err)
+	assert.Contains(t, full.Characters, "林凡")
+
+	percentages := token.DefaultTokenPercentages()
+	data, err := builder.BuildTokenAwareContext(percentages, 4000)
+	assert.NoError(t, err)
+	assert.Contains(t, data.Characters, "林凡")
+}
@@ -0,0 +1,244 @@
+package content
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+	"github.com/Kizunad/modular-workflow-v2/components/content/token"
+)
+
+// defaultContextCacheTTL 默认上下文缓存有效期，与 ContentConfig.CacheTTLSeconds 的默认值一致
+const defaultContextCacheTTL = 180 * time.Second
+
+// contextSourceFiles 参与构建上下文的固定文件（相对小说目录），章节文件另行枚举
+var contextSourceFiles = []string{"index.json", "title", "worldview.md", "character.md", managers.CharacterDBFile, managers.CharacterDBYAMLFile, managers.RelationshipGraphFile, managers.ChapterManifestFile, managers.StoryStructureFile, "planner.json"}
+
+// ContextCache 进程内共享的上下文缓存
+// 以小说目录、预算配置和构建参数为键，以源文件修改时间和检索器状态为指纹，任一变化即失效
+type ContextCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*contextCacheEntry
+}
+
+// contextCacheEntry 单条上下文缓存
+type contextCacheEntry struct {
+	novelDir    string
+	fingerprint string
+	data        *ContextData
+	expiresAt   time.Time
+}
+
+// sharedContextCache 所有工作流共用的上下文缓存
+var sharedContextCache = NewContextCache(defaultContextCacheTTL)
+
+// NewContextCache 创建上下文缓存，ttl<=0 时使用默认有效期
+func NewContextCache(ttl time.Duration) *ContextCache {
+	if ttl <= 0 {
+		ttl = defaultContextCacheTTL
+	}
+	return &ContextCache{
+		ttl:     ttl,
+		entries: make(map[string]*contextCacheEntry),
+	}
+}
+
+// SharedContextCache 获取进程内共享的上下文缓存
+func SharedContextCache() *ContextCache {
+	return sharedContextCache
+}
+
+// SetTTL 设置缓存有效期，只影响之后写入的条目
+func (cc *ContextCache) SetTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultContextCacheTTL
+	}
+	cc.mu.Lock()
+	cc.ttl = ttl
+	cc.mu.Unlock()
+}
+
+// Get 获取缓存的上下文，指纹不一致或已过期时返回false
+func (cc *ContextCache) Get(key, fingerprint string) (*ContextData, bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	entry, ok := cc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if entry.fingerprint != fingerprint || time.Now().After(entry.expiresAt) {
+		delete(cc.entries, key)
+		return nil, false
+	}
+
+	return cloneContextData(entry.data), true
+}
+
+// Put 写入上下文缓存
+func (cc *ContextCache) Put(key, novelDir, fingerprint string, data *ContextData) {
+	if data == nil {
+		return
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	cc.entries[key] = &contextCacheEntry{
+		novelDir:    novelDir,
+		fingerprint: fingerprint,
+		data:        cloneContextData(data),
+		expiresAt:   time.Now().Add(cc.ttl),
+	}
+}
+
+// cloneContextData 深拷贝上下文，缓存与调用方不共享分配报告和用量统计
+func cloneContextData(data *ContextData) *ContextData {
+	cloned := *data
+	if data.Allocation != nil {
+		allocation := *data.Allocation
+		if data.Allocation.Components != nil {
+			allocation.Components = make(map[string]*token.ComponentAllocation, len(data.Allocation.Components))
+			for name, component := range data.Allocation.Components {
+				if component != nil {
+					copied := *component
+					component = &copied
+				}
+				allocation.Components[name] = component
+			}
+		}
+		cloned.Allocation = &allocation
+	}
+	if data.Usage != nil {
+		cloned.Usage = make(map[string]*ComponentUsage, len(data.Usage))
+		for name, usage := range data.Usage {
+			if usage != nil {
+				copied := *usage
+				usage = &copied
+			}
+			cloned.Usage[name] = usage
+		}
+	}
+	return &cloned
+}
+
+// Invalidate 清除指定小说目录的所有缓存，novelDir 为空时清空全部缓存
+func (cc *ContextCache) Invalidate(novelDir string) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	for key, entry := range cc.entries {
+		if novelDir == "" || entry.novelDir == novelDir {
+			delete(cc.entries, key)
+		}
+	}
+}
+
+// Len 当前缓存条目数
+func (cc *ContextCache) Len() int {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return len(cc.entries)
+}
+
+// cacheKey 计算上下文缓存键：小说目录、总预算、各组件百分比以及影响构建结果的配置
+func (cb *ContextBuilder) cacheKey(tokenPercentages *token.TokenPercentages, maxTokens int) string {
+	var percentages []string
+	for name, value := range tokenPercentages.ToMap() {
+		percentages = append(percentages, fmt.Sprintf("%s=%.4f", name, value))
+	}
+	sort.Strings(percentages)
+
+	return fmt.Sprintf("%s|%d|%s|recent=%d|topk=%d|priorities=%v|weights=%v|truncate=%v|counter=%s|compressor=%s|retriever=%s",
+		cb.config.NovelDir, maxTokens, strings.Join(percentages, ","),
+		cb.config.RecentChapters, cb.config.RetrievalTopK,
+		cb.config.ContentPriorities, cb.config.ContentWeights, cb.config.TruncateModes,
+		instanceKey(cb.config.Counter), typeKey(cb.config.Compressor), sourceKey(cb.config.Retriever))
+}
+
+// instanceKey 区分不同实例的标识：指针类型取类型名和地址，其余只取类型名
+// 分词器按文件路径缓存，同一词表总是同一实例
+func instanceKey(v any) string {
+	if v != nil && reflect.ValueOf(v).Kind() == reflect.Ptr {
+		return fmt.Sprintf("%T@%p", v, v)
+	}
+	return typeKey(v)
+}
+
+// ContextCacheKeyer 可由上下文构建组件实现，返回值参与缓存键，
+// 用于区分同一类型但数据来源不同的实例，如检索不同会话或集合的检索器
+type ContextCacheKeyer interface {
+	ContextCacheKey() string
+}
+
+// sourceKey 按类型和组件声明的数据来源区分，未实现 ContextCacheKeyer 时只按类型区分
+func sourceKey(v any) string {
+	if keyer, ok := v.(ContextCacheKeyer); ok {
+		return fmt.Sprintf("%s(%s)", typeKey(v), keyer.ContextCacheKey())
+	}
+	return typeKey(v)
+}
+
+// typeKey 只按类型区分，用于每次构建都会重新创建的组件（压缩器、检索器）
+func typeKey(v any) string {
+	if v == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// ContextCacheFingerprinter 可由检索器实现，返回其数据状态的指纹，参与上下文缓存的失效判断
+// ok 为 false 表示状态无法在本地观测（如远端向量库），此时不缓存上下文
+type ContextCacheFingerprinter interface {
+	ContextCacheFingerprint() (fingerprint string, ok bool)
+}
+
+// retrieverFingerprint 检索器状态的指纹，未配置检索器时为空；检索器状态无法观测时返回false
+func (cb *ContextBuilder) retrieverFingerprint() (string, bool) {
+	if cb.config.Retriever == nil {
+		return "", true
+	}
+	fingerprinter, ok := cb.config.Retriever.(ContextCacheFingerprinter)
+	if !ok {
+		return "", false
+	}
+	return fingerprinter.ContextCacheFingerprint()
+}
+
+// sourceFingerprint 根据上下文源文件的修改时间和检索器状态计算指纹，文件新增、删除或修改都会改变指纹
+// 检索器状态无法观测时返回false，调用方应跳过缓存
+func (cb *ContextBuilder) sourceFingerprint() (string, bool) {
+	retrieverState, ok := cb.retrieverFingerprint()
+	if !ok {
+		return "", false
+	}
+
+	paths := make([]string, 0, len(contextSourceFiles))
+	for _, name := range contextSourceFiles {
+		paths = append(paths, filepath.Join(cb.config.NovelDir, name))
+	}
+	for _, name := range managers.NewChapterManager(cb.config.NovelDir).GetChapterFiles() {
+		paths = append(paths, filepath.Join(cb.config.NovelDir, name))
+	}
+
+	var builder strings.Builder
+	for _, path := range paths {
+		builder.WriteString(filepath.Base(path))
+		builder.WriteByte(':')
+		if modTime, err := managers.NewBaseFileManager(path).GetModTime(); err == nil {
+			builder.WriteString(fmt.Sprintf("%d", modTime.UnixNano()))
+		} else {
+			builder.WriteByte('-')
+		}
+		builder.WriteByte(';')
+	}
+	builder.WriteString("retriever:")
+	builder.WriteString(retrieverState)
+	return builder.String(), true
+}
@@ -0,0 +1,135 @@
+package content
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/token"
+)
+
+// sessionRetriever 按会话区分缓存键的测试检索器
+type sessionRetriever struct {
+	session string
+}
+
+func (r *sessionRetriever) RetrievePassages(ctx context.Context, query string, topK int) ([]RetrievedPassage, error) {
+	return nil, nil
+}
+
+func (r *sessionRetriever) ContextCacheKey() string {
+	return "session=" + r.session
+}
+
+// stateRetriever 带状态指纹的测试检索器，state 为空时表示状态无法观测
+type stateRetriever struct {
+	state string
+}
+
+func (r *stateRetriever) RetrievePassages(ctx context.Context, query string, topK int) ([]RetrievedPassage, error) {
+	return nil, nil
+}
+
+func (r *stateRetriever) ContextCacheFingerprint() (string, bool) {
+	return r.state, r.state != ""
+}
+
+func TestContextCacheHitAndInvalidation(t *testing.T) {
+	dir := t.TempDir()
+	worldview := filepath.Join(dir, "worldview.md")
+	assert.NoError(t, os.WriteFile(worldview, []byte("灵气复苏的世界"), 0644))
+
+	cache := NewContextCache(time.Minute)
+	builder := NewContextBuilder(&ContextConfig{NovelDir: dir, Cache: cache})
+	percentages, _ := token.BuiltinProfile(token.ProfileWrite)
+
+	first, err := builder.BuildTokenAwareContext(percentages, 4000)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cache.Len())
+
+	// 修改返回结果不影响缓存中的数据
+	first.Worldview = "已修改"
+	for _, usage := range first.Usage {
+		usage.Tokens = -1
+	}
+	if first.Allocation != nil {
+		for _, component := range first.Allocation.Components {
+			component.Allocated = -1
+		}
+	}
+
+	second, err := builder.BuildTokenAwareContext(percentages, 4000)
+	assert.NoError(t, err)
+	assert.Contains(t, second.Worldview, "灵气复苏")
+	for name, usage := range second.Usage {
+		assert.NotEqual(t, -1, usage.Tokens, name)
+	}
+	if second.Allocation != nil {
+		for name, component := range second.Allocation.Components {
+			assert.NotEqual(t, -1, component.Allocated, name)
+		}
+	}
+
+	// 源文件变化后重新构建
+	later := time.Now().Add(time.Second)
+	assert.NoError(t, os.WriteFile(worldview, []byte("末法时代的世界"), 0644))
+	assert.NoError(t, os.Chtimes(worldview, later, later))
+	third, err := builder.BuildTokenAwareContext(percentages, 4000)
+	assert.NoError(t, err)
+	assert.Contains(t, third.Worldview, "末法时代")
+
+	cache.Invalidate(dir)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestContextCacheKeyIncludesRetrieverSource(t *testing.T) {
+	dir := t.TempDir()
+	percentages, _ := token.BuiltinProfile(token.ProfileWrite)
+
+	keyFor := func(retriever PassageRetriever) string {
+		return NewContextBuilder(&ContextConfig{NovelDir: dir, Retriever: retriever}).cacheKey(percentages, 4000)
+	}
+
+	assert.Equal(t, keyFor(&sessionRetriever{session: "a"}), keyFor(&sessionRetriever{session: "a"}))
+	assert.NotEqual(t, keyFor(&sessionRetriever{session: "a"}), keyFor(&sessionRetriever{session: "b"}))
+	assert.NotEqual(t, keyFor(nil), keyFor(&sessionRetriever{session: "a"}))
+}
+
+func TestContextCacheRetrieverState(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "worldview.md"), []byte("灵气复苏的世界"), 0644))
+	percentages, _ := token.BuiltinProfile(token.ProfileWrite)
+
+	// 检索器未提供状态指纹时不缓存
+	cache := NewContextCache(time.Minute)
+	_, err := NewContextBuilder(&ContextConfig{NovelDir: dir, Cache: cache, Retriever: &sessionRetriever{session: "a"}}).BuildTokenAwareContext(percentages, 4000)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cache.Len())
+
+	// 状态无法观测时不缓存
+	_, err = NewContextBuilder(&ContextConfig{NovelDir: dir, Cache: cache, Retriever: &stateRetriever{}}).BuildTokenAwareContext(percentages, 4000)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cache.Len())
+
+	// 检索器状态参与指纹，状态变化后缓存失效
+	retriever := &stateRetriever{state: "v1"}
+	builder := NewContextBuilder(&ContextConfig{NovelDir: dir, Cache: cache, Retriever: retriever})
+	_, err = builder.BuildTokenAwareContext(percentages, 4000)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cache.Len())
+	key := builder.cacheKey(percentages, 4000)
+	fingerprint, ok := builder.sourceFingerprint()
+	assert.True(t, ok)
+	_, hit := cache.Get(key, fingerprint)
+	assert.True(t, hit)
+
+	retriever.state = "v2"
+	fingerprint, ok = builder.sourceFingerprint()
+	assert.True(t, ok)
+	_, hit = cache.Get(key, fingerprint)
+	assert.False(t, hit)
+}
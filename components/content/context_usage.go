@@ -0,0 +1,36 @@
+package content
+
+import "github.com/Kizunad/modular-workflow-v2/components/content/token"
+
+// ComponentUsage 单个上下文组件的实际Token使用情况
+type ComponentUsage struct {
+	Tokens    int  `json:"tokens"`    // 实际放入上下文的Token数
+	Limit     int  `json:"limit"`     // 分配的Token上限
+	Needed    int  `json:"needed"`    // 完整内容所需的Token数
+	Truncated bool `json:"truncated"` // 内容是否被截断或压缩
+}
+
+// recordUsage 统计各组件实际放入上下文的Token数，并与完整内容所需的Token数比较标记截断
+func (cb *ContextBuilder) recordUsage(ctx *ContextData, tokenBudget *token.TokenBudgetManager) {
+	texts := map[string]string{
+		"index":     ctx.Summary,
+		"worldview": ctx.Worldview,
+		"character": ctx.Characters,
+		"chapters":  ctx.Chapter,
+		"plan":      ctx.Plan,
+		"retrieved": ctx.Retrieved,
+	}
+
+	ctx.Usage = make(map[string]*ComponentUsage, len(texts))
+	for component, text := range texts {
+		usage := &ComponentUsage{Tokens: tokenBudget.CountTokens(text)}
+		if ctx.Allocation != nil {
+			if allocation, ok := ctx.Allocation.Components[component]; ok {
+				usage.Limit = allocation.Allocated
+				usage.Needed = allocation.Needed
+				usage.Truncated = allocation.Needed > allocation.Allocated
+			}
+		}
+		ctx.Usage[component] = usage
+	}
+}
@@ -0,0 +1,178 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"strings"
+)
+
+// epubContainer META-INF/container.xml，指向包文件
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+// epubItem EPUB 中的一个内容文档
+type epubItem struct {
+	id    string
+	href  string
+	title string
+	body  string
+}
+
+// RenderEPUB 渲染为 EPUB 3：扉页、导航目录（nav.xhtml，同时提供 toc.ncx 兼容旧阅读器）、卷标题页和章节
+func RenderEPUB(manuscript *Manuscript) ([]byte, error) {
+	var items []epubItem
+
+	var front strings.Builder
+	writeFrontMatter(&front, manuscript)
+	items = append(items, epubItem{id: "titlepage", href: "titlepage.xhtml", title: manuscript.Title, body: front.String()})
+
+	for _, part := range manuscript.Parts {
+		if part.Title != "" {
+			items = append(items, epubItem{
+				id:    partAnchor(part),
+				href:  partAnchor(part) + ".xhtml",
+				title: part.Heading(),
+				body:  fmt.Sprintf("<h1 class=\"part\">%s</h1>\n", escape(part.Heading())),
+			})
+		}
+		for _, chapter := range part.Chapters {
+			var body strings.Builder
+			writeChapterBody(&body, chapter)
+			items = append(items, epubItem{
+				id:    chapterAnchor(chapter),
+				href:  chapterAnchor(chapter) + ".xhtml",
+				title: chapter.Heading(),
+				body:  body.String(),
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	// mimetype 必须是第一个文件且不压缩
+	mimetype, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := mimetype.Write([]byte("application/epub+zip")); err != nil {
+		return nil, err
+	}
+
+	files := []struct{ name, content string }{
+		{"META-INF/container.xml", epubContainer},
+		{"OEBPS/content.opf", epubPackage(manuscript, items)},
+		{"OEBPS/nav.xhtml", epubNav(manuscript)},
+		{"OEBPS/toc.ncx", epubNCX(manuscript, items)},
+		{"OEBPS/style.css", bookCSS},
+	}
+	for _, item := range items {
+		files = append(files, struct{ name, content string }{"OEBPS/" + item.href, xhtmlDocument(manuscript.Language, item.title, item.body)})
+	}
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(file.content)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// bookIdentifier 由书名和作者生成的稳定标识，重复导出同一本书时阅读器能识别为同一本
+func bookIdentifier(manuscript *Manuscript) string {
+	sum := sha1.Sum([]byte(manuscript.Title + "\x00" + manuscript.Author))
+	sum[6] = sum[6]&0x0f | 0x50 // UUID 版本5
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// xhtmlDocument 生成 EPUB 内容文档
+func xhtmlDocument(language, title, body string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="%[1]s" xml:lang="%[1]s">
+<head>
+<meta charset="utf-8"/>
+<title>%[2]s</title>
+<link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+%[3]s</body>
+</html>
+`, escape(language), escape(title), body)
+}
+
+// epubPackage 生成包文件 content.opf
+func epubPackage(manuscript *Manuscript, items []epubItem) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+`)
+	fmt.Fprintf(&b, "    <dc:identifier id=\"book-id\">%s</dc:identifier>\n", bookIdentifier(manuscript))
+	fmt.Fprintf(&b, "    <dc:title>%s</dc:title>\n", escape(manuscript.Title))
+	fmt.Fprintf(&b, "    <dc:language>%s</dc:language>\n", escape(manuscript.Language))
+	if manuscript.Author != "" {
+		fmt.Fprintf(&b, "    <dc:creator>%s</dc:creator>\n", escape(manuscript.Author))
+	}
+	if manuscript.Description != "" {
+		fmt.Fprintf(&b, "    <dc:description>%s</dc:description>\n", escape(manuscript.Description))
+	}
+	fmt.Fprintf(&b, "    <dc:date>%s</dc:date>\n", manuscript.Date.Format("2006-01-02"))
+	fmt.Fprintf(&b, "    <meta property=\"dcterms:modified\">%s</meta>\n", manuscript.Date.UTC().Format("2006-01-02T15:04:05Z"))
+	b.WriteString("  </metadata>\n  <manifest>\n")
+	b.WriteString("    <item id=\"nav\" href=\"nav.xhtml\" media-type=\"application/xhtml+xml\" properties=\"nav\"/>\n")
+	b.WriteString("    <item id=\"ncx\" href=\"toc.ncx\" media-type=\"application/x-dtbncx+xml\"/>\n")
+	b.WriteString("    <item id=\"css\" href=\"style.css\" media-type=\"text/css\"/>\n")
+	for _, item := range items {
+		fmt.Fprintf(&b, "    <item id=\"%s\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", item.id, item.href)
+	}
+	b.WriteString("  </manifest>\n  <spine toc=\"ncx\">\n")
+	for _, item := range items {
+		fmt.Fprintf(&b, "    <itemref idref=\"%s\"/>\n", item.id)
+	}
+	b.WriteString("  </spine>\n</package>\n")
+	return b.String()
+}
+
+// epubNav 生成 EPUB 3 导航文档
+func epubNav(manuscript *Manuscript) string {
+	var b strings.Builder
+	b.WriteString("<nav epub:type=\"toc\" id=\"toc\">\n<h1>目录</h1>\n")
+	writeTOC(&b, manuscript, func(chapter *Chapter) string { return chapterAnchor(chapter) + ".xhtml" },
+		func(part *Part) string { return partAnchor(part) + ".xhtml" })
+	b.WriteString("</nav>\n")
+	return xhtmlDocument(manuscript.Language, "目录", b.String())
+}
+
+// epubNCX 生成 EPUB 2 的 toc.ncx，供不支持 EPUB 3 导航文档的阅读器使用
+func epubNCX(manuscript *Manuscript, items []epubItem) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head>
+`)
+	fmt.Fprintf(&b, "    <meta name=\"dtb:uid\" content=\"%s\"/>\n", bookIdentifier(manuscript))
+	b.WriteString("  </head>\n")
+	fmt.Fprintf(&b, "  <docTitle><text>%s</text></docTitle>\n  <navMap>\n", escape(manuscript.Title))
+	for i, item := range items {
+		fmt.Fprintf(&b, "    <navPoint id=\"nav-%s\" playOrder=\"%d\"><navLabel><text>%s</text></navLabel><content src=\"%s\"/></navPoint>\n",
+			item.id, i+1, escape(item.title), item.href)
+	}
+	b.WriteString("  </navMap>\n</ncx>\n")
+	return b.String()
+}
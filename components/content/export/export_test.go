@@ -0,0 +1,78 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+)
+
+func TestExportManuscript(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "title"), []byte("荒岛求生\n"), 0644))
+
+	chapterManager := managers.NewChapterManager(dir)
+	for _, chapter := range []struct{ title, content string }{
+		{"醒来", "海浪拍打着礁石。\n\n林凡睁开眼。"},
+		{"第2章 水源", "他沿着溪流向上走。"},
+		{"夜", "火堆渐渐熄灭 <却> 没人在意。"},
+	} {
+		_, _, err := chapterManager.CreateChapter(chapter.title, chapter.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, managers.NewStoryStructureStore(dir).ModifyStructure(func(structure *managers.StoryStructure) error {
+		volume, err := structure.UpsertVolume(&managers.Volume{Title: "荒岛"})
+		require.NoError(t, err)
+		_, err = structure.UpsertArc(&managers.Arc{Volume: volume.ID, Title: "生存", StartChapter: 1, EndChapter: 2})
+		return err
+	}))
+
+	manuscript, err := LoadManuscript(dir, Options{Author: "佚名"})
+	require.NoError(t, err)
+	assert.Equal(t, "荒岛求生", manuscript.Title)
+	require.Len(t, manuscript.Parts, 2)
+	assert.Equal(t, "第一卷 荒岛", manuscript.Parts[0].Heading())
+	assert.Equal(t, "", manuscript.Parts[1].Heading())
+	chapters := manuscript.Chapters()
+	assert.Equal(t, "第1章 醒来", chapters[0].Heading())
+	assert.Equal(t, "第2章 水源", chapters[1].Heading())
+
+	text := RenderText(manuscript)
+	assert.Contains(t, text, "第一卷 荒岛\n\n\n第1章 醒来\n\n　　海浪拍打着礁石。\n")
+
+	markdown := RenderMarkdown(manuscript)
+	assert.Contains(t, markdown, "title: \"荒岛求生\"")
+	assert.Contains(t, markdown, "  - [第2章 水源](#chapter-2)")
+
+	page := RenderHTML(manuscript)
+	assert.Contains(t, page, "火堆渐渐熄灭 &lt;却&gt; 没人在意。")
+
+	data, err := RenderEPUB(manuscript)
+	require.NoError(t, err)
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	assert.Equal(t, "mimetype", reader.File[0].Name)
+	assert.Equal(t, zip.Store, reader.File[0].Method)
+	names := make(map[string]*zip.File)
+	for _, file := range reader.File {
+		names[file.Name] = file
+	}
+	for _, name := range []string{"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/part-1.xhtml", "OEBPS/chapter-3.xhtml"} {
+		assert.Contains(t, names, name)
+	}
+	opf, err := names["OEBPS/content.opf"].Open()
+	require.NoError(t, err)
+	content, _ := io.ReadAll(opf)
+	assert.True(t, strings.Index(string(content), `idref="part-1"`) < strings.Index(string(content), `idref="chapter-1"`))
+
+	assert.Equal(t, "一百零五", ChineseNumber(105))
+	assert.Equal(t, "十二", ChineseNumber(12))
+}
@@ -0,0 +1,90 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format 导出格式
+type Format string
+
+// 支持的导出格式
+const (
+	FormatEPUB     Format = "epub"
+	FormatMarkdown Format = "md"
+	FormatText     Format = "txt"
+	FormatHTML     Format = "html"
+)
+
+// Formats 所有支持的导出格式
+var Formats = []Format{FormatEPUB, FormatMarkdown, FormatText, FormatHTML}
+
+// Extension 导出文件的扩展名
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// ParseFormat 解析导出格式，兼容 markdown、text、htm 等写法
+func ParseFormat(value string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(strings.TrimSpace(value), ".")) {
+	case "epub":
+		return FormatEPUB, nil
+	case "md", "markdown":
+		return FormatMarkdown, nil
+	case "txt", "text":
+		return FormatText, nil
+	case "html", "htm":
+		return FormatHTML, nil
+	default:
+		return "", fmt.Errorf("不支持的导出格式: %s（可选 epub/md/txt/html）", value)
+	}
+}
+
+// FormatFromPath 根据文件扩展名推断导出格式
+func FormatFromPath(path string) (Format, bool) {
+	format, err := ParseFormat(filepath.Ext(path))
+	return format, err == nil
+}
+
+// Render 将书稿渲染为指定格式
+func Render(manuscript *Manuscript, format Format) ([]byte, error) {
+	switch format {
+	case FormatEPUB:
+		return RenderEPUB(manuscript)
+	case FormatMarkdown:
+		return []byte(RenderMarkdown(manuscript)), nil
+	case FormatText:
+		return []byte(RenderText(manuscript)), nil
+	case FormatHTML:
+		return []byte(RenderHTML(manuscript)), nil
+	default:
+		return nil, fmt.Errorf("不支持的导出格式: %s", format)
+	}
+}
+
+// WriteFile 将书稿渲染为指定格式并写入文件，自动创建上级目录
+func WriteFile(manuscript *Manuscript, format Format, path string) error {
+	data, err := Render(manuscript, format)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// chapterAnchor 章节的锚点，Markdown 和 HTML 中用于目录跳转
+func chapterAnchor(chapter *Chapter) string {
+	return fmt.Sprintf("chapter-%d", chapter.Number)
+}
+
+// partAnchor 卷的锚点，以卷中第一章的序号区分，同一卷的章节不相邻时也不会重复
+func partAnchor(part *Part) string {
+	if len(part.Chapters) == 0 {
+		return fmt.Sprintf("part-%d", part.Number)
+	}
+	return fmt.Sprintf("part-%d", part.Chapters[0].Number)
+}
@@ -0,0 +1,101 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// bookCSS HTML 和 EPUB 共用的排版样式
+const bookCSS = `body { font-family: "Noto Serif SC", "Source Han Serif SC", "Songti SC", serif; line-height: 1.8; margin: 0 auto; max-width: 42em; padding: 0 1em; }
+h1, h2, h3 { text-align: center; font-weight: bold; }
+h1.part { margin: 3em 0 2em; }
+h2.chapter { margin: 2em 0 1em; }
+p { text-indent: 2em; margin: 0.6em 0; }
+.front { text-align: center; margin: 4em 0; }
+.front .author { margin-top: 1em; }
+.front .description { text-align: left; margin-top: 2em; }
+nav ol { list-style: none; padding-left: 1em; }
+`
+
+// RenderHTML 渲染为单个静态 HTML 页面：扉页、目录和正文，样式内联，可直接用浏览器打开
+func RenderHTML(manuscript *Manuscript) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n")
+	fmt.Fprintf(&b, "<html lang=\"%s\">\n<head>\n<meta charset=\"utf-8\">\n", escape(manuscript.Language))
+	b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", escape(manuscript.Title))
+	if manuscript.Author != "" {
+		fmt.Fprintf(&b, "<meta name=\"author\" content=\"%s\">\n", escape(manuscript.Author))
+	}
+	fmt.Fprintf(&b, "<style>\n%s</style>\n</head>\n<body>\n", bookCSS)
+
+	writeFrontMatter(&b, manuscript)
+
+	b.WriteString("<nav id=\"toc\">\n<h2>目录</h2>\n")
+	writeTOC(&b, manuscript, func(chapter *Chapter) string { return "#" + chapterAnchor(chapter) },
+		func(part *Part) string { return "#" + partAnchor(part) })
+	b.WriteString("</nav>\n")
+
+	for _, part := range manuscript.Parts {
+		if part.Title != "" {
+			fmt.Fprintf(&b, "<h1 class=\"part\" id=\"%s\">%s</h1>\n", partAnchor(part), escape(part.Heading()))
+		}
+		for _, chapter := range part.Chapters {
+			fmt.Fprintf(&b, "<section id=\"%s\">\n", chapterAnchor(chapter))
+			writeChapterBody(&b, chapter)
+			b.WriteString("</section>\n")
+		}
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+// writeFrontMatter 写入扉页：书名、作者和简介
+func writeFrontMatter(b *strings.Builder, manuscript *Manuscript) {
+	b.WriteString("<section class=\"front\">\n")
+	fmt.Fprintf(b, "<h1>%s</h1>\n", escape(manuscript.Title))
+	if manuscript.Author != "" {
+		fmt.Fprintf(b, "<p class=\"author\">%s 著</p>\n", escape(manuscript.Author))
+	}
+	if manuscript.Description != "" {
+		b.WriteString("<div class=\"description\">\n")
+		for _, line := range strings.Split(manuscript.Description, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Fprintf(b, "<p>%s</p>\n", escape(line))
+			}
+		}
+		b.WriteString("</div>\n")
+	}
+	b.WriteString("</section>\n")
+}
+
+// writeTOC 写入嵌套的目录列表，链接由调用方决定（单页锚点或 EPUB 中的文件）
+func writeTOC(b *strings.Builder, manuscript *Manuscript, chapterHref func(*Chapter) string, partHref func(*Part) string) {
+	b.WriteString("<ol>\n")
+	for _, part := range manuscript.Parts {
+		if part.Title != "" {
+			fmt.Fprintf(b, "<li><a href=\"%s\">%s</a>\n<ol>\n", partHref(part), escape(part.Heading()))
+		}
+		for _, chapter := range part.Chapters {
+			fmt.Fprintf(b, "<li><a href=\"%s\">%s</a></li>\n", chapterHref(chapter), escape(chapter.Heading()))
+		}
+		if part.Title != "" {
+			b.WriteString("</ol>\n</li>\n")
+		}
+	}
+	b.WriteString("</ol>\n")
+}
+
+// writeChapterBody 写入章节标题和段落
+func writeChapterBody(b *strings.Builder, chapter *Chapter) {
+	fmt.Fprintf(b, "<h2 class=\"chapter\">%s</h2>\n", escape(chapter.Heading()))
+	for _, paragraph := range chapter.Paragraphs {
+		fmt.Fprintf(b, "<p>%s</p>\n", escape(paragraph))
+	}
+}
+
+// escape 转义 HTML/XHTML 文本
+func escape(value string) string {
+	return html.EscapeString(value)
+}
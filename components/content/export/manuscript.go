@@ -0,0 +1,220 @@
+// Package export 将章节文件整理为可阅读的成书，导出为 EPUB、Markdown、TXT 和 HTML
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+)
+
+// Manuscript 按阅读顺序整理好的书稿
+type Manuscript struct {
+	Title       string
+	Author      string
+	Description string // 简介，放在扉页
+	Language    string
+	Date        time.Time
+	Parts       []*Part
+}
+
+// Part 书稿中的一卷；Title 为空表示不属于任何卷的章节，导出时不输出卷标题
+type Part struct {
+	Number   int // 卷序号，从1开始，不属于任何卷时为0
+	Title    string
+	Chapters []*Chapter
+}
+
+// Heading 卷标题，如 "第一卷 荒岛"
+func (p *Part) Heading() string {
+	if p.Title == "" {
+		return ""
+	}
+	return fmt.Sprintf("第%s卷 %s", ChineseNumber(p.Number), p.Title)
+}
+
+// Chapter 书稿中的一章
+type Chapter struct {
+	ID         int    // 章节ID（章节清单中的ID）
+	Number     int    // 在书稿中的序号，从1开始，不受章节ID空缺影响
+	Title      string // 原始标题
+	Paragraphs []string
+}
+
+// Heading 章节标题：标题本身已带 "第N章" 时原样使用，否则加上序号，如 "第3章 夜渡"
+func (c *Chapter) Heading() string {
+	if chapterHeadingPattern.MatchString(c.Title) {
+		return c.Title
+	}
+	if c.Title == "" {
+		return fmt.Sprintf("第%d章", c.Number)
+	}
+	return fmt.Sprintf("第%d章 %s", c.Number, c.Title)
+}
+
+// chapterHeadingPattern 已带章节序号的标题，如 "第3章"、"第十二章 夜渡"
+var chapterHeadingPattern = regexp.MustCompile(`^\s*第[0-9０-９零〇一二两三四五六七八九十百千]+[章回节]`)
+
+// Chapters 按阅读顺序返回所有章节
+func (m *Manuscript) Chapters() []*Chapter {
+	var chapters []*Chapter
+	for _, part := range m.Parts {
+		chapters = append(chapters, part.Chapters...)
+	}
+	return chapters
+}
+
+// CharCount 正文总字数
+func (m *Manuscript) CharCount() int {
+	count := 0
+	for _, chapter := range m.Chapters() {
+		for _, paragraph := range chapter.Paragraphs {
+			count += managers.CountChapterChars(paragraph)
+		}
+	}
+	return count
+}
+
+// Options 书稿整理选项
+type Options struct {
+	Title       string // 书名，为空时读取小说目录中的 title 文件
+	Author      string
+	Description string // 简介，为空时使用 index.json 中第一章的摘要
+	Language    string // 默认 zh-CN
+	From, To    int    // 只导出章节ID在此范围内的章节，0 表示不限制
+	Status      string // 只导出该状态的章节（draft/revised/final），为空时不限制
+}
+
+// LoadManuscript 读取小说目录，按章节清单的阅读顺序整理书稿；卷的划分取自卷与篇章结构
+func LoadManuscript(novelDir string, opts Options) (*Manuscript, error) {
+	indexReader := managers.NewIndexReader(novelDir)
+	manuscript := &Manuscript{
+		Title:       strings.TrimSpace(opts.Title),
+		Author:      strings.TrimSpace(opts.Author),
+		Description: strings.TrimSpace(opts.Description),
+		Language:    opts.Language,
+		Date:        time.Now(),
+	}
+	if manuscript.Title == "" {
+		manuscript.Title = indexReader.GetTitle()
+	}
+	if manuscript.Title == "" {
+		manuscript.Title = filepath.Base(filepath.Clean(novelDir))
+	}
+	if manuscript.Language == "" {
+		manuscript.Language = "zh-CN"
+	}
+	if manuscript.Description == "" {
+		if summaries := indexReader.GetChapterSummaries(); len(summaries) > 0 {
+			manuscript.Description = strings.TrimSpace(summaries[0].Summary)
+		}
+	}
+
+	structure, err := managers.NewStoryStructureStore(novelDir).LoadStructure()
+	if err != nil {
+		return nil, err
+	}
+
+	chapterManager := managers.NewChapterManager(novelDir)
+	filter := managers.ChapterFilter{Status: opts.Status}
+	var current *Part
+	for _, entry := range chapterManager.GetChapterEntries() {
+		if (opts.From > 0 && entry.ID < opts.From) || (opts.To > 0 && entry.ID > opts.To) {
+			continue
+		}
+		data, err := chapterManager.GetChapterData(entry.ID)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("读取第%d章失败: %w", entry.ID, err)
+		}
+		if !filter.Empty() && !filter.Match(data.Meta) {
+			continue
+		}
+
+		chapter := &Chapter{ID: entry.ID, Title: strings.TrimSpace(data.Title)}
+		if chapter.Title == "" {
+			if summary, ok := indexReader.FindChapterSummary(entry.ID, ""); ok {
+				chapter.Title = strings.TrimSpace(summary.Title)
+			}
+		}
+		for _, paragraph := range data.Content {
+			if text := strings.TrimSpace(paragraph.Text); text != "" {
+				chapter.Paragraphs = append(chapter.Paragraphs, text)
+			}
+		}
+
+		// 相邻且属于同一卷的章节归入同一部分
+		volumeID, volumeTitle := chapterVolume(structure, entry.ID)
+		if current == nil || current.Number != volumeID || current.Title != volumeTitle {
+			current = &Part{Number: volumeID, Title: volumeTitle}
+			manuscript.Parts = append(manuscript.Parts, current)
+		}
+		current.Chapters = append(current.Chapters, chapter)
+	}
+
+	for i, chapter := range manuscript.Chapters() {
+		chapter.Number = i + 1
+	}
+
+	if len(manuscript.Parts) == 0 {
+		return nil, fmt.Errorf("没有可导出的章节: %s", novelDir)
+	}
+	return manuscript, nil
+}
+
+// chapterVolume 章节所属的卷（通过章节所在篇章确定），返回卷在结构中的序号和标题；不属于任何卷时返回 0 和空标题
+func chapterVolume(structure *managers.StoryStructure, chapterID int) (int, string) {
+	arc, ok := structure.ArcForChapter(chapterID)
+	if !ok {
+		return 0, ""
+	}
+	for i, volume := range structure.Volumes {
+		if volume.ID == arc.Volume {
+			return i + 1, volume.Title
+		}
+	}
+	return 0, ""
+}
+
+// chineseDigits 中文数字
+var chineseDigits = []string{"零", "一", "二", "三", "四", "五", "六", "七", "八", "九"}
+
+// ChineseNumber 将 1-9999 的整数转换为中文数字，如 12 -> 十二、105 -> 一百零五；超出范围时返回阿拉伯数字
+func ChineseNumber(n int) string {
+	if n <= 0 || n >= 10000 {
+		return fmt.Sprintf("%d", n)
+	}
+	if n < 10 {
+		return chineseDigits[n]
+	}
+	if n < 20 {
+		if n == 10 {
+			return "十"
+		}
+		return "十" + chineseDigits[n-10]
+	}
+
+	units := []string{"千", "百", "十", ""}
+	divisors := []int{1000, 100, 10, 1}
+	var b strings.Builder
+	zero := false
+	for i, divisor := range divisors {
+		digit := n / divisor % 10
+		if digit == 0 {
+			zero = b.Len() > 0
+			continue
+		}
+		if zero {
+			b.WriteString("零")
+			zero = false
+		}
+		b.WriteString(chineseDigits[digit] + units[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RenderMarkdown 渲染为单个 Markdown 文件：YAML 元数据（兼容 pandoc）、扉页、目录和正文
+// 有分卷时卷为二级标题、章节为三级标题，否则章节为二级标题
+func RenderMarkdown(manuscript *Manuscript) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "title: %s\n", strconv.Quote(manuscript.Title))
+	if manuscript.Author != "" {
+		fmt.Fprintf(&b, "author: %s\n", strconv.Quote(manuscript.Author))
+	}
+	fmt.Fprintf(&b, "lang: %s\n", manuscript.Language)
+	fmt.Fprintf(&b, "date: %s\n", manuscript.Date.Format("2006-01-02"))
+	b.WriteString("---\n\n")
+
+	fmt.Fprintf(&b, "# %s\n\n", manuscript.Title)
+	if manuscript.Author != "" {
+		fmt.Fprintf(&b, "作者：%s\n\n", manuscript.Author)
+	}
+	if manuscript.Description != "" {
+		for _, line := range strings.Split(manuscript.Description, "\n") {
+			fmt.Fprintf(&b, "> %s\n", strings.TrimSpace(line))
+		}
+		b.WriteString("\n")
+	}
+
+	hasParts := false
+	for _, part := range manuscript.Parts {
+		hasParts = hasParts || part.Title != ""
+	}
+	chapterLevel, chapterIndent := "##", ""
+	if hasParts {
+		chapterLevel, chapterIndent = "###", "  "
+	}
+
+	b.WriteString("## 目录\n\n")
+	for _, part := range manuscript.Parts {
+		indent := ""
+		if part.Title != "" {
+			fmt.Fprintf(&b, "- [%s](#%s)\n", part.Heading(), partAnchor(part))
+			indent = chapterIndent
+		}
+		for _, chapter := range part.Chapters {
+			fmt.Fprintf(&b, "%s- [%s](#%s)\n", indent, chapter.Heading(), chapterAnchor(chapter))
+		}
+	}
+
+	for _, part := range manuscript.Parts {
+		if part.Title != "" {
+			fmt.Fprintf(&b, "\n<a id=\"%s\"></a>\n\n## %s\n", partAnchor(part), part.Heading())
+		}
+		for _, chapter := range part.Chapters {
+			fmt.Fprintf(&b, "\n<a id=\"%s\"></a>\n\n%s %s\n", chapterAnchor(chapter), chapterLevel, chapter.Heading())
+			for _, paragraph := range chapter.Paragraphs {
+				b.WriteString("\n" + paragraph + "\n")
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,40 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+)
+
+// textIndent 段首缩进，网文平台通用两个全角空格
+const textIndent = "　　"
+
+// RenderText 渲染为纯文本：书名、作者、简介后依次是卷标题、章节标题和缩进的段落
+// 不输出目录，网文平台按 "第N章" 标题自动识别章节，目录中的标题会被误识别为空章节
+func RenderText(manuscript *Manuscript) string {
+	var b strings.Builder
+	b.WriteString(manuscript.Title + "\n")
+	if manuscript.Author != "" {
+		fmt.Fprintf(&b, "作者：%s\n", manuscript.Author)
+	}
+	if manuscript.Description != "" {
+		b.WriteString("\n简介：\n")
+		for _, line := range strings.Split(manuscript.Description, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				b.WriteString(textIndent + line + "\n")
+			}
+		}
+	}
+
+	for _, part := range manuscript.Parts {
+		if heading := part.Heading(); heading != "" {
+			b.WriteString("\n\n" + heading + "\n")
+		}
+		for _, chapter := range part.Chapters {
+			b.WriteString("\n\n" + chapter.Heading() + "\n")
+			for _, paragraph := range chapter.Paragraphs {
+				b.WriteString("\n" + textIndent + paragraph + "\n")
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,319 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+)
+
+// 默认的章节和卷标题模式，匹配前会去掉行首空白和 Markdown 的 # 标记
+var (
+	DefaultChapterPatterns = []string{
+		`^第[0-9０-９零〇一二两三四五六七八九十百千万]+[章回节]`,
+		`^(?i)chapter\s+([0-9]+|[ivxlcdm]+)\b`,
+		`^(序章|序言|楔子|引子|尾声|后记|终章|番外)`,
+	}
+	DefaultVolumePatterns = []string{
+		`^第[0-9０-９零〇一二两三四五六七八九十百千万]+[卷部]`,
+		`^(?i)(volume|book|part)\s+([0-9]+|[ivxlcdm]+)\b`,
+	}
+)
+
+// maxHeadingLength 标题行的最大长度，超过时视为正文，避免把以 "第三章" 开头的叙述误判为标题
+const maxHeadingLength = 40
+
+// Options 拆分选项
+type Options struct {
+	ChapterPatterns []string // 章节标题正则，为空时使用 DefaultChapterPatterns
+	VolumePatterns  []string // 卷标题正则，为空时使用 DefaultVolumePatterns
+	Markdown        bool     // Markdown 以空行分段，TXT 每个非空行为一段
+}
+
+// Chapter 拆分出的一章
+type Chapter struct {
+	Title      string
+	Volume     string // 所属卷的标题，没有分卷时为空
+	Paragraphs []string
+}
+
+// Content 章节正文，段落之间以空行分隔，与 ChapterManager 的分段方式一致
+func (c *Chapter) Content() string {
+	return strings.Join(c.Paragraphs, "\n\n")
+}
+
+// Manuscript 拆分后的书稿
+type Manuscript struct {
+	Title    string     // 从书稿开头识别出的书名，可能为空
+	Preface  []string   // 第一章之前除书名外的内容，如简介、作者信息
+	Chapters []*Chapter // 按原文顺序排列的章节，不包含没有正文的标题（如书稿开头的目录）
+	Skipped  int        // 因没有正文被跳过的标题数
+}
+
+// Volumes 按出现顺序返回所有卷标题
+func (m *Manuscript) Volumes() []string {
+	var volumes []string
+	for _, chapter := range m.Chapters {
+		if chapter.Volume != "" && (len(volumes) == 0 || volumes[len(volumes)-1] != chapter.Volume) {
+			volumes = append(volumes, chapter.Volume)
+		}
+	}
+	return volumes
+}
+
+// compilePatterns 编译标题正则
+func compilePatterns(patterns, defaults []string) ([]*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		patterns = defaults
+	}
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("无效的标题模式 %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// headingText 去掉行首的 Markdown 标题标记和空白（含全角空格）
+func headingText(line string) string {
+	line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+	return strings.TrimFunc(line, unicode.IsSpace)
+}
+
+// matchHeading 判断一行是否为标题，含有句号的行视为正文
+func matchHeading(line string, patterns []*regexp.Regexp) bool {
+	if line == "" || utf8.RuneCountInString(line) > maxHeadingLength || strings.Contains(line, "。") {
+		return false
+	}
+	for _, re := range patterns {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse 按标题模式把书稿拆分为章节
+func Parse(text string, opts Options) (*Manuscript, error) {
+	chapterPatterns, err := compilePatterns(opts.ChapterPatterns, DefaultChapterPatterns)
+	if err != nil {
+		return nil, err
+	}
+	volumePatterns, err := compilePatterns(opts.VolumePatterns, DefaultVolumePatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	text = strings.TrimPrefix(text, "\ufeff")
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+
+	manuscript := &Manuscript{}
+	var current *Chapter
+	var volume string
+	var block []string
+
+	// flush 把缓冲的行作为段落写入当前章节（或书稿开头）
+	flush := func() {
+		if len(block) == 0 {
+			return
+		}
+		paragraph := joinLines(block)
+		block = nil
+		if current != nil {
+			current.Paragraphs = append(current.Paragraphs, paragraph)
+		} else if manuscript.Title == "" && opts.Markdown && len(manuscript.Preface) == 0 && strings.HasPrefix(paragraph, "# ") {
+			manuscript.Title = strings.TrimSpace(strings.TrimPrefix(paragraph, "# "))
+		} else {
+			manuscript.Preface = append(manuscript.Preface, paragraph)
+		}
+	}
+	// closeChapter 结束当前章节，没有正文的标题（通常是目录）被跳过
+	closeChapter := func() {
+		flush()
+		if current == nil {
+			return
+		}
+		if len(current.Paragraphs) == 0 {
+			manuscript.Skipped++
+		} else {
+			manuscript.Chapters = append(manuscript.Chapters, current)
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		heading := headingText(line)
+		switch {
+		case matchHeading(heading, volumePatterns):
+			closeChapter()
+			volume = heading
+			continue
+		case matchHeading(heading, chapterPatterns):
+			closeChapter()
+			current = &Chapter{Title: heading, Volume: volume}
+			continue
+		}
+
+		trimmed := strings.TrimFunc(line, unicode.IsSpace)
+		if trimmed == "" {
+			flush()
+			continue
+		}
+		if current == nil && !opts.Markdown {
+			// TXT 开头的书名通常写作《书名》
+			if manuscript.Title == "" && len(manuscript.Preface) == 0 && strings.HasPrefix(trimmed, "《") && strings.HasSuffix(trimmed, "》") {
+				manuscript.Title = strings.TrimSuffix(strings.TrimPrefix(trimmed, "《"), "》")
+				continue
+			}
+		}
+		block = append(block, trimmed)
+		if !opts.Markdown {
+			flush()
+		}
+	}
+	closeChapter()
+
+	if len(manuscript.Chapters) == 0 {
+		return nil, fmt.Errorf("没有识别到章节，请检查章节标题模式")
+	}
+	return manuscript, nil
+}
+
+// joinLines 合并 Markdown 段落中的折行：中文直接相连，英文以空格分隔
+func joinLines(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			last, _ := utf8.DecodeLastRuneInString(b.String())
+			first, _ := utf8.DecodeRuneInString(line)
+			if last < utf8.RuneSelf && first < utf8.RuneSelf {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// ParseFile 读取并拆分书稿文件，.md/.markdown 按 Markdown 分段，其余按 TXT 处理
+func ParseFile(path string, opts Options) (*Manuscript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取书稿失败: %w", err)
+	}
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("书稿不是 UTF-8 编码，请先转换编码: %s", path)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		opts.Markdown = true
+	}
+	return Parse(string(data), opts)
+}
+
+// ImportOptions 写入选项
+type ImportOptions struct {
+	Title       string // 书名，为空时使用书稿中识别出的书名
+	Status      string // 导入章节的状态（draft/revised/final），为空时不设置
+	FilePattern string // 新章节的文件名格式，为空时沿用章节清单中的格式
+}
+
+// Result 写入结果
+type Result struct {
+	ChapterIDs []int
+	Title      string // 写入 title 文件的书名，未写入时为空
+	Volumes    int    // 新建的卷数
+}
+
+// Import 通过 ChapterManager 把章节追加到小说目录；小说目录还没有书名时写入书名，
+// 有分卷时为每卷新建卷和覆盖其章节范围的篇章
+func Import(novelDir string, manuscript *Manuscript, opts ImportOptions) (*Result, error) {
+	if opts.Status != "" && !managers.ValidChapterStatus(opts.Status) {
+		return nil, fmt.Errorf("无效的章节状态: %s（可选 draft/revised/final）", opts.Status)
+	}
+
+	result := &Result{}
+	indexReader := managers.NewIndexReader(novelDir)
+	title := firstNonEmpty(opts.Title, manuscript.Title)
+	if title != "" && (opts.Title != "" || indexReader.GetTitle() == "") {
+		if err := indexReader.SetTitle(title); err != nil {
+			return nil, fmt.Errorf("写入书名失败: %w", err)
+		}
+		result.Title = title
+	}
+
+	chapterManager := managers.NewChapterManager(novelDir, managers.WithChapterFilePattern(opts.FilePattern))
+	if opts.Status != "" {
+		chapterManager.SetChapterMeta(&managers.ChapterMeta{Status: opts.Status})
+	}
+	// 连续属于同一卷的章节为一段，每段对应一个篇章
+	type volumeSpan struct {
+		title      string
+		start, end int
+	}
+	var spans []*volumeSpan
+	for _, chapter := range manuscript.Chapters {
+		id, _, err := chapterManager.CreateChapter(chapter.Title, chapter.Content())
+		if err != nil {
+			return result, fmt.Errorf("写入章节 %s 失败: %w", chapter.Title, err)
+		}
+		result.ChapterIDs = append(result.ChapterIDs, id)
+		if chapter.Volume == "" {
+			continue
+		}
+		if last := len(spans) - 1; last >= 0 && spans[last].title == chapter.Volume && spans[last].end == result.ChapterIDs[len(result.ChapterIDs)-2] {
+			spans[last].end = id
+			continue
+		}
+		spans = append(spans, &volumeSpan{title: chapter.Volume, start: id, end: id})
+	}
+
+	if len(spans) == 0 {
+		return result, nil
+	}
+	err := managers.NewStoryStructureStore(novelDir).ModifyStructure(func(structure *managers.StoryStructure) error {
+		volumeIDs := make(map[string]int)
+		for _, span := range spans {
+			if _, ok := volumeIDs[span.title]; !ok {
+				volume, err := structure.UpsertVolume(&managers.Volume{Title: span.title, Status: managers.StoryStatusCompleted})
+				if err != nil {
+					return err
+				}
+				volumeIDs[span.title] = volume.ID
+				result.Volumes++
+			}
+			if _, err := structure.UpsertArc(&managers.Arc{
+				Volume:       volumeIDs[span.title],
+				Title:        span.title,
+				Status:       managers.StoryStatusCompleted,
+				StartChapter: span.start,
+				EndChapter:   span.end,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("写入卷结构失败: %w", err)
+	}
+	return result, nil
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
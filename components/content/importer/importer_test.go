@@ -0,0 +1,82 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+)
+
+func TestParseText(t *testing.T) {
+	text := "\ufeff《荒岛求生》\r\n作者：佚名\r\n\r\n目录\r\n第一章 醒来\r\n第二章 水源\r\n\r\n第一卷 荒岛\r\n第一章 醒来\r\n　　海浪拍打着礁石。\r\n　　林凡睁开眼。\r\n第二章 水源\r\n　　第三章里会提到的那条溪流，此刻还藏在密林深处，他沿着岸边一直往上走。\r\n第二卷 归途\r\n第三章 夜\r\n　　火堆渐渐熄灭。\r\n"
+
+	manuscript, err := Parse(text, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "荒岛求生", manuscript.Title)
+	assert.Equal(t, []string{"作者：佚名", "目录"}, manuscript.Preface)
+	assert.Equal(t, 2, manuscript.Skipped)
+	require.Len(t, manuscript.Chapters, 3)
+	assert.Equal(t, "第一章 醒来", manuscript.Chapters[0].Title)
+	assert.Equal(t, "海浪拍打着礁石。\n\n林凡睁开眼。", manuscript.Chapters[0].Content())
+	assert.Len(t, manuscript.Chapters[1].Paragraphs, 1)
+	assert.Equal(t, []string{"第一卷 荒岛", "第二卷 归途"}, manuscript.Volumes())
+
+	_, err = Parse(text, Options{ChapterPatterns: []string{`^【\d+】`}})
+	assert.Error(t, err)
+}
+
+func TestParseMarkdown(t *testing.T) {
+	text := "# Island\n\n## Chapter 1 Awake\n\nThe waves hit\nthe rocks.\n\n他睁开眼，\n看见了海。\n\n## Chapter 2\n\nNight fell.\n"
+
+	manuscript, err := Parse(text, Options{Markdown: true})
+	require.NoError(t, err)
+	assert.Equal(t, "Island", manuscript.Title)
+	require.Len(t, manuscript.Chapters, 2)
+	assert.Equal(t, "Chapter 1 Awake", manuscript.Chapters[0].Title)
+	assert.Equal(t, []string{"The waves hit the rocks.", "他睁开眼，看见了海。"}, manuscript.Chapters[0].Paragraphs)
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	manuscript := &Manuscript{
+		Title: "荒岛求生",
+		Chapters: []*Chapter{
+			{Title: "第一章 醒来", Volume: "第一卷 荒岛", Paragraphs: []string{"海浪拍打着礁石。", "林凡睁开眼。"}},
+			{Title: "第二章 水源", Volume: "第一卷 荒岛", Paragraphs: []string{"他沿着溪流向上走。"}},
+			{Title: "第三章 夜", Paragraphs: []string{"火堆渐渐熄灭。"}},
+		},
+	}
+
+	result, err := Import(dir, manuscript, ImportOptions{Status: managers.ChapterStatusFinal})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, result.ChapterIDs)
+	assert.Equal(t, "荒岛求生", result.Title)
+	assert.Equal(t, 1, result.Volumes)
+	assert.Equal(t, "荒岛求生", managers.NewIndexReader(dir).GetTitle())
+
+	chapterManager := managers.NewChapterManager(dir)
+	data, err := chapterManager.GetChapterData(1)
+	require.NoError(t, err)
+	assert.Equal(t, "第一章 醒来", data.Title)
+	assert.Len(t, data.Content, 2)
+	assert.Equal(t, managers.ChapterStatusFinal, data.Meta.Status)
+
+	structure, err := managers.NewStoryStructureStore(dir).LoadStructure()
+	require.NoError(t, err)
+	arc, ok := structure.ArcForChapter(2)
+	require.True(t, ok)
+	assert.Equal(t, 1, arc.StartChapter)
+	assert.Equal(t, 2, arc.EndChapter)
+	_, ok = structure.ArcForChapter(3)
+	assert.False(t, ok)
+
+	// 再次导入时追加在已有章节之后，已有书名不被覆盖
+	manuscript.Title = "另一个书名"
+	result, err = Import(dir, manuscript, ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 5, 6}, result.ChapterIDs)
+	assert.Empty(t, result.Title)
+	assert.Equal(t, "荒岛求生", managers.NewIndexReader(dir).GetTitle())
+}
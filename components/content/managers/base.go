@@ -18,6 +18,8 @@ type BaseFileManager struct {
 	lastModTime  time.Time
 	tokenCounter token.TokenCounter
 	tokenBudget  *token.TokenBudgetManager
+	versionInfo  VersionInfo
+	component    string // 对应的上下文预算组件，截断和压缩时使用该组件的分配和截断模式
 }
 
 // NewBaseFileManager 创建基础文件管理器
@@ -33,6 +35,13 @@ func NewBaseFileManager(filePath string) *BaseFileManager {
 	}
 }
 
+// newComponentFileManager 创建对应某个上下文预算组件的文件管理器
+func newComponentFileManager(filePath, component string) *BaseFileManager {
+	bfm := NewBaseFileManager(filePath)
+	bfm.component = component
+	return bfm
+}
+
 // Load 加载文件内容
 func (bfm *BaseFileManager) Load() (string, error) {
 	if !bfm.Exists() {
@@ -56,16 +65,45 @@ func (bfm *BaseFileManager) Load() (string, error) {
 }
 
 // Save 保存文件内容
+// 写入时持有文件锁并先写临时文件再重命名，多个任务同时写入时不会得到混杂或半截的文件
 func (bfm *BaseFileManager) Save(content string) error {
-	// 确保父目录存在
-	dir := filepath.Dir(bfm.filePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to write file %s: %w", bfm.filePath, err)
+	return WithFileLock(bfm.filePath, func() error {
+		return bfm.write(content)
+	})
+}
+
+// Modify 读-改-写：在文件锁内读取磁盘上的最新内容（文件不存在时为空），由 fn 生成新内容后原子写入
+// 并发任务对同一文件的修改会依次进行，不会相互覆盖；fn 返回错误时不写入
+func (bfm *BaseFileManager) Modify(fn func(current string) (string, error)) error {
+	return WithFileLock(bfm.filePath, func() error {
+		data, err := os.ReadFile(bfm.filePath)
+		if err != nil && !os.IsNotExist(err) {
+			return content.NewFileReadError(bfm.filePath, err)
+		}
+
+		updated, err := fn(string(data))
+		if err != nil {
+			return err
+		}
+		return bfm.write(updated)
+	})
+}
+
+// write 原子写入文件、记录版本历史并更新内部状态，调用方需持有文件锁
+func (bfm *BaseFileManager) write(content string) error {
+	var previous *string
+	if data, err := os.ReadFile(bfm.filePath); err == nil {
+		existing := string(data)
+		previous = &existing
 	}
-	
-	// 写入文件
-	err := os.WriteFile(bfm.filePath, []byte(content), 0644)
-	if err != nil {
+
+	// 先记录版本快照再写入：记录失败时文件保持原样，调用方看到的错误与磁盘状态一致
+	// 内容未变化时不会产生新版本
+	if _, err := NewVersionStore(filepath.Dir(bfm.filePath)).Record(filepath.Base(bfm.filePath), previous, content, bfm.versionInfo); err != nil {
+		return err
+	}
+
+	if err := WriteFileAtomic(bfm.filePath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write file %s: %w", bfm.filePath, err)
 	}
 	
@@ -74,10 +112,25 @@ func (bfm *BaseFileManager) Save(content string) error {
 	if stat, err := os.Stat(bfm.filePath); err == nil {
 		bfm.lastModTime = stat.ModTime()
 	}
-	
+	bfm.versionInfo.changes.AddFile(bfm.filePath)
 	return nil
 }
 
+// SetVersionInfo 设置之后保存时记录到版本历史中的作者和原因
+func (bfm *BaseFileManager) SetVersionInfo(info VersionInfo) {
+	bfm.versionInfo = info
+}
+
+// GetVersionInfo 获取保存时记录的作者和原因
+func (bfm *BaseFileManager) GetVersionInfo() VersionInfo {
+	return bfm.versionInfo
+}
+
+// History 获取文件的版本历史（按时间顺序）
+func (bfm *BaseFileManager) History() ([]*FileVersion, error) {
+	return NewVersionStore(filepath.Dir(bfm.filePath)).List(filepath.Base(bfm.filePath))
+}
+
 // Exists 检查文件是否存在
 func (bfm *BaseFileManager) Exists() bool {
 	_, err := os.Stat(bfm.filePath)
@@ -149,6 +202,10 @@ func (bfm *BaseFileManager) Update(content string) error {
 // SetTokenBudget 设置Token预算管理器
 func (bfm *BaseFileManager) SetTokenBudget(budget *token.TokenBudgetManager) {
 	bfm.tokenBudget = budget
+	// 与预算管理器使用同一个计数器，保证计数口径一致
+	if budget != nil {
+		bfm.tokenCounter = budget.GetCounter()
+	}
 }
 
 // GetTokenBudget 获取Token预算管理器
@@ -156,119 +213,36 @@ func (bfm *BaseFileManager) GetTokenBudget() *token.TokenBudgetManager {
 	return bfm.tokenBudget
 }
 
-// TruncateToLimit 截断内容到指定Token限制
-func (bfm *BaseFileManager) TruncateToLimit(text string, limit int) (string, int) {
-	if bfm.tokenBudget != nil {
-		return bfm.tokenBudget.TruncateToTokenLimit(text, "default")
-	}
-	
-	// 简单的截断逻辑
-	currentTokens := bfm.tokenCounter.Count(text)
-	if currentTokens <= limit {
-		return text, currentTokens
+// CompressionSource 返回当前文件作为压缩缓存的来源
+func (bfm *BaseFileManager) CompressionSource() token.CompressionSource {
+	modTime, err := bfm.GetModTime()
+	if err != nil {
+		return token.CompressionSource{}
 	}
-	
-	return bfm.simpleTokenTruncate(text, limit)
+	return token.CompressionSource{Path: bfm.filePath, ModTime: modTime}
 }
 
-// simpleTokenTruncate 简单的Token截断
-func (bfm *BaseFileManager) simpleTokenTruncate(text string, limit int) (string, int) {
-	if limit <= 0 {
-		return "", 0
-	}
-	
-	// 先尝试按行截断
-	lines := strings.Split(text, "\n")
-	var result []string
-	totalTokens := 0
-	
-	for _, line := range lines {
-		lineTokens := bfm.tokenCounter.Count(line)
-		if totalTokens+lineTokens > limit {
-			// 如果当前行超出限制，尝试按词截断
-			if totalTokens < limit {
-				remainingTokens := limit - totalTokens
-				truncatedLine := bfm.truncateLineByWords(line, remainingTokens)
-				if truncatedLine != "" {
-					result = append(result, truncatedLine)
-					totalTokens += bfm.tokenCounter.Count(truncatedLine)
-				}
-			}
-			break
-		}
-		result = append(result, line)
-		totalTokens += lineTokens
+// TruncateToLimit 截断内容到指定Token限制
+// 按段落和句子边界保留开头，并在截断处插入省略标记
+func (bfm *BaseFileManager) TruncateToLimit(text string, limit int) (string, int) {
+	// 配置了压缩器时优先压缩，避免在设定中途截断
+	if bfm.tokenBudget != nil && bfm.tokenBudget.GetCompressor() != nil {
+		return bfm.tokenBudget.CompressToLimit(text, bfm.budgetComponent(), limit, bfm.CompressionSource())
 	}
-	
-	finalText := strings.Join(result, "\n")
-	return finalText, totalTokens
-}
 
-// truncateLineByWords 按词截断单行文本
-func (bfm *BaseFileManager) truncateLineByWords(line string, limit int) string {
-	if limit <= 0 {
-		return ""
-	}
-	
-	// 先尝试按空格分词
-	words := strings.Fields(line)
-	if len(words) > 1 {
-		var result []string
-		totalTokens := 0
-		
-		for _, word := range words {
-			wordTokens := bfm.tokenCounter.Count(word)
-			if totalTokens+wordTokens > limit {
-				break
-			}
-			result = append(result, word)
-			totalTokens += wordTokens
-		}
-		
-		if len(result) > 0 {
-			return strings.Join(result, " ")
-		}
+	if bfm.tokenBudget != nil {
+		return bfm.tokenBudget.TruncateToLimit(text, bfm.budgetComponent(), limit)
 	}
-	
-	// 如果只有一个词或没有成功分词，尝试按字符截断
-	return bfm.truncateLineByCharacters(line, limit)
+
+	return token.TruncateText(bfm.tokenCounter, text, limit, token.KeepHead)
 }
 
-// truncateLineByCharacters 按字符截断单行文本
-func (bfm *BaseFileManager) truncateLineByCharacters(line string, limit int) string {
-	if limit <= 0 {
-		return ""
-	}
-	
-	runes := []rune(line)
-	
-	// 二分搜索找到合适的截断点
-	left, right := 0, len(runes)
-	bestEnd := 0
-	
-	for left <= right {
-		mid := (left + right) / 2
-		if mid == 0 {
-			left = mid + 1
-			continue
-		}
-		
-		candidate := string(runes[:mid])
-		tokenCount := bfm.tokenCounter.Count(candidate)
-		
-		if tokenCount <= limit {
-			bestEnd = mid
-			left = mid + 1
-		} else {
-			right = mid - 1
-		}
+// budgetComponent 截断和压缩时使用的预算组件名，未指定时为 default
+func (bfm *BaseFileManager) budgetComponent() string {
+	if bfm.component == "" {
+		return "default"
 	}
-	
-	if bestEnd == 0 {
-		return ""
-	}
-	
-	return string(runes[:bestEnd])
+	return bfm.component
 }
 
 // GetFileInfo 获取文件信息
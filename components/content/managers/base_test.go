@@ -0,0 +1,168 @@
+package managers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/token"
+)
+
+func TestConcurrentModify(t *testing.T) {
+	dir := t.TempDir()
+	const workers = 16
+
+	// 每个任务使用独立的管理器实例，模拟多个队列任务或 CLI 进程同时写入
+	var wg sync.WaitGroup
+	for i := 1; i <= workers; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			chapter := fmt.Sprintf("%d", n)
+			assert.NoError(t, NewIndexManager(dir).UpdateSummary(ChapterSummary{ChapterID: chapter, Summary: "摘要" + chapter}))
+			assert.NoError(t, NewPlannerContentManager(dir).UpsertPlan(chapter, "计划"+chapter, "", false))
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, NewIndexReader(dir).GetChapterSummaries(), workers)
+	assert.Len(t, NewPlannerContentManager(dir).GetAllPlans(), workers)
+
+	// 原子写入不留下临时文件
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), ".tmp-")
+	}
+}
+
+func TestModifyErrorKeepsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "worldview.md")
+	manager := NewBaseFileManager(path)
+	assert.NoError(t, manager.Save("原内容"))
+
+	err := manager.Modify(func(current string) (string, error) {
+		assert.Equal(t, "原内容", current)
+		return "", fmt.Errorf("失败")
+	})
+	assert.Error(t, err)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "原内容", string(data))
+}
+
+func TestVersionHistory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "worldview.md")
+	assert.NoError(t, os.WriteFile(path, []byte("原有世界观\n"), 0644))
+
+	manager := NewBaseFileManager(path)
+	manager.SetVersionInfo(VersionInfo{Author: "task:test/1", Reason: "第一次修改"})
+	assert.NoError(t, manager.Save("世界观\n第二行\n"))
+	assert.NoError(t, manager.Save("世界观\n第二行\n")) // 内容相同不产生新版本
+	assert.NoError(t, manager.Save("世界观\n修改后的第二行\n"))
+
+	versions, err := manager.History()
+	assert.NoError(t, err)
+	if !assert.Len(t, versions, 3) {
+		return
+	}
+	assert.Equal(t, "修改前的原有内容", versions[0].Reason)
+	assert.Equal(t, "task:test/1", versions[1].Author)
+
+	store := NewVersionStore(dir)
+	diff, err := store.Diff(versions[1].ID, versions[2].ID)
+	assert.NoError(t, err)
+	assert.Contains(t, diff, "-第二行\n+修改后的第二行\n")
+
+	diff, err = store.Diff(versions[2].ID, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, diff)
+
+	// 恢复本身记录为新版本，可以再次撤销
+	restored, err := store.Restore(versions[0].ID, VersionInfo{Author: "cli"})
+	assert.NoError(t, err)
+	assert.Equal(t, versions[0].Hash, restored.Hash)
+	assert.Equal(t, versions[2].ID+1, restored.ID)
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, "原有世界观\n", string(data))
+}
+
+func TestVersionHistoryExternalEdit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "worldview.md")
+	manager := NewBaseFileManager(path)
+	assert.NoError(t, manager.Save("第一版\n"))
+
+	// 在工具之外修改文件，之后工具再次保存时外部修改的内容也要能恢复
+	assert.NoError(t, os.WriteFile(path, []byte("手动修改\n"), 0644))
+	assert.NoError(t, manager.Save("第二版\n"))
+
+	versions, err := manager.History()
+	assert.NoError(t, err)
+	if !assert.Len(t, versions, 3) {
+		return
+	}
+	assert.Equal(t, "工具外的修改", versions[1].Reason)
+	external, err := NewVersionStore(dir).Content(versions[1])
+	assert.NoError(t, err)
+	assert.Equal(t, "手动修改\n", external)
+}
+
+// 记录版本只依赖最新版本索引；版本记录在索引之外追加或索引损坏时，索引会补读或重建
+func TestVersionIndex(t *testing.T) {
+	dir := t.TempDir()
+	store := NewVersionStore(dir)
+	first, err := store.Record("worldview.md", nil, "第一版\n", VersionInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, first.ID)
+
+	stat, err := os.Stat(store.logPath())
+	assert.NoError(t, err)
+	index, err := store.loadIndex()
+	assert.NoError(t, err)
+	assert.Equal(t, stat.Size(), index.LogSize)
+	assert.Equal(t, first.Hash, index.Latest["worldview.md"].Hash)
+
+	// 其他写入方直接追加的版本记录
+	external := fmt.Sprintf(`{"id":7,"file":"worldview.md","hash":"%s","size":6,"created_at":"2024-01-01T00:00:00Z"}`+"\n", hashContent("外部版\n"))
+	logFile, err := os.OpenFile(store.logPath(), os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = logFile.WriteString(external)
+	assert.NoError(t, err)
+	assert.NoError(t, logFile.Close())
+
+	unchanged, err := store.Record("worldview.md", nil, "外部版\n", VersionInfo{})
+	assert.NoError(t, err)
+	assert.Nil(t, unchanged)
+
+	// 索引损坏时从版本记录重建
+	assert.NoError(t, os.WriteFile(store.indexPath(), []byte("{"), 0644))
+	next, err := store.Record("worldview.md", nil, "第二版\n", VersionInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, 8, next.ID)
+
+	versions, err := store.List("worldview.md")
+	assert.NoError(t, err)
+	assert.Len(t, versions, 3)
+}
+
+// 截断时使用管理器对应组件的截断模式，而不是 default
+func TestTruncateToLimitUsesComponent(t *testing.T) {
+	budget, err := token.NewTokenBudgetManager(4000, nil)
+	assert.NoError(t, err)
+	budget.SetTruncateMode("plan", token.KeepTail)
+
+	planner := NewPlannerContentManagerWithTokenBudget(t.TempDir(), budget)
+	text := "第一段规划开头。\n\n" + strings.Repeat("中间的规划内容。", 50) + "\n\n最后一段规划结尾。"
+	truncated, tokens := planner.TruncateToLimit(text, 30)
+	assert.LessOrEqual(t, tokens, 30)
+	assert.Contains(t, truncated, "规划结尾")
+	assert.NotContains(t, truncated, "规划开头")
+}
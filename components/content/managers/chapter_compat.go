@@ -2,116 +2,351 @@ package managers
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	content "github.com/Kizunad/modular-workflow-v2/components/content/utils"
 )
 
-// ChapterManager 章节管理器（兼容性包装）
-// 这是一个临时的兼容层，用于支持现有的章节计数功能
-// 完整的ChapterManager重构将在下一阶段进行
+// ChapterManager 章节管理器
+// 章节ID、文件和阅读顺序记录在章节清单 chapters.json 中；清单不存在时（旧小说目录）根据目录中的章节文件推断，
+// 第一次写入章节时自动生成清单
 type ChapterManager struct {
-	novelDir string
+	novelDir    string
+	versionInfo VersionInfo
+	naming      *chapterNaming // 新章节的文件名格式，为nil时在第一次使用时从章节清单读取
+	meta        *ChapterMeta
+
+	scanned        *ChapterManifest // 清单不存在时扫描章节文件得到的清单
+	scannedModTime time.Time        // 扫描时小说目录的修改时间，目录中的文件增删或替换后重新扫描
 }
 
-// NewChapterManager 创建章节管理器
-func NewChapterManager(novelDir string) *ChapterManager {
-	return &ChapterManager{
-		novelDir: novelDir,
+// ChapterManagerOption 章节管理器选项
+type ChapterManagerOption func(cm *ChapterManager)
+
+// WithChapterFilePattern 指定新章节的文件名格式（如配置中的 novel.chapter_file_pattern），空或无效时忽略
+// 指定的格式会在写入章节清单时一并记录，之后未指定格式的管理器沿用清单中的格式
+func WithChapterFilePattern(pattern string) ChapterManagerOption {
+	return func(cm *ChapterManager) {
+		if pattern == "" {
+			return
+		}
+		if naming, err := parseChapterFilePattern(pattern); err == nil {
+			cm.naming = naming
+		}
 	}
 }
 
-// GetChapterCount 获取章节数量（简化实现）
-func (cm *ChapterManager) GetChapterCount() int {
-	count, _ := cm.countChapterFiles()
-	return count
+// NewChapterManager 创建章节管理器；未指定文件名格式时使用章节清单中记录的格式，清单中也没有时使用默认格式
+func NewChapterManager(novelDir string, opts ...ChapterManagerOption) *ChapterManager {
+	cm := &ChapterManager{novelDir: novelDir}
+	for _, opt := range opts {
+		opt(cm)
+	}
+	return cm
 }
 
-// countChapterFiles 计算章节文件数量
-func (cm *ChapterManager) countChapterFiles() (int, error) {
-	// 检查目录是否存在
-	if _, err := os.Stat(cm.novelDir); os.IsNotExist(err) {
-		return 0, nil
+// fileNaming 新章节的文件名格式
+func (cm *ChapterManager) fileNaming() *chapterNaming {
+	if cm.naming != nil {
+		return cm.naming
 	}
-	
-	// 读取目录中的文件
-	files, err := os.ReadDir(cm.novelDir)
+	cm.naming = defaultChapterNaming
+	if data, err := os.ReadFile(cm.GetManifestPath()); err == nil {
+		if manifest, err := decodeManifest(string(data)); err == nil && manifest.FilePattern != "" {
+			if naming, err := parseChapterFilePattern(manifest.FilePattern); err == nil {
+				cm.naming = naming
+			}
+		}
+	}
+	return cm.naming
+}
+
+// SetVersionInfo 设置写入章节时记录到版本历史中的作者和原因
+func (cm *ChapterManager) SetVersionInfo(info VersionInfo) {
+	cm.versionInfo = info
+}
+
+// SetChapterMeta 设置写入章节时合并到章节元数据中的字段（视角、地点、状态、模型等），空字段保留文件中的原值
+func (cm *ChapterManager) SetChapterMeta(meta *ChapterMeta) {
+	cm.meta = meta
+}
+
+// SetFilePattern 设置新章节的文件名格式，如 chapter_%03d.json；已有章节保持原文件名
+func (cm *ChapterManager) SetFilePattern(pattern string) error {
+	naming, err := parseChapterFilePattern(pattern)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	
-	// 章节文件名模式：example_chapter_N.json
-	chapterPattern := regexp.MustCompile(`^example_chapter_(\d+)\.json$`)
-	maxChapterNum := 0
-	
-	for _, file := range files {
-		if file.IsDir() {
-			continue
+	cm.naming = naming
+	return nil
+}
+
+// GetFilePattern 获取新章节的文件名格式
+func (cm *ChapterManager) GetFilePattern() string {
+	return cm.fileNaming().pattern
+}
+
+// GetManifestPath 获取章节清单路径
+func (cm *ChapterManager) GetManifestPath() string {
+	return filepath.Join(cm.novelDir, ChapterManifestFile)
+}
+
+// LoadManifest 读取章节清单，清单不存在时根据目录中的章节文件生成（不写入）
+// 生成的清单在管理器内缓存，小说目录没有变化时不重复扫描
+func (cm *ChapterManager) LoadManifest() (*ChapterManifest, error) {
+	data, err := os.ReadFile(cm.GetManifestPath())
+	if os.IsNotExist(err) {
+		return cm.cachedScanManifest()
+	}
+	if err != nil {
+		return nil, content.NewFileReadError(cm.GetManifestPath(), err)
+	}
+	return decodeManifest(string(data))
+}
+
+// ModifyManifest 在文件锁内读取最新的章节清单（不存在时根据章节文件生成），由 fn 修改后写回；fn 返回错误时不写入
+func (cm *ChapterManager) ModifyManifest(fn func(manifest *ChapterManifest) error) error {
+	manifestFile := NewBaseFileManager(cm.GetManifestPath())
+	manifestFile.SetVersionInfo(cm.versionInfo)
+
+	return manifestFile.Modify(func(current string) (string, error) {
+		var manifest *ChapterManifest
+		var err error
+		if strings.TrimSpace(current) == "" {
+			manifest, err = cm.scanManifest()
+		} else {
+			manifest, err = decodeManifest(current)
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := fn(manifest); err != nil {
+			return "", err
+		}
+		if naming := cm.fileNaming(); naming != defaultChapterNaming {
+			manifest.FilePattern = naming.pattern
 		}
-		
-		matches := chapterPattern.FindStringSubmatch(file.Name())
-		if len(matches) == 2 {
-			if num, err := strconv.Atoi(matches[1]); err == nil {
-				if num > maxChapterNum {
-					maxChapterNum = num
-				}
+		return encodeManifest(manifest)
+	})
+}
+
+// RebuildManifest 按目录中的实际文件重建章节清单：保留已有章节的顺序，移除文件已不存在的章节，
+// 未记录的章节文件按ID插入；同一ID有多个文件时保留清单中已有的文件
+func (cm *ChapterManager) RebuildManifest() (*ChapterManifest, error) {
+	files, err := cm.scanChapterFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var rebuilt *ChapterManifest
+	err = cm.ModifyManifest(func(manifest *ChapterManifest) error {
+		tracked := make(map[int]bool)
+		kept := manifest.Chapters[:0]
+		for _, entry := range manifest.Chapters {
+			if !tracked[entry.ID] && fileExists(filepath.Join(cm.novelDir, entry.File)) {
+				tracked[entry.ID] = true
+				kept = append(kept, entry)
+			}
+		}
+		manifest.Chapters = kept
+
+		for _, name := range sortedChapterFiles(files) {
+			id := files[name]
+			if tracked[id] {
+				continue
+			}
+			tracked[id] = true
+			entry := &ChapterEntry{ID: id, File: name}
+			if chapter, err := cm.readChapterFile(name); err == nil {
+				entry.Title = chapter.Title
 			}
+			manifest.put(entry)
 		}
+		rebuilt = manifest
+		return nil
+	})
+	return rebuilt, err
+}
+
+// cachedScanManifest 返回扫描得到的清单，小说目录的修改时间未变化时复用上次的结果
+// 章节文件以原子替换方式写入，文件增删或改写都会更新目录的修改时间
+func (cm *ChapterManager) cachedScanManifest() (*ChapterManifest, error) {
+	info, err := os.Stat(cm.novelDir)
+	if err != nil {
+		return cm.scanManifest()
 	}
-	
-	return maxChapterNum, nil
+	if cm.scanned != nil && info.ModTime().Equal(cm.scannedModTime) {
+		return cm.scanned, nil
+	}
+
+	manifest, err := cm.scanManifest()
+	if err != nil {
+		return nil, err
+	}
+	cm.scanned, cm.scannedModTime = manifest, info.ModTime()
+	return manifest, nil
 }
 
-// HasChapters 检查是否有章节文件
-func (cm *ChapterManager) HasChapters() bool {
-	count := cm.GetChapterCount()
-	return count > 0
+// scanManifest 根据目录中的章节文件生成清单，按ID排序
+func (cm *ChapterManager) scanManifest() (*ChapterManifest, error) {
+	files, err := cm.scanChapterFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &ChapterManifest{NextID: 1}
+	for _, name := range sortedChapterFiles(files) {
+		if _, exists := manifest.Find(files[name]); exists {
+			continue
+		}
+		entry := &ChapterEntry{ID: files[name], File: name}
+		if chapter, err := cm.readChapterFile(name); err == nil {
+			entry.Title = chapter.Title
+		}
+		manifest.put(entry)
+	}
+	return manifest, nil
 }
 
-// GetChapterFiles 获取所有章节文件名
-func (cm *ChapterManager) GetChapterFiles() []string {
-	files, err := os.ReadDir(cm.novelDir)
+// scanChapterFiles 扫描目录中的章节文件（文件名 -> 章节ID），同时识别配置的格式和默认格式
+func (cm *ChapterManager) scanChapterFiles() (map[string]int, error) {
+	files := make(map[string]int)
+	entries, err := os.ReadDir(cm.novelDir)
+	if os.IsNotExist(err) {
+		return files, nil
+	}
 	if err != nil {
-		return []string{}
+		return nil, err
 	}
-	
-	chapterPattern := regexp.MustCompile(`^example_chapter_\d+\.json$`)
-	var chapterFiles []string
-	
-	for _, file := range files {
-		if file.IsDir() {
+
+	for _, entry := range entries {
+		if entry.IsDir() {
 			continue
 		}
-		
-		if chapterPattern.MatchString(file.Name()) {
-			chapterFiles = append(chapterFiles, file.Name())
+		if id, ok := cm.ChapterIDFromFile(entry.Name()); ok {
+			files[entry.Name()] = id
 		}
 	}
-	
+	return files, nil
+}
+
+// ChapterIDFromFile 从章节文件名中解析章节ID，同时识别配置的格式和默认格式
+func (cm *ChapterManager) ChapterIDFromFile(name string) (int, bool) {
+	for _, naming := range []*chapterNaming{cm.fileNaming(), defaultChapterNaming} {
+		if id, ok := naming.chapterID(name); ok {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// sortedChapterFiles 按章节ID排序文件名，ID相同时按文件名排序
+func sortedChapterFiles(files map[string]int) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if files[names[i]] != files[names[j]] {
+			return files[names[i]] < files[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// readChapterFile 读取并解析章节文件
+func (cm *ChapterManager) readChapterFile(name string) (*ChapterData, error) {
+	data, err := os.ReadFile(filepath.Join(cm.novelDir, name))
+	if err != nil {
+		return nil, err
+	}
+	var chapter ChapterData
+	if err := json.Unmarshal(data, &chapter); err != nil {
+		return nil, err
+	}
+	return &chapter, nil
+}
+
+// GetChapterEntries 按阅读顺序返回所有章节
+func (cm *ChapterManager) GetChapterEntries() []*ChapterEntry {
+	manifest, err := cm.LoadManifest()
+	if err != nil {
+		return nil
+	}
+	return manifest.Chapters
+}
+
+// GetChapterIDs 按阅读顺序返回所有章节ID
+func (cm *ChapterManager) GetChapterIDs() []int {
+	manifest, err := cm.LoadManifest()
+	if err != nil {
+		return nil
+	}
+	return manifest.IDs()
+}
+
+// GetChapterCount 获取章节数量
+func (cm *ChapterManager) GetChapterCount() int {
+	return len(cm.GetChapterEntries())
+}
+
+// GetLatestChapterID 获取阅读顺序中最后一章的ID，没有章节时返回0
+func (cm *ChapterManager) GetLatestChapterID() int {
+	entries := cm.GetChapterEntries()
+	if len(entries) == 0 {
+		return 0
+	}
+	return entries[len(entries)-1].ID
+}
+
+// HasChapter 检查指定ID的章节是否存在
+func (cm *ChapterManager) HasChapter(id int) bool {
+	return id > 0 && fileExists(cm.GetChapterPath(id))
+}
+
+// HasChapters 检查是否有章节文件
+func (cm *ChapterManager) HasChapters() bool {
+	return cm.GetChapterCount() > 0
+}
+
+// GetChapterFiles 按阅读顺序获取所有章节文件名
+func (cm *ChapterManager) GetChapterFiles() []string {
+	entries := cm.GetChapterEntries()
+	chapterFiles := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		chapterFiles = append(chapterFiles, entry.File)
+	}
 	return chapterFiles
 }
 
 // GetLatestChapterPath 获取最新章节文件路径
 func (cm *ChapterManager) GetLatestChapterPath() string {
-	count := cm.GetChapterCount()
-	if count == 0 {
+	entries := cm.GetChapterEntries()
+	if len(entries) == 0 {
 		return ""
 	}
-	
-	fileName := "example_chapter_" + strconv.Itoa(count) + ".json"
-	return filepath.Join(cm.novelDir, fileName)
+	return filepath.Join(cm.novelDir, entries[len(entries)-1].File)
 }
 
-// GetChapterPath 获取指定编号的章节文件路径
+// GetChapterPath 获取指定ID的章节文件路径：优先使用清单中记录的文件，否则按文件名格式生成
 func (cm *ChapterManager) GetChapterPath(chapterNum int) string {
 	if chapterNum <= 0 {
 		return ""
 	}
-	
-	fileName := "example_chapter_" + strconv.Itoa(chapterNum) + ".json"
-	return filepath.Join(cm.novelDir, fileName)
+
+	if manifest, err := cm.LoadManifest(); err == nil {
+		if entry, ok := manifest.Find(chapterNum); ok {
+			return filepath.Join(cm.novelDir, entry.File)
+		}
+	}
+	return filepath.Join(cm.novelDir, cm.fileNaming().fileName(chapterNum))
 }
 
 // ValidateChapterStructure 验证章节目录结构
@@ -127,10 +362,8 @@ func (cm *ChapterManager) ValidateChapterStructure() error {
 type ChapterData struct {
 	ChapterID string `json:"chapter_id"`
 	Title     string `json:"title"`
-	Content   []struct {
-		ParagraphID int    `json:"paragraph_id"`
-		Text        string `json:"text"`
-	} `json:"content"`
+	Content   []ChapterParagraph `json:"content"`
+	Meta      *ChapterMeta       `json:"meta,omitempty"` // 章节元数据，旧章节文件中没有
 }
 
 // GetLatestChapterContent 获取最新章节内容
@@ -196,6 +429,74 @@ func (cm *ChapterManager) GetChapterContent(chapterNum int) (string, error) {
 	return contentBuilder.String(), nil
 }
 
+// GetChapterData 获取指定章节的完整数据
+func (cm *ChapterManager) GetChapterData(chapterNum int) (*ChapterData, error) {
+	chapterPath := cm.GetChapterPath(chapterNum)
+	if chapterPath == "" {
+		return nil, os.ErrNotExist
+	}
+
+	data, err := os.ReadFile(chapterPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var chapter ChapterData
+	if err := json.Unmarshal(data, &chapter); err != nil {
+		return nil, err
+	}
+
+	return &chapter, nil
+}
+
+// GetChapterMeta 获取指定章节的元数据，旧章节没有元数据时返回nil
+func (cm *ChapterManager) GetChapterMeta(chapterNum int) (*ChapterMeta, error) {
+	chapter, err := cm.GetChapterData(chapterNum)
+	if err != nil {
+		return nil, err
+	}
+	return chapter.Meta, nil
+}
+
+// UpdateChapterMeta 只更新章节元数据，不修改正文
+func (cm *ChapterManager) UpdateChapterMeta(chapterNum int, patch *ChapterMeta) (*ChapterMeta, error) {
+	chapterPath := cm.GetChapterPath(chapterNum)
+	if chapterPath == "" {
+		return nil, os.ErrNotExist
+	}
+
+	var updated *ChapterMeta
+	chapterFile := NewBaseFileManager(chapterPath)
+	chapterFile.SetVersionInfo(cm.versionInfo)
+	err := chapterFile.Modify(func(current string) (string, error) {
+		var chapter ChapterData
+		if err := json.Unmarshal([]byte(current), &chapter); err != nil {
+			return "", err
+		}
+		chapter.Meta = buildChapterMeta(chapter.Meta, patch, fileModTime(chapterPath), chapter.GetText())
+		updated = chapter.Meta
+
+		jsonData, err := json.MarshalIndent(chapter, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(jsonData), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// GetText 拼接章节段落文本
+func (cd *ChapterData) GetText() string {
+	paragraphs := make([]string, 0, len(cd.Content))
+	for _, paragraph := range cd.Content {
+		paragraphs = append(paragraphs, paragraph.Text)
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
 // GetChapterMetadata 获取章节元数据
 func (cm *ChapterManager) GetChapterMetadata() map[string]interface{} {
 	metadata := map[string]interface{}{
@@ -203,6 +504,7 @@ func (cm *ChapterManager) GetChapterMetadata() map[string]interface{} {
 		"chapter_count":  cm.GetChapterCount(),
 		"has_chapters":   cm.HasChapters(),
 		"chapter_files":  cm.GetChapterFiles(),
+		"chapter_ids":    cm.GetChapterIDs(),
 	}
 	
 	if latestPath := cm.GetLatestChapterPath(); latestPath != "" {
@@ -218,64 +520,172 @@ func (cm *ChapterManager) GetChapterMetadata() map[string]interface{} {
 	return metadata
 }
 
-// WriteChapter 写入新章节（基于当前章节数量自动递增）
+// WriteChapter 写入新章节，返回章节文件路径
 func (cm *ChapterManager) WriteChapter(title, content string) (string, error) {
-	// 获取下一个章节编号
-	nextChapterNum := cm.GetChapterCount() + 1
-	chapterID := strconv.Itoa(nextChapterNum)
-	
-	return cm.WriteChapterWithID(chapterID, title, content)
+	_, chapterPath, err := cm.CreateChapter(title, content)
+	return chapterPath, err
 }
 
-// WriteChapterWithID 写入指定ID的章节
+// CreateChapter 写入新章节，在清单锁内分配新的章节ID，返回章节ID和文件路径
+// 新ID总是大于所有已分配过的ID，即使中间的章节文件被删除也不会与已有章节冲突
+func (cm *ChapterManager) CreateChapter(title, content string) (int, string, error) {
+	var id int
+	var chapterPath string
+	err := cm.ModifyManifest(func(manifest *ChapterManifest) error {
+		id = manifest.reserveID()
+		entry := &ChapterEntry{ID: id, File: cm.fileNaming().fileName(id), Title: title}
+		chapterPath = filepath.Join(cm.novelDir, entry.File)
+		if err := cm.writeChapterFile(chapterPath, id, title, content); err != nil {
+			return err
+		}
+		manifest.put(entry)
+		return nil
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	return id, chapterPath, nil
+}
+
+// WriteChapterWithID 写入指定ID的章节，chapterID 为数字（兼容 001 形式）；已有章节沿用原文件
 func (cm *ChapterManager) WriteChapterWithID(chapterID, title, content string) (string, error) {
-	// 构建章节文件路径
-	chapterFileName := "example_chapter_" + chapterID + ".json"
-	chapterPath := filepath.Join(cm.novelDir, chapterFileName)
-	
-	// 确保目录存在
-	if err := os.MkdirAll(cm.novelDir, 0755); err != nil {
+	id, err := strconv.Atoi(strings.TrimSpace(chapterID))
+	if err != nil || id <= 0 {
+		return "", fmt.Errorf("无效的章节ID: %s", chapterID)
+	}
+
+	var chapterPath string
+	err = cm.ModifyManifest(func(manifest *ChapterManifest) error {
+		entry := &ChapterEntry{ID: id, File: cm.fileNaming().fileName(id), Title: title}
+		if existing, ok := manifest.Find(id); ok {
+			entry.File = existing.File
+		}
+		chapterPath = filepath.Join(cm.novelDir, entry.File)
+		if err := cm.writeChapterFile(chapterPath, id, title, content); err != nil {
+			return err
+		}
+		manifest.put(entry)
+		return nil
+	})
+	if err != nil {
 		return "", err
 	}
-	
-	// 将内容分段处理（简化实现：按段落分割）
-	paragraphs := strings.Split(strings.TrimSpace(content), "\n\n")
-	var contentArray []struct {
-		ParagraphID int    `json:"paragraph_id"`
-		Text        string `json:"text"`
+	return chapterPath, nil
+}
+
+// writeChapterFile 将章节内容按段落写入章节文件
+func (cm *ChapterManager) writeChapterFile(chapterPath string, id int, title, content string) error {
+	// 确保目录存在
+	if err := os.MkdirAll(cm.novelDir, 0755); err != nil {
+		return err
 	}
 	
-	for i, paragraph := range paragraphs {
-		if strings.TrimSpace(paragraph) != "" {
-			contentArray = append(contentArray, struct {
-				ParagraphID int    `json:"paragraph_id"`
-				Text        string `json:"text"`
-			}{
-				ParagraphID: i + 1,
-				Text:        strings.TrimSpace(paragraph),
-			})
+	// 读取原有章节，保留元数据和未修改段落的ID
+	var previous ChapterData
+	var existing *ChapterMeta
+	if data, err := os.ReadFile(chapterPath); err == nil {
+		if json.Unmarshal(data, &previous) == nil {
+			existing = previous.Meta
+		} else {
+			previous = ChapterData{}
 		}
 	}
-	
-	// 构建章节数据
+
+	// 构建章节数据，元数据在文件原有元数据的基础上合并
 	chapterData := ChapterData{
-		ChapterID: chapterID,
+		ChapterID: strconv.Itoa(id),
 		Title:     title,
-		Content:   contentArray,
+		Content:   previous.reuseParagraphIDs(splitParagraphs(content)),
 	}
+	chapterData.Meta = buildChapterMeta(existing, cm.meta, fileModTime(chapterPath), chapterData.GetText())
 	
 	// 序列化为JSON
 	jsonData, err := json.MarshalIndent(chapterData, "", "  ")
 	if err != nil {
-		return "", err
+		return err
 	}
 	
-	// 写入文件
-	if err := os.WriteFile(chapterPath, jsonData, 0644); err != nil {
-		return "", err
+	// 写入文件（加锁原子写入并记录版本历史）
+	chapterFile := NewBaseFileManager(chapterPath)
+	chapterFile.SetVersionInfo(cm.versionInfo)
+	return chapterFile.Save(string(jsonData))
+}
+
+// Validate 校验章节：ID不连续、重复文件、清单与文件不一致、文件内 chapter_id 与清单不一致
+func (cm *ChapterManager) Validate() (*ChapterValidation, error) {
+	validation := &ChapterValidation{ManifestExists: fileExists(cm.GetManifestPath())}
+	manifest, err := cm.LoadManifest()
+	if err != nil {
+		return nil, err
 	}
-	
-	return chapterPath, nil
+	files, err := cm.scanChapterFiles()
+	if err != nil {
+		return nil, err
+	}
+	validation.Chapters = len(manifest.Chapters)
+
+	// 清单中的章节
+	seenIDs := make(map[int]bool)
+	fileOwners := make(map[string]int)
+	for _, entry := range manifest.Chapters {
+		if seenIDs[entry.ID] {
+			validation.add(ChapterIssueDuplicate, entry.ID, []string{entry.File}, "第%d章在清单中出现多次", entry.ID)
+		}
+		seenIDs[entry.ID] = true
+		if owner, ok := fileOwners[entry.File]; ok {
+			validation.add(ChapterIssueDuplicate, entry.ID, []string{entry.File}, "第%d章和第%d章指向同一文件 %s", owner, entry.ID, entry.File)
+		}
+		fileOwners[entry.File] = entry.ID
+
+		chapter, err := cm.readChapterFile(entry.File)
+		switch {
+		case os.IsNotExist(err):
+			validation.add(ChapterIssueMissing, entry.ID, []string{entry.File}, "第%d章的文件 %s 不存在", entry.ID, entry.File)
+		case err != nil:
+			validation.add(ChapterIssueInvalid, entry.ID, []string{entry.File}, "第%d章的文件 %s 无法解析: %v", entry.ID, entry.File, err)
+		default:
+			if id, err := strconv.Atoi(strings.TrimSpace(chapter.ChapterID)); err != nil || id != entry.ID {
+				validation.add(ChapterIssueMismatch, entry.ID, []string{entry.File}, "文件 %s 中的 chapter_id 为 %q，清单中为 %d", entry.File, chapter.ChapterID, entry.ID)
+			}
+		}
+	}
+
+	// 目录中的章节文件
+	filesByID := make(map[int][]string)
+	for _, name := range sortedChapterFiles(files) {
+		id := files[name]
+		filesByID[id] = append(filesByID[id], name)
+		if _, tracked := fileOwners[name]; !tracked && validation.ManifestExists {
+			validation.add(ChapterIssueUntracked, id, []string{name}, "章节文件 %s 不在清单中", name)
+		}
+	}
+	ids := make([]int, 0, len(filesByID))
+	for id := range filesByID {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		if names := filesByID[id]; len(names) > 1 {
+			validation.add(ChapterIssueDuplicate, id, names, "第%d章对应多个文件: %s", id, strings.Join(names, "、"))
+		}
+	}
+
+	// ID 不连续
+	maxID := 0
+	for id := range seenIDs {
+		maxID = max(maxID, id)
+	}
+	var gaps []int
+	for id := 1; id <= maxID; id++ {
+		if !seenIDs[id] {
+			gaps = append(gaps, id)
+		}
+	}
+	if len(gaps) > 0 {
+		validation.add(ChapterIssueGap, 0, nil, "章节ID不连续，缺少第 %s 章", FormatChapterIDs(gaps))
+	}
+
+	return validation, nil
 }
 
 // UpdateChapter 更新指定章节
@@ -0,0 +1,622 @@
+package managers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParagraphChange 段落的变化类型
+type ParagraphChange int
+
+const (
+	// ParagraphUnchanged 段落未修改
+	ParagraphUnchanged ParagraphChange = iota
+	// ParagraphAdded 新增的段落
+	ParagraphAdded
+	// ParagraphRemoved 删除的段落
+	ParagraphRemoved
+	// ParagraphModified 修改的段落，附带字符级差异
+	ParagraphModified
+)
+
+const (
+	// paragraphSimilarity 删除段与新增段的字符相似度达到该值时视为同一段被修改
+	paragraphSimilarity = 0.5
+	// maxAlignCells 一处修改中删除段数×新增段数的上限，超过时不再配对，直接视为删除后新增
+	maxAlignCells = 400
+	// minEqualRunes 夹在修改之间、短于该长度的相同片段并入修改，避免中文差异被切得过碎
+	minEqualRunes = 2
+)
+
+// TextSegment 字符级差异中的一个片段
+type TextSegment struct {
+	Op   DiffOp
+	Text string
+}
+
+// ParagraphDiff 一个段落的差异
+type ParagraphDiff struct {
+	Change   ParagraphChange
+	OldID    int // 旧章节中的段落ID，新增段落为0
+	NewID    int // 新章节中的段落ID，删除段落为0
+	OldText  string
+	NewText  string
+	Segments []TextSegment // 修改段落的字符级差异
+}
+
+// ChapterDiff 两个章节版本之间的差异：先按段落对齐，修改的段落再做字符级比较
+type ChapterDiff struct {
+	OldName    string
+	NewName    string
+	OldTitle   string
+	NewTitle   string
+	Paragraphs []ParagraphDiff
+	Added      int // 新增字数
+	Removed    int // 删除字数
+}
+
+// DiffChapters 比较两个章节版本
+func DiffChapters(oldChapter, newChapter *ChapterData) *ChapterDiff {
+	diff := &ChapterDiff{
+		OldTitle:   oldChapter.Title,
+		NewTitle:   newChapter.Title,
+		Paragraphs: alignParagraphs(oldChapter.Content, newChapter.Content),
+	}
+	for _, paragraph := range diff.Paragraphs {
+		switch paragraph.Change {
+		case ParagraphAdded:
+			diff.Added += CountChapterChars(paragraph.NewText)
+		case ParagraphRemoved:
+			diff.Removed += CountChapterChars(paragraph.OldText)
+		case ParagraphModified:
+			for _, segment := range paragraph.Segments {
+				switch segment.Op {
+				case DiffInsert:
+					diff.Added += CountChapterChars(segment.Text)
+				case DiffDelete:
+					diff.Removed += CountChapterChars(segment.Text)
+				}
+			}
+		}
+	}
+	return diff
+}
+
+// DiffChapterTexts 比较两段正文，段落按空行划分，段落ID为从1开始的序号
+func DiffChapterTexts(oldText, newText string) *ChapterDiff {
+	toChapter := func(text string) *ChapterData {
+		chapter := &ChapterData{}
+		for i, paragraph := range splitParagraphs(text) {
+			chapter.Content = append(chapter.Content, ChapterParagraph{ParagraphID: i + 1, Text: paragraph})
+		}
+		return chapter
+	}
+	return DiffChapters(toChapter(oldText), toChapter(newText))
+}
+
+// Changed 标题或正文是否有变化
+func (d *ChapterDiff) Changed() bool {
+	if d.OldTitle != d.NewTitle {
+		return true
+	}
+	for _, paragraph := range d.Paragraphs {
+		if paragraph.Change != ParagraphUnchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// Count 统计某种变化的段落数
+func (d *ChapterDiff) Count(change ParagraphChange) int {
+	count := 0
+	for _, paragraph := range d.Paragraphs {
+		if paragraph.Change == change {
+			count++
+		}
+	}
+	return count
+}
+
+// Summary 差异概要，如 "新增 120 字，删除 35 字；段落：新增 1，删除 0，修改 3"
+func (d *ChapterDiff) Summary() string {
+	return fmt.Sprintf("新增 %d 字，删除 %d 字；段落：新增 %d，删除 %d，修改 %d",
+		d.Added, d.Removed, d.Count(ParagraphAdded), d.Count(ParagraphRemoved), d.Count(ParagraphModified))
+}
+
+// alignParagraphs 按段落文本求最短编辑序列，每处修改中的删除段与新增段再按相似度配对
+func alignParagraphs(oldParagraphs, newParagraphs []ChapterParagraph) []ParagraphDiff {
+	texts := func(paragraphs []ChapterParagraph) []string {
+		result := make([]string, len(paragraphs))
+		for i, paragraph := range paragraphs {
+			result[i] = paragraph.Text
+		}
+		return result
+	}
+
+	var result []ParagraphDiff
+	var removed, added []ChapterParagraph
+	flush := func() {
+		result = append(result, pairParagraphs(removed, added)...)
+		removed, added = nil, nil
+	}
+
+	i, j := 0, 0
+	for _, op := range EditScript(texts(oldParagraphs), texts(newParagraphs)) {
+		switch op {
+		case DiffEqual:
+			flush()
+			result = append(result, ParagraphDiff{
+				Change:  ParagraphUnchanged,
+				OldID:   oldParagraphs[i].ParagraphID,
+				NewID:   newParagraphs[j].ParagraphID,
+				OldText: oldParagraphs[i].Text,
+				NewText: newParagraphs[j].Text,
+			})
+			i++
+			j++
+		case DiffDelete:
+			removed = append(removed, oldParagraphs[i])
+			i++
+		case DiffInsert:
+			added = append(added, newParagraphs[j])
+			j++
+		}
+	}
+	flush()
+	return result
+}
+
+// pairParagraphs 在保持顺序的前提下，让配对段落的相似度之和最大；未配对的段落视为删除或新增
+func pairParagraphs(removed, added []ChapterParagraph) []ParagraphDiff {
+	n, m := len(removed), len(added)
+	if n == 0 || m == 0 || n*m > maxAlignCells {
+		var result []ParagraphDiff
+		for _, paragraph := range removed {
+			result = append(result, ParagraphDiff{Change: ParagraphRemoved, OldID: paragraph.ParagraphID, OldText: paragraph.Text})
+		}
+		for _, paragraph := range added {
+			result = append(result, ParagraphDiff{Change: ParagraphAdded, NewID: paragraph.ParagraphID, NewText: paragraph.Text})
+		}
+		return result
+	}
+
+	removedRunes, addedRunes := make([]runeCounts, n), make([]runeCounts, m)
+	for i, paragraph := range removed {
+		removedRunes[i] = countRunes(paragraph.Text)
+	}
+	for j, paragraph := range added {
+		addedRunes[j] = countRunes(paragraph.Text)
+	}
+
+	similarity := make([][]float64, n)
+	for i := range similarity {
+		similarity[i] = make([]float64, m)
+		for j := range similarity[i] {
+			// 相同字符数不超过两段共有的字符数，上限达不到阈值时不必逐字比较
+			if similarityBound(removedRunes[i], addedRunes[j]) < paragraphSimilarity {
+				continue
+			}
+			similarity[i][j] = textSimilarity(removed[i].Text, added[j].Text)
+		}
+	}
+	// score[i][j] 为前 i 个删除段与前 j 个新增段的最大相似度之和
+	score := make([][]float64, n+1)
+	for i := range score {
+		score[i] = make([]float64, m+1)
+	}
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			score[i][j] = max(score[i-1][j], score[i][j-1])
+			if similarity[i-1][j-1] >= paragraphSimilarity {
+				score[i][j] = max(score[i][j], score[i-1][j-1]+similarity[i-1][j-1])
+			}
+		}
+	}
+
+	// 回溯时优先取新增段，使同一位置的删除段排在新增段之前
+	var reversed []ParagraphDiff
+	for i, j := n, m; i > 0 || j > 0; {
+		switch {
+		case j > 0 && (i == 0 || score[i][j] == score[i][j-1]):
+			reversed = append(reversed, ParagraphDiff{Change: ParagraphAdded, NewID: added[j-1].ParagraphID, NewText: added[j-1].Text})
+			j--
+		case j == 0 || score[i][j] == score[i-1][j]:
+			reversed = append(reversed, ParagraphDiff{Change: ParagraphRemoved, OldID: removed[i-1].ParagraphID, OldText: removed[i-1].Text})
+			i--
+		default:
+			reversed = append(reversed, ParagraphDiff{
+				Change:   ParagraphModified,
+				OldID:    removed[i-1].ParagraphID,
+				NewID:    added[j-1].ParagraphID,
+				OldText:  removed[i-1].Text,
+				NewText:  added[j-1].Text,
+				Segments: DiffRunes(removed[i-1].Text, added[j-1].Text),
+			})
+			i--
+			j--
+		}
+	}
+
+	result := make([]ParagraphDiff, len(reversed))
+	for i, paragraph := range reversed {
+		result[len(reversed)-1-i] = paragraph
+	}
+	return result
+}
+
+// runeCounts 段落的字符数及各字符出现次数
+type runeCounts struct {
+	total  int
+	counts map[rune]int
+}
+
+// countRunes 统计段落中各字符出现的次数
+func countRunes(text string) runeCounts {
+	rc := runeCounts{counts: make(map[rune]int)}
+	for _, r := range text {
+		rc.counts[r]++
+		rc.total++
+	}
+	return rc
+}
+
+// similarityBound textSimilarity 的上限：2×两段共有字符数 / 两段总字符数
+// 先按长度比判断，长度相差过大时不再统计共有字符
+func similarityBound(a, b runeCounts) float64 {
+	total := a.total + b.total
+	if total == 0 {
+		return 1
+	}
+	if bound := float64(2*min(a.total, b.total)) / float64(total); bound < paragraphSimilarity {
+		return bound
+	}
+	if len(a.counts) > len(b.counts) {
+		a, b = b, a
+	}
+	shared := 0
+	for r, count := range a.counts {
+		shared += min(count, b.counts[r])
+	}
+	return float64(2*shared) / float64(total)
+}
+
+// textSimilarity 字符级相似度：2×相同字符数 / 两段总字符数
+func textSimilarity(a, b string) float64 {
+	oldRunes, newRunes := []rune(a), []rune(b)
+	if len(oldRunes)+len(newRunes) == 0 {
+		return 1
+	}
+	equal := 0
+	for _, op := range EditScript(oldRunes, newRunes) {
+		if op == DiffEqual {
+			equal++
+		}
+	}
+	return float64(2*equal) / float64(len(oldRunes)+len(newRunes))
+}
+
+// DiffRunes 按字符（而非按行或按词）比较两段文本，适用于不以空格分词的中文
+func DiffRunes(a, b string) []TextSegment {
+	oldRunes, newRunes := []rune(a), []rune(b)
+	var segments []TextSegment
+	i, j := 0, 0
+	for _, op := range EditScript(oldRunes, newRunes) {
+		var r rune
+		switch op {
+		case DiffEqual:
+			r = oldRunes[i]
+			i++
+			j++
+		case DiffDelete:
+			r = oldRunes[i]
+			i++
+		case DiffInsert:
+			r = newRunes[j]
+			j++
+		}
+		if last := len(segments) - 1; last >= 0 && segments[last].Op == op {
+			segments[last].Text += string(r)
+		} else {
+			segments = append(segments, TextSegment{Op: op, Text: string(r)})
+		}
+	}
+	return cleanupSegments(segments)
+}
+
+// cleanupSegments 把夹在修改之间的过短相同片段并入修改，并把每处修改整理为先删除后新增
+func cleanupSegments(segments []TextSegment) []TextSegment {
+	var result []TextSegment
+	var deleted, inserted strings.Builder
+	flush := func() {
+		if deleted.Len() > 0 {
+			result = append(result, TextSegment{Op: DiffDelete, Text: deleted.String()})
+		}
+		if inserted.Len() > 0 {
+			result = append(result, TextSegment{Op: DiffInsert, Text: inserted.String()})
+		}
+		deleted.Reset()
+		inserted.Reset()
+	}
+
+	for i, segment := range segments {
+		switch segment.Op {
+		case DiffDelete:
+			deleted.WriteString(segment.Text)
+		case DiffInsert:
+			inserted.WriteString(segment.Text)
+		default:
+			short := utf8.RuneCountInString(segment.Text) < minEqualRunes
+			if short && i > 0 && i < len(segments)-1 {
+				deleted.WriteString(segment.Text)
+				inserted.WriteString(segment.Text)
+				continue
+			}
+			flush()
+			result = append(result, segment)
+		}
+	}
+	flush()
+	return result
+}
+
+// ANSI 终端颜色
+const (
+	ansiReset  = "\033[0m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiStrike = "\033[9m"
+	ansiDim    = "\033[2m"
+	ansiBold   = "\033[1m"
+)
+
+// visibleParagraphs 标出需要显示的段落：修改的段落及其前后 context 段，context 小于0时全部显示
+func (d *ChapterDiff) visibleParagraphs(context int) []bool {
+	visible := make([]bool, len(d.Paragraphs))
+	for i, paragraph := range d.Paragraphs {
+		if context < 0 {
+			visible[i] = true
+			continue
+		}
+		if paragraph.Change == ParagraphUnchanged {
+			continue
+		}
+		for k := max(i-context, 0); k <= min(i+context, len(d.Paragraphs)-1); k++ {
+			visible[k] = true
+		}
+	}
+	return visible
+}
+
+// paragraphLabel 段落标签，如 "¶3" 或 "¶3→¶5"（修改后段落ID不同时）
+func (p *ParagraphDiff) paragraphLabel() string {
+	switch {
+	case p.OldID == 0:
+		return fmt.Sprintf("¶%d", p.NewID)
+	case p.NewID == 0 || p.NewID == p.OldID:
+		return fmt.Sprintf("¶%d", p.OldID)
+	default:
+		return fmt.Sprintf("¶%d→¶%d", p.OldID, p.NewID)
+	}
+}
+
+// Text 渲染为终端文本：color 为true时删除显示为红色删除线、新增显示为绿色，否则以 [-删除-]{+新增+} 标记；
+// context 为每处修改前后显示的未修改段落数，小于0时显示全部段落
+func (d *ChapterDiff) Text(color bool, context int) string {
+	paint := func(code, text string) string {
+		if !color || text == "" {
+			return text
+		}
+		return code + text + ansiReset
+	}
+	mark := func(op DiffOp, text string) string {
+		switch {
+		case op == DiffDelete && color:
+			return paint(ansiRed+ansiStrike, text)
+		case op == DiffDelete:
+			return "[-" + text + "-]"
+		case op == DiffInsert && color:
+			return paint(ansiGreen, text)
+		case op == DiffInsert:
+			return "{+" + text + "+}"
+		}
+		return text
+	}
+
+	var b strings.Builder
+	if d.OldName != "" || d.NewName != "" {
+		b.WriteString(paint(ansiBold, fmt.Sprintf("--- %s\n+++ %s", d.OldName, d.NewName)) + "\n")
+	}
+	if d.OldTitle != d.NewTitle {
+		fmt.Fprintf(&b, "标题: %s → %s\n", mark(DiffDelete, d.OldTitle), mark(DiffInsert, d.NewTitle))
+	}
+
+	visible := d.visibleParagraphs(context)
+	skipped := 0
+	for i, paragraph := range d.Paragraphs {
+		if !visible[i] {
+			skipped++
+			continue
+		}
+		if skipped > 0 {
+			b.WriteString(paint(ansiDim, fmt.Sprintf("  ……（省略 %d 段未修改内容）", skipped)) + "\n")
+			skipped = 0
+		}
+
+		label := paragraph.paragraphLabel()
+		switch paragraph.Change {
+		case ParagraphUnchanged:
+			fmt.Fprintf(&b, "  %s %s\n", paint(ansiDim, label), paragraph.NewText)
+		case ParagraphAdded:
+			fmt.Fprintf(&b, "%s %s\n", paint(ansiGreen, "+ "+label), mark(DiffInsert, paragraph.NewText))
+		case ParagraphRemoved:
+			fmt.Fprintf(&b, "%s %s\n", paint(ansiRed, "- "+label), mark(DiffDelete, paragraph.OldText))
+		case ParagraphModified:
+			var line strings.Builder
+			for _, segment := range paragraph.Segments {
+				line.WriteString(mark(segment.Op, segment.Text))
+			}
+			fmt.Fprintf(&b, "~ %s %s\n", label, line.String())
+		}
+	}
+	if skipped > 0 {
+		b.WriteString(paint(ansiDim, fmt.Sprintf("  ……（省略 %d 段未修改内容）", skipped)) + "\n")
+	}
+	b.WriteString(d.Summary() + "\n")
+	return b.String()
+}
+
+// diffCSS HTML 差异页面的样式
+const diffCSS = `body { font-family: "Noto Serif SC", "Source Han Serif SC", serif; line-height: 1.8; margin: 0 auto; max-width: 48em; padding: 1em; }
+header { border-bottom: 1px solid #ddd; margin-bottom: 1em; }
+.summary { color: #555; }
+p { margin: 0.6em 0; padding: 0.2em 0.5em; border-left: 3px solid transparent; }
+p .label { color: #999; font-size: 0.8em; margin-right: 0.5em; }
+p.unchanged { color: #666; }
+p.added { border-left-color: #2da44e; background: #e6ffec; }
+p.removed { border-left-color: #cf222e; background: #ffebe9; }
+p.modified { border-left-color: #bf8700; }
+del { background: #ffcecb; color: #82071e; }
+ins { background: #abf2bc; color: #116329; text-decoration: none; }
+.skipped { color: #999; text-align: center; }
+`
+
+// HTML 渲染为独立的 HTML 页面，context 含义与 Text 相同
+func (d *ChapterDiff) HTML(context int) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"zh-CN\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(firstNonEmpty(d.NewTitle, d.OldTitle, "章节差异")))
+	fmt.Fprintf(&b, "<style>\n%s</style>\n</head>\n<body>\n<header>\n", diffCSS)
+	if d.OldTitle != d.NewTitle {
+		fmt.Fprintf(&b, "<h1><del>%s</del> <ins>%s</ins></h1>\n", html.EscapeString(d.OldTitle), html.EscapeString(d.NewTitle))
+	} else {
+		fmt.Fprintf(&b, "<h1>%s</h1>\n", html.EscapeString(d.NewTitle))
+	}
+	if d.OldName != "" || d.NewName != "" {
+		fmt.Fprintf(&b, "<p class=\"summary\">%s → %s</p>\n", html.EscapeString(d.OldName), html.EscapeString(d.NewName))
+	}
+	fmt.Fprintf(&b, "<p class=\"summary\">%s</p>\n</header>\n", html.EscapeString(d.Summary()))
+
+	visible := d.visibleParagraphs(context)
+	skipped := 0
+	for i, paragraph := range d.Paragraphs {
+		if !visible[i] {
+			skipped++
+			continue
+		}
+		if skipped > 0 {
+			fmt.Fprintf(&b, "<div class=\"skipped\">……省略 %d 段未修改内容……</div>\n", skipped)
+			skipped = 0
+		}
+
+		label := html.EscapeString(paragraph.paragraphLabel())
+		switch paragraph.Change {
+		case ParagraphUnchanged:
+			fmt.Fprintf(&b, "<p class=\"unchanged\"><span class=\"label\">%s</span>%s</p>\n", label, html.EscapeString(paragraph.NewText))
+		case ParagraphAdded:
+			fmt.Fprintf(&b, "<p class=\"added\"><span class=\"label\">%s</span><ins>%s</ins></p>\n", label, html.EscapeString(paragraph.NewText))
+		case ParagraphRemoved:
+			fmt.Fprintf(&b, "<p class=\"removed\"><span class=\"label\">%s</span><del>%s</del></p>\n", label, html.EscapeString(paragraph.OldText))
+		case ParagraphModified:
+			fmt.Fprintf(&b, "<p class=\"modified\"><span class=\"label\">%s</span>", label)
+			for _, segment := range paragraph.Segments {
+				text := html.EscapeString(segment.Text)
+				switch segment.Op {
+				case DiffDelete:
+					b.WriteString("<del>" + text + "</del>")
+				case DiffInsert:
+					b.WriteString("<ins>" + text + "</ins>")
+				default:
+					b.WriteString(text)
+				}
+			}
+			b.WriteString("</p>\n")
+		}
+	}
+	if skipped > 0 {
+		fmt.Fprintf(&b, "<div class=\"skipped\">……省略 %d 段未修改内容……</div>\n", skipped)
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+// ChapterVersions 列出章节文件的历史版本（按时间顺序）
+func (cm *ChapterManager) ChapterVersions(chapterNum int) ([]*FileVersion, error) {
+	chapterPath := cm.GetChapterPath(chapterNum)
+	if chapterPath == "" {
+		return nil, fmt.Errorf("第%d章不存在", chapterNum)
+	}
+	return NewVersionStore(cm.novelDir).List(filepath.Base(chapterPath))
+}
+
+// DiffChapterVersions 比较章节的两个历史版本：toVersion 为0时与当前内容比较；
+// fromVersion 为0时取正文或标题与比较目标不同的最近一个版本，即上一次改写前的内容
+func (cm *ChapterManager) DiffChapterVersions(chapterNum, fromVersion, toVersion int) (*ChapterDiff, error) {
+	versions, err := cm.ChapterVersions(chapterNum)
+	if err != nil {
+		return nil, err
+	}
+	store := NewVersionStore(cm.novelDir)
+	load := func(id int) (*ChapterData, *FileVersion, error) {
+		for _, version := range versions {
+			if version.ID != id {
+				continue
+			}
+			data, err := store.Content(version)
+			if err != nil {
+				return nil, nil, err
+			}
+			var chapter ChapterData
+			if err := json.Unmarshal([]byte(data), &chapter); err != nil {
+				return nil, nil, fmt.Errorf("解析版本 #%d 失败: %w", id, err)
+			}
+			return &chapter, version, nil
+		}
+		return nil, nil, fmt.Errorf("版本 #%d 不是第%d章的历史版本", id, chapterNum)
+	}
+
+	var newChapter *ChapterData
+	newName := fmt.Sprintf("第%d章（当前）", chapterNum)
+	if toVersion == 0 {
+		if newChapter, err = cm.GetChapterData(chapterNum); err != nil {
+			return nil, err
+		}
+	} else {
+		var version *FileVersion
+		if newChapter, version, err = load(toVersion); err != nil {
+			return nil, err
+		}
+		newName = fmt.Sprintf("第%d章 #%d %s", chapterNum, version.ID, version.CreatedAt)
+	}
+
+	if fromVersion == 0 {
+		for i := len(versions) - 1; i >= 0 && fromVersion == 0; i-- {
+			if toVersion != 0 && versions[i].ID >= toVersion {
+				continue
+			}
+			chapter, _, err := load(versions[i].ID)
+			if err != nil {
+				return nil, err
+			}
+			if chapter.Title != newChapter.Title || chapter.GetText() != newChapter.GetText() {
+				fromVersion = versions[i].ID
+			}
+		}
+		if fromVersion == 0 {
+			return nil, fmt.Errorf("第%d章没有内容不同的历史版本", chapterNum)
+		}
+	}
+	oldChapter, version, err := load(fromVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := DiffChapters(oldChapter, newChapter)
+	diff.OldName = fmt.Sprintf("第%d章 #%d %s", chapterNum, version.ID, version.CreatedAt)
+	diff.NewName = newName
+	return diff, nil
+}
@@ -0,0 +1,100 @@
+package managers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func changes(diff *ChapterDiff) []ParagraphChange {
+	result := make([]ParagraphChange, 0, len(diff.Paragraphs))
+	for _, paragraph := range diff.Paragraphs {
+		result = append(result, paragraph.Change)
+	}
+	return result
+}
+
+func TestDiffChapterTexts(t *testing.T) {
+	oldText := "海浪拍打着礁石。\n\n林凡睁开眼，看见灰蒙蒙的天空。\n\n远处传来鸟叫。\n\n他站了起来。"
+	newText := "海浪拍打着礁石。\n\n林凡猛地睁开眼，看见灰蒙蒙的天空。\n\n一只螃蟹从他手边爬过。\n\n他站了起来。"
+
+	diff := DiffChapterTexts(oldText, newText)
+	assert.True(t, diff.Changed())
+	assert.Equal(t, []ParagraphChange{ParagraphUnchanged, ParagraphModified, ParagraphRemoved, ParagraphAdded, ParagraphUnchanged}, changes(diff))
+	assert.Equal(t, []TextSegment{
+		{Op: DiffEqual, Text: "林凡"},
+		{Op: DiffInsert, Text: "猛地"},
+		{Op: DiffEqual, Text: "睁开眼，看见灰蒙蒙的天空。"},
+	}, diff.Paragraphs[1].Segments)
+	assert.Equal(t, 2+CountChapterChars("一只螃蟹从他手边爬过。"), diff.Added)
+	assert.Equal(t, CountChapterChars("远处传来鸟叫。"), diff.Removed)
+
+	text := diff.Text(false, 0)
+	assert.Contains(t, text, "~ ¶2 林凡{+猛地+}睁开眼")
+	assert.Contains(t, text, "- ¶3 [-远处传来鸟叫。-]")
+	assert.Contains(t, text, "……（省略 1 段未修改内容）")
+	assert.NotContains(t, text, "海浪拍打着礁石")
+	assert.Contains(t, diff.Text(true, -1), "\033[32m猛地\033[0m")
+
+	page := diff.HTML(-1)
+	assert.Contains(t, page, "林凡<ins>猛地</ins>睁开眼")
+	assert.Contains(t, page, "<del>远处传来鸟叫。</del>")
+
+	assert.False(t, DiffChapterTexts(oldText, oldText).Changed())
+
+	// 夹在修改之间的单个相同字符并入修改
+	assert.Equal(t, []TextSegment{
+		{Op: DiffDelete, Text: "他走得慢"},
+		{Op: DiffInsert, Text: "她跑得快"},
+		{Op: DiffEqual, Text: "。"},
+	}, DiffRunes("他走得慢。", "她跑得快。"))
+	assert.Equal(t, []TextSegment{
+		{Op: DiffEqual, Text: "天"},
+		{Op: DiffDelete, Text: "色很暗"},
+		{Op: DiffInsert, Text: "空很亮"},
+	}, DiffRunes("天色很暗", "天空很亮"))
+}
+
+func TestDiffChapterVersions(t *testing.T) {
+	dir := t.TempDir()
+	chapterManager := NewChapterManager(dir)
+	id, _, err := chapterManager.CreateChapter("醒来", "海浪拍打着礁石。\n\n林凡睁开眼。")
+	require.NoError(t, err)
+	require.NoError(t, chapterManager.UpdateChapter(id, "醒来", "海浪拍打着礁石。\n\n林凡缓缓睁开眼。"))
+	// 只修改元数据的版本不会被当作上一次改写
+	_, err = chapterManager.UpdateChapterMeta(id, &ChapterMeta{Status: ChapterStatusRevised})
+	require.NoError(t, err)
+
+	versions, err := chapterManager.ChapterVersions(id)
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+
+	diff, err := chapterManager.DiffChapterVersions(id, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []ParagraphChange{ParagraphUnchanged, ParagraphModified}, changes(diff))
+	assert.True(t, strings.HasPrefix(diff.OldName, "第1章 #1 "))
+	assert.Equal(t, "第1章（当前）", diff.NewName)
+
+	diff, err = chapterManager.DiffChapterVersions(id, versions[1].ID, versions[2].ID)
+	require.NoError(t, err)
+	assert.False(t, diff.Changed())
+
+	_, err = chapterManager.DiffChapterVersions(id, 99, 0)
+	assert.Error(t, err)
+}
+
+// 相似度上限不低于实际相似度，达不到阈值的配对可以跳过逐字比较
+func TestSimilarityBound(t *testing.T) {
+	pairs := [][2]string{
+		{"林凡睁开眼，看见灰蒙蒙的天空。", "林凡猛地睁开眼，看见灰蒙蒙的天空。"},
+		{"远处传来鸟叫。", "一只螃蟹从他手边爬过。"},
+		{"他站了起来。", "他站了起来，拍掉身上的沙子，朝着远处的树林走去。"},
+		{"", ""},
+	}
+	for _, pair := range pairs {
+		assert.GreaterOrEqual(t, similarityBound(countRunes(pair[0]), countRunes(pair[1])), textSimilarity(pair[0], pair[1]), pair)
+	}
+	assert.Less(t, similarityBound(countRunes("好"), countRunes(strings.Repeat("好", 10))), paragraphSimilarity)
+}
@@ -0,0 +1,240 @@
+package managers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChapterManifestFile 章节清单文件名，记录章节ID、文件和阅读顺序
+const ChapterManifestFile = "chapters.json"
+
+// DefaultChapterFilePattern 默认的章节文件名格式
+const DefaultChapterFilePattern = "example_chapter_%d.json"
+
+// chapterVerbPattern 文件名格式中的章节号占位符，只允许 %d 或补零的 %0Nd（如 %03d）
+// 不补零的宽度（如 %3d）会在文件名中填充空格，不予接受
+var chapterVerbPattern = regexp.MustCompile(`%(0[1-9])?d`)
+
+// chapterNaming 章节文件命名规则
+type chapterNaming struct {
+	pattern string
+	match   *regexp.Regexp
+}
+
+// defaultChapterNaming 默认命名规则，按此规则命名的旧章节文件总能被识别
+var defaultChapterNaming, _ = parseChapterFilePattern(DefaultChapterFilePattern)
+
+// parseChapterFilePattern 解析章节文件名格式，格式中必须有且只有一个章节号占位符（%d 或 %0Nd）
+func parseChapterFilePattern(pattern string) (*chapterNaming, error) {
+	if strings.ContainsAny(pattern, `/\`) {
+		return nil, fmt.Errorf("章节文件名格式不能包含路径分隔符: %s", pattern)
+	}
+	verbs := chapterVerbPattern.FindAllStringIndex(pattern, -1)
+	if len(verbs) != 1 || strings.Count(pattern, "%") != 1 {
+		return nil, fmt.Errorf("章节文件名格式必须包含且只包含一个章节号占位符（%%d 或补零的 %%0Nd，如 %%03d）: %s", pattern)
+	}
+
+	start, end := verbs[0][0], verbs[0][1]
+	expr := "^" + regexp.QuoteMeta(pattern[:start]) + `(\d+)` + regexp.QuoteMeta(pattern[end:]) + "$"
+	return &chapterNaming{pattern: pattern, match: regexp.MustCompile(expr)}, nil
+}
+
+// ValidateChapterFilePattern 检查章节文件名格式是否有效
+func ValidateChapterFilePattern(pattern string) error {
+	_, err := parseChapterFilePattern(pattern)
+	return err
+}
+
+// fileName 章节ID对应的文件名
+func (n *chapterNaming) fileName(id int) string {
+	return fmt.Sprintf(n.pattern, id)
+}
+
+// chapterID 从文件名中解析章节ID
+func (n *chapterNaming) chapterID(fileName string) (int, bool) {
+	matches := n.match.FindStringSubmatch(fileName)
+	if len(matches) != 2 {
+		return 0, false
+	}
+	id, err := strconv.Atoi(matches[1])
+	return id, err == nil && id > 0
+}
+
+// ChapterEntry 清单中的一个章节
+type ChapterEntry struct {
+	ID    int    `json:"id"`
+	File  string `json:"file"`
+	Title string `json:"title,omitempty"`
+}
+
+// ChapterManifest 章节清单，Chapters 按阅读顺序排列
+// 章节ID只增不减，删除章节后ID不会被复用，因此ID与文件名始终一一对应
+type ChapterManifest struct {
+	Chapters    []*ChapterEntry `json:"chapters"`
+	NextID      int             `json:"next_id"`
+	FilePattern string          `json:"file_pattern,omitempty"` // 新章节的文件名格式，为空时使用默认格式
+	UpdatedAt   string          `json:"updated_at"`
+}
+
+// Find 按ID查找章节
+func (m *ChapterManifest) Find(id int) (*ChapterEntry, bool) {
+	for _, entry := range m.Chapters {
+		if entry.ID == id {
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+// IDs 按阅读顺序返回所有章节ID
+func (m *ChapterManifest) IDs() []int {
+	ids := make([]int, 0, len(m.Chapters))
+	for _, entry := range m.Chapters {
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
+
+// Latest 阅读顺序中的最后一章
+func (m *ChapterManifest) Latest() (*ChapterEntry, bool) {
+	if len(m.Chapters) == 0 {
+		return nil, false
+	}
+	return m.Chapters[len(m.Chapters)-1], true
+}
+
+// reserveID 分配新章节ID
+func (m *ChapterManifest) reserveID() int {
+	id := m.NextID
+	for _, entry := range m.Chapters {
+		if entry.ID >= id {
+			id = entry.ID + 1
+		}
+	}
+	if id <= 0 {
+		id = 1
+	}
+	m.NextID = id + 1
+	return id
+}
+
+// put 新增或更新章节；新章节插入到第一个ID更大的章节之前，通常即追加到末尾
+func (m *ChapterManifest) put(entry *ChapterEntry) {
+	if existing, ok := m.Find(entry.ID); ok {
+		existing.File, existing.Title = entry.File, entry.Title
+		return
+	}
+
+	position := len(m.Chapters)
+	for i, other := range m.Chapters {
+		if other.ID > entry.ID {
+			position = i
+			break
+		}
+	}
+	m.Chapters = append(m.Chapters, nil)
+	copy(m.Chapters[position+1:], m.Chapters[position:])
+	m.Chapters[position] = entry
+	if entry.ID >= m.NextID {
+		m.NextID = entry.ID + 1
+	}
+}
+
+// decodeManifest 解析章节清单
+func decodeManifest(data string) (*ChapterManifest, error) {
+	manifest := &ChapterManifest{}
+	if strings.TrimSpace(data) == "" {
+		return manifest, nil
+	}
+	if err := json.Unmarshal([]byte(data), manifest); err != nil {
+		return nil, fmt.Errorf("解析章节清单失败: %w", err)
+	}
+	return manifest, nil
+}
+
+// encodeManifest 序列化章节清单并更新时间戳
+func encodeManifest(manifest *ChapterManifest) (string, error) {
+	manifest.UpdatedAt = time.Now().Format(time.RFC3339)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("序列化章节清单失败: %w", err)
+	}
+	return string(data), nil
+}
+
+// 章节校验发现的问题类型
+const (
+	ChapterIssueGap       = "gap"         // 章节ID不连续
+	ChapterIssueDuplicate = "duplicate"   // 同一章节ID对应多个文件，或多个章节指向同一文件
+	ChapterIssueMissing   = "missing"     // 清单中的章节文件不存在
+	ChapterIssueUntracked = "untracked"   // 章节文件不在清单中
+	ChapterIssueMismatch  = "id_mismatch" // 文件内的 chapter_id 与清单不一致
+	ChapterIssueInvalid   = "invalid"     // 章节文件无法解析
+)
+
+// ChapterIssue 章节校验发现的一个问题
+type ChapterIssue struct {
+	Type      string   `json:"type"`
+	ChapterID int      `json:"chapter_id,omitempty"`
+	Files     []string `json:"files,omitempty"`
+	Message   string   `json:"message"`
+}
+
+// ChapterValidation 章节校验结果
+type ChapterValidation struct {
+	ManifestExists bool            `json:"manifest_exists"`
+	Chapters       int             `json:"chapters"`
+	Issues         []*ChapterIssue `json:"issues"`
+}
+
+// OK 是否没有发现问题
+func (v *ChapterValidation) OK() bool {
+	return len(v.Issues) == 0
+}
+
+// add 记录一个问题
+func (v *ChapterValidation) add(issueType string, id int, files []string, format string, args ...any) {
+	v.Issues = append(v.Issues, &ChapterIssue{Type: issueType, ChapterID: id, Files: files, Message: fmt.Sprintf(format, args...)})
+}
+
+// FormatChapterIDs 将章节ID列表格式化为紧凑的区间表示，如 "1-3, 5, 8-9"
+func FormatChapterIDs(ids []int) string {
+	sorted := append([]int(nil), ids...)
+	sort.Ints(sorted)
+
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(sorted[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fileModTime 文件的修改时间，文件不存在时返回零值
+func fileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// fileExists 检查文件是否存在
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,140 @@
+package managers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChapterManifest(t *testing.T) {
+	dir := t.TempDir()
+	cm := NewChapterManager(dir)
+
+	// 旧目录：没有清单，按文件推断
+	_, err := cm.WriteChapterWithID("1", "第一章", "正文一")
+	assert.NoError(t, err)
+	_, err = cm.WriteChapterWithID("2", "第二章", "正文二")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, cm.GetChapterIDs())
+
+	// 删除第2章后新章节不会复用ID
+	assert.NoError(t, os.Remove(cm.GetChapterPath(2)))
+	assert.NoError(t, cm.SetFilePattern("chapter_%03d.json"))
+	id, path, err := cm.CreateChapter("第三章", "正文三")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, id)
+	assert.Equal(t, "chapter_003.json", filepath.Base(path))
+	assert.Equal(t, 3, cm.GetLatestChapterID())
+	assert.Equal(t, "example_chapter_1.json", filepath.Base(cm.GetChapterPath(1)))
+
+	validation, err := cm.Validate()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ChapterIssueMissing}, issueTypes(validation))
+
+	// 重建后只剩缺号
+	_, err = cm.RebuildManifest()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, cm.GetChapterIDs())
+	validation, err = cm.Validate()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ChapterIssueGap}, issueTypes(validation))
+
+	// 同一章节的两种命名，以及不在清单中的文件
+	data, _ := os.ReadFile(cm.GetChapterPath(1))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "chapter_001.json"), data, 0644))
+	validation, err = cm.Validate()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{ChapterIssueGap, ChapterIssueUntracked, ChapterIssueDuplicate}, issueTypes(validation))
+
+	assert.Error(t, cm.SetFilePattern("chapter.json"))
+	assert.Equal(t, "1-3, 5", FormatChapterIDs([]int{5, 1, 2, 3}))
+}
+
+// 指定的文件名格式写入清单，之后未指定格式的管理器沿用；没有清单时扫描结果在目录变化前复用
+func TestChapterFilePatternOption(t *testing.T) {
+	dir := t.TempDir()
+	cm := NewChapterManager(dir, WithChapterFilePattern("chapter_%03d.json"))
+	_, path, err := cm.CreateChapter("第一章", "正文一")
+	assert.NoError(t, err)
+	assert.Equal(t, "chapter_001.json", filepath.Base(path))
+
+	other := NewChapterManager(dir)
+	assert.Equal(t, "chapter_%03d.json", other.GetFilePattern())
+	_, path, err = other.CreateChapter("第二章", "正文二")
+	assert.NoError(t, err)
+	assert.Equal(t, "chapter_002.json", filepath.Base(path))
+
+	// 删除清单后按文件推断，目录变化后重新扫描
+	assert.NoError(t, os.Remove(cm.GetManifestPath()))
+	scanner := NewChapterManager(dir, WithChapterFilePattern("chapter_%03d.json"))
+	first, err := scanner.LoadManifest()
+	assert.NoError(t, err)
+	second, err := scanner.LoadManifest()
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, []int{1, 2}, first.IDs())
+
+	assert.NoError(t, os.Remove(filepath.Join(dir, "chapter_002.json")))
+	assert.Equal(t, []int{1}, scanner.GetChapterIDs())
+}
+
+func issueTypes(validation *ChapterValidation) []string {
+	types := make([]string, 0, len(validation.Issues))
+	for _, issue := range validation.Issues {
+		types = append(types, issue.Type)
+	}
+	return types
+}
+
+func TestChapterMeta(t *testing.T) {
+	dir := t.TempDir()
+	cm := NewChapterManager(dir)
+
+	cm.SetChapterMeta(&ChapterMeta{POV: "林凡", Locations: []string{"溪边", "山洞"}, Model: "deepseek-chat", PromptHash: HashPrompt("提示词")})
+	id, _, err := cm.CreateChapter("第一章", "林凡醒来。\n\n他走向 溪边。")
+	assert.NoError(t, err)
+
+	meta, err := cm.GetChapterMeta(id)
+	assert.NoError(t, err)
+	assert.Equal(t, ChapterStatusDraft, meta.Status)
+	assert.Equal(t, 11, meta.CharCount)
+	assert.NotEmpty(t, meta.CreatedAt)
+	createdAt := meta.CreatedAt
+
+	// 更新正文时保留已有元数据
+	cm.SetChapterMeta(nil)
+	assert.NoError(t, cm.UpdateChapter(id, "第一章", "林凡醒来。"))
+	meta, _ = cm.GetChapterMeta(id)
+	assert.Equal(t, "林凡", meta.POV)
+	assert.Equal(t, "deepseek-chat", meta.Model)
+	assert.Equal(t, createdAt, meta.CreatedAt)
+	assert.Equal(t, 5, meta.CharCount)
+
+	// 只更新元数据
+	meta, err = cm.UpdateChapterMeta(id, &ChapterMeta{Status: ChapterStatusFinal, StoryTime: "第三天 黄昏"})
+	assert.NoError(t, err)
+	assert.Equal(t, ChapterStatusFinal, meta.Status)
+	assert.Equal(t, []string{"溪边", "山洞"}, meta.Locations)
+	content, _ := cm.GetChapterContent(id)
+	assert.Equal(t, "林凡醒来。", content)
+
+	assert.True(t, ChapterFilter{Status: ChapterStatusFinal, Locations: []string{"山洞"}}.Match(meta))
+	// 多个地点时出现任一地点即满足
+	assert.True(t, ChapterFilter{Locations: []string{"营地", "溪边"}}.Match(meta))
+	assert.False(t, ChapterFilter{Locations: []string{"营地", "城池"}}.Match(meta))
+	assert.False(t, ChapterFilter{POV: "苏瑶"}.Match(meta))
+	assert.True(t, ChapterFilter{Status: ChapterStatusDraft}.Match(nil))
+	assert.Equal(t, []string{"溪边", "山洞", "营地"}, SplitLocations("溪边、山洞, 营地"))
+}
+
+func TestValidateChapterFilePattern(t *testing.T) {
+	for _, pattern := range []string{"example_chapter_%d.json", "chapter_%03d.json", "%02d.md"} {
+		assert.NoError(t, ValidateChapterFilePattern(pattern), pattern)
+	}
+	// 不补零的宽度会填充空格，多个或缺少占位符、包含路径分隔符均无效
+	for _, pattern := range []string{"chapter_%3d.json", "chapter_%00d.json", "chapter_%s.json", "chapter.json", "%d_%d.json", "ch/%d.json", "%d%%.json"} {
+		assert.Error(t, ValidateChapterFilePattern(pattern), pattern)
+	}
+}
@@ -0,0 +1,168 @@
+package managers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 章节状态
+const (
+	ChapterStatusDraft   = "draft"   // 初稿
+	ChapterStatusRevised = "revised" // 已修订
+	ChapterStatusFinal   = "final"   // 定稿
+)
+
+// ValidChapterStatus 检查章节状态是否有效
+func ValidChapterStatus(status string) bool {
+	return status == ChapterStatusDraft || status == ChapterStatusRevised || status == ChapterStatusFinal
+}
+
+// ChapterMeta 章节元数据，全部字段可选；写入章节时与文件中已有的元数据合并
+type ChapterMeta struct {
+	POV        string   `json:"pov,omitempty"`         // 视角人物
+	StoryTime  string   `json:"story_time,omitempty"`  // 故事内的日期/时间，如 "第三天 黄昏"
+	Locations  []string `json:"locations,omitempty"`   // 本章出现的地点
+	Status     string   `json:"status,omitempty"`      // draft/revised/final
+	Model      string   `json:"model,omitempty"`       // 生成本章的模型
+	PromptHash string   `json:"prompt_hash,omitempty"` // 生成时使用的提示词模板的哈希，用于区分提示词版本
+	CreatedAt  string   `json:"created_at,omitempty"`
+	UpdatedAt  string   `json:"updated_at,omitempty"`
+	CharCount  int      `json:"char_count"` // 正文字数（不含空白字符）
+}
+
+// merge 用 patch 中非空的字段覆盖当前值
+func (m *ChapterMeta) merge(patch *ChapterMeta) {
+	if patch == nil {
+		return
+	}
+	m.POV = firstNonEmpty(patch.POV, m.POV)
+	m.StoryTime = firstNonEmpty(patch.StoryTime, m.StoryTime)
+	m.Status = firstNonEmpty(patch.Status, m.Status)
+	m.Model = firstNonEmpty(patch.Model, m.Model)
+	m.PromptHash = firstNonEmpty(patch.PromptHash, m.PromptHash)
+	if len(patch.Locations) > 0 {
+		m.Locations = append([]string(nil), patch.Locations...)
+	}
+}
+
+// HasLocation 本章是否出现了该地点（包含匹配）
+func (m *ChapterMeta) HasLocation(location string) bool {
+	for _, candidate := range m.Locations {
+		if strings.Contains(candidate, location) {
+			return true
+		}
+	}
+	return false
+}
+
+// ChapterFilter 按元数据筛选章节，空字段表示不限制
+type ChapterFilter struct {
+	Status    string
+	POV       string
+	Locations []string // 章节出现其中任一地点即满足
+}
+
+// Empty 是否没有任何筛选条件
+func (f ChapterFilter) Empty() bool {
+	return f.Status == "" && f.POV == "" && len(f.Locations) == 0
+}
+
+// Match 元数据是否满足筛选条件；没有元数据的旧章节视为初稿
+func (f ChapterFilter) Match(meta *ChapterMeta) bool {
+	if meta == nil {
+		meta = &ChapterMeta{Status: ChapterStatusDraft}
+	}
+	if f.Status != "" && f.Status != meta.Status {
+		return false
+	}
+	if f.POV != "" && f.POV != meta.POV {
+		return false
+	}
+	if len(f.Locations) == 0 {
+		return true
+	}
+	for _, location := range f.Locations {
+		if meta.HasLocation(location) {
+			return true
+		}
+	}
+	return false
+}
+
+// CountChapterChars 统计正文字数，不计空白字符；中文按字计数
+func CountChapterChars(text string) int {
+	count := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			count++
+		}
+	}
+	return count
+}
+
+// HashPrompt 计算提示词的短哈希
+func HashPrompt(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:8])
+}
+
+// SplitLocations 拆分以逗号、顿号或分号分隔的地点列表
+func SplitLocations(value string) []string {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == '，' || r == '、' || r == ';' || r == '；'
+	})
+	locations := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			locations = append(locations, field)
+		}
+	}
+	return locations
+}
+
+// chapterMetaKey context 中章节元数据的键
+type chapterMetaKey struct{}
+
+// WithChapterMeta 返回携带章节元数据的 context，工作流用它把模型和提示词哈希传给写章节的工具
+func WithChapterMeta(ctx context.Context, meta *ChapterMeta) context.Context {
+	return context.WithValue(ctx, chapterMetaKey{}, meta)
+}
+
+// ChapterMetaFromContext 读取 context 中的章节元数据，返回副本；未设置时返回空元数据
+func ChapterMetaFromContext(ctx context.Context) *ChapterMeta {
+	meta := &ChapterMeta{}
+	if ctx != nil {
+		if value, ok := ctx.Value(chapterMetaKey{}).(*ChapterMeta); ok && value != nil {
+			meta.merge(value)
+		}
+	}
+	return meta
+}
+
+// buildChapterMeta 生成写入文件的元数据：在已有元数据上合并 patch，更新时间戳和字数
+// existing 为nil表示新章节；createdAt 为已有文件没有元数据时推断的创建时间
+func buildChapterMeta(existing, patch *ChapterMeta, createdAt time.Time, text string) *ChapterMeta {
+	meta := &ChapterMeta{}
+	if existing != nil {
+		*meta = *existing
+	}
+	meta.merge(patch)
+
+	now := time.Now()
+	if meta.CreatedAt == "" {
+		if createdAt.IsZero() {
+			createdAt = now
+		}
+		meta.CreatedAt = createdAt.Format(time.RFC3339)
+	}
+	if meta.Status == "" {
+		meta.Status = ChapterStatusDraft
+	}
+	meta.UpdatedAt = now.Format(time.RFC3339)
+	meta.CharCount = CountChapterChars(text)
+	return meta
+}
@@ -0,0 +1,240 @@
+package managers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// 段落编辑操作
+const (
+	ParagraphInsertAfter = "insert_after"
+	ParagraphReplace     = "replace"
+	ParagraphDelete      = "delete"
+	ParagraphMove        = "move"
+)
+
+// ValidParagraphOp 检查段落编辑操作是否合法
+func ValidParagraphOp(op string) bool {
+	switch op {
+	case ParagraphInsertAfter, ParagraphReplace, ParagraphDelete, ParagraphMove:
+		return true
+	}
+	return false
+}
+
+// ChapterParagraph 章节中的一段；ParagraphID 在章节内唯一，段落编辑不会改变其他段落的ID
+type ChapterParagraph struct {
+	ParagraphID int    `json:"paragraph_id"`
+	Text        string `json:"text"`
+}
+
+// ParagraphEdit 一次段落编辑
+type ParagraphEdit struct {
+	Op          string
+	ParagraphID int    // 要替换、删除或移动的段落；insert_after 时为插入位置，0 表示插到开头
+	AfterID     int    // move 的目标位置：移到该段之后，0 表示移到开头
+	Text        string // insert_after/replace 的新内容，包含空行时拆分为多段
+}
+
+// splitParagraphs 按空行拆分段落，去掉空段落
+func splitParagraphs(content string) []string {
+	var paragraphs []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(content), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	return paragraphs
+}
+
+// FindParagraph 返回段落在章节中的位置
+func (cd *ChapterData) FindParagraph(id int) (int, bool) {
+	for i, paragraph := range cd.Content {
+		if paragraph.ParagraphID == id {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// nextParagraphID 新段落的ID，总是大于章节中已有的ID
+func (cd *ChapterData) nextParagraphID() int {
+	next := 1
+	for _, paragraph := range cd.Content {
+		if paragraph.ParagraphID >= next {
+			next = paragraph.ParagraphID + 1
+		}
+	}
+	return next
+}
+
+// reuseParagraphIDs 整章改写时为新段落分配ID：与原有段落文本相同的段落沿用原ID（按顺序一一对应），
+// 其余段落从原有最大ID之后继续编号
+func (cd *ChapterData) reuseParagraphIDs(texts []string) []ChapterParagraph {
+	existing := make(map[string][]int)
+	for _, paragraph := range cd.Content {
+		if paragraph.ParagraphID > 0 {
+			existing[paragraph.Text] = append(existing[paragraph.Text], paragraph.ParagraphID)
+		}
+	}
+
+	next := cd.nextParagraphID()
+	used := make(map[int]bool)
+	paragraphs := make([]ChapterParagraph, 0, len(texts))
+	for _, text := range texts {
+		id := 0
+		for len(existing[text]) > 0 && id == 0 {
+			if candidate := existing[text][0]; !used[candidate] {
+				id = candidate
+			}
+			existing[text] = existing[text][1:]
+		}
+		if id == 0 {
+			id = next
+			next++
+		}
+		used[id] = true
+		paragraphs = append(paragraphs, ChapterParagraph{ParagraphID: id, Text: text})
+	}
+	return paragraphs
+}
+
+// normalizeParagraphIDs 为缺少ID或ID重复的段落分配新ID，保证段落可以按ID定位
+func (cd *ChapterData) normalizeParagraphIDs() {
+	seen := make(map[int]bool, len(cd.Content))
+	for i := range cd.Content {
+		id := cd.Content[i].ParagraphID
+		if id <= 0 || seen[id] {
+			cd.Content[i].ParagraphID = cd.nextParagraphID()
+		}
+		seen[cd.Content[i].ParagraphID] = true
+	}
+}
+
+// insertParagraphs 在指定位置插入段落并分配新ID，返回新段落的ID
+func (cd *ChapterData) insertParagraphs(index int, texts []string) []int {
+	ids := make([]int, 0, len(texts))
+	inserted := make([]ChapterParagraph, 0, len(texts))
+	next := cd.nextParagraphID()
+	for i, text := range texts {
+		inserted = append(inserted, ChapterParagraph{ParagraphID: next + i, Text: text})
+		ids = append(ids, next+i)
+	}
+	content := append([]ChapterParagraph{}, cd.Content[:index]...)
+	content = append(content, inserted...)
+	cd.Content = append(content, cd.Content[index:]...)
+	return ids
+}
+
+// positionAfter 插入到指定段落之后的位置，afterID 为0时为开头
+func (cd *ChapterData) positionAfter(afterID int) (int, error) {
+	if afterID == 0 {
+		return 0, nil
+	}
+	index, ok := cd.FindParagraph(afterID)
+	if !ok {
+		return 0, fmt.Errorf("段落不存在: %d", afterID)
+	}
+	return index + 1, nil
+}
+
+// ApplyParagraphEdit 执行一次段落编辑，返回受影响段落的ID（新增、替换后、删除或移动的段落）
+func (cd *ChapterData) ApplyParagraphEdit(edit ParagraphEdit) ([]int, error) {
+	cd.normalizeParagraphIDs()
+
+	switch edit.Op {
+	case ParagraphInsertAfter:
+		texts := splitParagraphs(edit.Text)
+		if len(texts) == 0 {
+			return nil, fmt.Errorf("插入段落需要提供内容")
+		}
+		index, err := cd.positionAfter(edit.ParagraphID)
+		if err != nil {
+			return nil, err
+		}
+		return cd.insertParagraphs(index, texts), nil
+
+	case ParagraphReplace:
+		texts := splitParagraphs(edit.Text)
+		if len(texts) == 0 {
+			return nil, fmt.Errorf("替换段落需要提供内容，删除段落请使用 delete")
+		}
+		index, ok := cd.FindParagraph(edit.ParagraphID)
+		if !ok {
+			return nil, fmt.Errorf("段落不存在: %d", edit.ParagraphID)
+		}
+		// 第一段沿用原ID，多出的段落紧随其后
+		cd.Content[index].Text = texts[0]
+		return append([]int{edit.ParagraphID}, cd.insertParagraphs(index+1, texts[1:])...), nil
+
+	case ParagraphDelete:
+		index, ok := cd.FindParagraph(edit.ParagraphID)
+		if !ok {
+			return nil, fmt.Errorf("段落不存在: %d", edit.ParagraphID)
+		}
+		if len(cd.Content) == 1 {
+			return nil, fmt.Errorf("不能删除章节的最后一个段落")
+		}
+		cd.Content = append(cd.Content[:index], cd.Content[index+1:]...)
+		return []int{edit.ParagraphID}, nil
+
+	case ParagraphMove:
+		index, ok := cd.FindParagraph(edit.ParagraphID)
+		if !ok {
+			return nil, fmt.Errorf("段落不存在: %d", edit.ParagraphID)
+		}
+		if edit.AfterID == edit.ParagraphID {
+			return nil, fmt.Errorf("不能把段落移动到自身之后")
+		}
+		paragraph := cd.Content[index]
+		cd.Content = append(cd.Content[:index], cd.Content[index+1:]...)
+		target, err := cd.positionAfter(edit.AfterID)
+		if err != nil {
+			cd.Content = append(cd.Content[:index], append([]ChapterParagraph{paragraph}, cd.Content[index:]...)...)
+			return nil, err
+		}
+		cd.Content = append(cd.Content[:target], append([]ChapterParagraph{paragraph}, cd.Content[target:]...)...)
+		return []int{edit.ParagraphID}, nil
+
+	default:
+		return nil, fmt.Errorf("未知的段落操作: %s（可选 insert_after/replace/delete/move）", edit.Op)
+	}
+}
+
+// EditParagraphs 按顺序执行段落编辑并保存，任一编辑失败时章节不做任何修改；返回所有受影响段落的ID
+func (cm *ChapterManager) EditParagraphs(chapterNum int, edits ...ParagraphEdit) ([]int, error) {
+	chapterPath := cm.GetChapterPath(chapterNum)
+	if chapterPath == "" {
+		return nil, os.ErrNotExist
+	}
+
+	var affected []int
+	chapterFile := NewBaseFileManager(chapterPath)
+	chapterFile.SetVersionInfo(cm.versionInfo)
+	err := chapterFile.Modify(func(current string) (string, error) {
+		var chapter ChapterData
+		if err := json.Unmarshal([]byte(current), &chapter); err != nil {
+			return "", err
+		}
+		for _, edit := range edits {
+			ids, err := chapter.ApplyParagraphEdit(edit)
+			if err != nil {
+				return "", err
+			}
+			affected = append(affected, ids...)
+		}
+		chapter.Meta = buildChapterMeta(chapter.Meta, cm.meta, fileModTime(chapterPath), chapter.GetText())
+
+		jsonData, err := json.MarshalIndent(chapter, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(jsonData), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return affected, nil
+}
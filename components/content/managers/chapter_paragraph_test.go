@@ -0,0 +1,75 @@
+package managers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func paragraphIDs(chapter *ChapterData) []int {
+	ids := make([]int, 0, len(chapter.Content))
+	for _, paragraph := range chapter.Content {
+		ids = append(ids, paragraph.ParagraphID)
+	}
+	return ids
+}
+
+func TestChapterParagraphEdits(t *testing.T) {
+	dir := t.TempDir()
+	chapterManager := NewChapterManager(dir)
+	id, _, err := chapterManager.CreateChapter("醒来", "海浪拍打着礁石。\n\n林凡睁开眼。\n\n远处传来鸟叫。")
+	require.NoError(t, err)
+
+	ids, err := chapterManager.EditParagraphs(id, ParagraphEdit{Op: ParagraphInsertAfter, ParagraphID: 1, Text: "他浑身湿透。\n\n嘴里满是咸味。"})
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 5}, ids)
+
+	ids, err = chapterManager.EditParagraphs(id,
+		ParagraphEdit{Op: ParagraphReplace, ParagraphID: 2, Text: "林凡猛地睁开眼。"},
+		ParagraphEdit{Op: ParagraphMove, ParagraphID: 3, AfterID: 0},
+		ParagraphEdit{Op: ParagraphDelete, ParagraphID: 5},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 3, 5}, ids)
+
+	data, err := chapterManager.GetChapterData(id)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 1, 4, 2}, paragraphIDs(data))
+	assert.Equal(t, "远处传来鸟叫。\n\n海浪拍打着礁石。\n\n他浑身湿透。\n\n林凡猛地睁开眼。", data.GetText())
+	assert.Equal(t, CountChapterChars(data.GetText()), data.Meta.CharCount)
+
+	// 删除后的ID不会被复用，出错时整批编辑都不生效
+	ids, err = chapterManager.EditParagraphs(id, ParagraphEdit{Op: ParagraphInsertAfter, Text: "序。"})
+	require.NoError(t, err)
+	assert.Equal(t, []int{5}, ids)
+	_, err = chapterManager.EditParagraphs(id,
+		ParagraphEdit{Op: ParagraphDelete, ParagraphID: 1},
+		ParagraphEdit{Op: ParagraphReplace, ParagraphID: 99, Text: "不存在"},
+	)
+	assert.Error(t, err)
+	data, err = chapterManager.GetChapterData(id)
+	require.NoError(t, err)
+	assert.Equal(t, []int{5, 3, 1, 4, 2}, paragraphIDs(data))
+
+	// 缺少或重复的段落ID会被重新分配
+	legacy := &ChapterData{Content: []ChapterParagraph{{ParagraphID: 1, Text: "a"}, {ParagraphID: 1, Text: "b"}, {Text: "c"}}}
+	_, err = legacy.ApplyParagraphEdit(ParagraphEdit{Op: ParagraphDelete, ParagraphID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 3}, paragraphIDs(legacy))
+}
+
+// 整章改写时未修改的段落保留原ID，新段落从最大ID之后继续编号
+func TestUpdateChapterKeepsParagraphIDs(t *testing.T) {
+	dir := t.TempDir()
+	chapterManager := NewChapterManager(dir)
+	id, _, err := chapterManager.CreateChapter("醒来", "海浪拍打着礁石。\n\n林凡睁开眼。\n\n远处传来鸟叫。")
+	require.NoError(t, err)
+	_, err = chapterManager.EditParagraphs(id, ParagraphEdit{Op: ParagraphMove, ParagraphID: 3, AfterID: 0})
+	require.NoError(t, err)
+
+	require.NoError(t, chapterManager.UpdateChapter(id, "醒来", "远处传来鸟叫。\n\n海浪拍打着礁石。\n\n林凡猛地睁开眼。\n\n海浪拍打着礁石。"))
+	data, err := chapterManager.GetChapterData(id)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 1, 4, 5}, paragraphIDs(data))
+}
@@ -1,6 +1,7 @@
 package managers
 
 import (
+	"fmt"
 	"path/filepath"
 	"strings"
 
@@ -9,9 +10,13 @@ import (
 )
 
 // CharacterManager 角色管理器
+// 存在结构化角色库（characters.json/characters.yaml）时以角色库为准，character.md 仅作为旧格式兼容
 type CharacterManager struct {
 	*BaseFileManager
 	novelDir string
+	store    *CharacterStore
+	history  *CharacterHistoryStore
+	graph    *RelationshipStore
 }
 
 // NewCharacterManager 创建角色管理器
@@ -19,8 +24,11 @@ func NewCharacterManager(novelDir string) *CharacterManager {
 	characterPath := filepath.Join(novelDir, "character.md")
 
 	manager := &CharacterManager{
-		BaseFileManager: NewBaseFileManager(characterPath),
+		BaseFileManager: newComponentFileManager(characterPath, "character"),
 		novelDir:        novelDir,
+		store:           NewCharacterStore(novelDir),
+		history:         NewCharacterHistoryStore(novelDir),
+		graph:           NewRelationshipStore(novelDir),
 	}
 
 	// 尝试加载现有内容
@@ -45,20 +53,389 @@ func (cm *CharacterManager) load() {
 	}
 }
 
-// GetCurrentWithTokenLimit 获取限制Token数量的当前角色信息
+// IsStructured 是否使用结构化角色库
+func (cm *CharacterManager) IsStructured() bool {
+	return cm.store.Exists()
+}
+
+// GetStore 获取结构化角色库管理器
+func (cm *CharacterManager) GetStore() *CharacterStore {
+	return cm.store
+}
+
+// LoadDB 读取角色库：结构化角色库存在时直接读取，否则解析 character.md
+func (cm *CharacterManager) LoadDB() (*CharacterDB, error) {
+	if cm.IsStructured() {
+		return cm.store.LoadDB()
+	}
+	return ParseCharacterMarkdown(cm.BaseFileManager.GetCurrent()), nil
+}
+
+// SaveDB 保存角色库：结构化模式写入角色库，否则只把有变化的角色写回 character.md，其余内容保持原样
+func (cm *CharacterManager) SaveDB(db *CharacterDB) error {
+	return cm.modifyDB(func(current *CharacterDB) error {
+		current.Characters = db.Characters
+		return nil
+	})
+}
+
+// GetCurrent 获取当前角色信息：结构化角色库渲染为 Markdown，否则返回 character.md 内容
+func (cm *CharacterManager) GetCurrent() string {
+	if !cm.IsStructured() {
+		return cm.BaseFileManager.GetCurrent()
+	}
+	db, err := cm.store.LoadDB()
+	if err != nil {
+		return ""
+	}
+	return db.RenderMarkdown()
+}
+
+// SetVersionInfo 设置之后保存角色设定、角色库、变更历史和关系图时记录到版本历史中的作者和原因
+func (cm *CharacterManager) SetVersionInfo(info VersionInfo) {
+	cm.BaseFileManager.SetVersionInfo(info)
+	cm.store.SetVersionInfo(info)
+	cm.history.SetVersionInfo(info)
+	cm.graph.SetVersionInfo(info)
+}
+
+// GetRelationshipStore 获取人物关系图管理器
+func (cm *CharacterManager) GetRelationshipStore() *RelationshipStore {
+	return cm.graph
+}
+
+// GetContextText 获取用于上下文的角色信息：角色设定加人物关系摘要
+func (cm *CharacterManager) GetContextText() string {
+	relationships, _ := cm.relationshipSection(0)
+	if relationships == "" {
+		return cm.GetCurrent()
+	}
+	return strings.TrimSpace(cm.GetCurrent() + "\n\n" + relationships)
+}
+
+// relationshipSection 渲染人物关系摘要，maxTokens 大于0时截断到该Token数以内（优先保留强度高的关系）
+// 角色设定的"关系"字段已完整表达的关系不再重复列出，避免同一信息占用两次预算
+func (cm *CharacterManager) relationshipSection(maxTokens int) (string, int) {
+	graph, err := cm.graph.LoadGraph()
+	if err != nil || len(graph.Edges) == 0 {
+		return "", 0
+	}
+
+	edges := graph.Edges
+	if db, err := cm.LoadDB(); err == nil {
+		edges = nil
+		for _, edge := range graph.Edges {
+			if !edge.describedBy(db) {
+				edges = append(edges, edge)
+			}
+		}
+	}
+	if len(edges) == 0 {
+		return "", 0
+	}
+
+	section := "## 人物关系\n" + graph.renderCompact(edges)
+	if maxTokens <= 0 {
+		return section, 0
+	}
+	// 关系摘要按行排列，直接截断即可，不参与压缩
+	return token.TruncateText(cm.tokenCounter, section, maxTokens, token.KeepHead)
+}
+
+// Update 更新角色信息：结构化模式下将 Markdown 解析为角色库后保存
+func (cm *CharacterManager) Update(characterInfo string) error {
+	if !cm.IsStructured() {
+		return cm.BaseFileManager.Update(characterInfo)
+	}
+	return cm.store.SaveDB(ParseCharacterMarkdown(characterInfo))
+}
+
+// CompressionSource 返回压缩缓存的来源文件（结构化模式下为角色库文件）
+func (cm *CharacterManager) CompressionSource() token.CompressionSource {
+	if cm.IsStructured() {
+		return cm.store.CompressionSource()
+	}
+	return cm.BaseFileManager.CompressionSource()
+}
+
+// MigrateToStructured 将 character.md 迁移为结构化角色库，返回迁移的角色数
+// 原文件保留不动并另存一份备份；角色库已存在且 force 为false时返回错误
+func (cm *CharacterManager) MigrateToStructured(format string, force bool) (int, error) {
+	if cm.IsStructured() && !force {
+		return 0, fmt.Errorf("角色库已存在: %s", cm.store.GetPath())
+	}
+	if !cm.BaseFileManager.Exists() {
+		return 0, content.NewFileNotFoundError(cm.BaseFileManager.GetPath(), nil)
+	}
+
+	markdown, err := cm.BaseFileManager.Load()
+	if err != nil {
+		return 0, err
+	}
+	if err := cm.BaseFileManager.BackupFile(); err != nil {
+		return 0, err
+	}
+
+	db := ParseCharacterMarkdown(markdown)
+	cm.store = NewCharacterStoreWithFormat(cm.novelDir, format)
+	cm.store.SetVersionInfo(cm.GetVersionInfo())
+	if err := cm.store.SaveDB(db); err != nil {
+		return 0, err
+	}
+	return len(db.Characters), nil
+}
+
+// UpdateWithHistory 更新角色信息，并为每个发生变化的角色记录一条变更历史
+// chapter 为引起变更的章节，source 为变更来源（ai/manual），返回记录的变更
+func (cm *CharacterManager) UpdateWithHistory(characterInfo string, chapter int, source, reason string) ([]*CharacterChange, error) {
+	before, err := cm.replace(characterInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := diffCharacterDBs(before, ParseCharacterMarkdown(characterInfo))
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	err = cm.history.ModifyHistory(func(history *CharacterHistory) error {
+		for _, change := range changes {
+			change.Chapter = chapter
+			change.Source = source
+			change.Reason = reason
+			history.Append(change)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存角色变更历史失败: %w", err)
+	}
+	if err := cm.syncRelationships(changes, chapter); err != nil {
+		return changes, err
+	}
+	return changes, nil
+}
+
+// replace 在文件锁内读取更新前的角色信息并写入新内容，返回更新前的角色库，保证变更记录与实际覆盖的内容一致
+func (cm *CharacterManager) replace(characterInfo string) (*CharacterDB, error) {
+	var before *CharacterDB
+	if cm.IsStructured() {
+		err := cm.store.ModifyDB(func(db *CharacterDB) error {
+			before = &CharacterDB{Characters: db.Characters}
+			db.Characters = ParseCharacterMarkdown(characterInfo).Characters
+			return nil
+		})
+		return before, err
+	}
+
+	err := cm.BaseFileManager.Modify(func(current string) (string, error) {
+		before = ParseCharacterMarkdown(current)
+		return characterInfo, nil
+	})
+	return before, err
+}
+
+// modifyDB 在文件锁内读取最新的角色库，由 fn 修改后写回
+// 非结构化模式下只重写 character.md 中有变化的角色小节，见 PatchCharacterMarkdown
+func (cm *CharacterManager) modifyDB(fn func(db *CharacterDB) error) error {
+	if cm.IsStructured() {
+		return cm.store.ModifyDB(fn)
+	}
+
+	return cm.BaseFileManager.Modify(func(current string) (string, error) {
+		db := ParseCharacterMarkdown(current)
+		if err := fn(db); err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(current) == "" {
+			return db.RenderMarkdown(), nil
+		}
+		return PatchCharacterMarkdown(current, db), nil
+	})
+}
+
+// syncRelationships 将发生变化的角色中记录的关系同步到人物关系图
+func (cm *CharacterManager) syncRelationships(changes []*CharacterChange, chapter int) error {
+	var characters []*Character
+	for _, change := range changes {
+		if change.After != nil && len(change.After.Relationships) > 0 {
+			characters = append(characters, change.After)
+		}
+	}
+	if len(characters) == 0 {
+		return nil
+	}
+
+	err := cm.graph.ModifyGraph(func(graph *RelationshipGraph) error {
+		graph.SyncFromCharacters(characters, chapter)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("保存人物关系图失败: %w", err)
+	}
+	return nil
+}
+
+// GetHistory 获取角色的变更历史
+func (cm *CharacterManager) GetHistory(name string) ([]*CharacterChange, error) {
+	history, err := cm.history.LoadHistory()
+	if err != nil {
+		return nil, err
+	}
+	return history.ForCharacter(name), nil
+}
+
+// StateAt 查询角色在指定章节结束时的状态，返回false表示角色当时尚未出现
+func (cm *CharacterManager) StateAt(name string, chapter int) (*Character, bool, error) {
+	history, err := cm.history.LoadHistory()
+	if err != nil {
+		return nil, false, err
+	}
+	db, err := cm.LoadDB()
+	if err != nil {
+		return nil, false, err
+	}
+
+	current, _ := db.Find(name)
+	state, ok := history.StateAt(name, chapter, current)
+	return state, ok, nil
+}
+
+// Rollback 将角色恢复到指定变更之前的状态，该变更之后对同一角色的变更一并撤销
+// 回滚本身也记录为一条变更（章节号与被回滚的变更相同），返回该记录
+func (cm *CharacterManager) Rollback(name string, changeID int) (*CharacterChange, error) {
+	var rollback *CharacterChange
+	err := cm.history.ModifyHistory(func(history *CharacterHistory) error {
+		target, ok := history.Get(changeID)
+		if !ok || !target.matches(name) {
+			return fmt.Errorf("角色 %s 没有编号为 %d 的变更", name, changeID)
+		}
+		if target.RolledBack {
+			return fmt.Errorf("变更 %d 已被回滚", changeID)
+		}
+
+		var current *Character
+		var found bool
+		restored := cloneCharacter(target.Before)
+		err := cm.modifyDB(func(db *CharacterDB) error {
+			// 以变更前后的名称查找当前角色，角色可能在变更中被改名
+			current, found = db.Find(name)
+			if !found && target.After != nil {
+				current, found = db.Find(target.After.Name)
+			}
+
+			switch {
+			case found && restored != nil:
+				for i, character := range db.Characters {
+					if character == current {
+						db.Characters[i] = restored
+					}
+				}
+			case found:
+				db.Remove(current.Name)
+			case restored != nil:
+				db.Characters = append(db.Characters, restored)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, change := range history.ForCharacter(name) {
+			if change.ID >= changeID && change.Source != ChangeSourceRollback {
+				change.RolledBack = true
+			}
+		}
+
+		var before *Character
+		if found {
+			before = cloneCharacter(current)
+		}
+		rollback = &CharacterChange{
+			Character: name,
+			Chapter:   target.Chapter,
+			Source:    ChangeSourceRollback,
+			Reason:    fmt.Sprintf("回滚变更 #%d", changeID),
+			Changes:   DiffCharacters(before, restored),
+			Before:    before,
+			After:     cloneCharacter(restored),
+		}
+		history.Append(rollback)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rollback, nil
+}
+
+// diffCharacterDBs 比较更新前后的角色库，为新增、删除和修改的角色生成变更记录
+func diffCharacterDBs(before, after *CharacterDB) []*CharacterChange {
+	var changes []*CharacterChange
+	matched := make(map[*Character]bool)
+
+	for _, newCharacter := range after.Characters {
+		oldCharacter, ok := before.Find(newCharacter.Name)
+		if ok {
+			matched[oldCharacter] = true
+		} else {
+			oldCharacter = nil
+		}
+
+		fields := DiffCharacters(oldCharacter, newCharacter)
+		if len(fields) == 0 {
+			continue
+		}
+		changes = append(changes, &CharacterChange{
+			Character: newCharacter.Name,
+			Changes:   fields,
+			Before:    cloneCharacter(oldCharacter),
+			After:     cloneCharacter(newCharacter),
+		})
+	}
+
+	for _, oldCharacter := range before.Characters {
+		if matched[oldCharacter] {
+			continue
+		}
+		changes = append(changes, &CharacterChange{
+			Character: oldCharacter.Name,
+			Changes:   DiffCharacters(oldCharacter, nil),
+			Before:    cloneCharacter(oldCharacter),
+		})
+	}
+	return changes
+}
+
+// GetCurrentWithTokenLimit 获取限制Token数量的当前角色信息（含人物关系摘要）
+// 人物关系最多占用四分之一的预算，其余预算用于角色设定
 func (cm *CharacterManager) GetCurrentWithTokenLimit(maxTokens int) (string, int) {
+	limit := maxTokens
+	if cm.GetTokenBudget() != nil {
+		limit = cm.GetTokenBudget().GetTokenAllocation("character")
+	}
+
+	relationships, relationTokens := cm.relationshipSection(limit / 4)
 	current := cm.GetCurrent()
 	if current == "" {
-		return "", 0
+		return relationships, relationTokens
 	}
 
-	// 如果有TokenBudget，使用正确的组件名
+	var text string
+	var tokens int
 	if cm.GetTokenBudget() != nil {
-		return cm.GetTokenBudget().TruncateToTokenLimit(current, "character")
+		// 配置了压缩器时超出预算的内容会被压缩而非截断
+		text, tokens = cm.GetTokenBudget().CompressToLimit(current, "character", limit-relationTokens, cm.CompressionSource())
+	} else {
+		text, tokens = cm.TruncateToLimit(current, limit-relationTokens)
+	}
+
+	if relationships != "" {
+		text = strings.TrimSpace(text + "\n\n" + relationships)
+		tokens += relationTokens
 	}
-	
-	// 否则使用基础的截断逻辑
-	return cm.TruncateToLimit(current, maxTokens)
+	return text, tokens
 }
 
 // UpdateCharacter 更新角色信息（对外接口，保持向后兼容）
@@ -74,32 +451,20 @@ func (cm *CharacterManager) GetCharacterPath() string {
 
 // HasCharacters 检查是否有角色设定
 func (cm *CharacterManager) HasCharacters() bool {
-	return cm.Exists() && cm.GetCurrent() != ""
+	return (cm.IsStructured() || cm.Exists()) && cm.GetCurrent() != ""
 }
 
-// TODO: 逻辑问题
-// GetCharacterCount 获取角色数量（简单统计）
+// GetCharacterCount 获取角色数量：结构化模式统计角色库条目，否则统计 character.md 解析出的角色
+// 分组标题（如"重要配角"）不计入；有内容但没有角色标题时视为1个角色
 func (cm *CharacterManager) GetCharacterCount() int {
-	current := cm.GetCurrent()
-	if current == "" {
+	db, err := cm.LoadDB()
+	if err != nil {
 		return 0
 	}
-
-	// 简单计算：以"##"开头的行作为角色
-	lines := strings.Split(current, "\n")
-	count := 0
-	for _, line := range lines {
-		if strings.HasPrefix(strings.TrimSpace(line), "##") {
-			count++
-		}
-	}
-
-	if count == 0 && current != "" {
-		// 如果没有标准格式但有内容，认为至少有1个角色
+	if len(db.Characters) == 0 && !cm.IsStructured() && strings.TrimSpace(cm.GetCurrent()) != "" {
 		return 1
 	}
-
-	return count
+	return len(db.Characters)
 }
 
 // GetCharacterSummary 获取角色摘要（前150个字符）
@@ -111,9 +476,20 @@ func (cm *CharacterManager) GetCharacterSummary() string {
 	return current[:150] + "..."
 }
 
+// GetTokenCount 获取角色信息的Token数量，结构化模式下统计角色库渲染后的内容
+func (cm *CharacterManager) GetTokenCount() int {
+	if !cm.IsStructured() {
+		return cm.BaseFileManager.GetTokenCount()
+	}
+	return cm.tokenCounter.Count(cm.GetCurrent())
+}
+
 // GetCharacterMetadata 获取角色元数据
 func (cm *CharacterManager) GetCharacterMetadata() map[string]interface{} {
 	info := cm.GetFileInfo()
+	if cm.IsStructured() {
+		info = cm.store.GetFileInfo()
+	}
 
 	metadata := map[string]interface{}{
 		"path":            info.Path,
@@ -123,6 +499,7 @@ func (cm *CharacterManager) GetCharacterMetadata() map[string]interface{} {
 		"token_count":     cm.GetTokenCount(),
 		"has_characters":  cm.HasCharacters(),
 		"character_count": cm.GetCharacterCount(),
+		"structured":      cm.IsStructured(),
 	}
 
 	if cm.GetTokenBudget() != nil {
@@ -157,25 +534,31 @@ func (cm *CharacterManager) AddCharacter(name, description string) error {
 		return content.NewInvalidConfigError("character name cannot be empty", nil)
 	}
 
-	current := cm.GetCurrent()
+	if cm.IsStructured() {
+		return cm.upsertStructured(name, description)
+	}
+
+	return cm.BaseFileManager.Modify(func(current string) (string, error) {
+		updated := appendCharacterSection(strings.TrimSpace(current), name, description)
+
+		// 验证更新后的内容
+		if err := cm.ValidateCharacter(updated); err != nil {
+			return "", err
+		}
+		return updated, nil
+	})
+}
 
+// appendCharacterSection 在角色设定末尾追加角色条目
+func appendCharacterSection(current, name, description string) string {
 	// 构建角色条目
 	characterEntry := "\n\n## " + name + "\n" + description
 
-	var updated string
 	if current == "" {
 		// 如果是第一个角色，添加标题
-		updated = "# 角色设定" + characterEntry
-	} else {
-		updated = current + characterEntry
+		return "# 角色设定" + characterEntry
 	}
-
-	// 验证更新后的内容
-	if err := cm.ValidateCharacter(updated); err != nil {
-		return err
-	}
-
-	return cm.Update(updated)
+	return current + characterEntry
 }
 
 // UpdateCharacterByName 根据名称更新特定角色
@@ -184,12 +567,31 @@ func (cm *CharacterManager) UpdateCharacterByName(name, newDescription string) e
 		return content.NewInvalidConfigError("character name cannot be empty", nil)
 	}
 
-	current := cm.GetCurrent()
-	if current == "" {
-		// 如果没有角色，直接添加
-		return cm.AddCharacter(name, newDescription)
+	if cm.IsStructured() {
+		return cm.upsertStructured(name, newDescription)
 	}
 
+	return cm.BaseFileManager.Modify(func(current string) (string, error) {
+		current = strings.TrimSpace(current)
+
+		var result string
+		if current == "" {
+			// 如果没有角色，直接添加
+			result = appendCharacterSection(current, name, newDescription)
+		} else {
+			result = replaceCharacterSection(current, name, newDescription)
+		}
+
+		// 验证更新后的内容
+		if err := cm.ValidateCharacter(result); err != nil {
+			return "", err
+		}
+		return result, nil
+	})
+}
+
+// replaceCharacterSection 将角色设定中指定角色的描述替换为新描述
+func replaceCharacterSection(current, name, newDescription string) string {
 	lines := strings.Split(current, "\n")
 	var updated []string
 	inTargetCharacter := false
@@ -242,14 +644,15 @@ func (cm *CharacterManager) UpdateCharacterByName(name, newDescription string) e
 		updated = append(updated, newDescription)
 	}
 
-	result := strings.Join(updated, "\n")
-
-	// 验证更新后的内容
-	if err := cm.ValidateCharacter(result); err != nil {
-		return err
-	}
+	return strings.Join(updated, "\n")
+}
 
-	return cm.Update(result)
+// upsertStructured 解析角色描述并合并到角色库：描述中给出的字段覆盖原值，未给出的字段保持不变
+func (cm *CharacterManager) upsertStructured(name, description string) error {
+	return cm.store.ModifyDB(func(db *CharacterDB) error {
+		db.Upsert(ParseCharacterSection(name, description))
+		return nil
+	})
 }
 
 // ClearCharacters 清空角色信息
@@ -257,8 +660,12 @@ func (cm *CharacterManager) ClearCharacters() error {
 	return cm.Update("")
 }
 
-// ResetToDefault 重置为默认角色模板
+// ResetToDefault 重置为默认角色模板（结构化模式下清空角色库）
 func (cm *CharacterManager) ResetToDefault() error {
+	if cm.IsStructured() {
+		return cm.ClearCharacters()
+	}
+
 	defaultCharacters := `# 角色设定
 
 ## 主角
@@ -0,0 +1,550 @@
+package managers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	content "github.com/Kizunad/modular-workflow-v2/components/content/utils"
+)
+
+// 结构化角色库文件名
+const (
+	CharacterDBFile     = "characters.json"
+	CharacterDBYAMLFile = "characters.yaml"
+)
+
+// 角色库文件格式
+const (
+	CharacterFormatJSON = "json"
+	CharacterFormatYAML = "yaml"
+)
+
+// CharacterRelation 角色关系
+type CharacterRelation struct {
+	Target   string `json:"target" yaml:"target"`     // 关系对象
+	Relation string `json:"relation" yaml:"relation"` // 关系描述，如 师姐、仇敌
+}
+
+// Character 结构化角色信息
+type Character struct {
+	Name            string              `json:"name" yaml:"name"`
+	Aliases         []string            `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	Age             string              `json:"age,omitempty" yaml:"age,omitempty"`
+	Identity        string              `json:"identity,omitempty" yaml:"identity,omitempty"`       // 身份
+	Personality     string              `json:"personality,omitempty" yaml:"personality,omitempty"` // 性格
+	Background      string              `json:"background,omitempty" yaml:"background,omitempty"`   // 背景
+	Goal            string              `json:"goal,omitempty" yaml:"goal,omitempty"`               // 目标
+	Abilities       []string            `json:"abilities,omitempty" yaml:"abilities,omitempty"`     // 能力、功法
+	Status          string              `json:"status,omitempty" yaml:"status,omitempty"`           // 当前状态（伤势、境界等）
+	Location        string              `json:"location,omitempty" yaml:"location,omitempty"`       // 当前位置
+	Inventory       []string            `json:"inventory,omitempty" yaml:"inventory,omitempty"`     // 持有物品
+	Relationships   []CharacterRelation `json:"relationships,omitempty" yaml:"relationships,omitempty"`
+	FirstAppearance int                 `json:"first_appearance,omitempty" yaml:"first_appearance,omitempty"` // 首次出场章节
+	LastAppearance  int                 `json:"last_appearance,omitempty" yaml:"last_appearance,omitempty"`   // 最近出场章节
+	Notes           string              `json:"notes,omitempty" yaml:"notes,omitempty"`                       // 无法归入字段的其他描述
+}
+
+// CharacterDB 结构化角色库
+type CharacterDB struct {
+	Characters []*Character `json:"characters" yaml:"characters"`
+	UpdatedAt  string       `json:"updated_at" yaml:"updated_at"`
+}
+
+// characterFieldLabels Markdown 字段标签到字段名的映射（解析时使用，渲染使用第一个标签）
+var characterFieldLabels = map[string]string{
+	"姓名": "name", "名字": "name",
+	"别名": "aliases", "别称": "aliases", "外号": "aliases",
+	"年龄": "age",
+	"身份": "identity", "职业": "identity",
+	"性格": "personality",
+	"背景": "background", "经历": "background",
+	"目标": "goal",
+	"能力": "abilities", "技能": "abilities", "功法": "abilities",
+	"状态": "status",
+	"位置": "location", "所在地": "location",
+	"物品": "inventory", "装备": "inventory", "持有物品": "inventory",
+	"关系": "relationships", "人际关系": "relationships",
+	"出场":   "appearance",
+	"首次出场": "first_appearance",
+	"最近出场": "last_appearance",
+	"描述":   "notes", "简介": "notes",
+}
+
+var (
+	characterFieldPattern = regexp.MustCompile(`^[-*]\s*([^：:]{1,8})[：:]\s*(.*)$`)
+	characterListSplitter = regexp.MustCompile(`[、,，;；/]`)
+	chapterNumberPattern  = regexp.MustCompile(`\d+`)
+	relationPattern       = regexp.MustCompile(`^(.+?)[（(](.+)[）)]$`)
+)
+
+// Find 按名称或别名查找角色
+func (db *CharacterDB) Find(name string) (*Character, bool) {
+	name = strings.TrimSpace(name)
+	for _, character := range db.Characters {
+		if character.Name == name {
+			return character, true
+		}
+	}
+	for _, character := range db.Characters {
+		for _, alias := range character.Aliases {
+			if alias == name {
+				return character, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Upsert 新增角色，同名角色已存在时用非空字段覆盖原有字段
+func (db *CharacterDB) Upsert(character *Character) {
+	if existing, ok := db.Find(character.Name); ok {
+		existing.Merge(character)
+		return
+	}
+	db.Characters = append(db.Characters, character)
+}
+
+// Remove 删除角色，返回是否找到
+func (db *CharacterDB) Remove(name string) bool {
+	target, ok := db.Find(name)
+	if !ok {
+		return false
+	}
+	for i, character := range db.Characters {
+		if character == target {
+			db.Characters = append(db.Characters[:i], db.Characters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Names 返回所有角色名称
+func (db *CharacterDB) Names() []string {
+	names := make([]string, 0, len(db.Characters))
+	for _, character := range db.Characters {
+		names = append(names, character.Name)
+	}
+	return names
+}
+
+// Merge 用 other 的非空字段覆盖当前角色（名称保持不变）
+func (c *Character) Merge(other *Character) {
+	mergeString := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
+		}
+	}
+	mergeString(&c.Age, other.Age)
+	mergeString(&c.Identity, other.Identity)
+	mergeString(&c.Personality, other.Personality)
+	mergeString(&c.Background, other.Background)
+	mergeString(&c.Goal, other.Goal)
+	mergeString(&c.Status, other.Status)
+	mergeString(&c.Location, other.Location)
+	mergeString(&c.Notes, other.Notes)
+
+	if len(other.Aliases) > 0 {
+		c.Aliases = other.Aliases
+	}
+	if len(other.Abilities) > 0 {
+		c.Abilities = other.Abilities
+	}
+	if len(other.Inventory) > 0 {
+		c.Inventory = other.Inventory
+	}
+	if len(other.Relationships) > 0 {
+		c.Relationships = other.Relationships
+	}
+	if other.FirstAppearance > 0 && (c.FirstAppearance == 0 || other.FirstAppearance < c.FirstAppearance) {
+		c.FirstAppearance = other.FirstAppearance
+	}
+	if other.LastAppearance > c.LastAppearance {
+		c.LastAppearance = other.LastAppearance
+	}
+}
+
+// isEmpty 判断角色除名称外是否没有任何信息（如模板中的分组标题）
+func (c *Character) isEmpty() bool {
+	return len(c.Aliases) == 0 && c.Age == "" && c.Identity == "" && c.Personality == "" &&
+		c.Background == "" && c.Goal == "" && len(c.Abilities) == 0 && c.Status == "" &&
+		c.Location == "" && len(c.Inventory) == 0 && len(c.Relationships) == 0 &&
+		c.FirstAppearance == 0 && c.LastAppearance == 0 && c.Notes == ""
+}
+
+// RenderMarkdown 将角色库渲染为 Markdown，格式与 ParseCharacterMarkdown 对应，可往返转换
+func (db *CharacterDB) RenderMarkdown() string {
+	if len(db.Characters) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("# 角色设定\n")
+	for _, character := range db.Characters {
+		b.WriteString("\n")
+		b.WriteString(character.RenderMarkdown())
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// RenderMarkdown 将单个角色渲染为 Markdown 小节
+func (c *Character) RenderMarkdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n", c.Name)
+
+	field := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "- %s：%s\n", label, value)
+		}
+	}
+	field("别名", strings.Join(c.Aliases, "、"))
+	field("年龄", c.Age)
+	field("身份", c.Identity)
+	field("性格", c.Personality)
+	field("背景", c.Background)
+	field("目标", c.Goal)
+	field("能力", strings.Join(c.Abilities, "、"))
+	field("状态", c.Status)
+	field("位置", c.Location)
+	field("物品", strings.Join(c.Inventory, "、"))
+
+	relations := make([]string, 0, len(c.Relationships))
+	for _, relation := range c.Relationships {
+		if relation.Relation == "" {
+			relations = append(relations, relation.Target)
+		} else {
+			relations = append(relations, fmt.Sprintf("%s（%s）", relation.Target, relation.Relation))
+		}
+	}
+	field("关系", strings.Join(relations, "、"))
+
+	switch {
+	case c.FirstAppearance > 0 && c.LastAppearance > c.FirstAppearance:
+		field("出场", fmt.Sprintf("第%d章 - 第%d章", c.FirstAppearance, c.LastAppearance))
+	case c.FirstAppearance > 0 && c.LastAppearance == c.FirstAppearance:
+		field("出场", fmt.Sprintf("第%d章", c.FirstAppearance))
+	case c.FirstAppearance > 0:
+		field("首次出场", fmt.Sprintf("第%d章", c.FirstAppearance))
+	case c.LastAppearance > 0:
+		field("最近出场", fmt.Sprintf("第%d章", c.LastAppearance))
+	}
+
+	if c.Notes != "" {
+		b.WriteString(c.Notes)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// ParseCharacterMarkdown 解析 Markdown 角色设定（## 或 ### 标题为角色名，"- 字段：值" 为字段）
+// 无法识别的行保留在 Notes 中；没有任何信息的标题（如"重要配角"分组）会被忽略
+func ParseCharacterMarkdown(markdown string) *CharacterDB {
+	db := &CharacterDB{}
+
+	var current *Character
+	var notes []string
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Notes = strings.TrimSpace(current.Notes + "\n" + strings.Join(notes, "\n"))
+		if !current.isEmpty() {
+			db.Upsert(current)
+		}
+		current, notes = nil, nil
+	}
+
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "##") {
+			flush()
+			current = &Character{Name: strings.TrimSpace(strings.TrimLeft(trimmed, "#"))}
+			continue
+		}
+		if current == nil || trimmed == "" {
+			continue
+		}
+
+		if match := characterFieldPattern.FindStringSubmatch(trimmed); match != nil {
+			if field, ok := characterFieldLabels[strings.TrimSpace(match[1])]; ok {
+				current.setField(field, strings.TrimSpace(match[2]))
+				continue
+			}
+		}
+		notes = append(notes, line)
+	}
+	flush()
+
+	return db
+}
+
+// ParseCharacterSection 解析单个角色的描述（不含标题），用于按名称新增或更新角色
+func ParseCharacterSection(name, description string) *Character {
+	db := ParseCharacterMarkdown("## " + name + "\n" + description)
+	if character, ok := db.Find(name); ok {
+		return character
+	}
+	return &Character{Name: name}
+}
+
+// PatchCharacterMarkdown 将角色库的修改写回原有的 Markdown：只重写发生变化的角色小节，删除已移除角色的小节，
+// 新角色追加在末尾；开头的说明文字、没有字段的分组标题（如"## 主角"）和未变化的小节保持原样
+func PatchCharacterMarkdown(markdown string, db *CharacterDB) string {
+	lines := strings.Split(strings.TrimRight(markdown, "\n"), "\n")
+
+	// 按 ## 标题切分为小节，第一个小节为标题前的内容
+	var sections [][]string
+	start := 0
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "##") && i > start {
+			sections = append(sections, lines[start:i])
+			start = i
+		}
+	}
+	sections = append(sections, lines[start:])
+
+	handled := make(map[*Character]bool)
+	var out []string
+	for _, section := range sections {
+		heading := strings.TrimSpace(section[0])
+		parsed := ParseCharacterMarkdown(strings.Join(section, "\n")).Characters
+		if !strings.HasPrefix(heading, "##") || len(parsed) != 1 {
+			out = append(out, section...)
+			continue
+		}
+
+		character, ok := db.Find(parsed[0].Name)
+		switch {
+		case !ok:
+			// 角色已被删除
+			continue
+		case handled[character] || len(DiffCharacters(parsed[0], character)) == 0:
+			out = append(out, section...)
+		default:
+			// 保留原标题的级别和小节之间的空行
+			level := heading[:len(heading)-len(strings.TrimLeft(heading, "#"))]
+			rendered := strings.Split(strings.TrimRight(character.RenderMarkdown(), "\n"), "\n")
+			rendered[0] = level + strings.TrimPrefix(rendered[0], "##")
+			out = append(out, rendered...)
+			for i := len(section) - 1; i > 0 && strings.TrimSpace(section[i]) == ""; i-- {
+				out = append(out, "")
+			}
+		}
+		handled[character] = true
+	}
+
+	for _, character := range db.Characters {
+		if handled[character] {
+			continue
+		}
+		if len(out) > 0 && strings.TrimSpace(out[len(out)-1]) != "" {
+			out = append(out, "")
+		}
+		out = append(out, strings.Split(strings.TrimRight(character.RenderMarkdown(), "\n"), "\n")...)
+	}
+	return strings.Join(out, "\n")
+}
+
+// setField 设置 Markdown 字段对应的结构化字段
+func (c *Character) setField(field, value string) {
+	if value == "" {
+		return
+	}
+
+	switch field {
+	case "name":
+		// 模板中的"主角"等标题配合姓名字段使用时，以姓名为准，标题作为身份
+		if c.Identity == "" && c.Name != value {
+			c.Identity = c.Name
+		}
+		c.Name = value
+	case "aliases":
+		c.Aliases = splitCharacterList(value)
+	case "age":
+		c.Age = value
+	case "identity":
+		c.Identity = value
+	case "personality":
+		c.Personality = value
+	case "background":
+		c.Background = value
+	case "goal":
+		c.Goal = value
+	case "abilities":
+		c.Abilities = splitCharacterList(value)
+	case "status":
+		c.Status = value
+	case "location":
+		c.Location = value
+	case "inventory":
+		c.Inventory = splitCharacterList(value)
+	case "relationships":
+		c.Relationships = nil
+		for _, item := range splitCharacterList(value) {
+			relation := CharacterRelation{Target: item}
+			if match := relationPattern.FindStringSubmatch(item); match != nil {
+				relation = CharacterRelation{Target: strings.TrimSpace(match[1]), Relation: strings.TrimSpace(match[2])}
+			}
+			c.Relationships = append(c.Relationships, relation)
+		}
+	case "appearance":
+		numbers := chapterNumberPattern.FindAllString(value, -1)
+		if len(numbers) > 0 {
+			c.FirstAppearance, _ = strconv.Atoi(numbers[0])
+			c.LastAppearance, _ = strconv.Atoi(numbers[len(numbers)-1])
+		}
+	case "first_appearance":
+		if number := chapterNumberPattern.FindString(value); number != "" {
+			c.FirstAppearance, _ = strconv.Atoi(number)
+		}
+	case "last_appearance":
+		if number := chapterNumberPattern.FindString(value); number != "" {
+			c.LastAppearance, _ = strconv.Atoi(number)
+		}
+	case "notes":
+		c.Notes = strings.TrimSpace(c.Notes + "\n" + value)
+	}
+}
+
+// splitCharacterList 按中英文分隔符切分列表字段，括号内的分隔符不切分
+func splitCharacterList(value string) []string {
+	var items []string
+	depth := 0
+	start := 0
+	runes := []rune(value)
+	for i, r := range runes {
+		switch r {
+		case '（', '(':
+			depth++
+		case '）', ')':
+			if depth > 0 {
+				depth--
+			}
+		default:
+			if depth == 0 && characterListSplitter.MatchString(string(r)) {
+				if item := strings.TrimSpace(string(runes[start:i])); item != "" {
+					items = append(items, item)
+				}
+				start = i + 1
+			}
+		}
+	}
+	if item := strings.TrimSpace(string(runes[start:])); item != "" {
+		items = append(items, item)
+	}
+	return items
+}
+
+// CharacterStore 结构化角色库文件管理器（characters.json 或 characters.yaml）
+type CharacterStore struct {
+	*BaseFileManager
+	format string
+}
+
+// NewCharacterStore 创建角色库管理器，已存在 characters.yaml 时使用 YAML，否则使用 JSON
+func NewCharacterStore(novelDir string) *CharacterStore {
+	yamlPath := filepath.Join(novelDir, CharacterDBYAMLFile)
+	if _, err := os.Stat(yamlPath); err == nil {
+		return NewCharacterStoreWithFormat(novelDir, CharacterFormatYAML)
+	}
+	return NewCharacterStoreWithFormat(novelDir, CharacterFormatJSON)
+}
+
+// NewCharacterStoreWithFormat 创建指定格式的角色库管理器
+func NewCharacterStoreWithFormat(novelDir, format string) *CharacterStore {
+	fileName := CharacterDBFile
+	if format == CharacterFormatYAML {
+		fileName = CharacterDBYAMLFile
+	} else {
+		format = CharacterFormatJSON
+	}
+	return &CharacterStore{
+		BaseFileManager: NewBaseFileManager(filepath.Join(novelDir, fileName)),
+		format:          format,
+	}
+}
+
+// GetFormat 获取角色库文件格式
+func (cs *CharacterStore) GetFormat() string {
+	return cs.format
+}
+
+// LoadDB 读取角色库，文件不存在时返回空库
+func (cs *CharacterStore) LoadDB() (*CharacterDB, error) {
+	if !cs.Exists() {
+		return &CharacterDB{}, nil
+	}
+
+	data, err := os.ReadFile(cs.GetPath())
+	if err != nil {
+		return nil, content.NewFileReadError(cs.GetPath(), err)
+	}
+	return cs.decode(string(data))
+}
+
+// SaveDB 写入角色库
+func (cs *CharacterStore) SaveDB(db *CharacterDB) error {
+	data, err := cs.encode(db)
+	if err != nil {
+		return err
+	}
+	return cs.Save(data)
+}
+
+// ModifyDB 在文件锁内读取最新的角色库，由 fn 修改后写回；fn 返回错误时不写入
+func (cs *CharacterStore) ModifyDB(fn func(db *CharacterDB) error) error {
+	return cs.Modify(func(current string) (string, error) {
+		db, err := cs.decode(current)
+		if err != nil {
+			return "", err
+		}
+		if err := fn(db); err != nil {
+			return "", err
+		}
+		return cs.encode(db)
+	})
+}
+
+// decode 按文件格式解析角色库
+func (cs *CharacterStore) decode(data string) (*CharacterDB, error) {
+	db := &CharacterDB{}
+	if strings.TrimSpace(data) == "" {
+		return db, nil
+	}
+
+	var err error
+	if cs.format == CharacterFormatYAML {
+		err = yaml.Unmarshal([]byte(data), db)
+	} else {
+		err = json.Unmarshal([]byte(data), db)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析角色库 %s 失败: %w", cs.GetPath(), err)
+	}
+	return db, nil
+}
+
+// encode 按文件格式序列化角色库并更新时间戳
+func (cs *CharacterStore) encode(db *CharacterDB) (string, error) {
+	db.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	var data []byte
+	var err error
+	if cs.format == CharacterFormatYAML {
+		data, err = yaml.Marshal(db)
+	} else {
+		data, err = json.MarshalIndent(db, "", "  ")
+	}
+	if err != nil {
+		return "", fmt.Errorf("序列化角色库失败: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,270 @@
+package managers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const legacyCharacterMarkdown = `# 角色设定
+
+## 主角
+- 姓名：林凡
+- 年龄：17
+- 能力：御剑术、炼丹（初阶、残缺）
+- 关系：苏瑶（师姐）、赵无极（仇敌）
+- 出场：第1章 - 第12章
+性格沉稳，不轻易信人。
+
+## 重要配角
+### 苏瑶
+- 别名：瑶儿
+- 状态：闭关
+
+### 角色B
+- 描述：
+`
+
+func TestParseCharacterMarkdown(t *testing.T) {
+	db := ParseCharacterMarkdown(legacyCharacterMarkdown)
+	assert.Equal(t, []string{"林凡", "苏瑶"}, db.Names())
+
+	linfan, ok := db.Find("林凡")
+	assert.True(t, ok)
+	assert.Equal(t, "主角", linfan.Identity)
+	assert.Equal(t, "17", linfan.Age)
+	assert.Equal(t, []string{"御剑术", "炼丹（初阶、残缺）"}, linfan.Abilities)
+	assert.Equal(t, []CharacterRelation{{Target: "苏瑶", Relation: "师姐"}, {Target: "赵无极", Relation: "仇敌"}}, linfan.Relationships)
+	assert.Equal(t, 1, linfan.FirstAppearance)
+	assert.Equal(t, 12, linfan.LastAppearance)
+	assert.Equal(t, "性格沉稳，不轻易信人。", linfan.Notes)
+
+	// 别名查找
+	suyao, ok := db.Find("瑶儿")
+	assert.True(t, ok)
+	assert.Equal(t, "闭关", suyao.Status)
+
+	// 渲染结果可以无损解析回来
+	assert.Equal(t, db.Characters, ParseCharacterMarkdown(db.RenderMarkdown()).Characters)
+}
+
+func TestCharacterManagerStructured(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "character.md"), []byte(legacyCharacterMarkdown), 0644))
+
+	cm := NewCharacterManager(dir)
+	assert.False(t, cm.IsStructured())
+	// 分组标题和空白角色不计入角色数量
+	assert.Equal(t, 2, cm.GetCharacterCount())
+
+	count, err := cm.MigrateToStructured(CharacterFormatYAML, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.FileExists(t, filepath.Join(dir, CharacterDBYAMLFile))
+
+	// 重新打开后自动识别 YAML 角色库
+	cm = NewCharacterManager(dir)
+	assert.True(t, cm.IsStructured())
+	assert.Equal(t, 2, cm.GetCharacterCount())
+
+	// 按名称更新只覆盖给出的字段
+	assert.NoError(t, cm.UpdateCharacterByName("林凡", "- 状态：重伤\n- 出场：第13章"))
+	db, err := cm.LoadDB()
+	assert.NoError(t, err)
+	linfan, _ := db.Find("林凡")
+	assert.Equal(t, "重伤", linfan.Status)
+	assert.Equal(t, "17", linfan.Age)
+	assert.Equal(t, 1, linfan.FirstAppearance)
+	assert.Equal(t, 13, linfan.LastAppearance)
+
+	_, err = cm.MigrateToStructured(CharacterFormatJSON, false)
+	assert.Error(t, err)
+}
+
+func TestCharacterMarkdownRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	original := "# 角色设定\n\n本文件记录主要角色。\n\n## 主角\n\n### 林凡\n- 年龄：17\n- 状态：健康\n\n## 配角\n\n### 苏瑶\n- 身份：师姐\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "character.md"), []byte(original), 0644))
+
+	// 非结构化模式下只重写变化的角色，说明文字和分组标题保持原样
+	cm := NewCharacterManager(dir)
+	db, err := cm.LoadDB()
+	assert.NoError(t, err)
+	linfan, _ := db.Find("林凡")
+	linfan.Status = "重伤"
+	assert.NoError(t, cm.SaveDB(db))
+	data, err := os.ReadFile(filepath.Join(dir, "character.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "# 角色设定\n\n本文件记录主要角色。\n\n## 主角\n\n### 林凡\n- 年龄：17\n- 状态：重伤\n\n## 配角\n\n### 苏瑶\n- 身份：师姐", string(data))
+
+	// 删除和新增角色
+	db, err = cm.LoadDB()
+	assert.NoError(t, err)
+	db.Remove("苏瑶")
+	db.Upsert(&Character{Name: "赵无极", Identity: "魔教长老"})
+	assert.NoError(t, cm.SaveDB(db))
+	data, err = os.ReadFile(filepath.Join(dir, "character.md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "本文件记录主要角色。\n\n## 主角\n")
+	assert.Contains(t, string(data), "## 配角\n")
+	assert.NotContains(t, string(data), "苏瑶")
+	assert.True(t, strings.HasSuffix(string(data), "\n\n## 赵无极\n- 身份：魔教长老"))
+}
+
+func TestCharacterTokenCountStructured(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "character.md"), []byte("## 林凡\n- 状态：健康"), 0644))
+	cm := NewCharacterManager(dir)
+	_, err := cm.MigrateToStructured(CharacterFormatJSON, false)
+	assert.NoError(t, err)
+	assert.NoError(t, cm.UpdateCharacterByName("苏瑶", "- 身份：师姐\n- 背景：出身名门，自幼拜入宗门修行"))
+
+	// 结构化模式下统计角色库的内容，而不是已不再更新的 character.md
+	cm = NewCharacterManager(dir)
+	assert.Equal(t, cm.tokenCounter.Count(cm.GetCurrent()), cm.GetTokenCount())
+	assert.Equal(t, cm.GetTokenCount(), cm.GetCharacterMetadata()["token_count"])
+	assert.NotEqual(t, cm.BaseFileManager.GetTokenCount(), cm.GetTokenCount())
+}
+
+func TestCharacterHistory(t *testing.T) {
+	dir := t.TempDir()
+	cm := NewCharacterManager(dir)
+	_, err := cm.MigrateToStructured(CharacterFormatJSON, false)
+	assert.Error(t, err) // 没有 character.md 时无法迁移
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "character.md"), []byte("## 林凡\n- 状态：健康\n- 物品：木剑"), 0644))
+	_, err = cm.MigrateToStructured(CharacterFormatJSON, false)
+	assert.NoError(t, err)
+
+	// 第5章：获得玄铁剑；第12章：AI 错误地写成身亡，同时新增角色
+	changes, err := cm.UpdateWithHistory("## 林凡\n- 状态：健康\n- 物品：木剑、玄铁剑", 5, ChangeSourceAI, "获得玄铁剑")
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, "物品: 木剑 -> 木剑、玄铁剑", changes[0].Summary())
+
+	changes, err = cm.UpdateWithHistory("## 林凡\n- 状态：身亡\n- 物品：木剑、玄铁剑\n\n## 苏瑶\n- 身份：师姐", 12, ChangeSourceAI, "误判")
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+
+	// 查询历史状态
+	state, ok, err := cm.StateAt("林凡", 3)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"木剑"}, state.Inventory)
+
+	state, _, _ = cm.StateAt("林凡", 8)
+	assert.Equal(t, "健康", state.Status)
+	assert.Equal(t, []string{"木剑", "玄铁剑"}, state.Inventory)
+
+	_, ok, _ = cm.StateAt("苏瑶", 8)
+	assert.False(t, ok)
+
+	// 回滚林凡在第12章的错误变更，不影响苏瑶
+	history, err := cm.GetHistory("林凡")
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	rollback, err := cm.Rollback("林凡", history[1].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ChangeSourceRollback, rollback.Source)
+	assert.Equal(t, 12, rollback.Chapter)
+
+	db, err := cm.LoadDB()
+	assert.NoError(t, err)
+	linfan, _ := db.Find("林凡")
+	assert.Equal(t, "健康", linfan.Status)
+	_, ok = db.Find("苏瑶")
+	assert.True(t, ok)
+
+	state, _, _ = cm.StateAt("林凡", 12)
+	assert.Equal(t, "健康", state.Status)
+
+	_, err = cm.Rollback("林凡", history[1].ID)
+	assert.Error(t, err)
+}
+
+func TestRelationshipGraph(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "character.md"), []byte("## 林凡\n- 状态：健康"), 0644))
+	cm := NewCharacterManager(dir)
+	_, err := cm.MigrateToStructured(CharacterFormatJSON, false)
+	assert.NoError(t, err)
+
+	// 角色更新中记录的关系同步到关系图
+	_, err = cm.UpdateWithHistory("## 林凡\n- 关系：苏瑶（师姐）、赵无极（仇敌）", 3, ChangeSourceAI, "")
+	assert.NoError(t, err)
+	graph, err := cm.GetRelationshipStore().LoadGraph()
+	assert.NoError(t, err)
+	assert.Len(t, graph.Edges, 2)
+	edge, ok := graph.Find("苏瑶", "林凡") // 双向关系不区分方向
+	assert.True(t, ok)
+	assert.Equal(t, "师姐", edge.Type)
+	assert.Equal(t, DefaultRelationshipStrength, edge.Strength)
+	assert.Equal(t, 3, edge.Since)
+
+	// 强度变化记录章节，超出范围的强度被限制
+	assert.True(t, graph.Upsert(&RelationshipEdge{From: "林凡", To: "苏瑶", Strength: 12}, 8))
+	assert.Equal(t, MaxRelationshipStrength, edge.Strength)
+	assert.Equal(t, "师姐", edge.Type)
+	assert.Equal(t, 8, edge.Changed)
+	assert.False(t, graph.Upsert(&RelationshipEdge{From: "林凡", To: "苏瑶", Strength: 10}, 9))
+	assert.NoError(t, cm.GetRelationshipStore().SaveGraph(graph))
+
+	compact := graph.RenderCompact()
+	assert.Equal(t, "- 林凡 ↔ 苏瑶：师姐（强度10，第8章变化）\n- 林凡 ↔ 赵无极：仇敌（强度5，第3章起）", compact)
+	// 角色设定中已列出的关系不再重复，强度等额外信息仍然保留
+	assert.Contains(t, cm.GetContextText(), "## 人物关系\n- 林凡 ↔ 苏瑶：师姐（强度10，第8章变化）")
+	assert.NotContains(t, cm.GetContextText(), "赵无极：仇敌（")
+
+	assert.Contains(t, graph.ToDOT(""), "\"林凡\" -> \"苏瑶\" [label=\"师姐 10\", penwidth=3.5, dir=none];")
+	mermaid := graph.ToMermaid("赵无极")
+	assert.Contains(t, mermaid, "c0[\"林凡\"]")
+	assert.Contains(t, mermaid, "c0 ---|\"仇敌 5\"| c1")
+	assert.NotContains(t, mermaid, "苏瑶")
+
+	assert.True(t, graph.Remove("赵无极", "林凡"))
+	assert.Len(t, graph.Edges, 1)
+}
+
+func TestSyncRelationshipsBothSides(t *testing.T) {
+	graph := &RelationshipGraph{}
+	characters := []*Character{
+		{Name: "林凡", Relationships: []CharacterRelation{{Target: "苏瑶", Relation: "师姐"}, {Target: "赵无极", Relation: "仇敌"}}},
+		{Name: "苏瑶", Relationships: []CharacterRelation{{Target: "林凡", Relation: "师弟"}}},
+		{Name: "赵无极", Relationships: []CharacterRelation{{Target: "林凡", Relation: "仇敌"}}},
+	}
+	graph.SyncFromCharacters(characters, 1)
+
+	// 称呼不同时拆分为两条单向关系，各自保留类型
+	forward, ok := graph.Find("林凡", "苏瑶")
+	assert.True(t, ok)
+	assert.Equal(t, "师姐", forward.Type)
+	assert.True(t, forward.Directed)
+	backward, ok := graph.Find("苏瑶", "林凡")
+	assert.True(t, ok)
+	assert.Equal(t, "师弟", backward.Type)
+	assert.NotSame(t, forward, backward)
+
+	// 称呼相同时仍为一条双向关系
+	enemy, ok := graph.Find("赵无极", "林凡")
+	assert.True(t, ok)
+	assert.False(t, enemy.Directed)
+	assert.Len(t, graph.Edges, 3)
+
+	// 再次同步不会互相覆盖
+	assert.Equal(t, 0, graph.SyncFromCharacters(characters, 2))
+	assert.Equal(t, "师姐", forward.Type)
+	assert.Equal(t, "师弟", backward.Type)
+}
+
+func TestCharacterCountPlainMarkdown(t *testing.T) {
+	dir := t.TempDir()
+	cm := NewCharacterManager(dir)
+	assert.Equal(t, 0, cm.GetCharacterCount())
+
+	// 没有角色标题但有内容时视为1个角色
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "character.md"), []byte("林凡，青云山弟子。"), 0644))
+	assert.Equal(t, 1, NewCharacterManager(dir).GetCharacterCount())
+}
@@ -0,0 +1,284 @@
+package managers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	content "github.com/Kizunad/modular-workflow-v2/components/content/utils"
+)
+
+// CharacterHistoryFile 角色变更历史文件名
+const CharacterHistoryFile = "character_history.json"
+
+// 角色变更来源
+const (
+	ChangeSourceAI       = "ai"       // AI 分析章节后自动更新
+	ChangeSourceManual   = "manual"   // 直接更新
+	ChangeSourceRollback = "rollback" // 回滚
+)
+
+// FieldChange 单个字段的变化
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// CharacterChange 一次角色变更记录，保存变更前后的完整快照，便于查询任意章节的状态和回滚
+type CharacterChange struct {
+	ID         int           `json:"id"`
+	Character  string        `json:"character"`
+	Chapter    int           `json:"chapter"`          // 引起变更的章节
+	Source     string        `json:"source"`           // ai/manual/rollback
+	Reason     string        `json:"reason,omitempty"` // 变更原因
+	Changes    []FieldChange `json:"changes"`
+	Before     *Character    `json:"before,omitempty"` // 为nil表示新增角色
+	After      *Character    `json:"after,omitempty"`  // 为nil表示删除角色
+	RolledBack bool          `json:"rolled_back,omitempty"`
+	CreatedAt  string        `json:"created_at"`
+}
+
+// CharacterHistory 角色变更历史
+type CharacterHistory struct {
+	Changes []*CharacterChange `json:"changes"`
+}
+
+// CharacterHistoryStore 角色变更历史文件管理器
+type CharacterHistoryStore struct {
+	*BaseFileManager
+}
+
+// NewCharacterHistoryStore 创建角色变更历史管理器
+func NewCharacterHistoryStore(novelDir string) *CharacterHistoryStore {
+	return &CharacterHistoryStore{
+		BaseFileManager: NewBaseFileManager(filepath.Join(novelDir, CharacterHistoryFile)),
+	}
+}
+
+// LoadHistory 读取变更历史，文件不存在时返回空历史
+func (hs *CharacterHistoryStore) LoadHistory() (*CharacterHistory, error) {
+	if !hs.Exists() {
+		return &CharacterHistory{}, nil
+	}
+
+	data, err := os.ReadFile(hs.GetPath())
+	if err != nil {
+		return nil, content.NewFileReadError(hs.GetPath(), err)
+	}
+	return decodeHistory(string(data))
+}
+
+// SaveHistory 写入变更历史
+func (hs *CharacterHistoryStore) SaveHistory(history *CharacterHistory) error {
+	data, err := encodeHistory(history)
+	if err != nil {
+		return err
+	}
+	return hs.Save(data)
+}
+
+// ModifyHistory 在文件锁内读取最新的变更历史，由 fn 修改后写回，并发记录变更时不会丢失或重复分配ID
+func (hs *CharacterHistoryStore) ModifyHistory(fn func(history *CharacterHistory) error) error {
+	return hs.Modify(func(current string) (string, error) {
+		history, err := decodeHistory(current)
+		if err != nil {
+			return "", err
+		}
+		if err := fn(history); err != nil {
+			return "", err
+		}
+		return encodeHistory(history)
+	})
+}
+
+// decodeHistory 解析变更历史
+func decodeHistory(data string) (*CharacterHistory, error) {
+	history := &CharacterHistory{}
+	if strings.TrimSpace(data) == "" {
+		return history, nil
+	}
+	if err := json.Unmarshal([]byte(data), history); err != nil {
+		return nil, fmt.Errorf("解析角色变更历史失败: %w", err)
+	}
+	return history, nil
+}
+
+// encodeHistory 序列化变更历史
+func encodeHistory(history *CharacterHistory) (string, error) {
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("序列化角色变更历史失败: %w", err)
+	}
+	return string(data), nil
+}
+
+// Append 追加变更记录并分配ID
+func (h *CharacterHistory) Append(change *CharacterChange) {
+	change.ID = 1
+	if n := len(h.Changes); n > 0 {
+		change.ID = h.Changes[n-1].ID + 1
+	}
+	if change.CreatedAt == "" {
+		change.CreatedAt = time.Now().Format(time.RFC3339)
+	}
+	h.Changes = append(h.Changes, change)
+}
+
+// Get 按ID查找变更记录
+func (h *CharacterHistory) Get(id int) (*CharacterChange, bool) {
+	for _, change := range h.Changes {
+		if change.ID == id {
+			return change, true
+		}
+	}
+	return nil, false
+}
+
+// ForCharacter 返回角色的所有变更记录（按时间顺序）
+func (h *CharacterHistory) ForCharacter(name string) []*CharacterChange {
+	var changes []*CharacterChange
+	for _, change := range h.Changes {
+		if change.matches(name) {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// StateAt 查询角色在指定章节结束时的状态
+// 取章节号不超过 chapter 的最后一次变更之后的快照；该章节之前没有变更时取第一次变更之前的快照；
+// 没有任何变更记录时返回 current。返回false表示角色在该章节时尚不存在（或已被删除）
+func (h *CharacterHistory) StateAt(name string, chapter int, current *Character) (*Character, bool) {
+	changes := h.ForCharacter(name)
+	if len(changes) == 0 {
+		return current, current != nil
+	}
+
+	var state *Character
+	found := false
+	for _, change := range changes {
+		if change.Chapter <= chapter {
+			state, found = change.After, true
+		}
+	}
+	if !found {
+		state = changes[0].Before
+	}
+	return state, state != nil
+}
+
+// matches 判断变更是否属于指定角色（按变更前后的名称和别名匹配）
+func (cc *CharacterChange) matches(name string) bool {
+	if cc.Character == name {
+		return true
+	}
+	for _, snapshot := range []*Character{cc.Before, cc.After} {
+		if snapshot == nil {
+			continue
+		}
+		if snapshot.Name == name {
+			return true
+		}
+		for _, alias := range snapshot.Aliases {
+			if alias == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Summary 变更内容的简要描述，如 "状态: 闭关 -> 重伤; 物品: +玄铁剑"
+func (cc *CharacterChange) Summary() string {
+	if cc.Before == nil {
+		return "新增角色"
+	}
+	if cc.After == nil {
+		return "删除角色"
+	}
+
+	parts := make([]string, 0, len(cc.Changes))
+	for _, change := range cc.Changes {
+		switch {
+		case change.Before == "":
+			parts = append(parts, fmt.Sprintf("%s: +%s", change.Field, change.After))
+		case change.After == "":
+			parts = append(parts, fmt.Sprintf("%s: -%s", change.Field, change.Before))
+		default:
+			parts = append(parts, fmt.Sprintf("%s: %s -> %s", change.Field, change.Before, change.After))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// characterFields 用于比较的字段及显示名称（按渲染顺序）
+func characterFields(c *Character) [][2]string {
+	if c == nil {
+		return nil
+	}
+
+	relations := make([]string, 0, len(c.Relationships))
+	for _, relation := range c.Relationships {
+		relations = append(relations, relation.Target+"（"+relation.Relation+"）")
+	}
+	appearance := ""
+	if c.FirstAppearance > 0 || c.LastAppearance > 0 {
+		appearance = fmt.Sprintf("%d-%d", c.FirstAppearance, c.LastAppearance)
+	}
+
+	return [][2]string{
+		{"名称", c.Name},
+		{"别名", strings.Join(c.Aliases, "、")},
+		{"年龄", c.Age},
+		{"身份", c.Identity},
+		{"性格", c.Personality},
+		{"背景", c.Background},
+		{"目标", c.Goal},
+		{"能力", strings.Join(c.Abilities, "、")},
+		{"状态", c.Status},
+		{"位置", c.Location},
+		{"物品", strings.Join(c.Inventory, "、")},
+		{"关系", strings.Join(relations, "、")},
+		{"出场", appearance},
+		{"备注", c.Notes},
+	}
+}
+
+// DiffCharacters 比较角色变更前后的字段，before 或 after 为nil时表示新增或删除
+func DiffCharacters(before, after *Character) []FieldChange {
+	beforeFields := characterFields(before)
+	afterFields := characterFields(after)
+
+	var changes []FieldChange
+	for i := range characterFields(&Character{}) {
+		var oldValue, newValue string
+		var field string
+		if beforeFields != nil {
+			field, oldValue = beforeFields[i][0], beforeFields[i][1]
+		}
+		if afterFields != nil {
+			field, newValue = afterFields[i][0], afterFields[i][1]
+		}
+		if oldValue != newValue {
+			changes = append(changes, FieldChange{Field: field, Before: oldValue, After: newValue})
+		}
+	}
+	return changes
+}
+
+// cloneCharacter 深拷贝角色快照
+func cloneCharacter(c *Character) *Character {
+	if c == nil {
+		return nil
+	}
+	clone := *c
+	clone.Aliases = append([]string(nil), c.Aliases...)
+	clone.Abilities = append([]string(nil), c.Abilities...)
+	clone.Inventory = append([]string(nil), c.Inventory...)
+	clone.Relationships = append([]CharacterRelation(nil), c.Relationships...)
+	return &clone
+}
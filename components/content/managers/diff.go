@@ -0,0 +1,240 @@
+package managers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffOp 差异操作类型
+type DiffOp int
+
+const (
+	// DiffEqual 两侧相同
+	DiffEqual DiffOp = iota
+	// DiffDelete 仅在旧内容中存在
+	DiffDelete
+	// DiffInsert 仅在新内容中存在
+	DiffInsert
+)
+
+// maxEditDistance 编辑距离上限，超过后不再求最短编辑序列，直接视为整体删除后插入
+const maxEditDistance = 4000
+
+// DiffLine 一行差异，OldLine/NewLine 为从1开始的行号（不存在于该侧时为0）
+type DiffLine struct {
+	Op      DiffOp
+	Text    string
+	OldLine int
+	NewLine int
+}
+
+// DiffLines 计算两组行之间的最短编辑序列
+func DiffLines(a, b []string) []DiffLine {
+	ops := EditScript(a, b)
+	lines := make([]DiffLine, 0, len(ops))
+	oldLine, newLine := 0, 0
+	for _, op := range ops {
+		switch op {
+		case DiffEqual:
+			oldLine++
+			newLine++
+			lines = append(lines, DiffLine{Op: op, Text: a[oldLine-1], OldLine: oldLine, NewLine: newLine})
+		case DiffDelete:
+			oldLine++
+			lines = append(lines, DiffLine{Op: op, Text: a[oldLine-1], OldLine: oldLine})
+		case DiffInsert:
+			newLine++
+			lines = append(lines, DiffLine{Op: op, Text: b[newLine-1], NewLine: newLine})
+		}
+	}
+	return lines
+}
+
+// EditScript 使用 Myers 算法计算将 a 变为 b 的最短编辑序列，可用于行、段落或字符
+func EditScript[T comparable](a, b []T) []DiffOp {
+	// 先去掉公共前缀和后缀，减少计算量
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]DiffOp, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, DiffEqual)
+	}
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for i := 0; i < suffix; i++ {
+		ops = append(ops, DiffEqual)
+	}
+	return ops
+}
+
+// myers Myers 差异算法，trace 只保存每一步用到的对角线范围
+func myers[T comparable](a, b []T) []DiffOp {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(n, m)
+	}
+
+	// v[k] 为对角线 k 上能到达的最远 x，trace[d] 保存第 d 步开始前 k ∈ [-d-1, d+1] 的值
+	limit := n + m
+	if limit > maxEditDistance {
+		limit = maxEditDistance
+	}
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m)
+			}
+		}
+	}
+
+	// 差异过大，视为整体替换
+	return replaceAll(n, m)
+}
+
+// backtrack 根据 trace 从终点回溯出编辑序列
+func backtrack(trace [][]int, n, m int) []DiffOp {
+	at := func(d, k int) int { return trace[d][k+d+1] }
+
+	var reversed []DiffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(d, k-1) < at(d, k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(d, prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, DiffEqual)
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, DiffInsert)
+			} else {
+				reversed = append(reversed, DiffDelete)
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	ops := make([]DiffOp, len(reversed))
+	for i, op := range reversed {
+		ops[len(reversed)-1-i] = op
+	}
+	return ops
+}
+
+// replaceAll 整体删除 n 项后插入 m 项
+func replaceAll(n, m int) []DiffOp {
+	ops := make([]DiffOp, 0, n+m)
+	for i := 0; i < n; i++ {
+		ops = append(ops, DiffDelete)
+	}
+	for i := 0; i < m; i++ {
+		ops = append(ops, DiffInsert)
+	}
+	return ops
+}
+
+// UnifiedDiff 生成统一格式（unified）的行差异，context 为每处修改前后保留的上下文行数；内容相同时返回空字符串
+func UnifiedDiff(oldName, newName, oldText, newText string, context int) string {
+	lines := DiffLines(splitDiffLines(oldText), splitDiffLines(newText))
+
+	var b strings.Builder
+	for start := 0; start < len(lines); {
+		// 找到下一处修改
+		for start < len(lines) && lines[start].Op == DiffEqual {
+			start++
+		}
+		if start == len(lines) {
+			break
+		}
+
+		// 向前扩展上下文，向后合并相距不超过 2*context 行的修改
+		first := max(start-context, 0)
+		last := start
+		for i := start; i < len(lines); i++ {
+			if lines[i].Op != DiffEqual {
+				last = i
+			} else if i-last > 2*context {
+				break
+			}
+		}
+		end := min(last+context+1, len(lines))
+
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		writeHunk(&b, lines[first:end])
+		start = end
+	}
+	return b.String()
+}
+
+// writeHunk 输出一个差异块
+func writeHunk(b *strings.Builder, hunk []DiffLine) {
+	oldStart, newStart, oldCount, newCount := 0, 0, 0, 0
+	for _, line := range hunk {
+		if line.Op != DiffInsert {
+			if oldStart == 0 {
+				oldStart = line.OldLine
+			}
+			oldCount++
+		}
+		if line.Op != DiffDelete {
+			if newStart == 0 {
+				newStart = line.NewLine
+			}
+			newCount++
+		}
+	}
+
+	fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, line := range hunk {
+		prefix := " "
+		switch line.Op {
+		case DiffDelete:
+			prefix = "-"
+		case DiffInsert:
+			prefix = "+"
+		}
+		b.WriteString(prefix + line.Text + "\n")
+	}
+}
+
+// splitDiffLines 按行切分，空内容没有任何行
+func splitDiffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,113 @@
+package managers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 锁文件统一放在被保护文件所在目录的版本历史目录下（.history/locks/<文件名>.lock），不与小说内容混在一起
+// 锁加在独立的锁文件上而不是目标文件本身，原子替换（rename）目标文件不会使锁失效
+const (
+	LockDirName    = "locks"
+	LockFileSuffix = ".lock"
+)
+
+// 获取文件锁的超时时间和重试间隔
+var (
+	FileLockTimeout   = 30 * time.Second
+	fileLockRetryWait = 20 * time.Millisecond
+)
+
+// FileLock 跨进程的建议性文件锁
+// 同一进程内的不同 goroutine 之间同样互斥（每次加锁都会单独打开锁文件）
+type FileLock struct {
+	path string
+	file *os.File
+}
+
+// LockFilePath 保护 path 的锁文件路径；path 本身位于版本历史目录中时直接使用该目录下的 locks
+func LockFilePath(path string) string {
+	dir := filepath.Dir(path)
+	if filepath.Base(dir) != HistoryDirName {
+		dir = filepath.Join(dir, HistoryDirName)
+	}
+	return filepath.Join(dir, LockDirName, filepath.Base(path)+LockFileSuffix)
+}
+
+// LockFile 获取保护 path 的排他锁，锁被占用时等待，超过 FileLockTimeout 返回错误
+func LockFile(path string) (*FileLock, error) {
+	lockPath := LockFilePath(path)
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		return nil, fmt.Errorf("创建锁文件目录失败: %w", err)
+	}
+
+	deadline := time.Now().Add(FileLockTimeout)
+	for {
+		file, err := tryLock(lockPath)
+		if err == nil {
+			return &FileLock{path: lockPath, file: file}, nil
+		}
+		if err != errLockBusy {
+			return nil, fmt.Errorf("获取文件锁失败 %s: %w", lockPath, err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("获取文件锁超时 %s（可能有其他任务正在写入）", lockPath)
+		}
+		time.Sleep(fileLockRetryWait)
+	}
+}
+
+// Unlock 释放文件锁
+func (l *FileLock) Unlock() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	err := unlock(l.path, l.file)
+	l.file = nil
+	return err
+}
+
+// WithFileLock 在持有 path 的文件锁期间执行 fn
+func WithFileLock(path string, fn func() error) error {
+	lock, err := LockFile(path)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	return fn()
+}
+
+// WriteFileAtomic 原子写入文件：先写入同目录下的临时文件并刷盘，再重命名覆盖目标文件
+// 读取方要么看到旧内容要么看到新内容，不会读到写了一半的文件
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	// 重命名成功后临时文件已不存在，删除失败可以忽略
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,41 @@
+//go:build !unix
+
+package managers
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// errLockBusy 锁已被其他进程或 goroutine 持有
+var errLockBusy = errors.New("文件锁已被占用")
+
+// staleLockAge 锁文件超过该时间未释放视为持有者已异常退出
+const staleLockAge = 10 * time.Minute
+
+// tryLock 以独占创建锁文件的方式加锁（不支持 flock 的平台）
+func tryLock(lockPath string) (*os.File, error) {
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0644)
+	if err == nil {
+		return file, nil
+	}
+	if !os.IsExist(err) {
+		return nil, err
+	}
+
+	// 清理异常退出遗留的锁文件
+	if stat, statErr := os.Stat(lockPath); statErr == nil && time.Since(stat.ModTime()) > staleLockAge {
+		os.Remove(lockPath)
+	}
+	return nil, errLockBusy
+}
+
+// unlock 关闭并删除锁文件
+func unlock(lockPath string, file *os.File) error {
+	err := file.Close()
+	if removeErr := os.Remove(lockPath); err == nil {
+		err = removeErr
+	}
+	return err
+}
@@ -0,0 +1,35 @@
+package managers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockFilePath(t *testing.T) {
+	dir := "novel"
+	assert.Equal(t, filepath.Join(dir, HistoryDirName, LockDirName, "worldview.md.lock"), LockFilePath(filepath.Join(dir, "worldview.md")))
+	// 版本历史目录中的文件不再嵌套一层 .history
+	assert.Equal(t, filepath.Join(dir, HistoryDirName, LockDirName, "versions.jsonl.lock"), LockFilePath(filepath.Join(dir, HistoryDirName, "versions.jsonl")))
+}
+
+func TestFileLockKeepsNovelDirClean(t *testing.T) {
+	dir := t.TempDir()
+	bfm := NewBaseFileManager(filepath.Join(dir, "worldview.md"))
+	require.NoError(t, bfm.Update("灵气复苏的世界"))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), LockFileSuffix)
+	}
+
+	// 同一把锁可以在释放后再次获取
+	lock, err := LockFile(filepath.Join(dir, "worldview.md"))
+	require.NoError(t, err)
+	require.NoError(t, lock.Unlock())
+	require.NoError(t, WithFileLock(filepath.Join(dir, "worldview.md"), func() error { return nil }))
+}
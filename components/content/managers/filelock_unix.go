@@ -0,0 +1,39 @@
+//go:build unix
+
+package managers
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// errLockBusy 锁已被其他进程或 goroutine 持有
+var errLockBusy = errors.New("文件锁已被占用")
+
+// tryLock 以非阻塞方式对锁文件加 flock 排他锁
+// 锁文件在释放后保留，删除锁文件会与其他正在等待的进程产生竞争
+func tryLock(lockPath string) (*os.File, error) {
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errLockBusy
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// unlock 释放 flock 并关闭锁文件
+func unlock(lockPath string, file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
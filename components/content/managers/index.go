@@ -3,6 +3,7 @@ package managers
 import (
 	"encoding/json"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -42,7 +43,7 @@ func NewIndexReader(novelDir string) *IndexReader {
 	titlePath := filepath.Join(novelDir, "title")
 	
 	reader := &IndexReader{
-		BaseFileManager: NewBaseFileManager(indexPath),
+		BaseFileManager: newComponentFileManager(indexPath, "index"),
 		novelDir:        novelDir,
 		titlePath:       titlePath,
 		indexPath:       indexPath,
@@ -180,6 +181,22 @@ func (ir *IndexReader) GetChapterSummaries() []ChapterSummary {
 	return ir.indexData.Summaries
 }
 
+// FindChapterSummary 查找指定章节的摘要
+// 摘要的 chapter_id 可能是章节编号（如 "3"、"003"）或章节标题，两种形式都会匹配
+func (ir *IndexReader) FindChapterSummary(chapterNum int, title string) (*ChapterSummary, bool) {
+	summaries := ir.GetChapterSummaries()
+	for i := range summaries {
+		id := strings.TrimSpace(summaries[i].ChapterID)
+		if num, err := strconv.Atoi(id); err == nil && num == chapterNum {
+			return &summaries[i], true
+		}
+		if title != "" && (id == title || summaries[i].Title == title) {
+			return &summaries[i], true
+		}
+	}
+	return nil, false
+}
+
 // GetRecentChapters 获取最近N章的摘要
 func (ir *IndexReader) GetRecentChapters(count int) []ChapterSummary {
 	summaries := ir.GetChapterSummaries()
@@ -313,67 +330,70 @@ func (im *IndexManager) GetIndexPath() string {
 }
 
 // UpdateSummary 更新章节摘要到索引文件，兼容旧的 summarizer.IndexManager 接口
+// 在文件锁内基于磁盘上的最新索引修改，多个总结任务并发写入时不会丢失彼此的摘要
 func (im *IndexManager) UpdateSummary(summary ChapterSummary) error {
-	// 重新加载最新数据
-	im.load()
-	
-	// 初始化索引数据结构
-	if im.indexData == nil {
-		im.indexData = &IndexJSON{
-			Version:    "1.0",
-			LastUpdate: time.Now().Format(time.RFC3339),
-			Chapters:   0,
-			Summaries:  make([]ChapterSummary, 0),
+	return im.Modify(func(current string) (string, error) {
+		// 基于最新数据修改
+		im.indexData = nil
+		if strings.TrimSpace(current) != "" {
+			var indexData IndexJSON
+			if err := json.Unmarshal([]byte(current), &indexData); err == nil {
+				im.indexData = &indexData
+			}
 		}
-	}
-	
-	// 查找是否已存在相同章节ID的摘要
-	existingIndex := -1
-	for i, existingSummary := range im.indexData.Summaries {
-		if existingSummary.ChapterID == summary.ChapterID {
-			existingIndex = i
-			break
+		
+		// 初始化索引数据结构
+		if im.indexData == nil {
+			im.indexData = &IndexJSON{
+				Version:    "1.0",
+				LastUpdate: time.Now().Format(time.RFC3339),
+				Chapters:   0,
+				Summaries:  make([]ChapterSummary, 0),
+			}
 		}
-	}
-	
-	// 处理时间戳格式兼容性（summary_crud_tool.go 使用 time.Time，而这里使用 string）
-	timestampStr := summary.Timestamp
-	if timestampStr == "" {
-		timestampStr = time.Now().Format(time.RFC3339)
-	}
-	
-	// 创建标准化的摘要对象
-	standardSummary := ChapterSummary{
-		ChapterID: summary.ChapterID,
-		Title:     summary.Title,
-		Summary:   summary.Summary,
-		WordCount: summary.WordCount,
-		Timestamp: timestampStr,
-	}
-	
-	// 更新或添加摘要
-	if existingIndex >= 0 {
-		// 更新现有摘要
-		im.indexData.Summaries[existingIndex] = standardSummary
-	} else {
-		// 添加新摘要
-		im.indexData.Summaries = append(im.indexData.Summaries, standardSummary)
-		im.indexData.Chapters = len(im.indexData.Summaries)
-	}
-	
-	// 更新最后修改时间
-	im.indexData.LastUpdate = time.Now().Format(time.RFC3339)
-	
-	// 序列化为JSON
-	jsonData, err := json.MarshalIndent(im.indexData, "", "  ")
-	if err != nil {
-		return content.NewInvalidConfigError("failed to marshal index data", err)
-	}
-	
-	// 保存到文件
-	if err := im.BaseFileManager.Save(string(jsonData)); err != nil {
-		return err
-	}
-	
-	return nil
+		
+		// 查找是否已存在相同章节ID的摘要
+		existingIndex := -1
+		for i, existingSummary := range im.indexData.Summaries {
+			if existingSummary.ChapterID == summary.ChapterID {
+				existingIndex = i
+				break
+			}
+		}
+		
+		// 处理时间戳格式兼容性（summary_crud_tool.go 使用 time.Time，而这里使用 string）
+		timestampStr := summary.Timestamp
+		if timestampStr == "" {
+			timestampStr = time.Now().Format(time.RFC3339)
+		}
+		
+		// 创建标准化的摘要对象
+		standardSummary := ChapterSummary{
+			ChapterID: summary.ChapterID,
+			Title:     summary.Title,
+			Summary:   summary.Summary,
+			WordCount: summary.WordCount,
+			Timestamp: timestampStr,
+		}
+		
+		// 更新或添加摘要
+		if existingIndex >= 0 {
+			// 更新现有摘要
+			im.indexData.Summaries[existingIndex] = standardSummary
+		} else {
+			// 添加新摘要
+			im.indexData.Summaries = append(im.indexData.Summaries, standardSummary)
+			im.indexData.Chapters = len(im.indexData.Summaries)
+		}
+		
+		// 更新最后修改时间
+		im.indexData.LastUpdate = time.Now().Format(time.RFC3339)
+		
+		// 序列化为JSON
+		jsonData, err := json.MarshalIndent(im.indexData, "", "  ")
+		if err != nil {
+			return "", content.NewInvalidConfigError("failed to marshal index data", err)
+		}
+		return string(jsonData), nil
+	})
 }
\ No newline at end of file
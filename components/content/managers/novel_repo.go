@@ -0,0 +1,268 @@
+package managers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// 提交作者的默认值
+const (
+	DefaultGitAuthorName  = "novel-workflow"
+	DefaultGitAuthorEmail = "novel-workflow@localhost"
+)
+
+// novelRepoIgnores 不纳入版本控制的文件：版本历史（含文件锁）、原子写入的临时文件、
+// 上下文压缩缓存（token.CompressionCacheDir）和向量重建进度（tools.ReindexStateFilePattern）
+var novelRepoIgnores = []string{HistoryDirName + "/", ".*.tmp-*", ".compressed/", ".reindex_*.json"}
+
+// WorkflowRun 一次工作流运行的信息，写入提交说明
+type WorkflowRun struct {
+	Workflow string   // 工作流名称，如 write、plan、summarize
+	Model    string   // 使用的模型
+	Author   string   // 工作流或任务ID，如 task:summarize/summary-latest-1700000000
+	Chapters []int    // 受影响的章节，提交时会与改动的章节文件合并
+	Files    []string // 本次运行写入的文件，只暂存这些文件；为空时不提交
+}
+
+// NovelCommit 一次工作流运行产生的提交
+type NovelCommit struct {
+	Hash     string
+	Message  string
+	Chapters []int
+	Files    []string // 改动的文件，带状态前缀，如 "M example_chapter_3.json"
+}
+
+// NovelRepo 以 git 仓库管理的小说目录，使用纯 Go 的 git 实现，不依赖 git 命令
+type NovelRepo struct {
+	dir  string
+	repo *git.Repository
+}
+
+// OpenNovelRepo 打开小说目录的 git 仓库；目录还不是 git 仓库且 create 为 true 时初始化新仓库
+// 小说目录必须是仓库根目录，避免把上层仓库中无关的文件一起提交
+func OpenNovelRepo(novelDir string, create bool) (*NovelRepo, error) {
+	dir, err := filepath.Abs(novelDir)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if errors.Is(err, git.ErrRepositoryNotExists) && create {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		repo, err = git.PlainInit(dir, false)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开小说目录的 git 仓库失败: %w", err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("打开小说目录的 git 仓库失败: %w", err)
+	}
+	if root := worktree.Filesystem.Root(); filepath.Clean(root) != dir {
+		return nil, fmt.Errorf("小说目录 %s 不是 git 仓库的根目录（仓库位于 %s）", dir, root)
+	}
+
+	nr := &NovelRepo{dir: dir, repo: repo}
+	if err := nr.ensureIgnores(); err != nil {
+		return nil, err
+	}
+	return nr, nil
+}
+
+// GetDir 获取仓库目录
+func (nr *NovelRepo) GetDir() string {
+	return nr.dir
+}
+
+// ensureIgnores 在 .gitignore 中补充不应提交的文件
+func (nr *NovelRepo) ensureIgnores() error {
+	return NewBaseFileManager(filepath.Join(nr.dir, ".gitignore")).Modify(func(current string) (string, error) {
+		existing := make(map[string]bool)
+		for _, line := range strings.Split(current, "\n") {
+			existing[strings.TrimSpace(line)] = true
+		}
+
+		updated := current
+		for _, pattern := range novelRepoIgnores {
+			if existing[pattern] {
+				continue
+			}
+			if updated != "" && !strings.HasSuffix(updated, "\n") {
+				updated += "\n"
+			}
+			updated += pattern + "\n"
+		}
+		return updated, nil
+	})
+}
+
+// CommitRun 暂存本次运行写入的文件并提交，提交说明记录工作流、模型、作者和受影响的章节
+// 其他任务同时产生的改动不会被带上；没有任何改动时不提交，返回nil；多个工作流同时运行时提交互斥进行
+func (nr *NovelRepo) CommitRun(run WorkflowRun, author *object.Signature) (*NovelCommit, error) {
+	if author == nil {
+		author = &object.Signature{Name: DefaultGitAuthorName, Email: DefaultGitAuthorEmail}
+	}
+	if author.When.IsZero() {
+		author.When = time.Now()
+	}
+
+	var commit *NovelCommit
+	err := WithFileLock(filepath.Join(nr.dir, HistoryDirName, "git"), func() error {
+		worktree, err := nr.repo.Worktree()
+		if err != nil {
+			return err
+		}
+		paths := nr.runPaths(run.Files)
+		if len(paths) == 0 {
+			return nil
+		}
+		for _, path := range paths {
+			if _, err := worktree.Add(path); err != nil {
+				return fmt.Errorf("暂存 %s 失败: %w", path, err)
+			}
+		}
+
+		status, err := worktree.Status()
+		if err != nil {
+			return fmt.Errorf("读取仓库状态失败: %w", err)
+		}
+		files, chapters := stagedChanges(status, paths, NewChapterManager(nr.dir))
+		if len(files) == 0 {
+			return nil
+		}
+
+		commit = &NovelCommit{Chapters: mergeChapters(run.Chapters, chapters), Files: files}
+		commit.Message = formatRunMessage(run, commit.Chapters, files)
+		hash, err := worktree.Commit(commit.Message, &git.CommitOptions{Author: author})
+		if err != nil {
+			return fmt.Errorf("提交失败: %w", err)
+		}
+		commit.Hash = hash.String()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return commit, nil
+}
+
+// runPaths 把运行写入的文件转换为仓库内的相对路径（按路径排序），跳过仓库外和被忽略的文件
+func (nr *NovelRepo) runPaths(files []string) []string {
+	patterns := make([]gitignore.Pattern, 0, len(novelRepoIgnores))
+	for _, pattern := range novelRepoIgnores {
+		patterns = append(patterns, gitignore.ParsePattern(pattern, nil))
+	}
+	matcher := gitignore.NewMatcher(patterns)
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, file := range files {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(nr.dir, file)
+		}
+		rel, err := filepath.Rel(nr.dir, file)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") || seen[rel] || matcher.Match(strings.Split(rel, "/"), false) {
+			continue
+		}
+		seen[rel] = true
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// stagedChanges 返回 paths 中已暂存改动的文件和其中改动的章节
+func stagedChanges(status git.Status, paths []string, chapterManager *ChapterManager) ([]string, []int) {
+	files := make([]string, 0, len(paths))
+	var chapters []int
+	for _, path := range paths {
+		fileStatus, ok := status[path]
+		if !ok || fileStatus.Staging == git.Unmodified || fileStatus.Staging == git.Untracked {
+			continue
+		}
+		files = append(files, fmt.Sprintf("%c %s", fileStatus.Staging, path))
+		if id, ok := chapterManager.ChapterIDFromFile(filepath.Base(path)); ok {
+			chapters = append(chapters, id)
+		}
+	}
+	return files, chapters
+}
+
+// mergeChapters 合并章节号，去重并排序
+func mergeChapters(lists ...[]int) []int {
+	seen := make(map[int]bool)
+	var merged []int
+	for _, list := range lists {
+		for _, chapter := range list {
+			if chapter > 0 && !seen[chapter] {
+				seen[chapter] = true
+				merged = append(merged, chapter)
+			}
+		}
+	}
+	sort.Ints(merged)
+	return merged
+}
+
+// formatRunMessage 生成结构化的提交说明，首行为概要，之后每行一个字段，便于 git log --grep 检索
+//
+//	write: 第12章
+//
+//	Workflow: write
+//	Model: deepseek-chat
+//	Author: task:write/write-1700000000
+//	Chapters: 12
+//	Files:
+//	  A example_chapter_12.json
+func formatRunMessage(run WorkflowRun, chapters []int, files []string) string {
+	workflow := run.Workflow
+	if workflow == "" {
+		workflow = "manual"
+	}
+
+	chapterNames := make([]string, len(chapters))
+	chapterIDs := make([]string, len(chapters))
+	for i, chapter := range chapters {
+		chapterNames[i] = fmt.Sprintf("第%d章", chapter)
+		chapterIDs[i] = strconv.Itoa(chapter)
+	}
+
+	var b strings.Builder
+	if len(chapters) > 0 {
+		fmt.Fprintf(&b, "%s: %s\n\n", workflow, strings.Join(chapterNames, "、"))
+	} else {
+		fmt.Fprintf(&b, "%s: 更新 %d 个文件\n\n", workflow, len(files))
+	}
+	fmt.Fprintf(&b, "Workflow: %s\n", workflow)
+	if run.Model != "" {
+		fmt.Fprintf(&b, "Model: %s\n", run.Model)
+	}
+	if run.Author != "" {
+		fmt.Fprintf(&b, "Author: %s\n", run.Author)
+	}
+	if len(chapters) > 0 {
+		fmt.Fprintf(&b, "Chapters: %s\n", strings.Join(chapterIDs, ", "))
+	}
+	b.WriteString("Files:\n")
+	for _, file := range files {
+		b.WriteString("  " + file + "\n")
+	}
+	return b.String()
+}
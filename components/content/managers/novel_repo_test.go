@@ -0,0 +1,73 @@
+package managers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNovelRepoCommitRun(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := OpenNovelRepo(dir, false)
+	assert.Error(t, err)
+
+	repo, err := OpenNovelRepo(dir, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// 本次运行通过 context 中的版本信息写入的文件会被登记
+	ctx := TrackRunChanges(WithVersionInfo(context.Background(), VersionInfo{Author: "task:write/1"}))
+	changes := RunChangesFromContext(ctx)
+	chapterManager := NewChapterManager(dir)
+	chapterManager.SetVersionInfo(VersionInfoFromContext(ctx))
+	_, err = chapterManager.WriteChapter("开端", "第一章正文")
+	assert.NoError(t, err)
+	indexManager := NewIndexManager(dir)
+	indexManager.SetVersionInfo(VersionInfoFromContext(ctx))
+	assert.NoError(t, indexManager.UpdateSummary(ChapterSummary{ChapterID: "1", Summary: "摘要"}))
+
+	// 其他任务同时产生的改动和缓存目录不会被提交
+	assert.NoError(t, NewBaseFileManager(filepath.Join(dir, "worldview.md")).Save("其他任务的世界观"))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, ".compressed"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".compressed", "cache.txt"), []byte("缓存"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".reindex_novel.json"), []byte("{}"), 0644))
+
+	files := append(changes.Files(), filepath.Join(dir, ".compressed", "cache.txt"))
+	commit, err := repo.CommitRun(WorkflowRun{Workflow: "write", Model: "deepseek-chat", Author: "task:write/1", Files: files}, nil)
+	if !assert.NoError(t, err) || !assert.NotNil(t, commit) {
+		return
+	}
+	assert.Equal(t, []int{1}, commit.Chapters)
+	assert.Contains(t, commit.Message, "write: 第1章\n")
+	assert.Contains(t, commit.Message, "Model: deepseek-chat\n")
+	assert.Contains(t, commit.Message, "Author: task:write/1\n")
+	assert.Contains(t, commit.Message, "A example_chapter_1.json\n")
+	assert.NotContains(t, commit.Message, HistoryDirName)
+	assert.NotContains(t, commit.Message, "worldview.md")
+	assert.NotContains(t, commit.Message, ".compressed")
+
+	// 摘要等不写章节文件的运行也在提交说明中列出来源章节
+	indexManager.SetVersionInfo(VersionInfo{})
+	assert.NoError(t, indexManager.UpdateSummary(ChapterSummary{ChapterID: "1", Summary: "新摘要"}))
+	commit, err = repo.CommitRun(WorkflowRun{Workflow: "summarize", Chapters: []int{1}, Files: []string{"index.json"}}, nil)
+	if assert.NoError(t, err) && assert.NotNil(t, commit) {
+		assert.Contains(t, commit.Message, "summarize: 第1章\n")
+		assert.Equal(t, []string{"M index.json"}, commit.Files)
+	}
+
+	// 没有改动时不提交
+	commit, err = repo.CommitRun(WorkflowRun{Workflow: "plan", Files: changes.Files()}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, commit)
+
+	// 小说目录位于其他仓库内部时拒绝使用
+	subDir := filepath.Join(dir, "sub")
+	assert.NoError(t, os.MkdirAll(subDir, 0755))
+	_, err = OpenNovelRepo(subDir, true)
+	assert.Error(t, err)
+}
@@ -15,10 +15,11 @@ import (
 
 // PlanEntry 单个章节的规划记录
 type PlanEntry struct {
-	Chapter  string `json:"chapter"`  // chapter_title，多个plan合成一个chapter
-	Plan     string `json:"plan"`     // title
-	Content  string `json:"content"`  // content
-	Finished bool   `json:"finished"` // boolean
+	Chapter  string `json:"chapter"`       // chapter_title，多个plan合成一个chapter
+	Plan     string `json:"plan"`          // title
+	Content  string `json:"content"`       // content
+	Finished bool   `json:"finished"`      // boolean
+	Arc      int    `json:"arc,omitempty"` // 所属篇章ID，见 structure.json
 }
 
 // PlannerState planner.json 的整体结构
@@ -40,7 +41,7 @@ func NewPlannerContentManager(novelDir string) *PlannerContentManager {
 	plannerPath := filepath.Join(novelDir, "planner.json")
 
 	manager := &PlannerContentManager{
-		BaseFileManager: NewBaseFileManager(plannerPath),
+		BaseFileManager: newComponentFileManager(plannerPath, "plan"),
 		novelDir:        novelDir,
 	}
 
@@ -112,6 +113,39 @@ func (pcm *PlannerContentManager) Save(ps *PlannerState) error {
 	return nil
 }
 
+// ModifyState 在文件锁内读取 planner.json 的最新状态，由 fn 修改后原子写回
+// 多个任务同时修改规划时依次进行，不会用各自的旧状态覆盖对方的修改；fn 返回错误时不写入
+func (pcm *PlannerContentManager) ModifyState(fn func(state *PlannerState) error) error {
+	var updated *PlannerState
+	err := pcm.Modify(func(current string) (string, error) {
+		state := &PlannerState{Plans: []PlanEntry{}}
+		if strings.TrimSpace(current) != "" {
+			if err := json.Unmarshal([]byte(current), state); err != nil {
+				return "", utils.NewInvalidConfigError("failed to parse planner state", err)
+			}
+		}
+
+		if err := fn(state); err != nil {
+			return "", err
+		}
+
+		state.UpdatedAt = time.Now().Format(time.RFC3339)
+		data, err := json.MarshalIndent(state, "", "  ")
+		if err != nil {
+			return "", utils.NewFileWriteError(pcm.GetPath(), err)
+		}
+		updated = state
+		return string(data), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 更新内存状态
+	pcm.state = updated
+	return nil
+}
+
 // CountChapters 计算章节数量
 func (pcm *PlannerContentManager) CountChapters() (int, error) {
 	// 使用新的ChapterManager（假设已重构）
@@ -125,34 +159,26 @@ func (pcm *PlannerContentManager) UpsertPlan(chapter, plan, content string, fini
 		return utils.NewInvalidConfigError("plan chapter cannot be empty", nil)
 	}
 
-	state, err := pcm.Load()
-	if err != nil {
-		return err
-	}
-
-	// 查找是否已存在该章节的规划
-	found := false
-	for i, entry := range state.Plans {
-		if entry.Chapter == chapter {
-			state.Plans[i].Plan = plan
-			state.Plans[i].Content = content
-			state.Plans[i].Finished = finished
-			found = true
-			break
+	return pcm.ModifyState(func(state *PlannerState) error {
+		// 查找是否已存在该章节的规划
+		for i, entry := range state.Plans {
+			if entry.Chapter == chapter {
+				state.Plans[i].Plan = plan
+				state.Plans[i].Content = content
+				state.Plans[i].Finished = finished
+				return nil
+			}
 		}
-	}
 
-	// 如果不存在，添加新的规划
-	if !found {
+		// 如果不存在，添加新的规划
 		state.Plans = append(state.Plans, PlanEntry{
 			Chapter:  chapter,
 			Plan:     plan,
 			Content:  content,
 			Finished: false,
 		})
-	}
-
-	return pcm.Save(state)
+		return nil
+	})
 }
 
 // GetPlan 获取指定章节的规划
@@ -205,21 +231,71 @@ func (pcm *PlannerContentManager) GetAllPlans() []PlanEntry {
 	return plans
 }
 
-// GetPlansWithTokenLimit 获取限制Token数量的规划内容
+// SetPlanArc 将规划关联到篇章，arcID 为0时取消关联
+func (pcm *PlannerContentManager) SetPlanArc(chapter string, arcID int) error {
+	if arcID != 0 {
+		structure, err := NewStoryStructureStore(pcm.novelDir).LoadStructure()
+		if err != nil {
+			return err
+		}
+		if _, ok := structure.FindArc(arcID); !ok {
+			return utils.NewInvalidConfigError("arc not found: "+strconv.Itoa(arcID), nil)
+		}
+	}
+
+	return pcm.ModifyState(func(state *PlannerState) error {
+		for i, entry := range state.Plans {
+			if entry.Chapter == chapter {
+				state.Plans[i].Arc = arcID
+				return nil
+			}
+		}
+		return utils.NewInvalidConfigError("plan entry not found: "+chapter, nil)
+	})
+}
+
+// GetPlansForArc 获取关联到篇章的所有规划
+func (pcm *PlannerContentManager) GetPlansForArc(arcID int) []PlanEntry {
+	var plans []PlanEntry
+	for _, plan := range pcm.GetAllPlans() {
+		if plan.Arc == arcID {
+			plans = append(plans, plan)
+		}
+	}
+	return plans
+}
+
+// arcSection 当前篇章的目标和前情，放在规划信息之前
+func (pcm *PlannerContentManager) arcSection() string {
+	return NewStoryStructureStore(pcm.novelDir).GetArcContext()
+}
+
+// GetPlansWithTokenLimit 获取限制Token数量的规划内容，当前篇章的目标放在最前面
 func (pcm *PlannerContentManager) GetPlansWithTokenLimit(maxTokens int) (string, int) {
 	plans := pcm.GetAllPlans()
-	if len(plans) == 0 {
+	arcSection := pcm.arcSection()
+	if len(plans) == 0 && arcSection == "" {
 		return "", 0
 	}
 
 	// 构建规划文本
 	var planBuilder strings.Builder
+	if arcSection != "" {
+		planBuilder.WriteString(arcSection)
+		if len(plans) > 0 {
+			planBuilder.WriteString("\n\n")
+		}
+	}
 	for i, entry := range plans {
 		if i > 0 {
 			planBuilder.WriteString("\n\n")
 		}
 		planBuilder.WriteString("章节: ")
 		planBuilder.WriteString(entry.Chapter)
+		if entry.Arc != 0 {
+			planBuilder.WriteString("\n篇章: #")
+			planBuilder.WriteString(strconv.Itoa(entry.Arc))
+		}
 		planBuilder.WriteString("\n规划: ")
 		planBuilder.WriteString(entry.Plan)
 		if entry.Content != "" {
@@ -236,9 +312,9 @@ func (pcm *PlannerContentManager) GetPlansWithTokenLimit(maxTokens int) (string,
 
 	planText := planBuilder.String()
 
-	// 如果有TokenBudget，使用正确的组件名
+	// 如果有TokenBudget，使用正确的组件名（配置了压缩器时超出预算的内容会被压缩而非截断）
 	if pcm.GetTokenBudget() != nil {
-		return pcm.GetTokenBudget().TruncateToTokenLimit(planText, "plan")
+		return pcm.GetTokenBudget().CompressToTokenLimit(planText, "plan", pcm.CompressionSource())
 	}
 
 	// 否则使用基础的截断逻辑
@@ -247,55 +323,42 @@ func (pcm *PlannerContentManager) GetPlansWithTokenLimit(maxTokens int) (string,
 
 // DeletePlan 删除指定章节的规划
 func (pcm *PlannerContentManager) DeletePlan(chapter string) error {
-	state, err := pcm.Load()
-	if err != nil {
-		return err
-	}
-
-	// 查找并删除
-	for i, entry := range state.Plans {
-		if entry.Chapter == chapter {
-			state.Plans = append(state.Plans[:i], state.Plans[i+1:]...)
-			return pcm.Save(state)
+	return pcm.ModifyState(func(state *PlannerState) error {
+		// 查找并删除，如果没找到，不报错
+		for i, entry := range state.Plans {
+			if entry.Chapter == chapter {
+				state.Plans = append(state.Plans[:i], state.Plans[i+1:]...)
+				break
+			}
 		}
-	}
-
-	// 如果没找到，不报错
-	return nil
+		return nil
+	})
 }
 
 // UpdatePlanContent 更新计划的内容
 func (pcm *PlannerContentManager) UpdatePlanContent(chapter, content string) error {
-	state, err := pcm.Load()
-	if err != nil {
-		return err
-	}
-
-	for i, entry := range state.Plans {
-		if entry.Chapter == chapter {
-			state.Plans[i].Content = content
-			return pcm.Save(state)
+	return pcm.ModifyState(func(state *PlannerState) error {
+		for i, entry := range state.Plans {
+			if entry.Chapter == chapter {
+				state.Plans[i].Content = content
+				return nil
+			}
 		}
-	}
-
-	return utils.NewInvalidConfigError("plan entry not found: "+chapter, nil)
+		return utils.NewInvalidConfigError("plan entry not found: "+chapter, nil)
+	})
 }
 
 // SetPlanFinished 设置计划完成状态
 func (pcm *PlannerContentManager) SetPlanFinished(chapter string, finished bool) error {
-	state, err := pcm.Load()
-	if err != nil {
-		return err
-	}
-
-	for i, entry := range state.Plans {
-		if entry.Chapter == chapter {
-			state.Plans[i].Finished = finished
-			return pcm.Save(state)
+	return pcm.ModifyState(func(state *PlannerState) error {
+		for i, entry := range state.Plans {
+			if entry.Chapter == chapter {
+				state.Plans[i].Finished = finished
+				return nil
+			}
 		}
-	}
-
-	return utils.NewInvalidConfigError("plan entry not found: "+chapter, nil)
+		return utils.NewInvalidConfigError("plan entry not found: "+chapter, nil)
+	})
 }
 
 // GetUnfinishedPlans 获取未完成的计划
@@ -327,14 +390,10 @@ func (pcm *PlannerContentManager) GetFirstUnfinishedPlan() (*PlanEntry, bool) {
 
 // ClearAllPlans 清空所有规划
 func (pcm *PlannerContentManager) ClearAllPlans() error {
-	state, err := pcm.Load()
-	if err != nil {
-		return err
-	}
-
-	state.Plans = []PlanEntry{}
-
-	return pcm.Save(state)
+	return pcm.ModifyState(func(state *PlannerState) error {
+		state.Plans = []PlanEntry{}
+		return nil
+	})
 }
 
 // GetPlannerMetadata 获取规划管理器元数据
@@ -404,20 +463,33 @@ func (pcm *PlannerContentManager) GetPlansSummary() string {
 	return summaryBuilder.String()
 }
 
-// FormatPlansForContext 格式化规划内容用于上下文
+// FormatPlansForContext 格式化规划内容用于上下文，当前篇章的目标放在最前面
 func (pcm *PlannerContentManager) FormatPlansForContext() string {
 	plans := pcm.GetAllPlans()
+	arcSection := pcm.arcSection()
 	if len(plans) == 0 {
+		if arcSection != "" {
+			return arcSection + "\n\n暂无章节规划"
+		}
 		return "暂无章节规划"
 	}
 
 	var contextBuilder strings.Builder
+	if arcSection != "" {
+		contextBuilder.WriteString(arcSection)
+		contextBuilder.WriteString("\n\n")
+	}
 	contextBuilder.WriteString("章节规划:\n\n")
 
 	for _, entry := range plans {
 		contextBuilder.WriteString("【")
 		contextBuilder.WriteString(entry.Chapter)
 		contextBuilder.WriteString("】\n")
+		if entry.Arc != 0 {
+			contextBuilder.WriteString("篇章: #")
+			contextBuilder.WriteString(strconv.Itoa(entry.Arc))
+			contextBuilder.WriteString("\n")
+		}
 		contextBuilder.WriteString("规划: ")
 		contextBuilder.WriteString(entry.Plan)
 		if entry.Content != "" {
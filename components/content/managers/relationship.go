@@ -0,0 +1,396 @@
+package managers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	content "github.com/Kizunad/modular-workflow-v2/components/content/utils"
+)
+
+// RelationshipGraphFile 人物关系图文件名
+const RelationshipGraphFile = "relationships.json"
+
+// 关系强度范围，强度表示关系的紧密或激烈程度，关系性质由类型表达
+const (
+	MinRelationshipStrength     = 1
+	MaxRelationshipStrength     = 10
+	DefaultRelationshipStrength = 5
+)
+
+// RelationshipEdge 两个角色之间的关系
+type RelationshipEdge struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Type        string `json:"type"`                  // 关系类型，如 师姐、仇敌、盟友
+	Strength    int    `json:"strength"`              // 关系强度 1-10
+	Directed    bool   `json:"directed,omitempty"`    // 是否为单向关系（如 暗恋），否则双向
+	Description string `json:"description,omitempty"` // 补充说明
+	Since       int    `json:"since,omitempty"`       // 建立关系的章节
+	Changed     int    `json:"changed,omitempty"`     // 最近一次变化的章节
+}
+
+// RelationshipGraph 人物关系图
+type RelationshipGraph struct {
+	Edges     []*RelationshipEdge `json:"edges"`
+	UpdatedAt string              `json:"updated_at"`
+}
+
+// Find 查找两个角色之间的关系，双向关系不区分方向
+func (g *RelationshipGraph) Find(from, to string) (*RelationshipEdge, bool) {
+	for _, edge := range g.Edges {
+		if edge.From == from && edge.To == to {
+			return edge, true
+		}
+		if !edge.Directed && edge.From == to && edge.To == from {
+			return edge, true
+		}
+	}
+	return nil, false
+}
+
+// Upsert 新增或更新关系，chapter 为关系建立或变化的章节；返回关系是否发生变化
+func (g *RelationshipGraph) Upsert(edge *RelationshipEdge, chapter int) bool {
+	if edge.Strength == 0 {
+		edge.Strength = DefaultRelationshipStrength
+	}
+	edge.Strength = clampStrength(edge.Strength)
+
+	existing, ok := g.Find(edge.From, edge.To)
+	if !ok {
+		edge.Since, edge.Changed = chapter, chapter
+		g.Edges = append(g.Edges, edge)
+		return true
+	}
+
+	changed := false
+	if edge.Type != "" && edge.Type != existing.Type {
+		existing.Type, changed = edge.Type, true
+	}
+	if edge.Strength != existing.Strength {
+		existing.Strength, changed = edge.Strength, true
+	}
+	if edge.Description != "" && edge.Description != existing.Description {
+		existing.Description, changed = edge.Description, true
+	}
+	if edge.Directed != existing.Directed {
+		existing.Directed, changed = edge.Directed, true
+	}
+	if changed {
+		existing.Changed = chapter
+	}
+	return changed
+}
+
+// Remove 删除两个角色之间的关系，返回是否找到
+func (g *RelationshipGraph) Remove(from, to string) bool {
+	target, ok := g.Find(from, to)
+	if !ok {
+		return false
+	}
+	for i, edge := range g.Edges {
+		if edge == target {
+			g.Edges = append(g.Edges[:i], g.Edges[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ForCharacter 返回与角色相关的所有关系
+func (g *RelationshipGraph) ForCharacter(name string) []*RelationshipEdge {
+	var edges []*RelationshipEdge
+	for _, edge := range g.Edges {
+		if edge.From == name || edge.To == name {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// SyncFromCharacters 根据角色库中记录的关系补充或更新关系图（不删除已有关系），返回变化的关系数
+// 角色"林凡"的关系"苏瑶（师姐）"表示 林凡 -> 苏瑶，类型为师姐；
+// 双方对彼此的称呼不同时（苏瑶记录"林凡（师弟）"），拆分为两条单向关系，各自保留自己的类型
+func (g *RelationshipGraph) SyncFromCharacters(characters []*Character, chapter int) int {
+	changed := 0
+	for _, character := range characters {
+		for _, relation := range character.Relationships {
+			if relation.Target == "" || relation.Target == character.Name {
+				continue
+			}
+			edge := &RelationshipEdge{From: character.Name, To: relation.Target, Type: relation.Relation}
+			if existing, ok := g.findExact(edge.From, edge.To); ok {
+				// 角色库中没有强度和方向信息，保留关系图中的值
+				edge.Strength, edge.Directed = existing.Strength, existing.Directed
+			} else if reverse, ok := g.findExact(edge.To, edge.From); ok {
+				if !reverse.Directed && (edge.Type == "" || edge.Type == reverse.Type) {
+					continue
+				}
+				if !reverse.Directed {
+					reverse.Directed, reverse.Changed = true, chapter
+					changed++
+				}
+				edge.Strength, edge.Directed = reverse.Strength, true
+			}
+			if g.Upsert(edge, chapter) {
+				changed++
+			}
+		}
+	}
+	return changed
+}
+
+// findExact 按方向查找关系
+func (g *RelationshipGraph) findExact(from, to string) (*RelationshipEdge, bool) {
+	for _, edge := range g.Edges {
+		if edge.From == from && edge.To == to {
+			return edge, true
+		}
+	}
+	return nil, false
+}
+
+// sortedEdges 按强度从高到低排序的关系（强度相同时按名称排序）
+func (g *RelationshipGraph) sortedEdges(edges []*RelationshipEdge) []*RelationshipEdge {
+	sorted := append([]*RelationshipEdge(nil), edges...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Strength != sorted[j].Strength {
+			return sorted[i].Strength > sorted[j].Strength
+		}
+		if sorted[i].From != sorted[j].From {
+			return sorted[i].From < sorted[j].From
+		}
+		return sorted[i].To < sorted[j].To
+	})
+	return sorted
+}
+
+// RenderCompact 渲染为紧凑的文本，每条关系一行，用于放入上下文
+func (g *RelationshipGraph) RenderCompact() string {
+	return g.renderCompact(g.Edges)
+}
+
+// renderCompact 将指定的关系渲染为紧凑的文本
+func (g *RelationshipGraph) renderCompact(edges []*RelationshipEdge) string {
+	if len(edges) == 0 {
+		return ""
+	}
+
+	lines := make([]string, 0, len(edges))
+	for _, edge := range g.sortedEdges(edges) {
+		lines = append(lines, edge.compact())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// describedBy 关系是否已由角色设定中的"关系"字段完整表达：类型相同，且没有强度、说明、变化章节等额外信息
+func (e *RelationshipEdge) describedBy(db *CharacterDB) bool {
+	if e.Strength != DefaultRelationshipStrength || e.Description != "" || (e.Changed > 0 && e.Changed != e.Since) {
+		return false
+	}
+	stated := func(from, to string) bool {
+		character, ok := db.Find(from)
+		if !ok {
+			return false
+		}
+		for _, relation := range character.Relationships {
+			if relation.Target == to && relation.Relation == e.Type {
+				return true
+			}
+		}
+		return false
+	}
+	return stated(e.From, e.To) || (!e.Directed && stated(e.To, e.From))
+}
+
+// compact 单条关系的紧凑表示，如 "- 林凡 → 苏瑶：师姐（强度8，第12章变化）"
+func (e *RelationshipEdge) compact() string {
+	arrow := "↔"
+	if e.Directed {
+		arrow = "→"
+	}
+
+	meta := fmt.Sprintf("强度%d", e.Strength)
+	if e.Changed > 0 && e.Changed != e.Since {
+		meta += fmt.Sprintf("，第%d章变化", e.Changed)
+	} else if e.Since > 0 {
+		meta += fmt.Sprintf("，第%d章起", e.Since)
+	}
+
+	line := fmt.Sprintf("- %s %s %s：%s（%s）", e.From, arrow, e.To, e.Type, meta)
+	if e.Description != "" {
+		line += " " + e.Description
+	}
+	return line
+}
+
+// edgeLabel 导出图时的边标签
+func (e *RelationshipEdge) edgeLabel() string {
+	if e.Type == "" {
+		return fmt.Sprintf("%d", e.Strength)
+	}
+	return fmt.Sprintf("%s %d", e.Type, e.Strength)
+}
+
+// nodes 返回关系图中出现的所有角色（按首次出现顺序）
+func (g *RelationshipGraph) nodes(edges []*RelationshipEdge) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, edge := range edges {
+		for _, name := range []string{edge.From, edge.To} {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// filterEdges 按角色筛选关系，name 为空时返回全部
+func (g *RelationshipGraph) filterEdges(name string) []*RelationshipEdge {
+	if name == "" {
+		return g.Edges
+	}
+	return g.ForCharacter(name)
+}
+
+// ToDOT 导出为 Graphviz DOT，name 不为空时只导出与该角色相关的关系
+// 边的粗细与关系强度对应，双向关系不画箭头
+func (g *RelationshipGraph) ToDOT(name string) string {
+	var b strings.Builder
+	b.WriteString("digraph relationships {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, fontname=\"sans-serif\"];\n")
+	b.WriteString("  edge [fontname=\"sans-serif\"];\n")
+
+	edges := g.filterEdges(name)
+	for _, node := range g.nodes(edges) {
+		fmt.Fprintf(&b, "  %s;\n", dotQuote(node))
+	}
+	for _, edge := range edges {
+		attrs := fmt.Sprintf("label=%s, penwidth=%.1f", dotQuote(edge.edgeLabel()), 0.5+float64(edge.Strength)*0.3)
+		if !edge.Directed {
+			attrs += ", dir=none"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotQuote(edge.From), dotQuote(edge.To), attrs)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// ToMermaid 导出为 Mermaid flowchart，name 不为空时只导出与该角色相关的关系
+func (g *RelationshipGraph) ToMermaid(name string) string {
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+
+	edges := g.filterEdges(name)
+	ids := make(map[string]string)
+	for i, node := range g.nodes(edges) {
+		ids[node] = fmt.Sprintf("c%d", i)
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[node], mermaidEscape(node))
+	}
+	for _, edge := range edges {
+		arrow := "---"
+		if edge.Directed {
+			arrow = "-->"
+		}
+		fmt.Fprintf(&b, "  %s %s|\"%s\"| %s\n", ids[edge.From], arrow, mermaidEscape(edge.edgeLabel()), ids[edge.To])
+	}
+	return b.String()
+}
+
+// dotQuote 生成 DOT 中的带引号标识符
+func dotQuote(value string) string {
+	return "\"" + strings.ReplaceAll(strings.ReplaceAll(value, "\\", "\\\\"), "\"", "\\\"") + "\""
+}
+
+// mermaidEscape 转义 Mermaid 标签中的双引号
+func mermaidEscape(value string) string {
+	return strings.ReplaceAll(value, "\"", "#quot;")
+}
+
+// clampStrength 将关系强度限制在有效范围内
+func clampStrength(strength int) int {
+	if strength < MinRelationshipStrength {
+		return MinRelationshipStrength
+	}
+	if strength > MaxRelationshipStrength {
+		return MaxRelationshipStrength
+	}
+	return strength
+}
+
+// RelationshipStore 人物关系图文件管理器
+type RelationshipStore struct {
+	*BaseFileManager
+}
+
+// NewRelationshipStore 创建人物关系图管理器
+func NewRelationshipStore(novelDir string) *RelationshipStore {
+	return &RelationshipStore{
+		BaseFileManager: NewBaseFileManager(filepath.Join(novelDir, RelationshipGraphFile)),
+	}
+}
+
+// LoadGraph 读取关系图，文件不存在时返回空图
+func (rs *RelationshipStore) LoadGraph() (*RelationshipGraph, error) {
+	if !rs.Exists() {
+		return &RelationshipGraph{}, nil
+	}
+
+	data, err := os.ReadFile(rs.GetPath())
+	if err != nil {
+		return nil, content.NewFileReadError(rs.GetPath(), err)
+	}
+	return decodeGraph(string(data))
+}
+
+// SaveGraph 写入关系图
+func (rs *RelationshipStore) SaveGraph(graph *RelationshipGraph) error {
+	data, err := encodeGraph(graph)
+	if err != nil {
+		return err
+	}
+	return rs.Save(data)
+}
+
+// ModifyGraph 在文件锁内读取最新的关系图，由 fn 修改后写回；fn 返回错误时不写入
+func (rs *RelationshipStore) ModifyGraph(fn func(graph *RelationshipGraph) error) error {
+	return rs.Modify(func(current string) (string, error) {
+		graph, err := decodeGraph(current)
+		if err != nil {
+			return "", err
+		}
+		if err := fn(graph); err != nil {
+			return "", err
+		}
+		return encodeGraph(graph)
+	})
+}
+
+// decodeGraph 解析关系图
+func decodeGraph(data string) (*RelationshipGraph, error) {
+	graph := &RelationshipGraph{}
+	if strings.TrimSpace(data) == "" {
+		return graph, nil
+	}
+	if err := json.Unmarshal([]byte(data), graph); err != nil {
+		return nil, fmt.Errorf("解析人物关系图失败: %w", err)
+	}
+	return graph, nil
+}
+
+// encodeGraph 序列化关系图并更新时间戳
+func encodeGraph(graph *RelationshipGraph) (string, error) {
+	graph.UpdatedAt = time.Now().Format(time.RFC3339)
+	data, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("序列化人物关系图失败: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,80 @@
+package managers
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// RunChanges 一次工作流运行写入的文件和处理的章节，提交时只暂存这些文件，不会带上同时运行的其他任务的改动
+// 多个工具可能并发写入，方法都是并发安全的；nil 表示不记录
+type RunChanges struct {
+	mu       sync.Mutex
+	files    map[string]bool
+	chapters []int
+}
+
+// TrackRunChanges 返回记录写入文件的 context；ctx 已在记录时沿用原有的记录
+// 通过 VersionInfoFromContext 设置版本信息的管理器，保存文件时会自动登记
+func TrackRunChanges(ctx context.Context) context.Context {
+	info := VersionInfoFromContext(ctx)
+	if info.changes != nil {
+		return ctx
+	}
+	info.changes = &RunChanges{files: make(map[string]bool)}
+	return WithVersionInfo(ctx, info)
+}
+
+// RunChangesFromContext 读取 context 中的运行记录，未记录时返回nil
+func RunChangesFromContext(ctx context.Context) *RunChanges {
+	return VersionInfoFromContext(ctx).changes
+}
+
+// AddFile 登记写入的文件
+func (rc *RunChanges) AddFile(path string) {
+	if rc == nil {
+		return
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.files[path] = true
+}
+
+// AddChapters 登记本次运行处理的章节，如摘要、角色分析的来源章节
+func (rc *RunChanges) AddChapters(chapters ...int) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.chapters = append(rc.chapters, chapters...)
+}
+
+// Files 写入过的文件（绝对路径，按路径排序）
+func (rc *RunChanges) Files() []string {
+	if rc == nil {
+		return nil
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	files := make([]string, 0, len(rc.files))
+	for path := range rc.files {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Chapters 处理过的章节，去重并排序
+func (rc *RunChanges) Chapters() []int {
+	if rc == nil {
+		return nil
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return mergeChapters(rc.chapters)
+}
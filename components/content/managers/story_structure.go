@@ -0,0 +1,458 @@
+package managers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	content "github.com/Kizunad/modular-workflow-v2/components/content/utils"
+)
+
+// StoryStructureFile 卷与篇章结构文件名
+const StoryStructureFile = "structure.json"
+
+// 卷与篇章的状态
+const (
+	StoryStatusPlanned   = "planned"   // 尚未开始
+	StoryStatusActive    = "active"    // 进行中
+	StoryStatusCompleted = "completed" // 已完成
+)
+
+// ValidStoryStatus 检查状态是否有效
+func ValidStoryStatus(status string) bool {
+	return status == StoryStatusPlanned || status == StoryStatusActive || status == StoryStatusCompleted
+}
+
+// storyStatusLabel 状态的中文名称
+func storyStatusLabel(status string) string {
+	switch status {
+	case StoryStatusActive:
+		return "进行中"
+	case StoryStatusCompleted:
+		return "已完成"
+	default:
+		return "未开始"
+	}
+}
+
+// Volume 卷，由若干篇章组成
+type Volume struct {
+	ID      int    `json:"id"`
+	Title   string `json:"title"`
+	Goal    string `json:"goal,omitempty"`    // 本卷要达成的目标
+	Status  string `json:"status"`            // planned/active/completed
+	Summary string `json:"summary,omitempty"` // 卷级摘要
+}
+
+// Arc 篇章（故事阶段），覆盖一段连续的章节，如 "生存" 篇第1-10章
+type Arc struct {
+	ID           int    `json:"id"`
+	Volume       int    `json:"volume,omitempty"` // 所属卷ID，0 表示不属于任何卷
+	Title        string `json:"title"`
+	Goal         string `json:"goal,omitempty"` // 本篇章要达成的目标
+	Status       string `json:"status"`         // planned/active/completed
+	StartChapter int    `json:"start_chapter,omitempty"`
+	EndChapter   int    `json:"end_chapter,omitempty"` // 0 表示尚未确定结束章节
+	Summary      string `json:"summary,omitempty"`     // 篇章级摘要
+}
+
+// Contains 章节是否属于本篇章；未指定起始章节的篇章不包含任何章节
+func (a *Arc) Contains(chapter int) bool {
+	if a.StartChapter <= 0 || chapter < a.StartChapter {
+		return false
+	}
+	return a.EndChapter <= 0 || chapter <= a.EndChapter
+}
+
+// RangeText 章节范围的文字描述，如 "第1-10章"、"第11章起"
+func (a *Arc) RangeText() string {
+	switch {
+	case a.StartChapter <= 0:
+		return "章节未定"
+	case a.EndChapter <= 0:
+		return fmt.Sprintf("第%d章起", a.StartChapter)
+	case a.EndChapter == a.StartChapter:
+		return fmt.Sprintf("第%d章", a.StartChapter)
+	default:
+		return fmt.Sprintf("第%d-%d章", a.StartChapter, a.EndChapter)
+	}
+}
+
+// StoryStructure 小说的卷与篇章结构，Volumes 和 Arcs 均按阅读顺序排列
+type StoryStructure struct {
+	Volumes   []*Volume `json:"volumes"`
+	Arcs      []*Arc    `json:"arcs"`
+	UpdatedAt string    `json:"updated_at"`
+}
+
+// FindVolume 按ID查找卷
+func (s *StoryStructure) FindVolume(id int) (*Volume, bool) {
+	for _, volume := range s.Volumes {
+		if volume.ID == id {
+			return volume, true
+		}
+	}
+	return nil, false
+}
+
+// FindArc 按ID查找篇章
+func (s *StoryStructure) FindArc(id int) (*Arc, bool) {
+	for _, arc := range s.Arcs {
+		if arc.ID == id {
+			return arc, true
+		}
+	}
+	return nil, false
+}
+
+// ArcsInVolume 返回卷中的所有篇章
+func (s *StoryStructure) ArcsInVolume(volumeID int) []*Arc {
+	var arcs []*Arc
+	for _, arc := range s.Arcs {
+		if arc.Volume == volumeID {
+			arcs = append(arcs, arc)
+		}
+	}
+	return arcs
+}
+
+// ArcForChapter 返回章节所属的篇章
+func (s *StoryStructure) ArcForChapter(chapter int) (*Arc, bool) {
+	for _, arc := range s.Arcs {
+		if arc.Contains(chapter) {
+			return arc, true
+		}
+	}
+	return nil, false
+}
+
+// CurrentArc 返回接下来要写的章节（latestChapter+1）所属的篇章；
+// 没有篇章覆盖该章节时返回最后一个进行中的篇章
+func (s *StoryStructure) CurrentArc(latestChapter int) (*Arc, bool) {
+	if arc, ok := s.ArcForChapter(latestChapter + 1); ok {
+		return arc, true
+	}
+	for i := len(s.Arcs) - 1; i >= 0; i-- {
+		if s.Arcs[i].Status == StoryStatusActive {
+			return s.Arcs[i], true
+		}
+	}
+	return nil, false
+}
+
+// PreviousArc 阅读顺序中位于 arc 之前的篇章
+func (s *StoryStructure) PreviousArc(arc *Arc) (*Arc, bool) {
+	for i, other := range s.Arcs {
+		if other == arc && i > 0 {
+			return s.Arcs[i-1], true
+		}
+	}
+	return nil, false
+}
+
+// UpsertVolume 新增或更新卷：ID 为0时追加新卷并分配ID，否则更新已有卷中非空的字段
+func (s *StoryStructure) UpsertVolume(volume *Volume) (*Volume, error) {
+	if volume.Status != "" && !ValidStoryStatus(volume.Status) {
+		return nil, fmt.Errorf("无效的状态: %s（可选 planned/active/completed）", volume.Status)
+	}
+
+	if volume.ID == 0 {
+		if volume.Title == "" {
+			return nil, fmt.Errorf("新建卷需要提供标题")
+		}
+		if volume.Status == "" {
+			volume.Status = StoryStatusPlanned
+		}
+		volume.ID = 1
+		for _, other := range s.Volumes {
+			if other.ID >= volume.ID {
+				volume.ID = other.ID + 1
+			}
+		}
+		s.Volumes = append(s.Volumes, volume)
+		return volume, nil
+	}
+
+	existing, ok := s.FindVolume(volume.ID)
+	if !ok {
+		return nil, fmt.Errorf("卷不存在: %d", volume.ID)
+	}
+	existing.Title = firstNonEmpty(volume.Title, existing.Title)
+	existing.Goal = firstNonEmpty(volume.Goal, existing.Goal)
+	existing.Status = firstNonEmpty(volume.Status, existing.Status)
+	existing.Summary = firstNonEmpty(volume.Summary, existing.Summary)
+	return existing, nil
+}
+
+// UpsertArc 新增或更新篇章：ID 为0时追加新篇章并分配ID，否则更新已有篇章中非零的字段
+// 篇章的章节范围不能与其他篇章重叠
+func (s *StoryStructure) UpsertArc(arc *Arc) (*Arc, error) {
+	if arc.Status != "" && !ValidStoryStatus(arc.Status) {
+		return nil, fmt.Errorf("无效的状态: %s（可选 planned/active/completed）", arc.Status)
+	}
+	if arc.Volume != 0 {
+		if _, ok := s.FindVolume(arc.Volume); !ok {
+			return nil, fmt.Errorf("卷不存在: %d", arc.Volume)
+		}
+	}
+
+	target := arc
+	if arc.ID == 0 {
+		if arc.Title == "" {
+			return nil, fmt.Errorf("新建篇章需要提供标题")
+		}
+		if arc.Status == "" {
+			arc.Status = StoryStatusPlanned
+		}
+	} else {
+		existing, ok := s.FindArc(arc.ID)
+		if !ok {
+			return nil, fmt.Errorf("篇章不存在: %d", arc.ID)
+		}
+		merged := *existing
+		if arc.Volume != 0 {
+			merged.Volume = arc.Volume
+		}
+		merged.Title = firstNonEmpty(arc.Title, existing.Title)
+		merged.Goal = firstNonEmpty(arc.Goal, existing.Goal)
+		merged.Status = firstNonEmpty(arc.Status, existing.Status)
+		merged.Summary = firstNonEmpty(arc.Summary, existing.Summary)
+		if arc.StartChapter != 0 {
+			merged.StartChapter = arc.StartChapter
+		}
+		if arc.EndChapter != 0 {
+			merged.EndChapter = arc.EndChapter
+		}
+		target = &merged
+	}
+
+	if err := s.checkArcRange(target); err != nil {
+		return nil, err
+	}
+
+	if target.ID == 0 {
+		target.ID = 1
+		for _, other := range s.Arcs {
+			if other.ID >= target.ID {
+				target.ID = other.ID + 1
+			}
+		}
+		s.Arcs = append(s.Arcs, target)
+		return target, nil
+	}
+	existing, _ := s.FindArc(target.ID)
+	*existing = *target
+	return existing, nil
+}
+
+// checkArcRange 检查篇章的章节范围有效且不与其他篇章重叠
+func (s *StoryStructure) checkArcRange(arc *Arc) error {
+	if arc.StartChapter < 0 || arc.EndChapter < 0 {
+		return fmt.Errorf("章节范围不能为负数")
+	}
+	if arc.EndChapter > 0 && arc.EndChapter < arc.StartChapter {
+		return fmt.Errorf("结束章节 %d 早于起始章节 %d", arc.EndChapter, arc.StartChapter)
+	}
+	if arc.StartChapter == 0 {
+		return nil
+	}
+
+	for _, other := range s.Arcs {
+		if other.ID == arc.ID || other.StartChapter <= 0 {
+			continue
+		}
+		// 两个区间重叠：各自的起点都不晚于对方的终点（终点为0视为无穷）
+		if (arc.EndChapter <= 0 || other.StartChapter <= arc.EndChapter) &&
+			(other.EndChapter <= 0 || arc.StartChapter <= other.EndChapter) {
+			return fmt.Errorf("篇章《%s》的章节范围 %s 与篇章《%s》的 %s 重叠", arc.Title, arc.RangeText(), other.Title, other.RangeText())
+		}
+	}
+	return nil
+}
+
+// RemoveArc 删除篇章，返回是否找到
+func (s *StoryStructure) RemoveArc(id int) bool {
+	for i, arc := range s.Arcs {
+		if arc.ID == id {
+			s.Arcs = append(s.Arcs[:i], s.Arcs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveVolume 删除卷，卷中的篇章保留但不再属于任何卷；返回是否找到
+func (s *StoryStructure) RemoveVolume(id int) bool {
+	for i, volume := range s.Volumes {
+		if volume.ID == id {
+			s.Volumes = append(s.Volumes[:i], s.Volumes[i+1:]...)
+			for _, arc := range s.ArcsInVolume(id) {
+				arc.Volume = 0
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// RenderOutline 渲染完整的卷与篇章大纲
+func (s *StoryStructure) RenderOutline() string {
+	if len(s.Volumes) == 0 && len(s.Arcs) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, volume := range s.Volumes {
+		fmt.Fprintf(&b, "%s\n", volume.compact())
+		for _, arc := range s.ArcsInVolume(volume.ID) {
+			fmt.Fprintf(&b, "  %s\n", arc.compact())
+		}
+	}
+	for _, arc := range s.ArcsInVolume(0) {
+		fmt.Fprintf(&b, "%s\n", arc.compact())
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// RenderArcContext 渲染当前篇章的目标和前情，用于放入上下文；没有当前篇章时返回空
+func (s *StoryStructure) RenderArcContext(latestChapter int) string {
+	arc, ok := s.CurrentArc(latestChapter)
+	if !ok {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("## 当前篇章\n")
+	if volume, ok := s.FindVolume(arc.Volume); ok {
+		fmt.Fprintf(&b, "卷：%s\n", volume.compact())
+	}
+	fmt.Fprintf(&b, "篇章：%s\n", arc.compact())
+	if arc.Summary != "" {
+		fmt.Fprintf(&b, "本篇进展：%s\n", arc.Summary)
+	}
+	if previous, ok := s.PreviousArc(arc); ok && previous.Summary != "" {
+		fmt.Fprintf(&b, "上一篇章《%s》：%s\n", previous.Title, previous.Summary)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// compact 卷的单行表示，如 "#1《荒岛》（进行中）目标：活下来并找到出路"
+func (v *Volume) compact() string {
+	line := fmt.Sprintf("#%d《%s》（%s）", v.ID, v.Title, storyStatusLabel(v.Status))
+	if v.Goal != "" {
+		line += "目标：" + v.Goal
+	}
+	return line
+}
+
+// compact 篇章的单行表示，如 "#2《适应》第11-20章（未开始）目标：建立稳定的食物来源"
+func (a *Arc) compact() string {
+	line := fmt.Sprintf("#%d《%s》%s（%s）", a.ID, a.Title, a.RangeText(), storyStatusLabel(a.Status))
+	if a.Goal != "" {
+		line += "目标：" + a.Goal
+	}
+	return line
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// StoryStructureStore 卷与篇章结构文件管理器
+type StoryStructureStore struct {
+	*BaseFileManager
+	novelDir string
+}
+
+// NewStoryStructureStore 创建卷与篇章结构管理器
+func NewStoryStructureStore(novelDir string) *StoryStructureStore {
+	return &StoryStructureStore{
+		BaseFileManager: NewBaseFileManager(filepath.Join(novelDir, StoryStructureFile)),
+		novelDir:        novelDir,
+	}
+}
+
+// LoadStructure 读取卷与篇章结构，文件不存在时返回空结构
+func (ss *StoryStructureStore) LoadStructure() (*StoryStructure, error) {
+	if !ss.Exists() {
+		return &StoryStructure{}, nil
+	}
+
+	data, err := os.ReadFile(ss.GetPath())
+	if err != nil {
+		return nil, content.NewFileReadError(ss.GetPath(), err)
+	}
+	return decodeStructure(string(data))
+}
+
+// SaveStructure 写入卷与篇章结构
+func (ss *StoryStructureStore) SaveStructure(structure *StoryStructure) error {
+	data, err := encodeStructure(structure)
+	if err != nil {
+		return err
+	}
+	return ss.Save(data)
+}
+
+// ModifyStructure 在文件锁内读取最新的结构，由 fn 修改后写回；fn 返回错误时不写入
+func (ss *StoryStructureStore) ModifyStructure(fn func(structure *StoryStructure) error) error {
+	return ss.Modify(func(current string) (string, error) {
+		structure, err := decodeStructure(current)
+		if err != nil {
+			return "", err
+		}
+		if err := fn(structure); err != nil {
+			return "", err
+		}
+		return encodeStructure(structure)
+	})
+}
+
+// GetCurrentArc 返回接下来要写的章节所属的篇章
+func (ss *StoryStructureStore) GetCurrentArc() (*Arc, bool) {
+	structure, err := ss.LoadStructure()
+	if err != nil {
+		return nil, false
+	}
+	return structure.CurrentArc(NewChapterManager(ss.novelDir).GetLatestChapterID())
+}
+
+// GetArcContext 渲染当前篇章的目标和前情，用于放入上下文
+func (ss *StoryStructureStore) GetArcContext() string {
+	structure, err := ss.LoadStructure()
+	if err != nil {
+		return ""
+	}
+	return structure.RenderArcContext(NewChapterManager(ss.novelDir).GetLatestChapterID())
+}
+
+// decodeStructure 解析卷与篇章结构
+func decodeStructure(data string) (*StoryStructure, error) {
+	structure := &StoryStructure{}
+	if strings.TrimSpace(data) == "" {
+		return structure, nil
+	}
+	if err := json.Unmarshal([]byte(data), structure); err != nil {
+		return nil, fmt.Errorf("解析卷与篇章结构失败: %w", err)
+	}
+	return structure, nil
+}
+
+// encodeStructure 序列化卷与篇章结构并更新时间戳
+func encodeStructure(structure *StoryStructure) (string, error) {
+	structure.UpdatedAt = time.Now().Format(time.RFC3339)
+	data, err := json.MarshalIndent(structure, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("序列化卷与篇章结构失败: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,66 @@
+package managers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoryStructure(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStoryStructureStore(dir)
+
+	err := store.ModifyStructure(func(structure *StoryStructure) error {
+		volume, err := structure.UpsertVolume(&Volume{Title: "荒岛", Goal: "活下来并找到出路", Status: StoryStatusActive})
+		require.NoError(t, err)
+		_, err = structure.UpsertArc(&Arc{Volume: volume.ID, Title: "生存", Goal: "找到水源和庇护所", StartChapter: 1, EndChapter: 2, Summary: "搭好了庇护所"})
+		require.NoError(t, err)
+		_, err = structure.UpsertArc(&Arc{Volume: volume.ID, Title: "适应", Goal: "建立稳定的食物来源", StartChapter: 3, Status: StoryStatusActive})
+		require.NoError(t, err)
+
+		// 章节范围重叠、卷不存在
+		_, err = structure.UpsertArc(&Arc{Title: "重叠", StartChapter: 2, EndChapter: 4})
+		assert.Error(t, err)
+		_, err = structure.UpsertArc(&Arc{Title: "无卷", Volume: 9})
+		assert.Error(t, err)
+		return nil
+	})
+	require.NoError(t, err)
+
+	structure, err := store.LoadStructure()
+	require.NoError(t, err)
+	assert.Len(t, structure.Arcs, 2)
+	arc, ok := structure.ArcForChapter(2)
+	assert.True(t, ok)
+	assert.Equal(t, "生存", arc.Title)
+	arc, ok = structure.CurrentArc(2)
+	assert.True(t, ok)
+	assert.Equal(t, "适应", arc.Title)
+	assert.Equal(t, "第3章起", arc.RangeText())
+
+	// 更新只覆盖提供的字段
+	_, err = structure.UpsertArc(&Arc{ID: arc.ID, EndChapter: 8})
+	assert.NoError(t, err)
+	assert.Equal(t, "建立稳定的食物来源", arc.Goal)
+	assert.Equal(t, "第3-8章", arc.RangeText())
+
+	// 已写完前两章，规划关联篇章后当前篇章的目标出现在规划上下文中
+	require.NoError(t, store.SaveStructure(structure))
+	chapterManager := NewChapterManager(dir)
+	for _, title := range []string{"第一章", "第二章"} {
+		_, _, err := chapterManager.CreateChapter(title, "正文")
+		require.NoError(t, err)
+	}
+	pcm := NewPlannerContentManager(dir)
+	require.NoError(t, pcm.UpsertPlan("第3章", "设陷阱", "在溪边布置陷阱", false))
+	require.NoError(t, pcm.SetPlanArc("第3章", arc.ID))
+	assert.Error(t, pcm.SetPlanArc("第3章", 99))
+	assert.Len(t, pcm.GetPlansForArc(arc.ID), 1)
+
+	text := pcm.FormatPlansForContext()
+	assert.Contains(t, text, "## 当前篇章")
+	assert.Contains(t, text, "目标：建立稳定的食物来源")
+	assert.Contains(t, text, "上一篇章《生存》：搭好了庇护所")
+	assert.Contains(t, text, "篇章: #2")
+}
@@ -0,0 +1,419 @@
+package managers
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	content "github.com/Kizunad/modular-workflow-v2/components/content/utils"
+)
+
+// HistoryDirName 版本历史目录名，位于被管理文件所在的目录（通常为小说目录）下
+const HistoryDirName = ".history"
+
+// 版本历史目录中的文件
+const (
+	versionLogFile    = "versions.jsonl"
+	versionIndexFile  = "latest.json"
+	versionObjectsDir = "objects"
+)
+
+// VersionInfo 保存操作的来源信息，随版本一起记录
+type VersionInfo struct {
+	Author string `json:"author,omitempty"` // 工作流或任务ID，如 task:summarize/summary-latest-1700000000
+	Reason string `json:"reason,omitempty"` // 修改原因
+
+	changes *RunChanges // 保存时登记写入的文件，见 TrackRunChanges
+}
+
+// WithReason 返回替换了修改原因的副本
+func (vi VersionInfo) WithReason(reason string) VersionInfo {
+	vi.Reason = reason
+	return vi
+}
+
+// DefaultVersionAuthor 未指定作者时记录的作者，默认为当前程序名（如 write、summery）
+var DefaultVersionAuthor = filepath.Base(os.Args[0])
+
+// versionInfoKey context 中保存 VersionInfo 的键
+type versionInfoKey struct{}
+
+// WithVersionInfo 将版本来源信息放入 context，工具据此为其写入的文件标注作者和原因
+func WithVersionInfo(ctx context.Context, info VersionInfo) context.Context {
+	return context.WithValue(ctx, versionInfoKey{}, info)
+}
+
+// VersionInfoFromContext 读取 context 中的版本来源信息，未设置作者时使用 DefaultVersionAuthor
+func VersionInfoFromContext(ctx context.Context) VersionInfo {
+	var info VersionInfo
+	if ctx != nil {
+		info, _ = ctx.Value(versionInfoKey{}).(VersionInfo)
+	}
+	if info.Author == "" {
+		info.Author = DefaultVersionAuthor
+	}
+	return info
+}
+
+// FileVersion 文件的一个历史版本
+type FileVersion struct {
+	ID        int    `json:"id"`
+	File      string `json:"file"` // 相对于历史目录所在目录的文件名
+	Hash      string `json:"hash"` // 内容的 SHA-256，同时是快照对象的文件名
+	Size      int    `json:"size"`
+	Author    string `json:"author,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// VersionStore 内容寻址的版本历史
+// 快照按内容哈希存放在 objects 下，相同内容只保存一份；版本记录逐行追加到 versions.jsonl，
+// 各文件的最新版本另存于 latest.json，记录版本时不必读取全部版本记录
+type VersionStore struct {
+	dir string
+}
+
+// NewVersionStore 创建目录 dir 的版本历史
+func NewVersionStore(dir string) *VersionStore {
+	return &VersionStore{dir: dir}
+}
+
+// GetDir 获取版本历史所属的目录
+func (vs *VersionStore) GetDir() string {
+	return vs.dir
+}
+
+// historyDir 版本历史目录
+func (vs *VersionStore) historyDir() string {
+	return filepath.Join(vs.dir, HistoryDirName)
+}
+
+// logPath 版本记录文件路径
+func (vs *VersionStore) logPath() string {
+	return filepath.Join(vs.historyDir(), versionLogFile)
+}
+
+// indexPath 各文件最新版本索引的路径
+func (vs *VersionStore) indexPath() string {
+	return filepath.Join(vs.historyDir(), versionIndexFile)
+}
+
+// objectPath 快照对象路径，按哈希前两位分目录
+func (vs *VersionStore) objectPath(hash string) string {
+	return filepath.Join(vs.historyDir(), versionObjectsDir, hash[:2], hash)
+}
+
+// Record 记录文件的新版本，previous 为写入前的内容（文件原本不存在时为nil）
+// 原有内容与最近一个版本不同时（文件第一次被记录，或在工具之外被修改过）先把原有内容保存为一个版本，
+// 保证覆盖前的内容总能恢复；内容与该文件最近一个版本相同时不记录新版本，返回nil
+func (vs *VersionStore) Record(file string, previous *string, current string, info VersionInfo) (*FileVersion, error) {
+	if info.Author == "" {
+		info.Author = DefaultVersionAuthor
+	}
+
+	var recorded *FileVersion
+	err := WithFileLock(vs.logPath(), func() error {
+		index, err := vs.loadIndex()
+		if err != nil {
+			return err
+		}
+
+		latest := index.Latest[file]
+		if previous != nil && *previous != current && (latest == nil || latest.Hash != hashContent(*previous)) {
+			baseline := VersionInfo{Author: info.Author, Reason: "修改前的原有内容"}
+			if latest != nil {
+				baseline.Reason = "工具外的修改"
+			}
+			if latest, err = vs.appendVersion(index, file, *previous, baseline); err != nil {
+				return err
+			}
+		}
+
+		if latest == nil || latest.Hash != hashContent(current) {
+			if recorded, err = vs.appendVersion(index, file, current, info); err != nil {
+				return err
+			}
+		}
+		return vs.saveIndex(index)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("记录 %s 的版本历史失败: %w", file, err)
+	}
+	return recorded, nil
+}
+
+// appendVersion 保存快照对象并追加版本记录，同时更新索引，调用方需持有版本记录的文件锁
+func (vs *VersionStore) appendVersion(index *versionIndex, file, data string, info VersionInfo) (*FileVersion, error) {
+	version := &FileVersion{
+		ID:        index.LastID + 1,
+		File:      file,
+		Hash:      hashContent(data),
+		Size:      len(data),
+		Author:    info.Author,
+		Reason:    info.Reason,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+
+	if err := vs.writeObject(version.Hash, data); err != nil {
+		return nil, err
+	}
+
+	line, err := json.Marshal(version)
+	if err != nil {
+		return nil, err
+	}
+	logFile, err := os.OpenFile(vs.logPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer logFile.Close()
+	if _, err := logFile.Write(append(line, '\n')); err != nil {
+		return nil, err
+	}
+
+	index.add(version)
+	index.LogSize += int64(len(line) + 1)
+	return version, nil
+}
+
+// versionIndex 各文件最新版本的索引，记录版本时据此判断内容是否变化，不必读取全部版本记录
+// LogSize 为索引覆盖到的版本记录长度；版本记录在索引之外被追加时只补读新增部分，变短或索引损坏时重建
+type versionIndex struct {
+	LogSize int64                   `json:"log_size"`
+	LastID  int                     `json:"last_id"`
+	Latest  map[string]*FileVersion `json:"latest"`
+
+	dirty bool // 与磁盘上的索引不一致，需要保存
+}
+
+// add 登记一条版本记录
+func (idx *versionIndex) add(version *FileVersion) {
+	if version.ID > idx.LastID {
+		idx.LastID = version.ID
+	}
+	idx.Latest[version.File] = version
+	idx.dirty = true
+}
+
+// loadIndex 读取索引并与版本记录对齐，调用方需持有版本记录的文件锁
+func (vs *VersionStore) loadIndex() (*versionIndex, error) {
+	index := &versionIndex{}
+	if data, err := os.ReadFile(vs.indexPath()); err == nil {
+		if json.Unmarshal(data, index) != nil {
+			index = &versionIndex{}
+		}
+	}
+
+	stat, err := os.Stat(vs.logPath())
+	if os.IsNotExist(err) {
+		return &versionIndex{Latest: make(map[string]*FileVersion)}, nil
+	}
+	if err != nil {
+		return nil, content.NewFileReadError(vs.logPath(), err)
+	}
+	if index.Latest == nil || stat.Size() < index.LogSize {
+		index = &versionIndex{Latest: make(map[string]*FileVersion), dirty: true}
+	}
+	if stat.Size() == index.LogSize {
+		return index, nil
+	}
+
+	// 补读索引之后追加的版本记录
+	logFile, err := os.Open(vs.logPath())
+	if err != nil {
+		return nil, content.NewFileReadError(vs.logPath(), err)
+	}
+	defer logFile.Close()
+	if _, err := logFile.Seek(index.LogSize, io.SeekStart); err != nil {
+		return nil, content.NewFileReadError(vs.logPath(), err)
+	}
+	versions, err := parseVersionLog(logFile)
+	if err != nil {
+		return nil, content.NewFileReadError(vs.logPath(), err)
+	}
+	for _, version := range versions {
+		index.add(version)
+	}
+	index.LogSize, index.dirty = stat.Size(), true
+	return index, nil
+}
+
+// saveIndex 索引有变化时保存，调用方需持有版本记录的文件锁
+func (vs *VersionStore) saveIndex(index *versionIndex) error {
+	if !index.dirty {
+		return nil
+	}
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(vs.indexPath(), data, 0644)
+}
+
+// writeObject 保存快照对象，相同内容已存在时跳过
+func (vs *VersionStore) writeObject(hash, data string) error {
+	path := vs.objectPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	return WriteFileAtomic(path, []byte(data), 0644)
+}
+
+// readLog 读取全部版本记录（按ID升序），无法解析的行会被跳过
+func (vs *VersionStore) readLog() ([]*FileVersion, error) {
+	logFile, err := os.Open(vs.logPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, content.NewFileReadError(vs.logPath(), err)
+	}
+	defer logFile.Close()
+
+	versions, err := parseVersionLog(logFile)
+	if err != nil {
+		return nil, content.NewFileReadError(vs.logPath(), err)
+	}
+	return versions, nil
+}
+
+// parseVersionLog 逐行解析版本记录，无法解析的行会被跳过
+func parseVersionLog(r io.Reader) ([]*FileVersion, error) {
+	var versions []*FileVersion
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var version FileVersion
+		if err := json.Unmarshal([]byte(line), &version); err == nil && version.Hash != "" {
+			versions = append(versions, &version)
+		}
+	}
+	return versions, scanner.Err()
+}
+
+// List 列出文件的所有版本（按时间顺序），file 为空时列出全部文件的版本
+func (vs *VersionStore) List(file string) ([]*FileVersion, error) {
+	versions, err := vs.readLog()
+	if err != nil || file == "" {
+		return versions, err
+	}
+
+	var filtered []*FileVersion
+	for _, version := range versions {
+		if version.File == file {
+			filtered = append(filtered, version)
+		}
+	}
+	return filtered, nil
+}
+
+// Get 按ID查找版本
+func (vs *VersionStore) Get(id int) (*FileVersion, error) {
+	versions, err := vs.readLog()
+	if err != nil {
+		return nil, err
+	}
+	for _, version := range versions {
+		if version.ID == id {
+			return version, nil
+		}
+	}
+	return nil, fmt.Errorf("版本 #%d 不存在", id)
+}
+
+// Content 读取版本的完整内容
+func (vs *VersionStore) Content(version *FileVersion) (string, error) {
+	data, err := os.ReadFile(vs.objectPath(version.Hash))
+	if err != nil {
+		return "", content.NewFileReadError(vs.objectPath(version.Hash), err)
+	}
+	return string(data), nil
+}
+
+// Diff 比较两个版本，返回统一格式（unified）的差异；toID 为0时与文件当前内容比较
+func (vs *VersionStore) Diff(fromID, toID int) (string, error) {
+	from, err := vs.Get(fromID)
+	if err != nil {
+		return "", err
+	}
+	fromContent, err := vs.Content(from)
+	if err != nil {
+		return "", err
+	}
+
+	toName := from.File + "（当前）"
+	var toContent string
+	if toID == 0 {
+		data, err := os.ReadFile(filepath.Join(vs.dir, from.File))
+		if err != nil && !os.IsNotExist(err) {
+			return "", content.NewFileReadError(from.File, err)
+		}
+		toContent = string(data)
+	} else {
+		to, err := vs.Get(toID)
+		if err != nil {
+			return "", err
+		}
+		if toContent, err = vs.Content(to); err != nil {
+			return "", err
+		}
+		toName = fmt.Sprintf("%s#%d", to.File, to.ID)
+	}
+
+	return UnifiedDiff(fmt.Sprintf("%s#%d", from.File, from.ID), toName, fromContent, toContent, 3), nil
+}
+
+// Restore 将文件恢复为指定版本的内容，恢复本身记录为一个新版本并返回
+func (vs *VersionStore) Restore(id int, info VersionInfo) (*FileVersion, error) {
+	version, err := vs.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := vs.Content(version)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Reason == "" {
+		info.Reason = fmt.Sprintf("恢复到版本 #%d", id)
+	}
+	manager := NewBaseFileManager(filepath.Join(vs.dir, version.File))
+	manager.SetVersionInfo(info)
+	if err := manager.Save(data); err != nil {
+		return nil, err
+	}
+
+	versions, err := vs.List(version.File)
+	if err != nil {
+		return nil, err
+	}
+	return latestVersion(versions, version.File), nil
+}
+
+// latestVersion 返回文件最近的版本
+func latestVersion(versions []*FileVersion, file string) *FileVersion {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].File == file {
+			return versions[i]
+		}
+	}
+	return nil
+}
+
+// hashContent 计算内容的 SHA-256
+func hashContent(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
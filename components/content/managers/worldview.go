@@ -18,7 +18,7 @@ func NewWorldviewManager(novelDir string) *WorldviewManager {
 	worldviewPath := filepath.Join(novelDir, "worldview.md")
 	
 	manager := &WorldviewManager{
-		BaseFileManager: NewBaseFileManager(worldviewPath),
+		BaseFileManager: newComponentFileManager(worldviewPath, "worldview"),
 		novelDir:        novelDir,
 	}
 	
@@ -52,9 +52,9 @@ func (wm *WorldviewManager) GetCurrentWithTokenLimit(maxTokens int) (string, int
 		return "", 0
 	}
 	
-	// 如果有TokenBudget，使用正确的组件名
+	// 如果有TokenBudget，使用正确的组件名（配置了压缩器时超出预算的内容会被压缩而非截断）
 	if wm.GetTokenBudget() != nil {
-		return wm.GetTokenBudget().TruncateToTokenLimit(current, "worldview")
+		return wm.GetTokenBudget().CompressToTokenLimit(current, "worldview", wm.CompressionSource())
 	}
 	
 	// 否则使用基础的截断逻辑
@@ -0,0 +1,110 @@
+package content
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/token"
+	"github.com/Kizunad/modular-workflow-v2/config"
+)
+
+// DefaultBudgetProfile 指向配置文件 token_percentages 的预算配置名称
+const DefaultBudgetProfile = "default"
+
+// BudgetPercentages 转换配置文件中的百分比配置为预算管理器使用的格式
+func BudgetPercentages(t config.TokenPercentageConfig) *token.TokenPercentages {
+	return &token.TokenPercentages{
+		Plan:      t.Plan,
+		Character: t.Character,
+		Worldview: t.Worldview,
+		Chapters:  t.Chapters,
+		Index:     t.Index,
+		Retrieved: t.Retrieved,
+	}
+}
+
+// ResolveBudgetProfile 按名称查找预算配置：配置文件中的 budget_profiles 优先，其次是内置配置，
+// default 指向 token_percentages
+func ResolveBudgetProfile(cfg *config.ContentConfig, name string) (*token.TokenPercentages, error) {
+	if profile, ok := cfg.BudgetProfiles[name]; ok {
+		percentages := BudgetPercentages(profile)
+		if err := percentages.Validate(); err != nil {
+			return nil, fmt.Errorf("预算配置'%s'无效: %w", name, err)
+		}
+		return percentages, nil
+	}
+
+	if percentages, ok := token.BuiltinProfile(name); ok {
+		return percentages, nil
+	}
+
+	if name == DefaultBudgetProfile {
+		percentages := BudgetPercentages(cfg.TokenPercentages)
+		if err := percentages.Validate(); err != nil {
+			return nil, fmt.Errorf("token_percentages 无效: %w", err)
+		}
+		return percentages, nil
+	}
+
+	return nil, fmt.Errorf("未知的预算配置'%s'，可用配置: %v", name, BudgetProfileNames(cfg))
+}
+
+// ResolveWorkflowBudget 解析工作流使用的预算配置
+// 优先级：profile 参数（命令行指定）> workflow_profiles > budget_profiles 中与工作流同名的配置 >
+// 配置文件中自定义的 token_percentages > 与工作流同名的内置配置
+func ResolveWorkflowBudget(cfg *config.ContentConfig, workflow, profile string) (*token.TokenPercentages, error) {
+	name := profile
+	if name == "" {
+		name = cfg.ProfileForWorkflow(workflow)
+	}
+	if name != "" {
+		return ResolveBudgetProfile(cfg, name)
+	}
+
+	if _, ok := cfg.BudgetProfiles[workflow]; !ok && cfg.TokenPercentages.IsCustomized() {
+		return ResolveBudgetProfile(cfg, DefaultBudgetProfile)
+	}
+	return ResolveBudgetProfile(cfg, workflow)
+}
+
+// BudgetProfileNames 返回所有可用的预算配置名称（含内置配置和 default），按名称排序
+func BudgetProfileNames(cfg *config.ContentConfig) []string {
+	seen := map[string]bool{DefaultBudgetProfile: true}
+	for name := range token.BuiltinProfiles() {
+		seen[name] = true
+	}
+	for name := range cfg.BudgetProfiles {
+		seen[name] = true
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveTruncateModes 解析各组件的截断方式，未配置的组件使用默认值
+func ResolveTruncateModes(cfg *config.ContentConfig) (map[string]token.TruncateMode, error) {
+	modes := token.DefaultTruncateModes()
+	for component, name := range cfg.TruncateModes {
+		mode, err := token.ParseTruncateMode(name)
+		if err != nil {
+			return nil, fmt.Errorf("组件'%s'的截断方式无效: %w", component, err)
+		}
+		modes[component] = mode
+	}
+	return modes, nil
+}
+
+// ValidateContentConfig 校验依赖内容层定义的配置项：工作流引用的预算配置和截断方式
+func ValidateContentConfig(cfg *config.ContentConfig) error {
+	for workflow, name := range cfg.WorkflowProfiles {
+		if _, err := ResolveBudgetProfile(cfg, name); err != nil {
+			return fmt.Errorf("工作流'%s'的预算配置无效: %w", workflow, err)
+		}
+	}
+	_, err := ResolveTruncateModes(cfg)
+	return err
+}
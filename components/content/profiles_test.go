@@ -0,0 +1,76 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/token"
+	"github.com/Kizunad/modular-workflow-v2/config"
+)
+
+func TestResolveBudgetProfile(t *testing.T) {
+	cfg := &config.ContentConfig{
+		TokenPercentages: config.DefaultTokenPercentages(),
+		BudgetProfiles: map[string]config.TokenPercentageConfig{
+			"write": {Plan: 0.1, Character: 0.1, Worldview: 0.1, Chapters: 0.6, Index: 0.1},
+			"bad":   {Plan: 0.5, Chapters: 0.6},
+		},
+		WorkflowProfiles: map[string]string{"plan": "write"},
+	}
+
+	// 配置文件中的同名配置覆盖内置配置
+	write, err := ResolveBudgetProfile(cfg, "write")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.1, write.Plan)
+
+	// 未配置时使用内置配置
+	summarize, err := ResolveBudgetProfile(cfg, "summarize")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.60, summarize.Chapters)
+
+	_, err = ResolveBudgetProfile(cfg, "bad")
+	assert.Error(t, err)
+	_, err = ResolveBudgetProfile(cfg, "missing")
+	assert.Error(t, err)
+	assert.Error(t, ValidateContentConfig(&config.ContentConfig{WorkflowProfiles: map[string]string{"plan": "missing"}}))
+	assert.NoError(t, ValidateContentConfig(cfg))
+
+	// workflow_profiles 指定的配置
+	plan, err := ResolveWorkflowBudget(cfg, token.ProfilePlan, "")
+	assert.NoError(t, err)
+	assert.Equal(t, write, plan)
+
+	// 命令行指定的配置优先
+	plan, err = ResolveWorkflowBudget(cfg, token.ProfilePlan, token.ProfileSummarize)
+	assert.NoError(t, err)
+	assert.Equal(t, summarize, plan)
+}
+
+func TestResolveWorkflowBudgetTokenPercentages(t *testing.T) {
+	// 未自定义 token_percentages 时使用与工作流同名的内置配置
+	cfg := &config.ContentConfig{TokenPercentages: config.DefaultTokenPercentages()}
+	builtin, _ := token.BuiltinProfile(token.ProfileSummarize)
+	percentages, err := ResolveWorkflowBudget(cfg, token.ProfileSummarize, "")
+	assert.NoError(t, err)
+	assert.Equal(t, builtin, percentages)
+
+	// 自定义的 token_percentages 作为没有单独配置的工作流的预算
+	cfg.TokenPercentages = config.TokenPercentageConfig{Plan: 0.2, Character: 0.2, Worldview: 0.2, Chapters: 0.3, Index: 0.1}
+	percentages, err = ResolveWorkflowBudget(cfg, token.ProfileSummarize, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.3, percentages.Chapters)
+
+	// budget_profiles 中的同名配置仍然优先
+	cfg.BudgetProfiles = map[string]config.TokenPercentageConfig{
+		token.ProfileSummarize: {Plan: 0.1, Character: 0.1, Worldview: 0.1, Chapters: 0.6, Index: 0.1},
+	}
+	percentages, err = ResolveWorkflowBudget(cfg, token.ProfileSummarize, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.6, percentages.Chapters)
+
+	// default 指向 token_percentages
+	percentages, err = ResolveBudgetProfile(cfg, DefaultBudgetProfile)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.3, percentages.Chapters)
+}
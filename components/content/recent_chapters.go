@@ -0,0 +1,121 @@
+package content
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/token"
+)
+
+// defaultRecentChapters 默认纳入上下文的最近章节数量
+const defaultRecentChapters = 3
+
+// recentChapterEntry 最近章节窗口中的单个章节
+type recentChapterEntry struct {
+	number    int
+	title     string
+	text      string
+	summary   bool // 是否以 index.json 中的摘要代替全文
+	truncated bool
+}
+
+// buildRecentChaptersContext 从最新章节开始向前填充章节预算
+// 最新章节总是放入全文（超出预算时截断）；更早的章节在全文放不下时改用 index.json 摘要，
+// 一旦某章改用摘要，更早的章节也只使用摘要，直到窗口用完或预算耗尽。
+// 返回拼接后的文本以及以全文形式纳入的章节编号
+func (cb *ContextBuilder) buildRecentChaptersContext(tokenBudget *token.TokenBudgetManager, sources *contextSources, maxTokens int) (string, map[int]bool) {
+	included := make(map[int]bool)
+	ids := sources.chapters.GetChapterIDs()
+	if len(ids) == 0 || maxTokens <= 0 {
+		return "", included
+	}
+	latest := ids[len(ids)-1]
+	window := cb.recentChapterWindow()
+
+	var entries []recentChapterEntry
+	usedTokens := 0
+	useSummary := false
+
+	// 按章节清单的阅读顺序从最新章节向前取，章节ID不连续也不影响窗口大小
+	for i := len(ids) - 1; i >= 0 && i >= len(ids)-window; i-- {
+		num := ids[i]
+		chapter, err := sources.chapter(num)
+		if err != nil {
+			// 跳过文件缺失或无法解析的章节
+			continue
+		}
+
+		entry := recentChapterEntry{number: num, title: chapter.Title}
+		remaining := maxTokens - usedTokens
+
+		if num == latest {
+			entry.text, _ = tokenBudget.TruncateToTokenLimit(chapter.GetText(), "chapters")
+			entry.truncated = entry.text != chapter.GetText()
+		} else if !useSummary && tokenBudget.CountTokens(chapter.GetText()) <= remaining {
+			entry.text = chapter.GetText()
+		} else {
+			useSummary = true
+			summary, found := sources.index.FindChapterSummary(num, chapter.Title)
+			if !found || tokenBudget.CountTokens(summary.Summary) > remaining {
+				// 没有摘要或摘要也放不下时跳过该章，继续尝试更早章节的摘要
+				continue
+			}
+			entry.text = summary.Summary
+			entry.summary = true
+		}
+
+		if entry.text == "" {
+			continue
+		}
+		usedTokens += tokenBudget.CountTokens(entry.text)
+		if !entry.summary {
+			included[num] = true
+		}
+		entries = append(entries, entry)
+
+		if usedTokens >= maxTokens {
+			break
+		}
+	}
+
+	if cb.config.Logger != nil {
+		cb.config.Logger.Info(fmt.Sprintf("最近章节窗口: 窗口=%d, 纳入=%d, 全文=%d, Token=%d/%d",
+			window, len(entries), len(included), usedTokens, maxTokens))
+	}
+
+	// 只有最新一章时保持原有的纯文本格式
+	if len(entries) == 1 && entries[0].number == latest {
+		return entries[0].text, included
+	}
+
+	// 按时间顺序输出，较早的章节在前
+	parts := make([]string, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		parts = append(parts, formatRecentChapter(entries[i]))
+	}
+	return strings.Join(parts, "\n\n"), included
+}
+
+// recentChapterWindow 最近章节窗口大小
+func (cb *ContextBuilder) recentChapterWindow() int {
+	if cb.config.RecentChapters <= 0 {
+		return defaultRecentChapters
+	}
+	return cb.config.RecentChapters
+}
+
+// formatRecentChapter 格式化单个最近章节
+func formatRecentChapter(entry recentChapterEntry) string {
+	header := fmt.Sprintf("【第%d章", entry.number)
+	if entry.title != "" {
+		header += " " + entry.title
+	}
+	switch {
+	case entry.summary:
+		header += "（摘要）"
+	case entry.truncated:
+		header += "（节选）"
+	}
+	header += "】"
+	return header + "\n" + entry.text
+}
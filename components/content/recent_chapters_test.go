@@ -0,0 +1,60 @@
+package content
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+	"github.com/Kizunad/modular-workflow-v2/components/content/token"
+)
+
+func TestBuildRecentChaptersContext(t *testing.T) {
+	dir := t.TempDir()
+	percentages := token.DefaultTokenPercentages()
+	tokenBudget, err := token.NewTokenBudgetManager(4000, percentages)
+	require.NoError(t, err)
+	builder := NewContextBuilder(&ContextConfig{NovelDir: dir, RecentChapters: 3})
+
+	cm := managers.NewChapterManager(dir)
+	_, err = cm.WriteChapter("醒来", "林凡在青云山下醒来。")
+	require.NoError(t, err)
+
+	// 只有一章时保持纯文本格式
+	text, included := builder.buildRecentChaptersContext(tokenBudget, builder.newContextSources(tokenBudget), 300)
+	assert.Equal(t, "林凡在青云山下醒来。", text)
+	assert.Equal(t, map[int]bool{1: true}, included)
+
+	_, err = cm.WriteChapter("下山", strings.Repeat("山路崎岖，", 200))
+	require.NoError(t, err)
+	_, err = cm.WriteChapter("入城", "远方的城池灯火通明。")
+	require.NoError(t, err)
+	_, err = cm.WriteChapter("拜师", "林凡跪在山门前。")
+	require.NoError(t, err)
+
+	index := managers.IndexJSON{Summaries: []managers.ChapterSummary{
+		{ChapterID: "1", Title: "醒来", Summary: "林凡醒来。"},
+		{ChapterID: "2", Title: "下山", Summary: "林凡下山赶路。"},
+	}}
+	data, err := json.Marshal(index)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.json"), data, 0644))
+
+	// 第2章全文超出预算改用摘要，第1章在窗口之外
+	text, included = builder.buildRecentChaptersContext(tokenBudget, builder.newContextSources(tokenBudget), 300)
+	assert.Equal(t, map[int]bool{3: true, 4: true}, included)
+	assert.Equal(t, "【第2章 下山（摘要）】\n林凡下山赶路。\n\n"+
+		"【第3章 入城】\n远方的城池灯火通明。\n\n"+
+		"【第4章 拜师】\n林凡跪在山门前。", text)
+	assert.NotContains(t, text, "林凡醒来")
+
+	// 预算为0时不输出
+	text, included = builder.buildRecentChaptersContext(tokenBudget, builder.newContextSources(tokenBudget), 0)
+	assert.Empty(t, text)
+	assert.Empty(t, included)
+}
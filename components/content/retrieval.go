@@ -0,0 +1,105 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+	"github.com/Kizunad/modular-workflow-v2/components/content/token"
+)
+
+// RetrievedPassage 检索到的前文片段
+type RetrievedPassage struct {
+	ID      string  `json:"id"`
+	Chapter int     `json:"chapter"` // 所属章节编号，未知时为0
+	Title   string  `json:"title"`
+	Content string  `json:"content"`
+	Score   float64 `json:"score"`
+}
+
+// PassageRetriever 前文片段检索器，可基于向量服务或本地关键词索引实现
+type PassageRetriever interface {
+	RetrievePassages(ctx context.Context, query string, topK int) ([]RetrievedPassage, error)
+}
+
+// defaultRetrievalTopK 默认检索片段数量
+const defaultRetrievalTopK = 8
+
+// BuildRetrievalQuery 根据当前未完成的规划条目构建检索查询
+func BuildRetrievalQuery(novelDir string) string {
+	return retrievalQuery(managers.NewPlannerContentManager(novelDir))
+}
+
+// retrievalQuery 使用已加载的规划管理器构建检索查询
+func retrievalQuery(plannerManager *managers.PlannerContentManager) string {
+	entry, found := plannerManager.GetFirstUnfinishedPlan()
+	if !found {
+		return ""
+	}
+	return strings.TrimSpace(entry.Plan + "\n" + entry.Content)
+}
+
+// buildRetrievedContext 检索与当前规划相关的前文片段，并按Token预算拼接
+// excludeChapters 为已以全文放入上下文的章节编号，其片段不再重复加入
+func (cb *ContextBuilder) buildRetrievedContext(tokenBudget *token.TokenBudgetManager, sources *contextSources, maxTokens int, excludeChapters map[int]bool) string {
+	if cb.config.Retriever == nil || maxTokens <= 0 {
+		return ""
+	}
+
+	query := retrievalQuery(sources.planner)
+	if query == "" {
+		return ""
+	}
+
+	topK := cb.config.RetrievalTopK
+	if topK <= 0 {
+		topK = defaultRetrievalTopK
+	}
+
+	passages, err := cb.config.Retriever.RetrievePassages(context.Background(), query, topK)
+	if err != nil {
+		if cb.config.Logger != nil {
+			cb.config.Logger.Warn(fmt.Sprintf("检索前文片段失败: %v", err))
+		}
+		return ""
+	}
+
+	var parts []string
+	usedTokens := 0
+	for _, passage := range passages {
+		if excludeChapters[passage.Chapter] {
+			continue
+		}
+
+		text := formatRetrievedPassage(passage)
+		tokens := tokenBudget.CountTokens(text)
+		if usedTokens+tokens > maxTokens {
+			continue
+		}
+		parts = append(parts, text)
+		usedTokens += tokens
+	}
+
+	if cb.config.Logger != nil {
+		cb.config.Logger.Info(fmt.Sprintf("检索前文片段: 候选=%d, 采用=%d, Token=%d/%d", len(passages), len(parts), usedTokens, maxTokens))
+	}
+
+	return strings.Join(parts, "\n\n")
+}
+
+// formatRetrievedPassage 格式化单个前文片段
+func formatRetrievedPassage(passage RetrievedPassage) string {
+	var header string
+	switch {
+	case passage.Chapter > 0 && passage.Title != "":
+		header = fmt.Sprintf("【第%d章 %s】", passage.Chapter, passage.Title)
+	case passage.Chapter > 0:
+		header = fmt.Sprintf("【第%d章】", passage.Chapter)
+	case passage.Title != "":
+		header = fmt.Sprintf("【%s】", passage.Title)
+	default:
+		header = fmt.Sprintf("【%s】", passage.ID)
+	}
+	return header + "\n" + strings.TrimSpace(passage.Content)
+}
@@ -0,0 +1,62 @@
+package content
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+	"github.com/Kizunad/modular-workflow-v2/components/content/token"
+)
+
+// stubRetriever 记录检索请求并返回固定片段的测试检索器
+type stubRetriever struct {
+	passages []RetrievedPassage
+	query    string
+	topK     int
+	calls    int
+}
+
+func (r *stubRetriever) RetrievePassages(ctx context.Context, query string, topK int) ([]RetrievedPassage, error) {
+	r.calls++
+	r.query = query
+	r.topK = topK
+	return r.passages, nil
+}
+
+func TestBuildRetrievedContext(t *testing.T) {
+	dir := t.TempDir()
+	percentages := token.DefaultTokenPercentages()
+	tokenBudget, err := token.NewTokenBudgetManager(4000, percentages)
+	require.NoError(t, err)
+
+	retriever := &stubRetriever{passages: []RetrievedPassage{
+		{ID: "chapter_3", Chapter: 3, Title: "入城", Content: "林凡在城门口遇见了师姐。"},
+		{ID: "chapter_1", Chapter: 1, Title: "醒来", Content: "林凡在青云山下醒来。"},
+		{ID: "chapter_2", Chapter: 2, Content: strings.Repeat("很长的片段", 200)},
+		{ID: "worldview", Content: "灵气复苏的世界。"},
+	}}
+	builder := NewContextBuilder(&ContextConfig{NovelDir: dir, Retriever: retriever, RetrievalTopK: 5})
+
+	// 没有未完成的规划时不检索
+	assert.Empty(t, builder.buildRetrievedContext(tokenBudget, builder.newContextSources(tokenBudget), 200, nil))
+	assert.Equal(t, 0, retriever.calls)
+
+	planner := managers.NewPlannerContentManager(dir)
+	require.NoError(t, planner.UpsertPlan("第一章", "醒来", "已完成", false))
+	require.NoError(t, planner.SetPlanFinished("第一章", true))
+	require.NoError(t, planner.UpsertPlan("第四章", "拜师", "林凡与师姐同行", false))
+
+	text := builder.buildRetrievedContext(tokenBudget, builder.newContextSources(tokenBudget), 200, map[int]bool{3: true})
+	assert.Equal(t, 1, retriever.calls)
+	assert.Equal(t, "拜师\n林凡与师姐同行", retriever.query)
+	assert.Equal(t, 5, retriever.topK)
+
+	// 已以全文纳入的章节和超出预算的片段被跳过
+	assert.NotContains(t, text, "城门口")
+	assert.NotContains(t, text, "很长的片段")
+	assert.Equal(t, "【第1章 醒来】\n林凡在青云山下醒来。\n\n【worldview】\n灵气复苏的世界。", text)
+}
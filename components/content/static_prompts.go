@@ -49,6 +49,7 @@ const Novel_writer_prompt prompt = `
 [必须遵守]当成功写入 之后 调用 plan_crud 通过 update 更新 chapter -> finished 为 true
 [必须遵守]你必须完整的将 "-现有规划" 提供的流程写完
 [必须遵守]多次调用 current_chapter_crud 编写多章小说章节 确认将 "-现有规划" 全部编写剧情
+[建议]修改已写章节的局部内容时，先用 current_chapter_crud 的 paragraphs 查看段落ID，再用 edit_paragraph 修改对应段落，不要重写整章
 [必须确认: 校验清单(自检勾选)]
 - [ ] 完成 "-现有规划" 的所有 "《本章目标》"
 - [ ] 囊括了 "-现有规划" 的所有 "《场景与节拍（5~9个）》 " 中的场景
@@ -61,6 +62,7 @@ const Novel_planner_prompt prompt = `
 如果主角已经探索过某片区域或遭遇过某种威胁，不得在续写中原封不动重复。  
 可以保留同类威胁，但必须有变化（位置、强度、资源情况、环境条件或心理状态）。
 5-10章为一个小阶段 -> 生存 - 适应 - 主宰
+每个小阶段对应一个篇章（story_structure 工具）：规划前先确认当前篇章的目标与章节范围，没有篇章时先创建；新规划通过 plan_crud 的 arc_id 关联到当前篇章。
 【总体目标】  
 1. 紧扣“生存/适应/资源管理/风险控制”，明确本章的生存问题与目标。  
 2. 控制节奏：危机与缓冲交替，避免全程高压。  
@@ -0,0 +1,384 @@
+package token
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 分词器文件格式
+const (
+	TokenizerFormatTiktoken    = "tiktoken"    // 每行 "<base64字节> <rank>"，如 cl100k_base.tiktoken
+	TokenizerFormatHuggingFace = "huggingface" // HF tokenizers 的 tokenizer.json（BPE 模型）
+)
+
+// maxBPECacheEntries 预分词片段计数缓存的最大条目数
+const maxBPECacheEntries = 100000
+
+// bpePair BPE 合并规则中的相邻符号对（原始字节）
+type bpePair struct {
+	left  string
+	right string
+}
+
+// BPETokenCounter 基于本地 BPE 词表的精确Token计数器
+// 支持 tiktoken 词表和 HuggingFace tokenizer.json，完全离线加载
+type BPETokenCounter struct {
+	ranks  map[string]int  // tiktoken：token字节 -> rank，合并时取拼接结果的rank
+	merges map[bpePair]int // HuggingFace：合并规则 -> 优先级
+
+	mu    sync.Mutex
+	cache map[string]int
+}
+
+// bpeCounterCache 按文件路径缓存已加载的分词器，避免重复解析大词表
+var bpeCounterCache = struct {
+	sync.Mutex
+	counters map[string]*BPETokenCounter
+}{counters: make(map[string]*BPETokenCounter)}
+
+// LoadBPETokenCounter 加载本地分词器文件，format 为空时按扩展名判断（.json 为 HuggingFace，其余为 tiktoken）
+// 同一文件只解析一次
+func LoadBPETokenCounter(path, format string) (*BPETokenCounter, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("解析分词器路径失败: %w", err)
+	}
+
+	bpeCounterCache.Lock()
+	defer bpeCounterCache.Unlock()
+
+	if counter, ok := bpeCounterCache.counters[absPath]; ok {
+		return counter, nil
+	}
+
+	if format == "" {
+		format = TokenizerFormatTiktoken
+		if strings.EqualFold(filepath.Ext(absPath), ".json") {
+			format = TokenizerFormatHuggingFace
+		}
+	}
+
+	var counter *BPETokenCounter
+	switch strings.ToLower(format) {
+	case TokenizerFormatTiktoken:
+		counter, err = loadTiktokenCounter(absPath)
+	case TokenizerFormatHuggingFace, "hf":
+		counter, err = loadHuggingFaceCounter(absPath)
+	default:
+		return nil, fmt.Errorf("不支持的分词器格式: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bpeCounterCache.counters[absPath] = counter
+	return counter, nil
+}
+
+// loadTiktokenCounter 加载 tiktoken 词表文件
+func loadTiktokenCounter(path string) (*BPETokenCounter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开分词器文件失败: %w", err)
+	}
+	defer file.Close()
+
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("分词器文件第%d行格式错误", lineNum)
+		}
+		tokenBytes, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("分词器文件第%d行解码失败: %w", lineNum, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("分词器文件第%d行rank无效: %w", lineNum, err)
+		}
+		ranks[string(tokenBytes)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取分词器文件失败: %w", err)
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("分词器文件为空: %s", path)
+	}
+
+	return &BPETokenCounter{ranks: ranks, cache: make(map[string]int)}, nil
+}
+
+// hfTokenizerFile tokenizer.json 中计数所需的部分
+type hfTokenizerFile struct {
+	Model struct {
+		Type   string            `json:"type"`
+		Merges []json.RawMessage `json:"merges"`
+	} `json:"model"`
+}
+
+// loadHuggingFaceCounter 加载 HuggingFace tokenizer.json（字节级 BPE）
+func loadHuggingFaceCounter(path string) (*BPETokenCounter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取分词器文件失败: %w", err)
+	}
+
+	var file hfTokenizerFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析分词器文件失败: %w", err)
+	}
+	if file.Model.Type != "" && file.Model.Type != "BPE" {
+		return nil, fmt.Errorf("不支持的分词模型类型: %s", file.Model.Type)
+	}
+	if len(file.Model.Merges) == 0 {
+		return nil, fmt.Errorf("分词器文件缺少合并规则: %s", path)
+	}
+
+	decoder := byteLevelDecoder()
+	merges := make(map[bpePair]int, len(file.Model.Merges))
+	for rank, raw := range file.Model.Merges {
+		// 合并规则有 "a b" 和 ["a", "b"] 两种写法
+		var left, right string
+		var pair []string
+		var joined string
+		if err := json.Unmarshal(raw, &pair); err == nil && len(pair) == 2 {
+			left, right = pair[0], pair[1]
+		} else if err := json.Unmarshal(raw, &joined); err == nil {
+			parts := strings.SplitN(joined, " ", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("合并规则格式错误: %s", joined)
+			}
+			left, right = parts[0], parts[1]
+		} else {
+			return nil, fmt.Errorf("合并规则格式错误: %s", string(raw))
+		}
+
+		key := bpePair{left: decodeByteLevel(left, decoder), right: decodeByteLevel(right, decoder)}
+		if _, exists := merges[key]; !exists {
+			merges[key] = rank
+		}
+	}
+
+	return &BPETokenCounter{merges: merges, cache: make(map[string]int)}, nil
+}
+
+// byteLevelDecoder GPT-2 字节级编码中可见字符到原始字节的映射
+func byteLevelDecoder() map[rune]byte {
+	decoder := make(map[rune]byte, 256)
+	next := 0
+	for b := 0; b < 256; b++ {
+		if (b >= '!' && b <= '~') || (b >= 0xA1 && b <= 0xAC) || (b >= 0xAE && b <= 0xFF) {
+			decoder[rune(b)] = byte(b)
+		} else {
+			decoder[rune(256+next)] = byte(b)
+			next++
+		}
+	}
+	return decoder
+}
+
+// decodeByteLevel 将字节级编码的符号还原为原始字节，非字节级字符按 UTF-8 保留
+func decodeByteLevel(symbol string, decoder map[rune]byte) string {
+	var builder strings.Builder
+	for _, r := range symbol {
+		if b, ok := decoder[r]; ok {
+			builder.WriteByte(b)
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// Count 精确计算Token数：先按 cl100k 规则预分词，再对每个片段执行 BPE 合并
+func (c *BPETokenCounter) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	total := 0
+	for _, piece := range pretokenize(text) {
+		total += c.countPiece(piece)
+	}
+	return total
+}
+
+// CountRunes 计算文本中的总字符数
+func (c *BPETokenCounter) CountRunes(text string) int {
+	return utf8.RuneCountInString(text)
+}
+
+// EstimateTokens 估算Token数，精确计数带缓存，直接复用
+func (c *BPETokenCounter) EstimateTokens(text string) int {
+	return c.Count(text)
+}
+
+// countPiece 计算单个预分词片段的Token数（带缓存）
+func (c *BPETokenCounter) countPiece(piece string) int {
+	c.mu.Lock()
+	if count, ok := c.cache[piece]; ok {
+		c.mu.Unlock()
+		return count
+	}
+	c.mu.Unlock()
+
+	count := len(c.mergePiece(piece))
+
+	c.mu.Lock()
+	if len(c.cache) >= maxBPECacheEntries {
+		c.cache = make(map[string]int)
+	}
+	c.cache[piece] = count
+	c.mu.Unlock()
+
+	return count
+}
+
+// mergePiece 对片段的字节序列反复合并优先级最高的相邻符号对
+func (c *BPETokenCounter) mergePiece(piece string) []string {
+	if c.ranks != nil {
+		if _, ok := c.ranks[piece]; ok {
+			return []string{piece}
+		}
+	}
+
+	symbols := make([]string, len(piece))
+	for i := 0; i < len(piece); i++ {
+		symbols[i] = piece[i : i+1]
+	}
+
+	for len(symbols) > 1 {
+		best, bestRank := -1, 0
+		for i := 0; i+1 < len(symbols); i++ {
+			if rank, ok := c.pairRank(symbols[i], symbols[i+1]); ok && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		symbols[best] += symbols[best+1]
+		symbols = append(symbols[:best+1], symbols[best+2:]...)
+	}
+	return symbols
+}
+
+// pairRank 查询相邻符号对的合并优先级
+func (c *BPETokenCounter) pairRank(left, right string) (int, bool) {
+	if c.ranks != nil {
+		rank, ok := c.ranks[left+right]
+		return rank, ok
+	}
+	rank, ok := c.merges[bpePair{left: left, right: right}]
+	return rank, ok
+}
+
+// pretokenize 按 cl100k 预分词规则切分文本：
+// 英文缩写、（可带一个前导符号的）字母串、1-3位数字、（可带前导空格的）标点串、空白
+// 汉字属于字母类，连续汉字作为一个片段交给 BPE 合并
+func pretokenize(text string) []string {
+	runes := []rune(text)
+	n := len(runes)
+	var pieces []string
+
+	for i := 0; i < n; {
+		r := runes[i]
+		j := i
+
+		switch {
+		case r == '\'' && contractionLength(runes[i:]) > 0:
+			j = i + contractionLength(runes[i:])
+		case unicode.IsLetter(r) || (!isNewline(r) && !unicode.IsNumber(r) && i+1 < n && unicode.IsLetter(runes[i+1])):
+			j = i + 1
+			for j < n && unicode.IsLetter(runes[j]) {
+				j++
+			}
+		case unicode.IsNumber(r):
+			for j < n && j-i < 3 && unicode.IsNumber(runes[j]) {
+				j++
+			}
+		case isPunctRune(r) || (r == ' ' && i+1 < n && isPunctRune(runes[i+1])):
+			j = i + 1
+			for j < n && isPunctRune(runes[j]) {
+				j++
+			}
+			for j < n && isNewline(runes[j]) {
+				j++
+			}
+		default:
+			// 空白：优先切到最后一个换行；其后紧跟非空白时保留最后一个空白给下一个片段
+			end := i
+			lastNewline := -1
+			for end < n && unicode.IsSpace(runes[end]) {
+				if isNewline(runes[end]) {
+					lastNewline = end
+				}
+				end++
+			}
+			switch {
+			case lastNewline >= 0:
+				j = lastNewline + 1
+			case end < n && end-i > 1:
+				j = end - 1
+			default:
+				j = end
+			}
+		}
+
+		if j <= i {
+			j = i + 1
+		}
+		pieces = append(pieces, string(runes[i:j]))
+		i = j
+	}
+
+	return pieces
+}
+
+// contractionLength 返回英文缩写（'s 't 're 've 'm 'll 'd）的长度，不匹配时为0
+func contractionLength(runes []rune) int {
+	if len(runes) < 2 {
+		return 0
+	}
+	second := unicode.ToLower(runes[1])
+	if len(runes) >= 3 {
+		pair := string([]rune{second, unicode.ToLower(runes[2])})
+		if pair == "re" || pair == "ve" || pair == "ll" {
+			return 3
+		}
+	}
+	switch second {
+	case 's', 't', 'm', 'd':
+		return 2
+	}
+	return 0
+}
+
+// isNewline 判断是否为换行符
+func isNewline(r rune) bool {
+	return r == '\n' || r == '\r'
+}
+
+// isPunctRune 判断是否为既非空白、也非字母数字的字符
+func isPunctRune(r rune) bool {
+	return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
@@ -0,0 +1,51 @@
+package token
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPretokenize(t *testing.T) {
+	pieces := pretokenize("Hello world, 你好世界！12345\n\nIt's")
+	assert.Equal(t, []string{"Hello", " world", ",", " 你好世界", "！", "123", "45", "\n\n", "It", "'s"}, pieces)
+}
+
+func TestBPETokenCounter(t *testing.T) {
+	dir := t.TempDir()
+
+	// tiktoken 格式：单字节 + "ab" + "abc"
+	var lines []string
+	for rank, tok := range []string{"a", "b", "c", " ", "ab", "abc"} {
+		lines = append(lines, fmt.Sprintf("%s %d", base64.StdEncoding.EncodeToString([]byte(tok)), rank))
+	}
+	tiktokenPath := filepath.Join(dir, "test.tiktoken")
+	assert.NoError(t, os.WriteFile(tiktokenPath, []byte(strings.Join(lines, "\n")), 0644))
+
+	counter, err := LoadBPETokenCounter(tiktokenPath, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, counter.Count("abc"))
+	assert.Equal(t, 2, counter.Count("abcab"))
+	assert.Equal(t, 3, counter.Count("abc abc")) // "abc" + " abc" -> " " + "abc"
+
+	// HuggingFace 格式：字节级编码，Ġ 表示空格
+	hfPath := filepath.Join(dir, "tokenizer.json")
+	hf := `{"model": {"type": "BPE", "vocab": {}, "merges": ["a b", ["ab", "c"], "Ġ abc"]}}`
+	assert.NoError(t, os.WriteFile(hfPath, []byte(hf), 0644))
+
+	hfCounter, err := LoadBPETokenCounter(hfPath, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, hfCounter.Count("abc"))
+	assert.Equal(t, 2, hfCounter.Count("abc abc"))
+
+	// 预算管理器使用替换后的计数器
+	tbm, err := NewTokenBudgetManager(1000, nil)
+	assert.NoError(t, err)
+	tbm.SetCounter(hfCounter)
+	assert.Equal(t, 2, tbm.CountTokens("abc abc"))
+}
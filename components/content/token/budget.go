@@ -3,7 +3,6 @@ package token
 import (
 	"fmt"
 	"math"
-	"strings"
 	"errors"
 )
 
@@ -13,6 +12,10 @@ type TokenBudgetManager struct {
 	percentages *TokenPercentages
 	counter     TokenCounter
 	budget      *TokenBudget
+	override    map[string]int // 两轮重新分配后的结果，为nil时按百分比分配
+	compressor  TextCompressor // 超出预算时的压缩器，为nil时直接截断
+
+	truncateModes map[string]TruncateMode // 各组件的截断方式，为nil时使用默认值
 }
 
 // TokenPercentages Token百分比配置
@@ -22,6 +25,7 @@ type TokenPercentages struct {
 	Worldview float64 `json:"worldview" yaml:"worldview"` // 世界观占比
 	Chapters  float64 `json:"chapters" yaml:"chapters"`   // 章节内容占比
 	Index     float64 `json:"index" yaml:"index"`         // 索引摘要占比
+	Retrieved float64 `json:"retrieved" yaml:"retrieved"` // 检索到的前文片段占比（可选）
 }
 
 // DefaultTokenPercentages 默认Token百分比配置
@@ -37,7 +41,7 @@ func DefaultTokenPercentages() *TokenPercentages {
 
 // Validate 验证百分比配置
 func (tp *TokenPercentages) Validate() error {
-	total := tp.Plan + tp.Character + tp.Worldview + tp.Chapters + tp.Index
+	total := tp.Plan + tp.Character + tp.Worldview + tp.Chapters + tp.Index + tp.Retrieved
 	
 	// 允许±1%的误差
 	if math.Abs(total-1.0) > 0.01 {
@@ -45,7 +49,7 @@ func (tp *TokenPercentages) Validate() error {
 	}
 	
 	// 检查每个百分比是否为正数
-	if tp.Plan < 0 || tp.Character < 0 || tp.Worldview < 0 || tp.Chapters < 0 || tp.Index < 0 {
+	if tp.Plan < 0 || tp.Character < 0 || tp.Worldview < 0 || tp.Chapters < 0 || tp.Index < 0 || tp.Retrieved < 0 {
 		return errors.New("all token percentages must be non-negative")
 	}
 	
@@ -60,6 +64,7 @@ func (tp *TokenPercentages) ToMap() map[string]float64 {
 		"worldview": tp.Worldview,
 		"chapters":  tp.Chapters,
 		"index":     tp.Index,
+		"retrieved": tp.Retrieved,
 	}
 }
 
@@ -87,8 +92,27 @@ func NewTokenBudgetManager(maxTokens int, percentages *TokenPercentages) (*Token
 	return manager, nil
 }
 
+// SetCounter 设置Token计数器（如与目标模型匹配的 BPETokenCounter），为nil时保持不变
+func (tbm *TokenBudgetManager) SetCounter(counter TokenCounter) {
+	if counter != nil {
+		tbm.counter = counter
+	}
+}
+
+// GetCounter 获取当前使用的Token计数器
+func (tbm *TokenBudgetManager) GetCounter() TokenCounter {
+	return tbm.counter
+}
+
 // GetAllocatedTokens 获取各组件分配的Token数量
 func (tbm *TokenBudgetManager) GetAllocatedTokens() map[string]int {
+	if tbm.override != nil {
+		allocation := make(map[string]int, len(tbm.override))
+		for component, tokens := range tbm.override {
+			allocation[component] = tokens
+		}
+		return allocation
+	}
 	return tbm.budget.AllocateTokens()
 }
 
@@ -119,59 +143,34 @@ func (tbm *TokenBudgetManager) TruncateToTokenLimit(text string, component strin
 	}
 	
 	// 需要截断文本
-	return tbm.truncateText(text, maxTokens)
+	return tbm.truncateText(text, component, maxTokens)
 }
 
-// truncateText 智能截断文本
-func (tbm *TokenBudgetManager) truncateText(text string, maxTokens int) (string, int) {
-	lines := strings.Split(text, "\n")
-	var result []string
-	totalTokens := 0
-	
-	for _, line := range lines {
-		lineTokens := tbm.counter.Count(line)
-		
-		// 如果添加这一行会超出限制
-		if totalTokens+lineTokens > maxTokens {
-			// 尝试部分添加这一行
-			if totalTokens < maxTokens {
-				remainingTokens := maxTokens - totalTokens
-				truncatedLine := tbm.truncateLineByTokens(line, remainingTokens)
-				if truncatedLine != "" {
-					result = append(result, truncatedLine)
-					totalTokens += tbm.counter.Count(truncatedLine)
-				}
-			}
-			break
-		}
-		
-		result = append(result, line)
-		totalTokens += lineTokens
-	}
-	
-	return strings.Join(result, "\n"), totalTokens
+// TruncateToLimit 将文本截断到指定Token数以内，保留方式取决于组件的截断配置
+func (tbm *TokenBudgetManager) TruncateToLimit(text string, component string, maxTokens int) (string, int) {
+	return tbm.truncateText(text, component, maxTokens)
 }
 
-// truncateLineByTokens 按Token数截断单行
-func (tbm *TokenBudgetManager) truncateLineByTokens(line string, maxTokens int) string {
-	if maxTokens <= 0 {
-		return ""
+// SetTruncateMode 设置组件的截断方式（保留开头或结尾）
+func (tbm *TokenBudgetManager) SetTruncateMode(component string, mode TruncateMode) {
+	if tbm.truncateModes == nil {
+		tbm.truncateModes = DefaultTruncateModes()
 	}
-	
-	words := strings.Fields(line)
-	var result []string
-	totalTokens := 0
-	
-	for _, word := range words {
-		wordTokens := tbm.counter.Count(word)
-		if totalTokens+wordTokens > maxTokens {
-			break
-		}
-		result = append(result, word)
-		totalTokens += wordTokens
+	tbm.truncateModes[component] = mode
+}
+
+// GetTruncateMode 获取组件的截断方式，未配置的组件保留开头
+func (tbm *TokenBudgetManager) GetTruncateMode(component string) TruncateMode {
+	modes := tbm.truncateModes
+	if modes == nil {
+		modes = DefaultTruncateModes()
 	}
-	
-	return strings.Join(result, " ")
+	return modes[component]
+}
+
+// truncateText 按段落和句子边界截断文本，并在截断处插入省略标记
+func (tbm *TokenBudgetManager) truncateText(text string, component string, maxTokens int) (string, int) {
+	return TruncateText(tbm.counter, text, maxTokens, tbm.GetTruncateMode(component))
 }
 
 // GetUsageStats 获取使用统计
@@ -196,6 +195,7 @@ func (tbm *TokenBudgetManager) UpdatePercentages(newPercentages *TokenPercentage
 	
 	tbm.percentages = newPercentages
 	tbm.budget = NewTokenBudget(tbm.maxTokens, newPercentages.ToMap())
+	tbm.override = nil
 	
 	return nil
 }
@@ -0,0 +1,168 @@
+package token
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CompressionCacheDir 压缩结果缓存目录（位于源文件所在目录下）
+const CompressionCacheDir = ".compressed"
+
+// defaultCompressTimeout 单次压缩调用的超时时间
+const defaultCompressTimeout = 2 * time.Minute
+
+// TextCompressor 文本压缩器，将超出预算的内容浓缩到指定Token数以内
+type TextCompressor interface {
+	Compress(ctx context.Context, component, text string, maxTokens int) (string, error)
+}
+
+// CompressionSource 被压缩内容的来源文件，用于缓存压缩结果
+// Path 为空时不使用缓存
+type CompressionSource struct {
+	Path    string
+	ModTime time.Time
+}
+
+// compressionCacheEntry 压缩结果缓存文件的内容
+type compressionCacheEntry struct {
+	Source     string    `json:"source"`
+	Component  string    `json:"component"`
+	ModTime    time.Time `json:"mod_time"`
+	SourceHash string    `json:"source_hash"`
+	MaxTokens  int       `json:"max_tokens"`
+	Text       string    `json:"text"`
+}
+
+// SetCompressor 设置文本压缩器，为nil时超出预算的内容直接截断
+func (tbm *TokenBudgetManager) SetCompressor(compressor TextCompressor) {
+	tbm.compressor = compressor
+}
+
+// GetCompressor 获取文本压缩器
+func (tbm *TokenBudgetManager) GetCompressor() TextCompressor {
+	return tbm.compressor
+}
+
+// CompressToTokenLimit 将文本压缩到组件的Token分配以内
+// 未设置压缩器或模型不可用时退回到 TruncateToTokenLimit 的截断逻辑
+func (tbm *TokenBudgetManager) CompressToTokenLimit(text string, component string, source CompressionSource) (string, int) {
+	return tbm.CompressToLimit(text, component, tbm.GetTokenAllocation(component), source)
+}
+
+// CompressToLimit 将文本压缩到指定Token数以内
+// 压缩结果按来源文件的修改时间缓存，文件未变化且缓存结果不超过预算时直接复用；
+// 压缩失败或结果仍超限时截断，超限的结果同样缓存，预算不变时不再重复调用模型
+func (tbm *TokenBudgetManager) CompressToLimit(text string, component string, maxTokens int, source CompressionSource) (string, int) {
+	if text == "" || maxTokens <= 0 {
+		return "", 0
+	}
+
+	currentTokens := tbm.counter.Count(text)
+	if currentTokens <= maxTokens {
+		return text, currentTokens
+	}
+
+	if tbm.compressor == nil {
+		return tbm.truncateText(text, component, maxTokens)
+	}
+
+	// 缓存复用规则：来源未变化时，缓存的压缩结果只要不超过当前预算就直接复用。
+	// 预算比生成缓存时更大也不重新压缩（可能没有用满），避免重新分配带来的预算波动反复调用模型；
+	// 缓存结果超出当前预算时，若它正是按当前预算生成的（模型输出超限），重新调用多半也一样，直接截断，
+	// 否则按当前预算重新压缩
+	cachePath := compressionCachePath(source, component)
+	sourceHash := hashText(text)
+	if cached, ok := loadCompressionCache(cachePath, source, sourceHash); ok {
+		tokens := tbm.counter.Count(cached.Text)
+		if tokens <= maxTokens {
+			return cached.Text, tokens
+		}
+		if cached.MaxTokens == maxTokens {
+			return tbm.truncateText(cached.Text, component, maxTokens)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCompressTimeout)
+	defer cancel()
+
+	compressed, err := tbm.compressor.Compress(ctx, component, text, maxTokens)
+	compressed = strings.TrimSpace(compressed)
+	if err != nil || compressed == "" {
+		return tbm.truncateText(text, component, maxTokens)
+	}
+
+	if cachePath != "" {
+		_ = saveCompressionCache(cachePath, &compressionCacheEntry{
+			Source:     source.Path,
+			Component:  component,
+			ModTime:    source.ModTime,
+			SourceHash: sourceHash,
+			MaxTokens:  maxTokens,
+			Text:       compressed,
+		})
+	}
+
+	// 模型输出仍可能略超预算，此时对压缩结果再做截断
+	if tokens := tbm.counter.Count(compressed); tokens > maxTokens {
+		return tbm.truncateText(compressed, component, maxTokens)
+	}
+	return compressed, tbm.counter.Count(compressed)
+}
+
+// compressionCachePath 计算压缩缓存文件路径，来源未知时返回空字符串
+func compressionCachePath(source CompressionSource, component string) string {
+	if source.Path == "" {
+		return ""
+	}
+	name := fmt.Sprintf("%s.%s.json", filepath.Base(source.Path), component)
+	return filepath.Join(filepath.Dir(source.Path), CompressionCacheDir, name)
+}
+
+// loadCompressionCache 读取压缩缓存，来源文件修改时间或内容不一致时视为失效
+func loadCompressionCache(cachePath string, source CompressionSource, sourceHash string) (*compressionCacheEntry, bool) {
+	if cachePath == "" {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil, false
+	}
+
+	var entry compressionCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+
+	if !entry.ModTime.Equal(source.ModTime) || entry.SourceHash != sourceHash || entry.Text == "" {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// saveCompressionCache 写入压缩缓存
+func saveCompressionCache(cachePath string, entry *compressionCacheEntry) error {
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return fmt.Errorf("创建压缩缓存目录失败: %w", err)
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化压缩缓存失败: %w", err)
+	}
+
+	return os.WriteFile(cachePath, data, 0644)
+}
+
+// hashText 计算文本的 sha256 摘要
+func hashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,82 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCompressor 记录调用次数的测试压缩器
+type fakeCompressor struct {
+	calls  int
+	result string
+	err    error
+}
+
+func (f *fakeCompressor) Compress(ctx context.Context, component, text string, maxTokens int) (string, error) {
+	f.calls++
+	return f.result, f.err
+}
+
+func TestCompressToLimit(t *testing.T) {
+	tbm, err := NewTokenBudgetManager(1000, nil)
+	assert.NoError(t, err)
+
+	text := strings.Repeat("世界观设定内容。\n", 200)
+	source := CompressionSource{
+		Path:    filepath.Join(t.TempDir(), "worldview.md"),
+		ModTime: time.Unix(1700000000, 0),
+	}
+
+	// 未设置压缩器时直接截断
+	truncated, tokens := tbm.CompressToLimit(text, "worldview", 50, source)
+	assert.LessOrEqual(t, tokens, 50)
+	assert.True(t, strings.HasSuffix(truncated, ElisionMarker))
+	assert.True(t, strings.HasPrefix(text, strings.TrimSuffix(truncated, "\n"+ElisionMarker)))
+
+	// 压缩结果写入缓存，文件未修改时不再调用模型
+	compressor := &fakeCompressor{result: "浓缩后的世界观"}
+	tbm.SetCompressor(compressor)
+	compressed, _ := tbm.CompressToLimit(text, "worldview", 50, source)
+	assert.Equal(t, "浓缩后的世界观", compressed)
+	compressed, _ = tbm.CompressToLimit(text, "worldview", 50, source)
+	assert.Equal(t, "浓缩后的世界观", compressed)
+	assert.Equal(t, 1, compressor.calls)
+
+	// 缓存结果不超过新预算时直接复用
+	compressed, _ = tbm.CompressToLimit(text, "worldview", 80, source)
+	assert.Equal(t, "浓缩后的世界观", compressed)
+	assert.Equal(t, 1, compressor.calls)
+
+	// 修改时间变化后缓存失效
+	source.ModTime = source.ModTime.Add(time.Second)
+	tbm.CompressToLimit(text, "worldview", 50, source)
+	assert.Equal(t, 2, compressor.calls)
+
+	// 压缩结果仍超限时截断，预算不变时复用缓存不再调用模型
+	verbose := &fakeCompressor{result: strings.Repeat("仍然很长的世界观。", 40)}
+	tbm.SetCompressor(verbose)
+	source.ModTime = source.ModTime.Add(time.Second)
+	first, tokens := tbm.CompressToLimit(text, "worldview", 50, source)
+	assert.LessOrEqual(t, tokens, 50)
+	second, _ := tbm.CompressToLimit(text, "worldview", 50, source)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, verbose.calls)
+
+	// 预算变化且缓存结果超出新预算时重新压缩
+	tbm.CompressToLimit(text, "worldview", 30, source)
+	assert.Equal(t, 2, verbose.calls)
+	tbm.CompressToLimit(text, "worldview", 60, source)
+	assert.Equal(t, 3, verbose.calls)
+
+	// 模型不可用时退回截断
+	tbm.SetCompressor(&fakeCompressor{err: errors.New("model unavailable")})
+	source.ModTime = source.ModTime.Add(time.Second)
+	fallback, _ := tbm.CompressToLimit(text, "worldview", 50, source)
+	assert.Equal(t, truncated, fallback)
+}
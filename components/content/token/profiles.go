@@ -0,0 +1,60 @@
+package token
+
+// 内置预算配置名称，与各工作流一一对应
+const (
+	ProfileWrite     = "write"     // 章节写作
+	ProfilePlan      = "plan"      // 章节规划
+	ProfileSummarize = "summarize" // 章节摘要
+	ProfileWorldview = "worldview" // 世界观总结
+	ProfileCharacter = "character" // 角色更新
+)
+
+// BuiltinProfiles 内置的Token预算配置，配置文件中的同名配置优先
+func BuiltinProfiles() map[string]TokenPercentages {
+	return map[string]TokenPercentages{
+		ProfileWrite: {
+			Plan:      0.15, // 适合写作的规划信息
+			Character: 0.05,
+			Worldview: 0.05,
+			Chapters:  0.60, // 更多章节上下文
+			Index:     0.15,
+		},
+		ProfilePlan: {
+			Plan:      0.60, // 规划以已有规划为主
+			Character: 0.03,
+			Worldview: 0.04,
+			Chapters:  0.30,
+			Index:     0.03,
+		},
+		ProfileSummarize: {
+			Plan:      0.05, // 很少规划信息
+			Character: 0.15, // 适量角色信息
+			Worldview: 0.10, // 少量世界观
+			Chapters:  0.60, // 重点关注章节内容
+			Index:     0.10, // 已有摘要索引
+		},
+		ProfileWorldview: {
+			Plan:      0.15, // 适量规划信息
+			Character: 0.10, // 减少角色信息
+			Worldview: 0.40, // 重点关注世界观
+			Chapters:  0.25, // 章节上下文
+			Index:     0.10,
+		},
+		ProfileCharacter: {
+			Plan:      0.10, // 减少规划信息
+			Character: 0.40, // 重点关注角色信息
+			Worldview: 0.10,
+			Chapters:  0.30, // 适量章节上下文
+			Index:     0.10,
+		},
+	}
+}
+
+// BuiltinProfile 获取内置预算配置的副本
+func BuiltinProfile(name string) (*TokenPercentages, bool) {
+	profile, ok := BuiltinProfiles()[name]
+	if !ok {
+		return nil, false
+	}
+	return &profile, true
+}
@@ -0,0 +1,186 @@
+package token
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// componentAliases 配置中的内容名称与预算组件名称的对应关系
+var componentAliases = map[string]string{
+	"characters": "character",
+	"chapter":    "chapters",
+}
+
+// ComponentAllocation 单个组件的最终分配情况
+type ComponentAllocation struct {
+	Cap       int `json:"cap"`       // 按百分比计算的初始上限
+	Needed    int `json:"needed"`    // 实际内容所需Token
+	Allocated int `json:"allocated"` // 最终分配的Token
+	Extra     int `json:"extra"`     // 第二轮重新分配获得的额外Token
+}
+
+// AllocationReport 两轮分配后的预算报告
+type AllocationReport struct {
+	Total      int                             `json:"total"`
+	Allocated  int                             `json:"allocated"`
+	Unused     int                             `json:"unused"` // 所有组件都已满足后仍剩余的Token
+	Components map[string]*ComponentAllocation `json:"components"`
+}
+
+// String 格式化分配报告，按组件名排序
+func (r *AllocationReport) String() string {
+	names := make([]string, 0, len(r.Components))
+	for name := range r.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		c := r.Components[name]
+		parts = append(parts, fmt.Sprintf("%s=%d(需%d/限%d/+%d)", name, c.Allocated, c.Needed, c.Cap, c.Extra))
+	}
+	return fmt.Sprintf("总计=%d, 已分配=%d, 未使用=%d: %s", r.Total, r.Allocated, r.Unused, strings.Join(parts, ", "))
+}
+
+// Redistribute 两轮分配Token预算
+// 第一轮：每个组件获得 min(实际需求, 百分比上限)；
+// 第二轮：把未用完的Token按优先级（数字越小越优先）分给仍有缺口的组件，同一优先级内按权重比例分配。
+// 分配结果会覆盖百分比分配，之后的 GetAllocatedTokens/TruncateToTokenLimit 都使用新的分配。
+// needs 中未出现的组件视为需要完整的百分比上限。
+func (tbm *TokenBudgetManager) Redistribute(needs map[string]int, priorities map[string]int, weights map[string]float64) *AllocationReport {
+	caps := tbm.budget.AllocateTokens()
+	report := &AllocationReport{
+		Total:      tbm.maxTokens,
+		Components: make(map[string]*ComponentAllocation, len(caps)),
+	}
+
+	// 第一轮：满足需求，但不超过百分比上限
+	used := 0
+	for component, cap := range caps {
+		needed, ok := needs[component]
+		if !ok {
+			needed = cap
+		}
+		allocated := needed
+		if allocated > cap {
+			allocated = cap
+		}
+		if allocated < 0 {
+			allocated = 0
+		}
+		report.Components[component] = &ComponentAllocation{Cap: cap, Needed: needed, Allocated: allocated}
+		used += allocated
+	}
+
+	leftover := tbm.maxTokens - used
+
+	// 第二轮：按优先级分组，把剩余Token分给仍有缺口的组件
+	groups := make(map[int][]string)
+	for component, c := range report.Components {
+		if c.Needed > c.Allocated {
+			priority := lookupPriority(priorities, component)
+			groups[priority] = append(groups[priority], component)
+		}
+	}
+	levels := make([]int, 0, len(groups))
+	for level := range groups {
+		levels = append(levels, level)
+	}
+	sort.Ints(levels)
+
+	for _, level := range levels {
+		if leftover <= 0 {
+			break
+		}
+		members := groups[level]
+		sort.Strings(members)
+		leftover = fillGroup(report, members, weights, leftover)
+	}
+
+	final := make(map[string]int, len(report.Components))
+	for component, c := range report.Components {
+		final[component] = c.Allocated
+		report.Allocated += c.Allocated
+	}
+	report.Unused = tbm.maxTokens - report.Allocated
+
+	tbm.override = final
+	return report
+}
+
+// fillGroup 在同一优先级内按权重比例分配剩余Token，直到缺口全部满足或Token用完
+func fillGroup(report *AllocationReport, members []string, weights map[string]float64, leftover int) int {
+	for leftover > 0 {
+		var hungry []string
+		totalWeight := 0.0
+		for _, component := range members {
+			c := report.Components[component]
+			if c.Needed > c.Allocated {
+				hungry = append(hungry, component)
+				totalWeight += lookupWeight(weights, component)
+			}
+		}
+		if len(hungry) == 0 {
+			return leftover
+		}
+
+		distributed := 0
+		pool := leftover
+		for _, component := range hungry {
+			c := report.Components[component]
+			share := int(float64(pool) * lookupWeight(weights, component) / totalWeight)
+			if share < 1 {
+				share = 1
+			}
+			if deficit := c.Needed - c.Allocated; share > deficit {
+				share = deficit
+			}
+			if share > leftover {
+				share = leftover
+			}
+			c.Allocated += share
+			c.Extra += share
+			leftover -= share
+			distributed += share
+			if leftover == 0 {
+				break
+			}
+		}
+		if distributed == 0 {
+			break
+		}
+	}
+	return leftover
+}
+
+// lookupPriority 查询组件优先级，支持别名，未配置时排在最后
+func lookupPriority(priorities map[string]int, component string) int {
+	if p, ok := priorities[component]; ok {
+		return p
+	}
+	for alias, name := range componentAliases {
+		if name == component {
+			if p, ok := priorities[alias]; ok {
+				return p
+			}
+		}
+	}
+	return int(^uint(0) >> 1)
+}
+
+// lookupWeight 查询组件权重，支持别名，未配置或非正数时为1
+func lookupWeight(weights map[string]float64, component string) float64 {
+	if w, ok := weights[component]; ok && w > 0 {
+		return w
+	}
+	for alias, name := range componentAliases {
+		if name == component {
+			if w, ok := weights[alias]; ok && w > 0 {
+				return w
+			}
+		}
+	}
+	return 1
+}
@@ -0,0 +1,63 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedistribute(t *testing.T) {
+	tbm, err := NewTokenBudgetManager(1000, &TokenPercentages{
+		Plan:      0.10,
+		Character: 0.10,
+		Worldview: 0.20,
+		Chapters:  0.50,
+		Index:     0.10,
+	})
+	assert.NoError(t, err)
+
+	needs := map[string]int{
+		"plan":      50,   // 用不完
+		"character": 300,  // 超出上限
+		"worldview": 0,    // 空内容
+		"chapters":  2000, // 超出上限
+		"index":     100,
+	}
+	priorities := map[string]int{"chapters": 1, "characters": 2}
+	weights := map[string]float64{"chapters": 3, "characters": 1}
+
+	report := tbm.Redistribute(needs, priorities, weights)
+
+	// 第一轮剩余 1000-(50+100+0+500+100)=250，全部给优先级最高的章节
+	assert.Equal(t, 750, report.Components["chapters"].Allocated)
+	assert.Equal(t, 250, report.Components["chapters"].Extra)
+	assert.Equal(t, 100, report.Components["character"].Allocated)
+	assert.Equal(t, 0, report.Components["worldview"].Allocated)
+	assert.Equal(t, 1000, report.Allocated)
+	assert.Equal(t, 0, report.Unused)
+
+	// 重新分配结果用于后续截断
+	assert.Equal(t, 750, tbm.GetTokenAllocation("chapters"))
+}
+
+func TestRedistributeSharesWithinPriority(t *testing.T) {
+	tbm, err := NewTokenBudgetManager(1000, &TokenPercentages{
+		Plan:      0.20,
+		Character: 0.20,
+		Worldview: 0.20,
+		Chapters:  0.20,
+		Index:     0.20,
+	})
+	assert.NoError(t, err)
+
+	needs := map[string]int{"plan": 0, "index": 0, "worldview": 0, "character": 1000, "chapters": 1000}
+	priorities := map[string]int{"characters": 1, "chapters": 1}
+	weights := map[string]float64{"characters": 1, "chapters": 3}
+
+	report := tbm.Redistribute(needs, priorities, weights)
+
+	// 剩余600按 1:3 分配
+	assert.Equal(t, 350, report.Components["character"].Allocated)
+	assert.Equal(t, 650, report.Components["chapters"].Allocated)
+	assert.Equal(t, 0, report.Unused)
+}
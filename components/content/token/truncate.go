@@ -0,0 +1,229 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// TruncateMode 截断时保留文本的哪一端
+type TruncateMode int
+
+const (
+	// KeepHead 保留开头，用于世界观、角色等设定类内容
+	KeepHead TruncateMode = iota
+	// KeepTail 保留结尾，用于最新章节，保证与下文衔接
+	KeepTail
+)
+
+// ElisionMarker 截断处插入的省略标记，提示模型此处有内容被省略
+const ElisionMarker = "……（此处省略部分内容）……"
+
+// sentenceTerminators 句末标点（中英文）
+const sentenceTerminators = "。！？!?；;…"
+
+// sentenceClosers 可以紧跟在句末标点之后的后引号和括号
+const sentenceClosers = "”’」』）)》\"'"
+
+// DefaultTruncateModes 各组件默认的截断方式：章节保留结尾，其余保留开头
+func DefaultTruncateModes() map[string]TruncateMode {
+	return map[string]TruncateMode{
+		"chapters":  KeepTail,
+		"retrieved": KeepHead,
+		"worldview": KeepHead,
+		"character": KeepHead,
+		"plan":      KeepHead,
+		"index":     KeepHead,
+	}
+}
+
+// ParseTruncateMode 解析截断方式名称：head 或 tail
+func ParseTruncateMode(name string) (TruncateMode, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "head":
+		return KeepHead, nil
+	case "tail":
+		return KeepTail, nil
+	default:
+		return KeepHead, fmt.Errorf("未知的截断方式: %s（可选 head、tail）", name)
+	}
+}
+
+// String 截断方式名称
+func (m TruncateMode) String() string {
+	if m == KeepTail {
+		return "tail"
+	}
+	return "head"
+}
+
+// TruncateText 按段落和句子边界将文本截断到 maxTokens 以内，并在截断处插入省略标记
+// 优先保留完整段落，放不下时在段落内按句子保留，单句仍放不下时才按字符截断
+func TruncateText(counter TokenCounter, text string, maxTokens int, mode TruncateMode) (string, int) {
+	if text == "" || maxTokens <= 0 {
+		return "", 0
+	}
+	if tokens := counter.Count(text); tokens <= maxTokens {
+		return text, tokens
+	}
+
+	budget := maxTokens - counter.Count(ElisionMarker) - 1
+	if budget <= 0 {
+		return "", 0
+	}
+
+	kept := keepWithinBudget(counter, text, budget, mode)
+	for kept != "" {
+		result := joinElision(kept, mode)
+		if tokens := counter.Count(result); tokens <= maxTokens {
+			return result, tokens
+		}
+		// 计数器对拼接文本的计数可能略大于各部分之和，逐字收缩直到满足预算
+		kept = cutRunes(counter, kept, counter.Count(kept)-1, mode)
+	}
+	return "", 0
+}
+
+// keepWithinBudget 依次按段落、句子、字符选取保留部分
+func keepWithinBudget(counter TokenCounter, text string, budget int, mode TruncateMode) string {
+	paragraphs := strings.Split(text, "\n")
+	if mode == KeepTail {
+		reverseStrings(paragraphs)
+	}
+
+	var kept []string
+	used := 0
+	for _, paragraph := range paragraphs {
+		tokens := counter.Count(paragraph)
+		if used+tokens <= budget {
+			kept = append(kept, paragraph)
+			used += tokens
+			continue
+		}
+
+		// 段落放不下时按句子保留
+		if partial := keepSentences(counter, paragraph, budget-used, mode); partial != "" {
+			kept = append(kept, partial)
+		} else if len(kept) == 0 {
+			// 开头（或结尾）的第一句就超出预算，只能按字符截断
+			kept = append(kept, cutRunes(counter, paragraph, budget, mode))
+		}
+		break
+	}
+
+	if mode == KeepTail {
+		reverseStrings(kept)
+	}
+	return strings.Trim(strings.Join(kept, "\n"), "\n")
+}
+
+// keepSentences 在预算内从段落的一端保留尽可能多的完整句子
+func keepSentences(counter TokenCounter, paragraph string, budget int, mode TruncateMode) string {
+	if budget <= 0 {
+		return ""
+	}
+
+	sentences := SplitSentences(paragraph)
+	if mode == KeepTail {
+		reverseStrings(sentences)
+	}
+
+	var kept []string
+	used := 0
+	for _, sentence := range sentences {
+		tokens := counter.Count(sentence)
+		if used+tokens > budget {
+			break
+		}
+		kept = append(kept, sentence)
+		used += tokens
+	}
+
+	if mode == KeepTail {
+		reverseStrings(kept)
+	}
+	return strings.TrimSpace(strings.Join(kept, ""))
+}
+
+// cutRunes 按字符截断到预算以内（二分查找截断位置）
+func cutRunes(counter TokenCounter, text string, budget int, mode TruncateMode) string {
+	runes := []rune(text)
+	if budget <= 0 || len(runes) == 0 {
+		return ""
+	}
+
+	slice := func(n int) string {
+		if mode == KeepTail {
+			return string(runes[len(runes)-n:])
+		}
+		return string(runes[:n])
+	}
+
+	low, high := 0, len(runes)
+	for low < high {
+		mid := (low + high + 1) / 2
+		if counter.Count(slice(mid)) <= budget {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return strings.TrimSpace(slice(low))
+}
+
+// joinElision 在保留内容的截断一侧加上省略标记
+func joinElision(kept string, mode TruncateMode) string {
+	if mode == KeepTail {
+		return ElisionMarker + "\n" + kept
+	}
+	return kept + "\n" + ElisionMarker
+}
+
+// SplitSentences 按中英文句末标点切分句子，标点及其后的引号、括号和空白保留在句尾
+func SplitSentences(text string) []string {
+	runes := []rune(text)
+	var sentences []string
+	start := 0
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if !strings.ContainsRune(sentenceTerminators, r) && !isEnglishPeriod(runes, i) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && strings.ContainsRune(sentenceTerminators, runes[end]) {
+			end++
+		}
+		for end < len(runes) && strings.ContainsRune(sentenceClosers, runes[end]) {
+			end++
+		}
+		for end < len(runes) && unicode.IsSpace(runes[end]) {
+			end++
+		}
+
+		sentences = append(sentences, string(runes[start:end]))
+		start = end
+		i = end - 1
+	}
+
+	if start < len(runes) {
+		sentences = append(sentences, string(runes[start:]))
+	}
+	return sentences
+}
+
+// isEnglishPeriod 判断英文句号：其后为空白或文本结尾（排除小数点和缩写中的点）
+func isEnglishPeriod(runes []rune, i int) bool {
+	if runes[i] != '.' {
+		return false
+	}
+	return i+1 == len(runes) || unicode.IsSpace(runes[i+1])
+}
+
+// reverseStrings 原地反转字符串切片
+func reverseStrings(items []string) {
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+}
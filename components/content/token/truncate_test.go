@@ -0,0 +1,73 @@
+package token
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSentences(t *testing.T) {
+	sentences := SplitSentences("他停下脚步。“你来了？”她笑道：“等你很久了！”Version 1.5 is out. Done")
+	assert.Equal(t, []string{
+		"他停下脚步。",
+		"“你来了？”",
+		"她笑道：“等你很久了！”",
+		"Version 1.5 is out. ",
+		"Done",
+	}, sentences)
+}
+
+func TestTruncateText(t *testing.T) {
+	counter := NewSimpleTokenCounter()
+	paragraphs := []string{
+		"第一段：山门外风雪正紧。少年背着剑走进来。",
+		"第二段：长老抬眼看他。目光中带着审视。",
+		"第三段：少年跪下行礼。殿中一片寂静。",
+	}
+	text := strings.Join(paragraphs, "\n")
+
+	// 预算充足时原样返回
+	result, tokens := TruncateText(counter, text, 1000, KeepHead)
+	assert.Equal(t, text, result)
+	assert.Equal(t, counter.Count(text), tokens)
+
+	// 保留开头：完整段落 + 省略标记，不在句子中间截断
+	limit := counter.Count(paragraphs[0]) + counter.Count("第二段：长老抬眼看他。") + counter.Count(ElisionMarker) + 2
+	result, tokens = TruncateText(counter, text, limit, KeepHead)
+	assert.LessOrEqual(t, tokens, limit)
+	assert.Equal(t, paragraphs[0]+"\n第二段：长老抬眼看他。\n"+ElisionMarker, result)
+
+	// 保留结尾：省略标记在开头，保留最后的段落
+	limit = counter.Count(paragraphs[2]) + counter.Count(ElisionMarker) + 2
+	result, tokens = TruncateText(counter, text, limit, KeepTail)
+	assert.LessOrEqual(t, tokens, limit)
+	assert.Equal(t, ElisionMarker+"\n"+paragraphs[2], result)
+
+	// 单句超出预算时按字符截断
+	long := strings.Repeat("风", 200)
+	result, tokens = TruncateText(counter, long, 60, KeepTail)
+	assert.LessOrEqual(t, tokens, 60)
+	assert.True(t, strings.HasPrefix(result, ElisionMarker+"\n风"))
+
+	// 预算连省略标记都放不下时返回空
+	result, tokens = TruncateText(counter, text, 3, KeepHead)
+	assert.Empty(t, result)
+	assert.Zero(t, tokens)
+}
+
+func TestTruncateModes(t *testing.T) {
+	tbm, err := NewTokenBudgetManager(1000, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, KeepTail, tbm.GetTruncateMode("chapters"))
+	assert.Equal(t, KeepHead, tbm.GetTruncateMode("worldview"))
+
+	tbm.SetTruncateMode("worldview", KeepTail)
+	assert.Equal(t, KeepTail, tbm.GetTruncateMode("worldview"))
+
+	mode, err := ParseTruncateMode("Tail")
+	assert.NoError(t, err)
+	assert.Equal(t, KeepTail, mode)
+	_, err = ParseTruncateMode("middle")
+	assert.Error(t, err)
+}
@@ -25,12 +25,16 @@ type CharacterUpdateWorkflowConfig struct {
 	LLMManager   *providers.Manager
 	ShowProgress bool
 	Model        string // 模型名称
+
+	BudgetProfile string // Token预算配置名称，为空时使用配置文件为该工作流指定的配置
 }
 
 // CharacterUpdateWorkflow 角色更新工作流
 type CharacterUpdateWorkflow struct {
-	config *CharacterUpdateWorkflowConfig
-	cli    *common.CLIHelper
+	config        *CharacterUpdateWorkflowConfig
+	cli           *common.CLIHelper
+	resolvedModel string          // 实际使用的模型名，用于计算上下文预算
+	snapshot      contextSnapshot // 最近一次构建的上下文，用于上下文转储
 }
 
 // NewCharacterUpdateWorkflow 创建角色更新工作流
@@ -54,6 +58,7 @@ func (cw *CharacterUpdateWorkflow) CreateReActAgent() (*react.Agent, error) {
 	ctx := context.Background()
 
 	// 获取模型
+	cw.resolvedModel = cw.config.Model
 	model, err := cw.config.LLMManager.GetOllamaModel(ctx, providers.WithModel(cw.config.Model))
 	if err != nil {
 		if cw.config.Logger != nil {
@@ -64,17 +69,19 @@ func (cw *CharacterUpdateWorkflow) CreateReActAgent() (*react.Agent, error) {
 		if err != nil {
 			return nil, fmt.Errorf("所有模型都不可用: %w", err)
 		}
+		cw.resolvedModel = cw.config.LLMManager.DefaultOllamaModelName()
 	}
 
 	// 创建角色管理工具
 	characterTool := tools.NewCharacterCRUDTool(cw.config.NovelDir, cw.config.LLMManager)
-	chapterTool := tools.NewCurrentChapterCRUDTool(cw.config.NovelDir)
+	chapterTool := tools.NewCurrentChapterCRUDTool(cw.config.NovelDir, chapterManagerOptions()...)
 	chapterAnalysisTool := tools.NewChapterAnalysisTool(cw.config.NovelDir)
 	planTool := tools.NewPlanCRUDTool(cw.config.NovelDir)
+	relationshipTool := tools.NewRelationshipTool(cw.config.NovelDir)
 
 	// 创建工具节点配置
 	toolsNodeConfig := &compose.ToolsNodeConfig{
-		Tools: []tool.BaseTool{characterTool, chapterTool, chapterAnalysisTool, planTool},
+		Tools: []tool.BaseTool{characterTool, chapterTool, chapterAnalysisTool, planTool, relationshipTool},
 		ExecuteSequentially: false,
 	}
 
@@ -92,8 +99,19 @@ func (cw *CharacterUpdateWorkflow) CreateReActAgent() (*react.Agent, error) {
 
 // createMessageModifier 创建消息修饰器
 func (cw *CharacterUpdateWorkflow) createMessageModifier() react.MessageModifier {
-	// 预先加载上下文数据
-	ctxData := cw.getContextData()
+	// 预先加载上下文并构建系统提示词，避免重复调用
+	sysPrompt := cw.buildSystemPrompt(cw.getContextData())
+
+	return func(ctx context.Context, input []*schema.Message) []*schema.Message {
+		result := make([]*schema.Message, 0, len(input)+1)
+		result = append(result, schema.SystemMessage(sysPrompt))
+		result = append(result, input...)
+		return result
+	}
+}
+
+// buildSystemPrompt 根据上下文数据构建系统提示词
+func (cw *CharacterUpdateWorkflow) buildSystemPrompt(ctxData map[string]any) string {
 	// 使用默认角色更新提示词
 	prompt := `你是一个专业的小说角色状态管理专家。你的任务是：
 
@@ -107,12 +125,12 @@ func (cw *CharacterUpdateWorkflow) createMessageModifier() react.MessageModifier
 2. 读取当前角色信息
 3. 使用AI分析角色变化
 4. 如果需要更新，执行更新操作
+5. 如果章节中角色之间的关系发生了建立、转变或强弱变化，使用 relationship_graph 工具更新人物关系（类型、强度1-10、章节号）
 
 请严格按照分析结果决定是否更新，避免不必要的修改。`
 
-	return func(ctx context.Context, input []*schema.Message) []*schema.Message {
-		sysPrompt := fmt.Sprintf(
-			`%v
+	sysPrompt := fmt.Sprintf(
+		`%v
 
 当前上下文信息:
 - 章节信息: %v
@@ -121,39 +139,32 @@ func (cw *CharacterUpdateWorkflow) createMessageModifier() react.MessageModifier
 - 总结: %v
 
 请根据用户要求分析角色变化并进行相应操作。`,
-			prompt,
-			ctxData["chapter"],
-			ctxData["characters"], 
-			ctxData["worldview"],
-			ctxData["summary"],
-		)
-		
-		result := make([]*schema.Message, 0, len(input)+1)
-		result = append(result, schema.SystemMessage(sysPrompt))
-		result = append(result, input...)
-		return result
-	}
+		prompt,
+		ctxData["chapter"],
+		ctxData["characters"], 
+		ctxData["worldview"],
+		ctxData["summary"],
+	)
+
+	return sysPrompt
 }
 
 // getContextData 获取上下文数据
 func (cw *CharacterUpdateWorkflow) getContextData() map[string]any {
+	model := resolvedModelName(cw.resolvedModel, cw.config.Model)
 	var cfg = content.ContextConfig{
 		NovelDir: cw.config.NovelDir,
 		Logger:   cw.config.Logger,
 	}
+	applyContentConfig(&cfg, cw.config.LLMManager, model)
 
 	var cb = content.NewContextBuilder(&cfg)
-	const maxTokens = 64000 // 适合角色更新任务的token限制
-	var percentage = token.TokenPercentages{
-		Plan:      0.10, // 减少规划信息
-		Character: 0.40, // 重点关注角色信息
-		Worldview: 0.10,
-		Chapters:  0.30, // 适量章节上下文
-		Index:     0.10,
-	}
+	maxTokens := contextTokenBudget(model, 64000, cw.config.Logger) // 适合角色更新任务的token限制
+	percentage := resolveTokenPercentages(token.ProfileCharacter, cw.config.BudgetProfile, cw.config.Logger)
 
 	// 生成上下文数据结构体
-	var data, err = cb.BuildTokenAwareContext(&percentage, maxTokens)
+	var data, err = cb.BuildTokenAwareContext(percentage, maxTokens)
+	cw.snapshot = contextSnapshot{model: model, maxTokens: maxTokens, percentages: percentage, counter: cfg.Counter, data: data}
 	if err != nil {
 		if cw.config.Logger != nil {
 			cw.config.Logger.Error("构建上下文失败", zap.Error(err))
@@ -175,14 +186,25 @@ func (cw *CharacterUpdateWorkflow) getContextData() map[string]any {
 	return cb.GetContextAsMap(data)
 }
 
+// DumpContext 构建上下文和系统提示词并写入文件，用于查看模型实际收到的内容（不调用模型）
+func (cw *CharacterUpdateWorkflow) DumpContext(path string) error {
+	sysPrompt := cw.buildSystemPrompt(cw.getContextData())
+	return cw.snapshot.newContextDump(token.ProfileCharacter, sysPrompt).WriteFile(path)
+}
+
 // ExecuteWithMonitoring 执行角色更新工作流并提供监控
 func (cw *CharacterUpdateWorkflow) ExecuteWithMonitoring(input string) (string, error) {
+	return cw.ExecuteWithContext(context.Background(), input)
+}
+
+// ExecuteWithContext 在 ctx 下执行角色更新工作流，ctx 中的任务信息会记录到文件版本历史和 git 提交中
+func (cw *CharacterUpdateWorkflow) ExecuteWithContext(ctx context.Context, input string) (string, error) {
 	agent, err := cw.CreateReActAgent()
 	if err != nil {
 		return "", fmt.Errorf("创建 ReAct Agent 失败: %w", err)
 	}
 
-	ctx := context.Background()
+	ctx = startWorkflowRun(ctx, token.ProfileCharacter)
 
 	// 创建 MessageFuture 选项进行监控
 	option, future := react.WithMessageFuture()
@@ -196,6 +218,9 @@ func (cw *CharacterUpdateWorkflow) ExecuteWithMonitoring(input string) (string,
 		return "", err
 	}
 
+	// 启用 git 仓库模式时提交本次运行的改动
+	commitWorkflowRun(ctx, cw.config.NovelDir, token.ProfileCharacter, resolvedModelName(cw.resolvedModel, cw.config.Model), cw.config.Logger)
+
 	// 如果开启进度显示，展示执行步骤
 	if cw.config.ShowProgress {
 		cw.showExecutionSteps(future)
@@ -244,9 +269,16 @@ func (cw *CharacterUpdateWorkflow) ProcessCharacterUpdate(ctx context.Context, c
 	} else {
 		// AI分析模式
 		input = fmt.Sprintf("请分析最新章节内容对角色 %s 的影响，并根据需要更新角色状态", characterName)
+		ctx = withLatestChapter(ctx, cw.config.NovelDir)
 	}
 
-	_, err := cw.ExecuteWithMonitoring(input)
+	_, err := cw.ExecuteWithContext(ctx, input)
 	return err
 }
 
+// ProcessChapterCharacters 分析指定章节中出现的角色，创建或更新角色档案，用于导入已有书稿后补全角色信息
+func (cw *CharacterUpdateWorkflow) ProcessChapterCharacters(ctx context.Context, chapterID string) error {
+	input := fmt.Sprintf("请分析章节 %s 的内容，为其中出现的重要角色创建或更新角色档案", chapterID)
+	_, err := cw.ExecuteWithContext(withRunChapters(ctx, chapterID), input)
+	return err
+}
@@ -0,0 +1,100 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content/token"
+	"github.com/Kizunad/modular-workflow-v2/config"
+	"github.com/Kizunad/modular-workflow-v2/providers"
+)
+
+// compressionComponentNames 压缩提示词中使用的组件名称
+var compressionComponentNames = map[string]string{
+	"worldview": "世界观设定",
+	"character": "角色信息",
+	"plan":      "章节规划",
+}
+
+// thinkTagPattern 匹配推理模型输出的 <think> 标签
+var thinkTagPattern = regexp.MustCompile(`(?s)<think>.*?</think>`)
+
+// ModelCompressor 使用小模型浓缩超出预算的上下文内容
+type ModelCompressor struct {
+	llmManager *providers.Manager
+	model      string
+}
+
+// NewModelCompressor 创建模型压缩器
+func NewModelCompressor(llmManager *providers.Manager, model string) *ModelCompressor {
+	if model == "" {
+		model = "qwen3:4b"
+	}
+	return &ModelCompressor{
+		llmManager: llmManager,
+		model:      model,
+	}
+}
+
+// Compress 将文本浓缩到约 maxTokens 个Token以内
+func (mc *ModelCompressor) Compress(ctx context.Context, component, text string, maxTokens int) (string, error) {
+	if mc.llmManager == nil {
+		return "", fmt.Errorf("未配置LLM管理器")
+	}
+
+	model, err := mc.llmManager.GetOllamaModel(ctx, providers.WithModel(mc.model))
+	if err != nil {
+		return "", fmt.Errorf("获取压缩模型 %s 失败: %w", mc.model, err)
+	}
+
+	name := compressionComponentNames[component]
+	if name == "" {
+		name = "内容"
+	}
+
+	template := prompt.FromMessages(
+		schema.Jinja2,
+		schema.SystemMessage(`你是一个小说资料整理助手。你的任务是把给定的{{name}}浓缩到指定长度以内，供后续写作参考。
+
+要求：
+1. 保留所有专有名词（人名、地名、功法、势力、物品）和关键数值设定
+2. 保留每一项设定的核心规则和相互关系，删除修饰性描述和重复内容
+3. 保持原有的标题层级和条目结构，不要合并不同的条目
+4. 不要添加原文没有的信息
+5. 只输出浓缩后的内容，不要任何解释`),
+		schema.UserMessage(`请将以下{{name}}浓缩到约 {{max_tokens}} 个Token（约 {{max_chars}} 个汉字）以内：
+
+{{text}}`),
+	)
+
+	messages, err := template.Format(ctx, map[string]any{
+		"name":       name,
+		"max_tokens": maxTokens,
+		"max_chars":  maxTokens * 2 / 3,
+		"text":       text,
+	})
+	if err != nil {
+		return "", fmt.Errorf("格式化压缩提示失败: %w", err)
+	}
+
+	response, err := model.Generate(ctx, messages)
+	if err != nil {
+		return "", fmt.Errorf("模型压缩失败: %w", err)
+	}
+
+	return strings.TrimSpace(thinkTagPattern.ReplaceAllString(response.Content, "")), nil
+}
+
+// newContextCompressor 根据全局配置创建上下文压缩器，未启用时返回nil
+func newContextCompressor(llmManager *providers.Manager) token.TextCompressor {
+	global := config.GetGlobalOrNil()
+	if global == nil || llmManager == nil || !global.Novel.Content.Compression.Enabled {
+		return nil
+	}
+	return NewModelCompressor(llmManager, global.Novel.Content.Compression.Model)
+}
@@ -0,0 +1,185 @@
+package workflows
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content"
+	"github.com/Kizunad/modular-workflow-v2/components/content/token"
+)
+
+// dumpComponents 转储时各组件的输出顺序及显示名称
+var dumpComponents = []struct {
+	name  string
+	label string
+}{
+	{"index", "索引摘要"},
+	{"worldview", "世界观"},
+	{"character", "角色信息"},
+	{"chapters", "章节内容"},
+	{"plan", "规划信息"},
+	{"retrieved", "相关前文"},
+}
+
+// contextSnapshot 最近一次构建上下文时的参数和结果，用于上下文转储
+type contextSnapshot struct {
+	model       string
+	maxTokens   int
+	percentages *token.TokenPercentages
+	counter     token.TokenCounter
+	data        *content.ContextData
+}
+
+// ContextDump 模型实际收到的上下文，用于排查生成问题
+type ContextDump struct {
+	Workflow     string
+	Model        string
+	MaxTokens    int
+	Percentages  *token.TokenPercentages
+	SystemPrompt string
+	PromptTokens int
+	Context      *content.ContextData
+	GeneratedAt  time.Time
+}
+
+// newContextDump 根据上下文快照和系统提示词创建转储
+func (s *contextSnapshot) newContextDump(workflow, sysPrompt string) *ContextDump {
+	counter := s.counter
+	if counter == nil {
+		counter = token.NewSimpleTokenCounter()
+	}
+
+	return &ContextDump{
+		Workflow:     workflow,
+		Model:        s.model,
+		MaxTokens:    s.maxTokens,
+		Percentages:  s.percentages,
+		SystemPrompt: sysPrompt,
+		PromptTokens: counter.Count(sysPrompt),
+		Context:      s.data,
+		GeneratedAt:  time.Now(),
+	}
+}
+
+// Render 将转储渲染为 Markdown 文本
+func (d *ContextDump) Render() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# 上下文转储: %s\n\n", d.Workflow)
+	fmt.Fprintf(&b, "- 生成时间: %s\n", d.GeneratedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "- 目标模型: %s\n", d.Model)
+	fmt.Fprintf(&b, "- 上下文预算: %d tokens\n", d.MaxTokens)
+	fmt.Fprintf(&b, "- 系统提示词: %d tokens\n", d.PromptTokens)
+	if d.Percentages != nil {
+		fmt.Fprintf(&b, "- 预算百分比: %s\n", formatPercentages(d.Percentages))
+	}
+
+	b.WriteString("\n## Token 分配\n\n")
+	if d.Context == nil {
+		b.WriteString("上下文构建失败，无分配信息。\n")
+	} else {
+		b.WriteString("| 组件 | 百分比上限 | 完整需要 | 最终分配 | 重新分配 | 实际使用 | 状态 |\n")
+		b.WriteString("| --- | ---: | ---: | ---: | ---: | ---: | --- |\n")
+		for _, component := range dumpComponents {
+			var cap, extra int
+			if d.Context.Allocation != nil {
+				if allocation, ok := d.Context.Allocation.Components[component.name]; ok {
+					cap, extra = allocation.Cap, allocation.Extra
+				}
+			}
+			usage := d.Context.Usage[component.name]
+			if usage == nil {
+				usage = &content.ComponentUsage{}
+			}
+			fmt.Fprintf(&b, "| %s | %d | %d | %d | +%d | %d | %s |\n",
+				component.label, cap, usage.Needed, usage.Limit, extra, usage.Tokens, usageStatus(usage))
+		}
+		if d.Context.Allocation != nil {
+			fmt.Fprintf(&b, "\n总计 %d，已分配 %d，未使用 %d\n",
+				d.Context.Allocation.Total, d.Context.Allocation.Allocated, d.Context.Allocation.Unused)
+		}
+	}
+
+	b.WriteString("\n## 系统提示词\n\n")
+	b.WriteString("````text\n")
+	b.WriteString(d.SystemPrompt)
+	b.WriteString("\n````\n")
+
+	if d.Context != nil {
+		b.WriteString("\n## 各组件内容\n")
+		texts := map[string]string{
+			"index":     d.Context.Summary,
+			"worldview": d.Context.Worldview,
+			"character": d.Context.Characters,
+			"chapters":  d.Context.Chapter,
+			"plan":      d.Context.Plan,
+			"retrieved": d.Context.Retrieved,
+		}
+		for _, component := range dumpComponents {
+			text := texts[component.name]
+			usage := d.Context.Usage[component.name]
+			fmt.Fprintf(&b, "\n### %s", component.label)
+			if usage != nil {
+				fmt.Fprintf(&b, "（%d tokens）", usage.Tokens)
+				if usage.Truncated {
+					fmt.Fprintf(&b, " ⚠️ 已截断或压缩：完整需要 %d，保留 %d", usage.Needed, usage.Tokens)
+				}
+			}
+			b.WriteString("\n\n")
+			if text == "" {
+				b.WriteString("（空）\n")
+				continue
+			}
+			b.WriteString("````text\n")
+			b.WriteString(text)
+			b.WriteString("\n````\n")
+		}
+	}
+
+	return b.String()
+}
+
+// WriteFile 将转储写入文件
+func (d *ContextDump) WriteFile(path string) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建转储目录失败: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(d.Render()), 0644); err != nil {
+		return fmt.Errorf("写入上下文转储失败: %w", err)
+	}
+	return nil
+}
+
+// usageStatus 组件状态说明
+func usageStatus(usage *content.ComponentUsage) string {
+	switch {
+	case usage.Truncated:
+		return "截断/压缩"
+	case usage.Tokens == 0:
+		return "空"
+	default:
+		return "完整"
+	}
+}
+
+// formatPercentages 格式化预算百分比，按组件名排序
+func formatPercentages(percentages *token.TokenPercentages) string {
+	values := percentages.ToMap()
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%.2f", name, values[name]))
+	}
+	return strings.Join(parts, ", ")
+}
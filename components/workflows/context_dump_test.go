@@ -0,0 +1,73 @@
+package workflows
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content"
+	"github.com/Kizunad/modular-workflow-v2/components/content/token"
+)
+
+func TestContextDumpRender(t *testing.T) {
+	percentages, _ := token.BuiltinProfile(token.ProfileWrite)
+	snapshot := &contextSnapshot{
+		model:       "qwen3:4b",
+		maxTokens:   4000,
+		percentages: percentages,
+		data: &content.ContextData{
+			Worldview: "灵气复苏的世界",
+			Chapter:   "林凡在青云山下醒来。",
+			Usage: map[string]*content.ComponentUsage{
+				"worldview": {Tokens: 7, Limit: 800, Needed: 7},
+				"chapters":  {Tokens: 10, Limit: 10, Needed: 50, Truncated: true},
+			},
+			Allocation: &token.AllocationReport{
+				Total:     4000,
+				Allocated: 17,
+				Unused:    3983,
+				Components: map[string]*token.ComponentAllocation{
+					"chapters": {Cap: 1600, Needed: 50, Allocated: 10, Extra: 5},
+				},
+			},
+		},
+	}
+
+	dump := snapshot.newContextDump("write", "你是一名小说作者")
+	assert.Equal(t, "qwen3:4b", dump.Model)
+	assert.Positive(t, dump.PromptTokens)
+
+	text := dump.Render()
+	assert.Contains(t, text, "# 上下文转储: write")
+	assert.Contains(t, text, "- 目标模型: qwen3:4b")
+	assert.Contains(t, text, "- 上下文预算: 4000 tokens")
+	assert.Contains(t, text, "| 章节内容 | 1600 | 50 | 10 | +5 | 10 | 截断/压缩 |")
+	assert.Contains(t, text, "| 世界观 | 0 | 7 | 800 | +0 | 7 | 完整 |")
+	assert.Contains(t, text, "| 相关前文 | 0 | 0 | 0 | +0 | 0 | 空 |")
+	assert.Contains(t, text, "总计 4000，已分配 17，未使用 3983")
+	assert.Contains(t, text, "````text\n你是一名小说作者\n````")
+	assert.Contains(t, text, "### 章节内容（10 tokens） ⚠️ 已截断或压缩：完整需要 50，保留 10")
+	assert.Contains(t, text, "### 角色信息\n\n（空）")
+
+	// 组件按固定顺序输出
+	assert.Less(t, strings.Index(text, "### 索引摘要"), strings.Index(text, "### 世界观"))
+	assert.Less(t, strings.Index(text, "### 规划信息"), strings.Index(text, "### 相关前文"))
+}
+
+func TestContextDumpWithoutContext(t *testing.T) {
+	dump := &ContextDump{Workflow: "plan", SystemPrompt: "规划", GeneratedAt: time.Now()}
+	text := dump.Render()
+	assert.Contains(t, text, "上下文构建失败，无分配信息。")
+	assert.NotContains(t, text, "## 各组件内容")
+
+	path := filepath.Join(t.TempDir(), "dumps", "ctx.md")
+	require.NoError(t, dump.WriteFile(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, text, string(data))
+}
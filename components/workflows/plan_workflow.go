@@ -24,12 +24,16 @@ type PlanWorkflowConfig struct {
 	LLMManager   *providers.Manager
 	ShowProgress bool
 	PlannerModel string // 规划模型名称
+
+	BudgetProfile string // Token预算配置名称，为空时使用配置文件为该工作流指定的配置
 }
 
 // PlanWorkflow 规划工作流
 type PlanWorkflow struct {
-	config *PlanWorkflowConfig
-	cli    *common.CLIHelper
+	config        *PlanWorkflowConfig
+	cli           *common.CLIHelper
+	resolvedModel string          // 实际使用的模型名，用于计算上下文预算
+	snapshot      contextSnapshot // 最近一次构建的上下文，用于上下文转储
 }
 
 // NewPlanWorkflow 创建规划工作流
@@ -49,26 +53,23 @@ func NewPlanWorkflow(config *PlanWorkflowConfig) *PlanWorkflow {
 }
 
 // getContextData 获取上下文数据
-func (pw *PlanWorkflow) getContextData() map[string]any {
+func (pw *PlanWorkflow) getContextData() (map[string]any, error) {
+	model := resolvedModelName(pw.resolvedModel, pw.config.PlannerModel)
 	var cfg = content.ContextConfig{
 		NovelDir: pw.config.NovelDir,
 		Logger:   pw.config.Logger,
 	}
+	applyContentConfig(&cfg, pw.config.LLMManager, model)
 
 	var cb = content.NewContextBuilder(&cfg)
-	const maxTokens = 128000 //128k tokens
-	var percentage = token.TokenPercentages{
-		Plan:      0.6,  // 1000000 * 0.6 / 1.5 = 400000 中文
-		Character: 0.03, // 1000000 * 0.03 / 1.5 = 20000
-		Worldview: 0.04, // 1000000 * 0.04 / 1.5 = 26666
-		Index:     0.03, // 1000000 * 0.03 / 1.5 = 20000
-		Chapters:  0.3,  // 1000000 * 0.3 / 1.5 = 200000
-	}
+	maxTokens := contextTokenBudget(model, 128000, pw.config.Logger) //128k tokens
+	percentage := resolveTokenPercentages(token.ProfilePlan, pw.config.BudgetProfile, pw.config.Logger)
 
 	// 生成 文章Data 数据结构体
-	var data, err = cb.BuildTokenAwareContext(&percentage, maxTokens)
+	var data, err = cb.BuildTokenAwareContext(percentage, maxTokens)
+	pw.snapshot = contextSnapshot{model: model, maxTokens: maxTokens, percentages: percentage, counter: cfg.Counter, data: data}
 	if err != nil {
-		panic(fmt.Errorf("%w", err))
+		return nil, fmt.Errorf("构建上下文失败: %w", err)
 	}
 
 	if pw.config.ShowProgress {
@@ -76,12 +77,24 @@ func (pw *PlanWorkflow) getContextData() map[string]any {
 	}
 
 	// 使用ContextBuilder的GetContextAsMap方法确保键名正确
-	return cb.GetContextAsMap(data)
+	return cb.GetContextAsMap(data), nil
+}
+
+// DumpContext 构建上下文和系统提示词并写入文件，用于查看模型实际收到的内容（不调用模型）
+// 上下文构建失败时仍写出不含上下文的转储，并返回构建错误
+func (pw *PlanWorkflow) DumpContext(path string) error {
+	ctxData, buildErr := pw.getContextData()
+	sysPrompt := pw.buildSystemPrompt(ctxData)
+	if err := pw.snapshot.newContextDump(token.ProfilePlan, sysPrompt).WriteFile(path); err != nil {
+		return err
+	}
+	return buildErr
 }
 
 func (pw *PlanWorkflow) CreateReActAgent() (*react.Agent, error) {
 	ctx := context.Background()
 
+	pw.resolvedModel = pw.config.PlannerModel
 	plannerModel, err := pw.config.LLMManager.GetOpenAIModel(ctx, providers.WithModel(pw.config.PlannerModel))
 	if err != nil {
 		if pw.config.Logger != nil {
@@ -92,6 +105,7 @@ func (pw *PlanWorkflow) CreateReActAgent() (*react.Agent, error) {
 		if err != nil {
 			return nil, fmt.Errorf("所有规划模型都不可用: %w", err)
 		}
+		pw.resolvedModel = pw.config.LLMManager.DefaultOpenAIModelName()
 	}
 
 	if err != nil {
@@ -100,51 +114,73 @@ func (pw *PlanWorkflow) CreateReActAgent() (*react.Agent, error) {
 
 	// 创建工具配置
 	planCrudTool := tools.NewPlanCRUDTool(pw.config.NovelDir)
-	currentChapterTool := tools.NewCurrentChapterCRUDTool(pw.config.NovelDir)
+	currentChapterTool := tools.NewCurrentChapterCRUDTool(pw.config.NovelDir, chapterManagerOptions()...)
+	storyStructureTool := tools.NewStoryStructureTool(pw.config.NovelDir)
+
+	messageModifier, err := pw.createMessageModifier()
+	if err != nil {
+		return nil, err
+	}
 
 	agentConfig := &react.AgentConfig{
 		ToolCallingModel: plannerModel,
 		ToolsConfig: compose.ToolsNodeConfig{
-			Tools:               []tool.BaseTool{planCrudTool, currentChapterTool},
+			Tools:               []tool.BaseTool{planCrudTool, currentChapterTool, storyStructureTool},
 			ExecuteSequentially: false,
 		},
 		MaxStep:         10,
-		MessageModifier: pw.createMessageModifier(),
+		MessageModifier: messageModifier,
 	}
 
 	return react.NewAgent(ctx, agentConfig)
 
 }
 
-func (pw *PlanWorkflow) createMessageModifier() react.MessageModifier {
-	// 预先加载上下文数据，避免重复调用
-	ctxData := pw.getContextData()
-	prompt := content.Novel_planner_prompt
+func (pw *PlanWorkflow) createMessageModifier() (react.MessageModifier, error) {
+	// 预先加载上下文并构建系统提示词，避免重复调用
+	ctxData, err := pw.getContextData()
+	if err != nil {
+		return nil, err
+	}
+	sysPrompt := pw.buildSystemPrompt(ctxData)
 
 	return func(ctx context.Context, input []*schema.Message) []*schema.Message {
-		sysPrompt := fmt.Sprintf(
-			`%v,当前上下文:-章节:%v-世界观:%v-角色:%v-现有规划:%v-总结: %v`,
-			prompt,
-			ctxData["chapter"],
-			ctxData["worldview"],
-			ctxData["characters"],
-			ctxData["plan"],
-			ctxData["summary"],
-		)
 		result := make([]*schema.Message, 0, len(input)+1)
 		result = append(result, schema.SystemMessage(sysPrompt))
 		result = append(result, input...)
 		return result
-	}
+	}, nil
+}
+
+// buildSystemPrompt 根据上下文数据构建系统提示词
+func (pw *PlanWorkflow) buildSystemPrompt(ctxData map[string]any) string {
+	prompt := content.Novel_planner_prompt
+
+	sysPrompt := fmt.Sprintf(
+		`%v,当前上下文:-章节:%v-世界观:%v-角色:%v-现有规划:%v-总结: %v`,
+		prompt,
+		ctxData["chapter"],
+		ctxData["worldview"],
+		ctxData["characters"],
+		ctxData["plan"],
+		ctxData["summary"],
+	)
+
+	return sysPrompt
 }
 
 func (pw *PlanWorkflow) ExecuteWithMonitoring(input string) (string, error) {
+	return pw.ExecuteWithContext(context.Background(), input)
+}
+
+// ExecuteWithContext 在 ctx 下执行规划工作流，ctx 中的任务信息会记录到文件版本历史和 git 提交中
+func (pw *PlanWorkflow) ExecuteWithContext(ctx context.Context, input string) (string, error) {
 	agent, err := pw.CreateReActAgent()
 	if err != nil {
 		return "", fmt.Errorf("创建 ReAct Agent 失败: %w", err)
 	}
 
-	ctx := context.Background()
+	ctx = startWorkflowRun(ctx, token.ProfilePlan)
 
 	// 创建 MessageFuture 选项
 	option, future := react.WithMessageFuture()
@@ -158,6 +194,9 @@ func (pw *PlanWorkflow) ExecuteWithMonitoring(input string) (string, error) {
 		return "", err
 	}
 
+	// 启用 git 仓库模式时提交本次运行的改动
+	commitWorkflowRun(ctx, pw.config.NovelDir, token.ProfilePlan, resolvedModelName(pw.resolvedModel, pw.config.PlannerModel), pw.config.Logger)
+
 	// 获取每次循环的消息
 	iter := future.GetMessages()
 	stepCount := 0
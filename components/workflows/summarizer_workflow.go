@@ -25,12 +25,16 @@ type SummarizerWorkflowConfig struct {
 	LLMManager   *providers.Manager
 	ShowProgress bool
 	Model        string // 模型名称
+
+	BudgetProfile string // Token预算配置名称，为空时使用配置文件为该工作流指定的配置
 }
 
 // SummarizerWorkflow 摘要工作流
 type SummarizerWorkflow struct {
-	config *SummarizerWorkflowConfig
-	cli    *common.CLIHelper
+	config        *SummarizerWorkflowConfig
+	cli           *common.CLIHelper
+	resolvedModel string          // 实际使用的模型名，用于计算上下文预算
+	snapshot      contextSnapshot // 最近一次构建的上下文，用于上下文转储
 }
 
 // NewSummarizerWorkflow 创建摘要工作流
@@ -54,6 +58,7 @@ func (sw *SummarizerWorkflow) CreateReActAgent() (*react.Agent, error) {
 	ctx := context.Background()
 
 	// 获取模型
+	sw.resolvedModel = sw.config.Model
 	model, err := sw.config.LLMManager.GetOllamaModel(ctx, providers.WithModel(sw.config.Model))
 	if err != nil {
 		if sw.config.Logger != nil {
@@ -64,16 +69,18 @@ func (sw *SummarizerWorkflow) CreateReActAgent() (*react.Agent, error) {
 		if err != nil {
 			return nil, fmt.Errorf("所有模型都不可用: %w", err)
 		}
+		sw.resolvedModel = sw.config.LLMManager.DefaultOllamaModelName()
 	}
 
 	// 创建摘要管理工具
 	summaryTool := tools.NewSummaryCRUDTool(sw.config.NovelDir, sw.config.LLMManager)
-	chapterTool := tools.NewCurrentChapterCRUDTool(sw.config.NovelDir)
+	chapterTool := tools.NewCurrentChapterCRUDTool(sw.config.NovelDir, chapterManagerOptions()...)
 	chapterAnalysisTool := tools.NewChapterAnalysisTool(sw.config.NovelDir)
+	storyStructureTool := tools.NewStoryStructureTool(sw.config.NovelDir)
 
 	// 创建工具节点配置
 	toolsNodeConfig := &compose.ToolsNodeConfig{
-		Tools: []tool.BaseTool{summaryTool, chapterTool, chapterAnalysisTool},
+		Tools: []tool.BaseTool{summaryTool, chapterTool, chapterAnalysisTool, storyStructureTool},
 		ExecuteSequentially: false,
 	}
 
@@ -91,8 +98,19 @@ func (sw *SummarizerWorkflow) CreateReActAgent() (*react.Agent, error) {
 
 // createMessageModifier 创建消息修饰器
 func (sw *SummarizerWorkflow) createMessageModifier() react.MessageModifier {
-	// 预先加载上下文数据
-	ctxData := sw.getContextData()
+	// 预先加载上下文并构建系统提示词，避免重复调用
+	sysPrompt := sw.buildSystemPrompt(sw.getContextData())
+
+	return func(ctx context.Context, input []*schema.Message) []*schema.Message {
+		result := make([]*schema.Message, 0, len(input)+1)
+		result = append(result, schema.SystemMessage(sysPrompt))
+		result = append(result, input...)
+		return result
+	}
+}
+
+// buildSystemPrompt 根据上下文数据构建系统提示词
+func (sw *SummarizerWorkflow) buildSystemPrompt(ctxData map[string]any) string {
 	// 使用默认摘要生成提示词
 	prompt := `你是一个专业的小说章节摘要分析师。你的任务是：
 
@@ -106,6 +124,7 @@ func (sw *SummarizerWorkflow) createMessageModifier() react.MessageModifier {
 2. 提取章节基本信息（标题、字数等）
 3. 使用AI生成结构化摘要
 4. 更新索引文件
+5. 章节属于某个篇章时（story_structure 的 current 操作），用 upsert_arc 更新该篇章的 summary；篇章最后一章完成后将其 status 设为 completed
 
 摘要格式要求：
 - 关键事件: 列出2-3个主要事件
@@ -113,9 +132,8 @@ func (sw *SummarizerWorkflow) createMessageModifier() react.MessageModifier {
 - 重要地点: 记录关键场景
 - 情节进展: 简述推进的主要情节`
 
-	return func(ctx context.Context, input []*schema.Message) []*schema.Message {
-		sysPrompt := fmt.Sprintf(
-			`%v
+	sysPrompt := fmt.Sprintf(
+		`%v
 
 当前上下文信息:
 - 章节信息: %v
@@ -124,39 +142,32 @@ func (sw *SummarizerWorkflow) createMessageModifier() react.MessageModifier {
 - 已有索引: %v
 
 请根据用户要求进行章节摘要分析和生成。`,
-			prompt,
-			ctxData["chapter"],
-			ctxData["characters"],
-			ctxData["worldview"],
-			ctxData["summary"],
-		)
-		
-		result := make([]*schema.Message, 0, len(input)+1)
-		result = append(result, schema.SystemMessage(sysPrompt))
-		result = append(result, input...)
-		return result
-	}
+		prompt,
+		ctxData["chapter"],
+		ctxData["characters"],
+		ctxData["worldview"],
+		ctxData["summary"],
+	)
+
+	return sysPrompt
 }
 
 // getContextData 获取上下文数据
 func (sw *SummarizerWorkflow) getContextData() map[string]any {
+	model := resolvedModelName(sw.resolvedModel, sw.config.Model)
 	var cfg = content.ContextConfig{
 		NovelDir: sw.config.NovelDir,
 		Logger:   sw.config.Logger,
 	}
+	applyContentConfig(&cfg, sw.config.LLMManager, model)
 
 	var cb = content.NewContextBuilder(&cfg)
-	const maxTokens = 32000 // 摘要任务相对简单，token需求较少
-	var percentage = token.TokenPercentages{
-		Plan:      0.05, // 很少规划信息
-		Character: 0.15, // 适量角色信息
-		Worldview: 0.10, // 少量世界观
-		Chapters:  0.60, // 重点关注章节内容
-		Index:     0.10, // 已有摘要索引
-	}
+	maxTokens := contextTokenBudget(model, 32000, sw.config.Logger) // 摘要任务相对简单，token需求较少
+	percentage := resolveTokenPercentages(token.ProfileSummarize, sw.config.BudgetProfile, sw.config.Logger)
 
 	// 生成上下文数据结构体
-	var data, err = cb.BuildTokenAwareContext(&percentage, maxTokens)
+	var data, err = cb.BuildTokenAwareContext(percentage, maxTokens)
+	sw.snapshot = contextSnapshot{model: model, maxTokens: maxTokens, percentages: percentage, counter: cfg.Counter, data: data}
 	if err != nil {
 		if sw.config.Logger != nil {
 			sw.config.Logger.Error("构建上下文失败", zap.Error(err))
@@ -178,14 +189,25 @@ func (sw *SummarizerWorkflow) getContextData() map[string]any {
 	return cb.GetContextAsMap(data)
 }
 
+// DumpContext 构建上下文和系统提示词并写入文件，用于查看模型实际收到的内容（不调用模型）
+func (sw *SummarizerWorkflow) DumpContext(path string) error {
+	sysPrompt := sw.buildSystemPrompt(sw.getContextData())
+	return sw.snapshot.newContextDump(token.ProfileSummarize, sysPrompt).WriteFile(path)
+}
+
 // ExecuteWithMonitoring 执行摘要工作流并提供监控
 func (sw *SummarizerWorkflow) ExecuteWithMonitoring(input string) (string, error) {
+	return sw.ExecuteWithContext(context.Background(), input)
+}
+
+// ExecuteWithContext 在 ctx 下执行摘要工作流，ctx 中的任务信息会记录到文件版本历史和 git 提交中
+func (sw *SummarizerWorkflow) ExecuteWithContext(ctx context.Context, input string) (string, error) {
 	agent, err := sw.CreateReActAgent()
 	if err != nil {
 		return "", fmt.Errorf("创建 ReAct Agent 失败: %w", err)
 	}
 
-	ctx := context.Background()
+	ctx = startWorkflowRun(ctx, token.ProfileSummarize)
 
 	// 创建 MessageFuture 选项进行监控
 	option, future := react.WithMessageFuture()
@@ -199,6 +221,9 @@ func (sw *SummarizerWorkflow) ExecuteWithMonitoring(input string) (string, error
 		return "", err
 	}
 
+	// 启用 git 仓库模式时提交本次运行的改动
+	commitWorkflowRun(ctx, sw.config.NovelDir, token.ProfileSummarize, resolvedModelName(sw.resolvedModel, sw.config.Model), sw.config.Logger)
+
 	// 如果开启进度显示，展示执行步骤
 	if sw.config.ShowProgress {
 		sw.showExecutionSteps(future)
@@ -241,21 +266,21 @@ func (sw *SummarizerWorkflow) showExecutionSteps(future react.MessageFuture) {
 // ProcessSummarize 处理摘要任务（兼容原有接口）
 func (sw *SummarizerWorkflow) ProcessSummarize(ctx context.Context, chapterContent string) error {
 	input := fmt.Sprintf("请为以下章节内容生成摘要：\n\n%s", chapterContent)
-	_, err := sw.ExecuteWithMonitoring(input)
+	_, err := sw.ExecuteWithContext(ctx, input)
 	return err
 }
 
 // ProcessSummarizeByID 通过章节ID处理摘要任务
 func (sw *SummarizerWorkflow) ProcessSummarizeByID(ctx context.Context, chapterID string) error {
 	input := fmt.Sprintf("请为章节 %s 生成摘要", chapterID)
-	_, err := sw.ExecuteWithMonitoring(input)
+	_, err := sw.ExecuteWithContext(withRunChapters(ctx, chapterID), input)
 	return err
 }
 
 // ProcessLatestChapterSummary 处理最新章节摘要任务
 func (sw *SummarizerWorkflow) ProcessLatestChapterSummary(ctx context.Context) error {
 	input := "请为最新章节生成摘要"
-	_, err := sw.ExecuteWithMonitoring(input)
+	_, err := sw.ExecuteWithContext(withLatestChapter(ctx, sw.config.NovelDir), input)
 	return err
 }
 
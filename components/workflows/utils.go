@@ -1,9 +1,219 @@
 package workflows
 
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/Kizunad/modular-workflow-v2/components/content"
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
+	"github.com/Kizunad/modular-workflow-v2/components/content/token"
+	"github.com/Kizunad/modular-workflow-v2/config"
+	"github.com/Kizunad/modular-workflow-v2/logger"
+	"github.com/Kizunad/modular-workflow-v2/providers"
+)
+
 // truncateContent 截断内容用于日志显示
 func truncateContent(content string, maxLen int) string {
 	if len(content) <= maxLen {
 		return content
 	}
 	return content[:maxLen] + "..."
-}
\ No newline at end of file
+}
+
+// applyContentConfig 将全局配置中的内容选项（最近章节窗口、优先级、权重、截断方式、压缩、分词器、缓存）应用到上下文构建配置
+// model 为使用该上下文的目标模型，用于选择匹配的分词器
+func applyContentConfig(cfg *content.ContextConfig, llmManager *providers.Manager, model string) {
+	global := config.GetGlobalOrNil()
+	if global == nil {
+		return
+	}
+
+	contentCfg := global.Novel.Content
+	if contentCfg.RecentChapters > 0 {
+		cfg.RecentChapters = contentCfg.RecentChapters
+	}
+	if contentCfg.ContentPriorities != nil {
+		cfg.ContentPriorities = contentCfg.ContentPriorities
+	}
+	if contentCfg.ContentWeights != nil {
+		cfg.ContentWeights = contentCfg.ContentWeights
+	}
+
+	if modes, err := content.ResolveTruncateModes(&contentCfg); err == nil {
+		cfg.TruncateModes = modes
+	} else if cfg.Logger != nil {
+		cfg.Logger.Warn(fmt.Sprintf("截断方式配置无效，使用默认值: %v", err))
+	}
+
+	cfg.Compressor = newContextCompressor(llmManager)
+	cfg.Counter = tokenCounterForModel(global, model, cfg.Logger)
+
+	if contentCfg.EnableCache {
+		cache := content.SharedContextCache()
+		cache.SetTTL(time.Duration(contentCfg.CacheTTLSeconds) * time.Second)
+		cfg.Cache = cache
+	}
+}
+
+// tokenCounterForModel 加载模型对应的本地分词器，未配置或加载失败时返回nil（使用估算计数器）
+func tokenCounterForModel(global *config.Config, model string, log *logger.ZapLogger) token.TokenCounter {
+	tokenizer, ok := global.LLM.GetTokenizer(model)
+	if !ok || tokenizer.Path == "" {
+		return nil
+	}
+
+	counter, err := token.LoadBPETokenCounter(tokenizer.Path, tokenizer.Format)
+	if err != nil {
+		if log != nil {
+			log.Warn(fmt.Sprintf("加载模型 %s 的分词器失败，使用估算计数: %v", model, err))
+		}
+		return nil
+	}
+	return counter
+}
+
+// chapterManagerOptions 按全局配置创建章节管理器的选项（新章节的文件名格式）
+func chapterManagerOptions() []managers.ChapterManagerOption {
+	global := config.GetGlobalOrNil()
+	if global == nil {
+		return nil
+	}
+	return []managers.ChapterManagerOption{managers.WithChapterFilePattern(global.Novel.ChapterFilePattern)}
+}
+
+// resolvedModelName 返回实际使用的模型名，尚未解析模型时使用配置的模型名
+func resolvedModelName(resolved, configured string) string {
+	if resolved != "" {
+		return resolved
+	}
+	return configured
+}
+
+// contextTokenBudget 根据模型能力计算上下文Token预算
+// 预算为模型上下文窗口减去最大输出和提示词预留，并且不超过任务自身的上限 taskLimit
+func contextTokenBudget(model string, taskLimit int, log *logger.ZapLogger) int {
+	global := config.GetGlobalOrNil()
+	if global == nil {
+		return taskLimit
+	}
+
+	capability := global.LLM.GetModelCapability(model)
+	budget := global.LLM.ContextBudget(model)
+	if budget > taskLimit {
+		budget = taskLimit
+	}
+
+	if log != nil {
+		log.Info(fmt.Sprintf("模型 %s 上下文预算: 窗口=%d, 最大输出=%d, 上下文=%d",
+			model, capability.ContextWindow, capability.MaxOutput, budget))
+		if !global.LLM.HasModelCapability(model) {
+			log.Warn(fmt.Sprintf("模型 %s 不在模型能力表中，按 default 条目计算上下文预算 %d；可在 llm.capabilities 中配置该模型的上下文窗口",
+				model, budget))
+		}
+		if !capability.SupportsTools {
+			log.Warn(fmt.Sprintf("模型 %s 未声明支持工具调用，ReAct 工作流可能无法正常执行", model))
+		}
+	}
+	return budget
+}
+
+// resolveTokenPercentages 解析工作流使用的Token预算配置，优先级见 content.ResolveWorkflowBudget；
+// 配置无效时记录警告并使用内置配置
+func resolveTokenPercentages(workflow, profile string, log *logger.ZapLogger) *token.TokenPercentages {
+	if global := config.GetGlobalOrNil(); global != nil {
+		percentages, err := content.ResolveWorkflowBudget(&global.Novel.Content, workflow, profile)
+		if err == nil {
+			return percentages
+		}
+		if log != nil {
+			log.Warn(fmt.Sprintf("加载预算配置失败，使用内置配置 %s: %v", workflow, err))
+		}
+	} else if percentages, ok := token.BuiltinProfile(profile); ok {
+		return percentages
+	}
+
+	percentages, _ := token.BuiltinProfile(workflow)
+	return percentages
+}
+
+// startWorkflowRun 为工作流运行准备 context：未由任务指定作者时，以工作流名称作为写入文件的版本作者，
+// 并记录本次运行写入的文件，提交时只暂存这些文件
+func startWorkflowRun(ctx context.Context, workflow string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	info := managers.VersionInfoFromContext(ctx)
+	if info.Author == managers.DefaultVersionAuthor {
+		info.Author = "workflow:" + workflow
+	}
+	return managers.TrackRunChanges(managers.WithVersionInfo(ctx, info))
+}
+
+// withRunChapters 登记本次运行处理的章节，提交说明中会列出这些章节；无法解析的章节ID被忽略
+func withRunChapters(ctx context.Context, chapterIDs ...string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = managers.TrackRunChanges(ctx)
+	changes := managers.RunChangesFromContext(ctx)
+	for _, chapterID := range chapterIDs {
+		if id, err := strconv.Atoi(strings.TrimSpace(chapterID)); err == nil {
+			changes.AddChapters(id)
+		}
+	}
+	return ctx
+}
+
+// withLatestChapter 登记小说目录的最新章节，用于分析最新章节的运行
+func withLatestChapter(ctx context.Context, novelDir string) context.Context {
+	return withRunChapters(ctx, strconv.Itoa(managers.NewChapterManager(novelDir, chapterManagerOptions()...).GetLatestChapterID()))
+}
+
+// commitWorkflowRun 启用 git 仓库模式时，将本次工作流运行产生的改动提交到小说目录的仓库
+// 提交失败只记录警告，不影响工作流结果
+func commitWorkflowRun(ctx context.Context, novelDir, workflow, model string, log *logger.ZapLogger) {
+	global := config.GetGlobalOrNil()
+	if global == nil || !global.Novel.Git.Enabled {
+		return
+	}
+	gitCfg := global.Novel.Git
+
+	author := &object.Signature{Name: gitCfg.AuthorName, Email: gitCfg.AuthorEmail, When: time.Now()}
+	if author.Name == "" {
+		author.Name = managers.DefaultGitAuthorName
+	}
+	if author.Email == "" {
+		author.Email = managers.DefaultGitAuthorEmail
+	}
+
+	commit, err := func() (*managers.NovelCommit, error) {
+		repo, err := managers.OpenNovelRepo(novelDir, gitCfg.AutoInit)
+		if err != nil {
+			return nil, err
+		}
+		changes := managers.RunChangesFromContext(ctx)
+		return repo.CommitRun(managers.WorkflowRun{
+			Workflow: workflow,
+			Model:    model,
+			Author:   managers.VersionInfoFromContext(ctx).Author,
+			Chapters: changes.Chapters(),
+			Files:    changes.Files(),
+		}, author)
+	}()
+	if log == nil {
+		return
+	}
+	switch {
+	case err != nil:
+		log.Warn(fmt.Sprintf("提交工作流 %s 的改动失败: %v", workflow, err))
+	case commit == nil:
+		log.Info(fmt.Sprintf("工作流 %s 没有产生改动，跳过提交", workflow))
+	default:
+		log.Info(fmt.Sprintf("已提交工作流 %s 的改动: %s（%d 个文件）", workflow, commit.Hash[:8], len(commit.Files)))
+	}
+}
@@ -25,12 +25,16 @@ type WorldviewSummarizerWorkflowConfig struct {
 	LLMManager   *providers.Manager
 	ShowProgress bool
 	Model        string // 模型名称
+
+	BudgetProfile string // Token预算配置名称，为空时使用配置文件为该工作流指定的配置
 }
 
 // WorldviewSummarizerWorkflow 世界观总结工作流
 type WorldviewSummarizerWorkflow struct {
-	config *WorldviewSummarizerWorkflowConfig
-	cli    *common.CLIHelper
+	config        *WorldviewSummarizerWorkflowConfig
+	cli           *common.CLIHelper
+	resolvedModel string          // 实际使用的模型名，用于计算上下文预算
+	snapshot      contextSnapshot // 最近一次构建的上下文，用于上下文转储
 }
 
 // NewWorldviewSummarizerWorkflow 创建世界观总结工作流
@@ -54,6 +58,7 @@ func (ww *WorldviewSummarizerWorkflow) CreateReActAgent() (*react.Agent, error)
 	ctx := context.Background()
 
 	// 获取模型
+	ww.resolvedModel = ww.config.Model
 	model, err := ww.config.LLMManager.GetOllamaModel(ctx, providers.WithModel(ww.config.Model))
 	if err != nil {
 		if ww.config.Logger != nil {
@@ -64,11 +69,12 @@ func (ww *WorldviewSummarizerWorkflow) CreateReActAgent() (*react.Agent, error)
 		if err != nil {
 			return nil, fmt.Errorf("所有模型都不可用: %w", err)
 		}
+		ww.resolvedModel = ww.config.LLMManager.DefaultOllamaModelName()
 	}
 
 	// 创建世界观管理工具
 	worldviewTool := tools.NewWorldviewCRUDTool(ww.config.NovelDir, ww.config.LLMManager)
-	chapterTool := tools.NewCurrentChapterCRUDTool(ww.config.NovelDir)
+	chapterTool := tools.NewCurrentChapterCRUDTool(ww.config.NovelDir, chapterManagerOptions()...)
 	chapterAnalysisTool := tools.NewChapterAnalysisTool(ww.config.NovelDir)
 	planTool := tools.NewPlanCRUDTool(ww.config.NovelDir)
 
@@ -92,8 +98,19 @@ func (ww *WorldviewSummarizerWorkflow) CreateReActAgent() (*react.Agent, error)
 
 // createMessageModifier 创建消息修饰器
 func (ww *WorldviewSummarizerWorkflow) createMessageModifier() react.MessageModifier {
-	// 预先加载上下文数据
-	ctxData := ww.getContextData()
+	// 预先加载上下文并构建系统提示词，避免重复调用
+	sysPrompt := ww.buildSystemPrompt(ww.getContextData())
+
+	return func(ctx context.Context, input []*schema.Message) []*schema.Message {
+		result := make([]*schema.Message, 0, len(input)+1)
+		result = append(result, schema.SystemMessage(sysPrompt))
+		result = append(result, input...)
+		return result
+	}
+}
+
+// buildSystemPrompt 根据上下文数据构建系统提示词
+func (ww *WorldviewSummarizerWorkflow) buildSystemPrompt(ctxData map[string]any) string {
 	// 使用默认世界观总结提示词
 	prompt := `你是一个专业的小说世界观分析专家。你的任务是：
 
@@ -114,9 +131,8 @@ func (ww *WorldviewSummarizerWorkflow) createMessageModifier() react.MessageModi
 - 全新世界创建新的编号条目
 - 只有在确实发现新设定时才更新`
 
-	return func(ctx context.Context, input []*schema.Message) []*schema.Message {
-		sysPrompt := fmt.Sprintf(
-			`%v
+	sysPrompt := fmt.Sprintf(
+		`%v
 
 当前上下文信息:
 - 最新世界观设定: %v
@@ -125,39 +141,32 @@ func (ww *WorldviewSummarizerWorkflow) createMessageModifier() react.MessageModi
 - 总结: %v
 
 请根据用户要求分析世界观变化并进行相应操作。`,
-			prompt,
-			ctxData["worldview"],
-			ctxData["chapter"],
-			ctxData["plan"],
-			ctxData["summary"],
-		)
-		
-		result := make([]*schema.Message, 0, len(input)+1)
-		result = append(result, schema.SystemMessage(sysPrompt))
-		result = append(result, input...)
-		return result
-	}
+		prompt,
+		ctxData["worldview"],
+		ctxData["chapter"],
+		ctxData["plan"],
+		ctxData["summary"],
+	)
+
+	return sysPrompt
 }
 
 // getContextData 获取上下文数据
 func (ww *WorldviewSummarizerWorkflow) getContextData() map[string]any {
+	model := resolvedModelName(ww.resolvedModel, ww.config.Model)
 	var cfg = content.ContextConfig{
 		NovelDir: ww.config.NovelDir,
 		Logger:   ww.config.Logger,
 	}
+	applyContentConfig(&cfg, ww.config.LLMManager, model)
 
 	var cb = content.NewContextBuilder(&cfg)
-	const maxTokens = 64000 // 适合世界观分析的token限制
-	var percentage = token.TokenPercentages{
-		Plan:      0.15, // 适量规划信息
-		Character: 0.10, // 减少角色信息
-		Worldview: 0.40, // 重点关注世界观
-		Chapters:  0.25, // 章节上下文
-		Index:     0.10,
-	}
+	maxTokens := contextTokenBudget(model, 64000, ww.config.Logger) // 适合世界观分析的token限制
+	percentage := resolveTokenPercentages(token.ProfileWorldview, ww.config.BudgetProfile, ww.config.Logger)
 
 	// 生成上下文数据结构体
-	var data, err = cb.BuildTokenAwareContext(&percentage, maxTokens)
+	var data, err = cb.BuildTokenAwareContext(percentage, maxTokens)
+	ww.snapshot = contextSnapshot{model: model, maxTokens: maxTokens, percentages: percentage, counter: cfg.Counter, data: data}
 	if err != nil {
 		if ww.config.Logger != nil {
 			ww.config.Logger.Error("构建上下文失败", zap.Error(err))
@@ -179,14 +188,25 @@ func (ww *WorldviewSummarizerWorkflow) getContextData() map[string]any {
 	return cb.GetContextAsMap(data)
 }
 
+// DumpContext 构建上下文和系统提示词并写入文件，用于查看模型实际收到的内容（不调用模型）
+func (ww *WorldviewSummarizerWorkflow) DumpContext(path string) error {
+	sysPrompt := ww.buildSystemPrompt(ww.getContextData())
+	return ww.snapshot.newContextDump(token.ProfileWorldview, sysPrompt).WriteFile(path)
+}
+
 // ExecuteWithMonitoring 执行世界观总结工作流并提供监控
 func (ww *WorldviewSummarizerWorkflow) ExecuteWithMonitoring(input string) (string, error) {
+	return ww.ExecuteWithContext(context.Background(), input)
+}
+
+// ExecuteWithContext 在 ctx 下执行世界观总结工作流，ctx 中的任务信息会记录到文件版本历史和 git 提交中
+func (ww *WorldviewSummarizerWorkflow) ExecuteWithContext(ctx context.Context, input string) (string, error) {
 	agent, err := ww.CreateReActAgent()
 	if err != nil {
 		return "", fmt.Errorf("创建 ReAct Agent 失败: %w", err)
 	}
 
-	ctx := context.Background()
+	ctx = startWorkflowRun(ctx, token.ProfileWorldview)
 
 	// 创建 MessageFuture 选项进行监控
 	option, future := react.WithMessageFuture()
@@ -200,6 +220,9 @@ func (ww *WorldviewSummarizerWorkflow) ExecuteWithMonitoring(input string) (stri
 		return "", err
 	}
 
+	// 启用 git 仓库模式时提交本次运行的改动
+	commitWorkflowRun(ctx, ww.config.NovelDir, token.ProfileWorldview, resolvedModelName(ww.resolvedModel, ww.config.Model), ww.config.Logger)
+
 	// 如果开启进度显示，展示执行步骤
 	if ww.config.ShowProgress {
 		ww.showExecutionSteps(future)
@@ -248,9 +271,16 @@ func (ww *WorldviewSummarizerWorkflow) ProcessWorldviewSummarizer(ctx context.Co
 	} else {
 		// AI分析模式
 		input = "请分析最新章节内容中的世界设定信息，并根据需要更新世界观文档"
+		ctx = withLatestChapter(ctx, ww.config.NovelDir)
 	}
 
-	_, err := ww.ExecuteWithMonitoring(input)
+	_, err := ww.ExecuteWithContext(ctx, input)
 	return err
 }
 
+// ProcessChapterWorldview 分析指定章节中的世界设定信息并更新世界观文档，用于导入已有书稿后补全世界观
+func (ww *WorldviewSummarizerWorkflow) ProcessChapterWorldview(ctx context.Context, chapterID string) error {
+	input := fmt.Sprintf("请分析章节 %s 内容中的世界设定信息，并根据需要更新世界观文档", chapterID)
+	_, err := ww.ExecuteWithContext(withRunChapters(ctx, chapterID), input)
+	return err
+}
@@ -26,12 +26,19 @@ type WriteWorkflowConfig struct {
 	LLMManager   *providers.Manager
 	ShowProgress bool
 	WriterModel  string // 写作模型名称
+
+	BudgetProfile string // Token预算配置名称，为空时使用配置文件为该工作流指定的配置
+
+	Retriever content.PassageRetriever // 前文检索器（可选），设置后会把相关前文片段加入上下文
 }
 
 // WriteWorkflow 写作工作流
 type WriteWorkflow struct {
-	config *WriteWorkflowConfig
-	cli    *common.CLIHelper
+	config        *WriteWorkflowConfig
+	cli           *common.CLIHelper
+	resolvedModel string          // 实际使用的模型名，用于计算上下文预算
+	snapshot      contextSnapshot // 最近一次构建的上下文，用于上下文转储
+	promptHash    string          // 写作提示词模板的哈希，写入章节元数据
 }
 
 // NewWriteWorkflow 创建写作工作流
@@ -54,6 +61,7 @@ func NewWriteWorkflow(config *WriteWorkflowConfig) *WriteWorkflow {
 func (ww *WriteWorkflow) CreateReActAgent() (*react.Agent, error) {
 	ctx := context.Background()
 
+	ww.resolvedModel = ww.config.WriterModel
 	writerModel, err := ww.config.LLMManager.GetOpenAIModel(ctx, providers.WithModel(ww.config.WriterModel))
 	if err != nil {
 		if ww.config.Logger != nil {
@@ -64,10 +72,11 @@ func (ww *WriteWorkflow) CreateReActAgent() (*react.Agent, error) {
 		if err != nil {
 			return nil, fmt.Errorf("所有规划模型都不可用: %w", err)
 		}
+		ww.resolvedModel = ww.config.LLMManager.DefaultOpenAIModelName()
 	}
 
 	// 创建章节管理工具
-	currentChapterTool := tools.NewCurrentChapterCRUDTool(ww.config.NovelDir)
+	currentChapterTool := tools.NewCurrentChapterCRUDTool(ww.config.NovelDir, chapterManagerOptions()...)
 	planCRUDTool := tools.NewPlanCRUDTool(ww.config.NovelDir)
 
 	// 创建工具节点配置
@@ -76,21 +85,43 @@ func (ww *WriteWorkflow) CreateReActAgent() (*react.Agent, error) {
 		ExecuteSequentially: false,
 	}
 
+	messageModifier, err := ww.createMessageModifier()
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建 ReAct Agent 配置
 	agentConfig := &react.AgentConfig{
 		ToolCallingModel: writerModel,
 		ToolsConfig:      *toolsNodeConfig,
 		MaxStep:          10,
-		MessageModifier:  ww.createMessageModifier(),
+		MessageModifier:  messageModifier,
 	}
 
 	// 创建 ReAct Agent
 	return react.NewAgent(ctx, agentConfig)
 }
 
-func (ww *WriteWorkflow) createMessageModifier() react.MessageModifier {
-	// 预先加载上下文数据，避免重复调用
-	ctxData := ww.getContextData()
+func (ww *WriteWorkflow) createMessageModifier() (react.MessageModifier, error) {
+	// 预先加载上下文并构建系统提示词，避免重复调用
+	ctxData, err := ww.getContextData()
+	if err != nil {
+		return nil, err
+	}
+	sysPrompt := ww.buildSystemPrompt(ctxData)
+	// 只对静态提示词模板取哈希，同一版模板写出的章节哈希相同，不受上下文内容影响
+	ww.promptHash = managers.HashPrompt(string(content.Novel_writer_prompt))
+
+	return func(ctx context.Context, input []*schema.Message) []*schema.Message {
+		result := make([]*schema.Message, 0, len(input)+1)
+		result = append(result, schema.SystemMessage(sysPrompt))
+		result = append(result, input...)
+		return result
+	}, nil
+}
+
+// buildSystemPrompt 根据上下文数据构建系统提示词
+func (ww *WriteWorkflow) buildSystemPrompt(ctxData map[string]any) string {
 	prompt := content.Novel_writer_prompt
 	planManager := managers.NewPlannerContentManager(ww.config.NovelDir)
 
@@ -100,51 +131,53 @@ func (ww *WriteWorkflow) createMessageModifier() react.MessageModifier {
 	} else {
 		ww.config.Logger.Info("当前plan:", zap.String("plan", firstPlan.Plan))
 	}
-	return func(ctx context.Context, input []*schema.Message) []*schema.Message {
-		var planInfo string
-		if found {
-			planInfo = fmt.Sprintf("章节:%s 规划:%s", firstPlan.Chapter, firstPlan.Plan)
-		} else {
-			planInfo = "无未完成计划"
-		}
+	var planInfo string
+	if found {
+		planInfo = fmt.Sprintf("章节:%s 规划:%s", firstPlan.Chapter, firstPlan.Plan)
+	} else {
+		planInfo = "无未完成计划"
+	}
 
-		sysPrompt := fmt.Sprintf(
-			`%v,当前上下文:-章节:%v-世界观:%v-角色:%v-现有规划:%v-总结: %v`,
-			prompt,
-			ctxData["chapter"],
-			ctxData["worldview"],
-			ctxData["characters"],
-			planInfo,
-			ctxData["summary"],
-		)
-		result := make([]*schema.Message, 0, len(input)+1)
-		result = append(result, schema.SystemMessage(sysPrompt))
-		result = append(result, input...)
-		return result
+	sysPrompt := fmt.Sprintf(
+		`%v,当前上下文:-章节:%v-世界观:%v-角色:%v-现有规划:%v-总结: %v`,
+		prompt,
+		ctxData["chapter"],
+		ctxData["worldview"],
+		ctxData["characters"],
+		planInfo,
+		ctxData["summary"],
+	)
+	if retrieved, _ := ctxData["retrieved"].(string); retrieved != "" {
+		sysPrompt += fmt.Sprintf(`-相关前文: %v`, retrieved)
 	}
+
+	return sysPrompt
 }
 
 // getContextData 获取上下文数据
-func (ww *WriteWorkflow) getContextData() map[string]any {
+func (ww *WriteWorkflow) getContextData() (map[string]any, error) {
+	model := resolvedModelName(ww.resolvedModel, ww.config.WriterModel)
 	var cfg = content.ContextConfig{
-		NovelDir: ww.config.NovelDir,
-		Logger:   ww.config.Logger,
+		NovelDir:  ww.config.NovelDir,
+		Logger:    ww.config.Logger,
+		Retriever: ww.config.Retriever,
 	}
+	applyContentConfig(&cfg, ww.config.LLMManager, model)
 
 	var cb = content.NewContextBuilder(&cfg)
-	const maxTokens = 128000 // 适合写作任务的token限制
-	var percentage = token.TokenPercentages{
-		Plan:      0.15, // 适合写作的规划信息
-		Character: 0.05,
-		Worldview: 0.05,
-		Chapters:  0.60, // 更多章节上下文
-		Index:     0.15,
+	maxTokens := contextTokenBudget(model, 128000, ww.config.Logger) // 适合写作任务的token限制
+	percentage := resolveTokenPercentages(token.ProfileWrite, ww.config.BudgetProfile, ww.config.Logger)
+	if ww.config.Retriever != nil && percentage.Retrieved == 0 && percentage.Chapters >= 0.10 {
+		// 预算配置未给检索片段分配预算时，从章节内容中划出一部分
+		percentage.Chapters -= 0.10
+		percentage.Retrieved = 0.10
 	}
 
 	// 生成上下文数据结构体
-	var data, err = cb.BuildTokenAwareContext(&percentage, maxTokens)
+	var data, err = cb.BuildTokenAwareContext(percentage, maxTokens)
+	ww.snapshot = contextSnapshot{model: model, maxTokens: maxTokens, percentages: percentage, counter: cfg.Counter, data: data}
 	if err != nil {
-		panic(fmt.Errorf("%w", err))
+		return nil, fmt.Errorf("构建上下文失败: %w", err)
 	}
 
 	if ww.config.ShowProgress {
@@ -152,16 +185,37 @@ func (ww *WriteWorkflow) getContextData() map[string]any {
 	}
 
 	// 使用ContextBuilder的GetContextAsMap方法确保键名正确
-	return cb.GetContextAsMap(data)
+	return cb.GetContextAsMap(data), nil
+}
+
+// DumpContext 构建上下文和系统提示词并写入文件，用于查看模型实际收到的内容（不调用模型）
+// 上下文构建失败时仍写出不含上下文的转储，并返回构建错误
+func (ww *WriteWorkflow) DumpContext(path string) error {
+	ctxData, buildErr := ww.getContextData()
+	sysPrompt := ww.buildSystemPrompt(ctxData)
+	if err := ww.snapshot.newContextDump(token.ProfileWrite, sysPrompt).WriteFile(path); err != nil {
+		return err
+	}
+	return buildErr
 }
 
 func (ww *WriteWorkflow) ExecuteWithMonitoring(input string) (string, error) {
+	return ww.ExecuteWithContext(context.Background(), input)
+}
+
+// ExecuteWithContext 在 ctx 下执行写作工作流，ctx 中的任务信息会记录到文件版本历史和 git 提交中
+func (ww *WriteWorkflow) ExecuteWithContext(ctx context.Context, input string) (string, error) {
 	agent, err := ww.CreateReActAgent()
 	if err != nil {
 		return "", fmt.Errorf("创建 ReAct Agent 失败: %w", err)
 	}
 
-	ctx := context.Background()
+	ctx = startWorkflowRun(ctx, token.ProfileWrite)
+	// 本次生成的章节记录使用的模型和提示词版本
+	ctx = managers.WithChapterMeta(ctx, &managers.ChapterMeta{
+		Model:      resolvedModelName(ww.resolvedModel, ww.config.WriterModel),
+		PromptHash: ww.promptHash,
+	})
 
 	// 创建 MessageFuture 选项
 	option, future := react.WithMessageFuture()
@@ -175,6 +229,9 @@ func (ww *WriteWorkflow) ExecuteWithMonitoring(input string) (string, error) {
 		return "", err
 	}
 
+	// 启用 git 仓库模式时提交本次运行的改动
+	commitWorkflowRun(ctx, ww.config.NovelDir, token.ProfileWrite, resolvedModelName(ww.resolvedModel, ww.config.WriterModel), ww.config.Logger)
+
 	// 获取每次循环的消息
 	iter := future.GetMessages()
 	stepCount := 0
@@ -24,6 +24,25 @@ type LLMConfig struct {
 	Ollama  OllamaConfig  `yaml:"ollama" mapstructure:"ollama"`
 	OpenAI  OpenAIConfig  `yaml:"openai" mapstructure:"openai"`
 	Timeout time.Duration `yaml:"timeout" mapstructure:"timeout"`
+
+	// 按模型名配置本地分词器，键可以是完整模型名、模型名前缀（如 qwen3）或 default
+	Tokenizers map[string]TokenizerConfig `yaml:"tokenizers" mapstructure:"tokenizers"`
+
+	// 按模型名配置模型能力（上下文窗口、最大输出等），键的匹配规则同 Tokenizers，未配置时使用内置表，
+	// 都未匹配的模型按 default 条目计算（见 DefaultModelCapabilities）
+	Capabilities  map[string]ModelCapability `yaml:"capabilities" mapstructure:"capabilities"`
+	PromptReserve int                        `yaml:"prompt_reserve" mapstructure:"prompt_reserve"` // 为系统提示词预留的Token数，默认2000
+}
+
+// TokenizerConfig 本地分词器配置
+type TokenizerConfig struct {
+	Path   string `yaml:"path" mapstructure:"path"`     // 词表文件路径（tiktoken 文件或 tokenizer.json）
+	Format string `yaml:"format" mapstructure:"format"` // tiktoken 或 huggingface，为空时按扩展名判断
+}
+
+// GetTokenizer 查找模型对应的分词器配置：完整模型名 > 最长前缀 > default
+func (c *LLMConfig) GetTokenizer(model string) (TokenizerConfig, bool) {
+	return lookupByModel(c.Tokenizers, model, true)
 }
 
 // OllamaConfig Ollama配置
@@ -55,8 +74,19 @@ type AppConfig struct {
 
 // NovelConfig 小说配置
 type NovelConfig struct {
-	Path         string        `yaml:"path" mapstructure:"path"`
-	Content      ContentConfig `yaml:"content" mapstructure:"content"`
+	Path string `yaml:"path" mapstructure:"path"`
+	// 新章节的文件名格式，必须包含一个章节号占位符（%d 或 %0Nd），如 chapter_%03d.json；默认 example_chapter_%d.json
+	ChapterFilePattern string        `yaml:"chapter_file_pattern" mapstructure:"chapter_file_pattern"`
+	Content            ContentConfig `yaml:"content" mapstructure:"content"`
+	Git                GitConfig     `yaml:"git" mapstructure:"git"`
+}
+
+// GitConfig git 仓库模式配置：启用后小说目录作为 git 仓库，每次工作流运行结束提交一次
+type GitConfig struct {
+	Enabled     bool   `yaml:"enabled" mapstructure:"enabled"`
+	AutoInit    bool   `yaml:"auto_init" mapstructure:"auto_init"`       // 小说目录还不是 git 仓库时自动初始化
+	AuthorName  string `yaml:"author_name" mapstructure:"author_name"`   // 提交作者，默认 novel-workflow
+	AuthorEmail string `yaml:"author_email" mapstructure:"author_email"` // 提交作者邮箱，默认 novel-workflow@localhost
 }
 
 // ContentConfig 内容管理配置
@@ -65,6 +95,11 @@ type ContentConfig struct {
 	MaxTokens        int                  `yaml:"max_tokens" mapstructure:"max_tokens"`
 	TokenPercentages TokenPercentageConfig `yaml:"token_percentages" mapstructure:"token_percentages"`
 	
+	// 命名预算配置（write/plan/summarize/worldview/character 或自定义名称），覆盖同名内置配置
+	BudgetProfiles   map[string]TokenPercentageConfig `yaml:"budget_profiles" mapstructure:"budget_profiles"`
+	// 工作流使用的预算配置名称，未指定时使用与工作流同名的配置
+	WorkflowProfiles map[string]string                `yaml:"workflow_profiles" mapstructure:"workflow_profiles"`
+	
 	// 缓存配置
 	EnableCache      bool   `yaml:"enable_cache" mapstructure:"enable_cache"`
 	CacheTTLSeconds  int    `yaml:"cache_ttl_seconds" mapstructure:"cache_ttl_seconds"`
@@ -73,6 +108,15 @@ type ContentConfig struct {
 	ContentWeights   map[string]float64 `yaml:"content_weights" mapstructure:"content_weights"`
 	ContentPriorities map[string]int    `yaml:"content_priorities" mapstructure:"content_priorities"`
 	
+	// 最近章节窗口：从最新章节向前填充章节预算，较早章节以摘要代替
+	RecentChapters   int     `yaml:"recent_chapters" mapstructure:"recent_chapters"`
+	
+	// 压缩配置：世界观、角色、规划超出预算时由小模型浓缩，而不是直接截断
+	Compression      CompressionConfig `yaml:"compression" mapstructure:"compression"`
+	
+	// 截断方式：组件名 -> head（保留开头）或 tail（保留结尾），未配置时章节保留结尾，其余保留开头
+	TruncateModes    map[string]string `yaml:"truncate_modes" mapstructure:"truncate_modes"`
+	
 	// 高级选项
 	PreferRecent     bool    `yaml:"prefer_recent" mapstructure:"prefer_recent"`
 	AllowPartial     bool    `yaml:"allow_partial" mapstructure:"allow_partial"`
@@ -80,6 +124,12 @@ type ContentConfig struct {
 	QualityThreshold float64 `yaml:"quality_threshold" mapstructure:"quality_threshold"`
 }
 
+// CompressionConfig 上下文压缩配置
+type CompressionConfig struct {
+	Enabled bool   `yaml:"enabled" mapstructure:"enabled"`
+	Model   string `yaml:"model" mapstructure:"model"` // 用于压缩的小模型，默认 qwen3:4b
+}
+
 // TokenPercentageConfig Token百分比配置
 type TokenPercentageConfig struct {
 	Plan      float64 `yaml:"plan" mapstructure:"plan"`
@@ -87,6 +137,7 @@ type TokenPercentageConfig struct {
 	Worldview float64 `yaml:"worldview" mapstructure:"worldview"`
 	Chapters  float64 `yaml:"chapters" mapstructure:"chapters"`
 	Index     float64 `yaml:"index" mapstructure:"index"`
+	Retrieved float64 `yaml:"retrieved" mapstructure:"retrieved"` // 检索前文片段，默认0表示不启用
 }
 
 // MessageQueueConfig 消息队列配置
@@ -127,13 +178,15 @@ func (c *ContentConfig) SetDefaults() {
 		c.TokenPercentages.Worldview == 0 && c.TokenPercentages.Chapters == 0 &&
 		c.TokenPercentages.Index == 0 {
 		
-		c.TokenPercentages = TokenPercentageConfig{
-			Plan:      0.15,
-			Character: 0.10,
-			Worldview: 0.10,
-			Chapters:  0.60,
-			Index:     0.05,
-		}
+		c.TokenPercentages = DefaultTokenPercentages()
+	}
+	
+	if c.RecentChapters <= 0 {
+		c.RecentChapters = 3
+	}
+	
+	if c.Compression.Model == "" {
+		c.Compression.Model = "qwen3:4b"
 	}
 	
 	// 设置默认缓存配置
@@ -144,24 +197,12 @@ func (c *ContentConfig) SetDefaults() {
 	
 	// 设置默认内容权重
 	if c.ContentWeights == nil {
-		c.ContentWeights = map[string]float64{
-			"worldview":  1.0,
-			"characters": 1.0,
-			"chapters":   3.0,
-			"plan":       1.5,
-			"index":      0.5,
-		}
+		c.ContentWeights = DefaultContentWeights()
 	}
 	
 	// 设置默认内容优先级
 	if c.ContentPriorities == nil {
-		c.ContentPriorities = map[string]int{
-			"worldview":  2,
-			"characters": 2,
-			"chapters":   1, // 最高优先级
-			"plan":       3,
-			"index":      4,
-		}
+		c.ContentPriorities = DefaultContentPriorities()
 	}
 	
 	// 设置默认高级选项
@@ -180,27 +221,12 @@ func (c *ContentConfig) Validate() error {
 	}
 	
 	// 验证Token百分比
-	total := c.TokenPercentages.Plan + c.TokenPercentages.Character +
-		c.TokenPercentages.Worldview + c.TokenPercentages.Chapters +
-		c.TokenPercentages.Index
-		
-	if total < 0.99 || total > 1.01 { // 允许±1%的误差
-		return fmt.Errorf("token百分比总和应该等于1.0，当前为%.3f", total)
-	}
-	
-	// 检查各个百分比是否为非负数
-	percentages := map[string]float64{
-		"plan":      c.TokenPercentages.Plan,
-		"character": c.TokenPercentages.Character,
-		"worldview": c.TokenPercentages.Worldview,
-		"chapters":  c.TokenPercentages.Chapters,
-		"index":     c.TokenPercentages.Index,
+	if err := c.TokenPercentages.Validate(); err != nil {
+		return err
 	}
 	
-	for name, value := range percentages {
-		if value < 0 {
-			return fmt.Errorf("token百分比'%s'不能为负数: %.3f", name, value)
-		}
+	if err := c.validateBudgetProfiles(); err != nil {
+		return err
 	}
 	
 	// 验证质量阈值
@@ -299,3 +325,11 @@ func GetGlobal() *Config {
 	}
 	return globalLoader.Get()
 }
+
+// GetGlobalOrNil 获取全局配置，未初始化时返回nil
+func GetGlobalOrNil() *Config {
+	if globalLoader == nil {
+		return nil
+	}
+	return globalLoader.Get()
+}
@@ -168,3 +168,42 @@ func TestGetters(t *testing.T) {
 	// 验证Get方法
 	assert.Equal(t, cfg, loader.Get())
 }
+
+func TestModelCapabilities(t *testing.T) {
+	llm := LLMConfig{
+		Capabilities: map[string]ModelCapability{
+			"deepseek": {ContextWindow: 128000, MaxOutput: 8000, SupportsTools: true},
+		},
+		PromptReserve: 1000,
+	}
+
+	// 配置的前缀优先于内置表
+	assert.Equal(t, 128000, llm.GetModelCapability("deepseek-chat").ContextWindow)
+	assert.Equal(t, 128000-8000-1000, llm.ContextBudget("deepseek-chat"))
+
+	// 内置表按最长前缀匹配
+	assert.Equal(t, 32768, llm.GetModelCapability("qwen3:4b").ContextWindow)
+
+	// 未知模型使用默认能力
+	assert.Equal(t, DefaultModelCapabilities()["default"], llm.GetModelCapability("unknown-model"))
+	assert.False(t, llm.HasModelCapability("unknown-model"))
+	assert.True(t, llm.HasModelCapability("deepseek-chat"))
+	assert.True(t, llm.HasModelCapability("qwen3:4b"))
+}
+
+func TestBudgetProfiles(t *testing.T) {
+	content := ContentConfig{
+		BudgetProfiles: map[string]TokenPercentageConfig{
+			"write": {Plan: 0.1, Character: 0.1, Worldview: 0.1, Chapters: 0.6, Index: 0.1},
+			"bad":   {Plan: 0.5, Chapters: 0.6},
+		},
+		WorkflowProfiles: map[string]string{"plan": "write"},
+	}
+
+	assert.Equal(t, "write", content.ProfileForWorkflow("plan"))
+	assert.Equal(t, "", content.ProfileForWorkflow("summarize"))
+	assert.Error(t, content.validateBudgetProfiles())
+
+	assert.False(t, DefaultTokenPercentages().IsCustomized())
+	assert.True(t, content.BudgetProfiles["write"].IsCustomized())
+}
@@ -0,0 +1,93 @@
+package config
+
+import "strings"
+
+// defaultPromptReserve 默认为系统提示词和工具定义预留的Token数
+const defaultPromptReserve = 2000
+
+// ModelCapability 模型能力描述
+type ModelCapability struct {
+	ContextWindow int  `yaml:"context_window" mapstructure:"context_window"` // 上下文窗口（输入+输出）
+	MaxOutput     int  `yaml:"max_output" mapstructure:"max_output"`         // 最大输出Token数
+	SupportsTools bool `yaml:"supports_tools" mapstructure:"supports_tools"` // 是否支持工具调用
+	SupportsJSON  bool `yaml:"supports_json" mapstructure:"supports_json"`   // 是否支持 JSON 模式
+}
+
+// DefaultModelCapabilities 内置的常用模型能力表，配置文件中的同名条目优先
+// 未匹配任何条目的模型使用 default（32000 窗口、4096 输出），上下文预算约为 25904，
+// 小于写作和规划工作流原先固定的 128000；长上下文模型需要在 llm.capabilities 中配置
+func DefaultModelCapabilities() map[string]ModelCapability {
+	return map[string]ModelCapability{
+		"deepseek-chat":     {ContextWindow: 64000, MaxOutput: 8192, SupportsTools: true, SupportsJSON: true},
+		"deepseek-reasoner": {ContextWindow: 64000, MaxOutput: 8192, SupportsTools: false, SupportsJSON: true},
+		"qwen3":             {ContextWindow: 32768, MaxOutput: 8192, SupportsTools: true, SupportsJSON: true},
+		"qwen2.5":           {ContextWindow: 32768, MaxOutput: 8192, SupportsTools: true, SupportsJSON: true},
+		"gpt-4o":            {ContextWindow: 128000, MaxOutput: 16384, SupportsTools: true, SupportsJSON: true},
+		"default":           {ContextWindow: 32000, MaxOutput: 4096, SupportsTools: true, SupportsJSON: false},
+	}
+}
+
+// GetModelCapability 查找模型能力：配置文件优先于内置表，匹配顺序为完整模型名 > 最长前缀 > default
+func (c *LLMConfig) GetModelCapability(model string) ModelCapability {
+	if capability, ok := lookupByModel(c.Capabilities, model, false); ok {
+		return capability
+	}
+	defaults := DefaultModelCapabilities()
+	if capability, ok := lookupByModel(defaults, model, false); ok {
+		return capability
+	}
+	if capability, ok := c.Capabilities["default"]; ok {
+		return capability
+	}
+	return defaults["default"]
+}
+
+// HasModelCapability 判断模型是否在配置文件或内置表中有对应条目（不含 default）
+func (c *LLMConfig) HasModelCapability(model string) bool {
+	if _, ok := lookupByModel(c.Capabilities, model, false); ok {
+		return true
+	}
+	_, ok := lookupByModel(DefaultModelCapabilities(), model, false)
+	return ok
+}
+
+// ContextBudget 计算模型可用于上下文的Token数：上下文窗口 - 最大输出 - 提示词预留
+func (c *LLMConfig) ContextBudget(model string) int {
+	capability := c.GetModelCapability(model)
+
+	reserve := c.PromptReserve
+	if reserve <= 0 {
+		reserve = defaultPromptReserve
+	}
+
+	budget := capability.ContextWindow - capability.MaxOutput - reserve
+	if budget <= 0 {
+		// 配置异常时至少保留一半窗口给上下文
+		budget = capability.ContextWindow / 2
+	}
+	return budget
+}
+
+// lookupByModel 按模型名查找配置项：完整模型名 > 最长前缀，withDefault 为 true 时最后尝试 default
+func lookupByModel[T any](entries map[string]T, model string, withDefault bool) (T, bool) {
+	if entry, ok := entries[model]; ok {
+		return entry, true
+	}
+
+	bestLen := 0
+	var best T
+	for name, entry := range entries {
+		if name != "default" && len(name) > bestLen && strings.HasPrefix(model, name) {
+			best, bestLen = entry, len(name)
+		}
+	}
+	if bestLen > 0 {
+		return best, true
+	}
+
+	if withDefault {
+		entry, ok := entries["default"]
+		return entry, ok
+	}
+	return best, false
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultTokenPercentages 未配置 token_percentages 时使用的默认百分比
+func DefaultTokenPercentages() TokenPercentageConfig {
+	return TokenPercentageConfig{
+		Plan:      0.15,
+		Character: 0.10,
+		Worldview: 0.10,
+		Chapters:  0.60,
+		Index:     0.05,
+	}
+}
+
+// DefaultContentPriorities 剩余Token的默认分配优先级，数字越小越优先
+func DefaultContentPriorities() map[string]int {
+	return map[string]int{
+		"worldview":  2,
+		"characters": 2,
+		"chapters":   1, // 最高优先级
+		"plan":       3,
+		"index":      4,
+	}
+}
+
+// DefaultContentWeights 同一优先级内的默认分配权重
+func DefaultContentWeights() map[string]float64 {
+	return map[string]float64{
+		"worldview":  1.0,
+		"characters": 1.0,
+		"chapters":   3.0,
+		"plan":       1.5,
+		"index":      0.5,
+	}
+}
+
+// IsCustomized token_percentages 是否由配置文件指定（与默认值不同）
+func (t TokenPercentageConfig) IsCustomized() bool {
+	return t != DefaultTokenPercentages()
+}
+
+// Validate 检查各百分比非负且总和为1.0（允许±1%的误差）
+func (t TokenPercentageConfig) Validate() error {
+	total := t.Plan + t.Character + t.Worldview + t.Chapters + t.Index + t.Retrieved
+	if math.Abs(total-1.0) > 0.01 {
+		return fmt.Errorf("token百分比总和应该等于1.0，当前为%.3f", total)
+	}
+
+	percentages := map[string]float64{
+		"plan":      t.Plan,
+		"character": t.Character,
+		"worldview": t.Worldview,
+		"chapters":  t.Chapters,
+		"index":     t.Index,
+		"retrieved": t.Retrieved,
+	}
+	for name, value := range percentages {
+		if value < 0 {
+			return fmt.Errorf("token百分比'%s'不能为负数: %.3f", name, value)
+		}
+	}
+	return nil
+}
+
+// ProfileForWorkflow 返回 workflow_profiles 为工作流指定的预算配置名称，未指定时返回空字符串
+func (c *ContentConfig) ProfileForWorkflow(workflow string) string {
+	return c.WorkflowProfiles[workflow]
+}
+
+// validateBudgetProfiles 验证配置文件中的预算配置
+// 工作流引用的配置名称可能是内置配置，由内容层在解析时校验
+func (c *ContentConfig) validateBudgetProfiles() error {
+	for name, profile := range c.BudgetProfiles {
+		if err := profile.Validate(); err != nil {
+			return fmt.Errorf("预算配置'%s'无效: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -45,6 +45,22 @@ func (m *Manager) GetOpenAIModel(ctx context.Context, options ...ProviderOption)
 	return m.openai.GetModel(ctx, options...)
 }
 
+// DefaultOllamaModelName 返回未指定模型时 GetOllamaModel 使用的模型名（Ollama 未配置模型时为 OpenAI 默认模型）
+func (m *Manager) DefaultOllamaModelName() string {
+	if models := m.ollama.GetConfig().Models; len(models) > 0 {
+		return models[0]
+	}
+	return m.DefaultOpenAIModelName()
+}
+
+// DefaultOpenAIModelName 返回未指定模型时 GetOpenAIModel 使用的模型名
+func (m *Manager) DefaultOpenAIModelName() string {
+	if models := m.openai.GetConfig().Models; len(models) > 0 {
+		return models[0]
+	}
+	return ""
+}
+
 // GetOllamaProvider 获取Ollama提供商（用于测试等场景）
 func (m *Manager) GetOllamaProvider() *OllamaProvider {
 	return m.ollama
@@ -73,6 +73,10 @@ func (a *CharacterUpdateAdapter) ProcessTask(ctx context.Context, task Task) err
 	
 	switch p := payload.(type) {
 	case map[string]interface{}:
+		if chapterID, ok := p["chapter_id"].(string); ok {
+			// 分析指定章节中的角色
+			return a.workflow.ProcessChapterCharacters(ctx, chapterID)
+		}
 		characterName, nameOk := p["character_name"].(string)
 		updateContent, _ := p["update_content"].(string) // 可选参数
 		
@@ -110,6 +114,10 @@ func (a *WorldviewSummarizerAdapter) ProcessTask(ctx context.Context, task Task)
 	
 	switch p := payload.(type) {
 	case map[string]interface{}:
+		if chapterID, ok := p["chapter_id"].(string); ok {
+			// 分析指定章节中的世界设定
+			return a.workflow.ProcessChapterWorldview(ctx, chapterID)
+		}
 		updateContent, _ := p["update_content"].(string) // 可选参数
 		return a.workflow.ProcessWorldviewSummarizer(ctx, updateContent)
 	case string:
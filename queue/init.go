@@ -9,13 +9,36 @@ import (
 	"github.com/Kizunad/modular-workflow-v2/providers"
 )
 
+// AnalysisModel 队列中摘要、角色、世界观工作流使用的模型
+const AnalysisModel = "qwen3:4b"
+
+// initOptions 注册工作流时的选项
+type initOptions struct {
+	budgetProfile string
+}
+
+// InitOption 初始化队列的可选参数
+type InitOption func(*initOptions)
+
+// WithBudgetProfile 指定队列中摘要、角色、世界观工作流本次运行使用的预算配置
+func WithBudgetProfile(profile string) InitOption {
+	return func(o *initOptions) {
+		o.budgetProfile = profile
+	}
+}
+
 // InitQueue 初始化队列并注册所有 Worker
 func InitQueue(
 	cfg *config.MessageQueueConfig,
 	novelDir string,
 	llmManager *providers.Manager,
 	logger *logger.ZapLogger,
+	opts ...InitOption,
 ) (*MessageQueue, error) {
+	options := &initOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
 	
 	if !cfg.Enabled {
 		logger.Info("消息队列被禁用，跳过初始化")
@@ -28,33 +51,36 @@ func InitQueue(
 	
 	// 注册 Summarizer 工作流
 	summarizerWorkflow := workflows.NewSummarizerWorkflow(&workflows.SummarizerWorkflowConfig{
-		Logger:       logger,
-		NovelDir:     novelDir,
-		LLMManager:   llmManager,
-		ShowProgress: true,
-		Model:        "qwen3:4b",
+		Logger:        logger,
+		NovelDir:      novelDir,
+		LLMManager:    llmManager,
+		ShowProgress:  true,
+		Model:         AnalysisModel,
+		BudgetProfile: options.budgetProfile,
 	})
 	summarizerAdapter := NewSummarizerAdapter(summarizerWorkflow)
 	mq.Register(summarizerAdapter)
 	
 	// 注册 CharacterUpdate 工作流
 	characterUpdateWorkflow := workflows.NewCharacterUpdateWorkflow(&workflows.CharacterUpdateWorkflowConfig{
-		Logger:       logger,
-		NovelDir:     novelDir,
-		LLMManager:   llmManager,
-		ShowProgress: true,
-		Model:        "qwen3:4b",
+		Logger:        logger,
+		NovelDir:      novelDir,
+		LLMManager:    llmManager,
+		ShowProgress:  true,
+		Model:         AnalysisModel,
+		BudgetProfile: options.budgetProfile,
 	})
 	characterUpdateAdapter := NewCharacterUpdateAdapter(characterUpdateWorkflow)
 	mq.Register(characterUpdateAdapter)
 	
 	// 注册 WorldviewSummarizer 工作流
 	worldviewSummarizerWorkflow := workflows.NewWorldviewSummarizerWorkflow(&workflows.WorldviewSummarizerWorkflowConfig{
-		Logger:       logger,
-		NovelDir:     novelDir,
-		LLMManager:   llmManager,
-		ShowProgress: true,
-		Model:        "qwen3:4b",
+		Logger:        logger,
+		NovelDir:      novelDir,
+		LLMManager:    llmManager,
+		ShowProgress:  true,
+		Model:         AnalysisModel,
+		BudgetProfile: options.budgetProfile,
 	})
 	worldviewSummarizerAdapter := NewWorldviewSummarizerAdapter(worldviewSummarizerWorkflow)
 	mq.Register(worldviewSummarizerAdapter)
@@ -144,6 +170,18 @@ func CreateCharacterUpdateTask(taskID, characterName, updateContent string) Task
 	}
 }
 
+// Helper 创建分析指定章节角色任务的辅助函数
+func CreateChapterCharacterTask(taskID, chapterID string) Task {
+	return &GenericTask{
+		ID:       taskID,
+		Type:     "character_update",
+		Priority: 5,
+		Payload: map[string]interface{}{
+			"chapter_id": chapterID,
+		},
+	}
+}
+
 // Helper 创建世界观总结任务的辅助函数
 func CreateWorldviewSummarizerTask(taskID, updateContent string) Task {
 	return &GenericTask{
@@ -164,4 +202,16 @@ func CreateWorldviewAnalysisTask(taskID string) Task {
 		Priority: 5,
 		Payload:  map[string]interface{}{},
 	}
-}
\ No newline at end of file
+}
+
+// Helper 创建分析指定章节世界观任务的辅助函数
+func CreateChapterWorldviewTask(taskID, chapterID string) Task {
+	return &GenericTask{
+		ID:       taskID,
+		Type:     "worldview_summarizer",
+		Priority: 5,
+		Payload: map[string]interface{}{
+			"chapter_id": chapterID,
+		},
+	}
+}
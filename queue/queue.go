@@ -7,6 +7,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/Kizunad/modular-workflow-v2/components/content/managers"
 	"github.com/Kizunad/modular-workflow-v2/logger"
 )
 
@@ -107,6 +108,19 @@ func (mq *MessageQueue) Enqueue(task Task) error {
 	}
 }
 
+// EnqueueWait 入队任务，队列已满时等待空位，适合一次提交大量任务
+func (mq *MessageQueue) EnqueueWait(ctx context.Context, task Task) error {
+	select {
+	case <-mq.ctx.Done():
+		return fmt.Errorf("队列已关闭")
+	case <-ctx.Done():
+		return ctx.Err()
+	case mq.tasks <- task:
+		mq.logger.Debug(fmt.Sprintf("任务入队: %s [%s]", task.GetID(), task.GetType()))
+		return nil
+	}
+}
+
 // workerLoop Worker 主循环
 func (mq *MessageQueue) workerLoop(worker *Worker) {
 	defer mq.wg.Done()
@@ -168,6 +182,8 @@ func (mq *MessageQueue) ProcessTask(ctx context.Context, task Task) error {
 		return fmt.Errorf("未找到任务类型 %s 的处理器", task.GetType())
 	}
 
+	// 任务中写入的小说文件在版本历史中以任务ID作为作者
+	ctx = managers.WithVersionInfo(ctx, managers.VersionInfo{Author: "task:" + task.GetType() + "/" + task.GetID()})
 	return processor.ProcessTask(ctx, task)
 }
 